@@ -0,0 +1,121 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Severity - Alert severity
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Alert - Operational alert
+type Alert struct {
+	Severity Severity          `json:"severity"`
+	Source   string            `json:"source"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Alerter - Alert sink
+type Alerter interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+var (
+	_ Alerter = LogAlerter{}
+	_ Alerter = WebhookAlerter{}
+	_ Alerter = Multi(nil)
+)
+
+// LogAlerter - Write alerts to the standard logger
+type LogAlerter struct{}
+
+// Send - Implements Alerter
+func (LogAlerter) Send(_ context.Context, a Alert) error {
+	log.Printf("🚨 [%s] %s: %s - %s %v", a.Severity, a.Source, a.Title, a.Message, a.Labels)
+	return nil
+}
+
+// WebhookArchive - Keeps sent webhook bodies (e.g. storage.TransactionStore, encrypted at rest)
+type WebhookArchive interface {
+	ArchiveWebhook(ctx context.Context, body []byte) (string, error)
+}
+
+// WebhookAlerter - POST alerts as JSON (Slack-compatible "text" field included)
+type WebhookAlerter struct {
+	URL     string
+	Client  *http.Client
+	Archive WebhookArchive // Optional
+}
+
+// Send - Implements Alerter
+func (w WebhookAlerter) Send(ctx context.Context, a Alert) error {
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	payload := struct {
+		Alert
+		Text string `json:"text"`
+	}{a, fmt.Sprintf("[%s] %s: %s", a.Severity, a.Title, a.Message)}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if w.Archive != nil {
+		if _, err := w.Archive.ArchiveWebhook(ctx, body); err != nil {
+			log.Printf("failed to archive alert webhook body: %v", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Multi - Fan out to several alerters
+type Multi []Alerter
+
+// Send - Implements Alerter
+func (m Multi) Send(ctx context.Context, a Alert) error {
+	var errs []error
+	for _, alerter := range m {
+		if err := alerter.Send(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// New - Log alerter plus webhook alerter when webhookURL is set (archive is optional)
+func New(webhookURL string, archive WebhookArchive) Alerter {
+	if webhookURL == "" {
+		return LogAlerter{}
+	}
+	return Multi{LogAlerter{}, WebhookAlerter{URL: webhookURL, Archive: archive}}
+}
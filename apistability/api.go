@@ -0,0 +1,261 @@
+// Package apistability - Exported API snapshots of library packages, compared against golden
+// files by its tests so signature changes can't ship without a version.GoAPIVersion bump.
+//
+//	go test ./apistability            # check
+//	go test ./apistability -update    # record additions, or incompatible changes after a bump
+package apistability
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Extract - One line per exported identifier of the package in dir: functions and methods with
+// their signatures, type definitions, exported struct fields and interface methods, consts and vars.
+// Parameter names, comments and values are ignored.
+func Extract(dir string) ([]string, error) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if line, ok := funcLine(fset, d); ok {
+					lines = append(lines, line)
+				}
+			case *ast.GenDecl:
+				lines = append(lines, genLines(fset, d)...)
+			}
+		}
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+func funcLine(fset *token.FileSet, d *ast.FuncDecl) (string, bool) {
+	if !d.Name.IsExported() {
+		return "", false
+	}
+	if d.Recv == nil {
+		return "func " + d.Name.Name + signature(fset, d.Type), true
+	}
+	recv := d.Recv.List[0].Type
+	if !ast.IsExported(baseTypeName(recv)) {
+		return "", false
+	}
+	return "method (" + expr(fset, recv) + ") " + d.Name.Name + signature(fset, d.Type), true
+}
+
+func genLines(fset *token.FileSet, d *ast.GenDecl) []string {
+	var lines []string
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if !s.Name.IsExported() {
+				continue
+			}
+			lines = append(lines, typeLines(fset, s)...)
+		case *ast.ValueSpec:
+			kind := "var"
+			if d.Tok == token.CONST {
+				kind = "const"
+			}
+			for _, name := range s.Names {
+				if !name.IsExported() {
+					continue
+				}
+				line := kind + " " + name.Name
+				if s.Type != nil {
+					line += " " + expr(fset, s.Type)
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+func typeLines(fset *token.FileSet, s *ast.TypeSpec) []string {
+	name := s.Name.Name
+	params := ""
+	if s.TypeParams != nil {
+		params = "[" + fieldTypes(fset, s.TypeParams, true) + "]"
+	}
+	if s.Assign.IsValid() {
+		return []string{"type " + name + params + " = " + expr(fset, s.Type)}
+	}
+
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		lines := []string{"type " + name + params + " struct"}
+		for _, field := range t.Fields.List {
+			if len(field.Names) == 0 {
+				if ast.IsExported(baseTypeName(field.Type)) {
+					lines = append(lines, "embed "+name+"."+expr(fset, field.Type))
+				}
+				continue
+			}
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					lines = append(lines, "field "+name+"."+fieldName.Name+" "+expr(fset, field.Type))
+				}
+			}
+		}
+		return lines
+
+	case *ast.InterfaceType:
+		lines := []string{"type " + name + params + " interface"}
+		for _, method := range t.Methods.List {
+			if len(method.Names) == 0 {
+				lines = append(lines, "embed "+name+"."+expr(fset, method.Type))
+				continue
+			}
+			if fn, ok := method.Type.(*ast.FuncType); ok && method.Names[0].IsExported() {
+				lines = append(lines, "imethod "+name+"."+method.Names[0].Name+signature(fset, fn))
+			}
+		}
+		return lines
+
+	default:
+		return []string{"type " + name + params + " " + expr(fset, s.Type)}
+	}
+}
+
+// signature - "(T1, T2) (R1, error)" without parameter names
+func signature(fset *token.FileSet, fn *ast.FuncType) string {
+	sig := "(" + fieldTypes(fset, fn.Params, false) + ")"
+	if fn.Results != nil && len(fn.Results.List) > 0 {
+		results := fieldTypes(fset, fn.Results, false)
+		if len(fn.Results.List) == 1 && len(fn.Results.List[0].Names) <= 1 {
+			sig += " " + results
+		} else {
+			sig += " (" + results + ")"
+		}
+	}
+	return sig
+}
+
+// fieldTypes - Types of a field list, repeated per name; withNames keeps type parameter names
+func fieldTypes(fset *token.FileSet, list *ast.FieldList, withNames bool) string {
+	if list == nil {
+		return ""
+	}
+	var parts []string
+	for _, field := range list.List {
+		typ := expr(fset, field.Type)
+		if len(field.Names) == 0 {
+			parts = append(parts, typ)
+			continue
+		}
+		for _, name := range field.Names {
+			if withNames {
+				parts = append(parts, name.Name+" "+typ)
+			} else {
+				parts = append(parts, typ)
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func expr(fset *token.FileSet, e ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, e)
+	// Multi-line struct/interface literals collapse to one line
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// baseTypeName - T of T, *T, T[P], pkg.T
+func baseTypeName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexListExpr:
+		return baseTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// Diff - Lines of golden missing from current (incompatible) and lines only in current (additions)
+func Diff(golden, current []string) (removed, added []string) {
+	inCurrent := make(map[string]bool, len(current))
+	for _, line := range current {
+		inCurrent[line] = true
+	}
+	inGolden := make(map[string]bool, len(golden))
+	for _, line := range golden {
+		inGolden[line] = true
+		if !inCurrent[line] {
+			removed = append(removed, line)
+		}
+	}
+	for _, line := range current {
+		if !inGolden[line] {
+			added = append(added, line)
+		}
+	}
+	return removed, added
+}
+
+const versionHeader = "# version: "
+
+// ReadGolden - API lines and the version.GoAPIVersion they were recorded under
+func ReadGolden(path string) (lines []string, apiVersion string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, versionHeader):
+			apiVersion = strings.TrimPrefix(line, versionHeader)
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines, apiVersion, scanner.Err()
+}
+
+// WriteGolden - Record API lines under apiVersion
+func WriteGolden(path, pkg, apiVersion string, lines []string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Exported API of blockchain/%s - generated by: go test ./apistability -update\n", pkg)
+	fmt.Fprintf(&buf, "%s%s\n", versionHeader, apiVersion)
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,78 @@
+package apistability
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blockchain/version"
+)
+
+var update = flag.Bool("update", false, "record the current API in testdata (incompatible changes need a version.GoAPIVersion bump)")
+
+// stablePackages - Packages other teams build against
+var stablePackages = []string{"solprogram", "chainsol", "chainbnb"}
+
+func TestAPIStability(t *testing.T) {
+	for _, pkg := range stablePackages {
+		t.Run(pkg, func(t *testing.T) {
+			current, err := Extract(filepath.Join("..", pkg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			goldenPath := filepath.Join("testdata", pkg+".api")
+			golden, goldenVersion, err := ReadGolden(goldenPath)
+			if err != nil && !(errors.Is(err, fs.ErrNotExist) && *update) {
+				t.Fatalf("read golden API: %v", err)
+			}
+			removed, added := Diff(golden, current)
+
+			if *update {
+				if len(removed) > 0 && goldenVersion == version.GoAPIVersion {
+					t.Fatalf("incompatible API change; bump version.GoAPIVersion before -update:\n%s", list("-", removed))
+				}
+				if err := WriteGolden(goldenPath, pkg, version.GoAPIVersion, current); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if len(removed) > 0 {
+				if goldenVersion == version.GoAPIVersion {
+					t.Errorf("incompatible API change without a version bump; bump version.GoAPIVersion and run go test ./apistability -update:\n%s",
+						list("-", removed))
+				} else {
+					t.Errorf("version.GoAPIVersion bumped to %s; run go test ./apistability -update to record:\n%s",
+						version.GoAPIVersion, list("-", removed))
+				}
+			}
+			if len(added) > 0 {
+				t.Errorf("API additions not recorded; run go test ./apistability -update:\n%s", list("+", added))
+			}
+			if len(removed) == 0 && goldenVersion != version.GoAPIVersion {
+				t.Errorf("golden API recorded under version %s, current is %s; run go test ./apistability -update",
+					goldenVersion, version.GoAPIVersion)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	golden := []string{"func A()", "func B(int) error", "type T struct"}
+	current := []string{"func A()", "func B(int64) error", "type T struct", "field T.X string"}
+
+	removed, added := Diff(golden, current)
+	if len(removed) != 1 || removed[0] != "func B(int) error" {
+		t.Errorf("removed = %v", removed)
+	}
+	if len(added) != 2 || added[0] != "func B(int64) error" || added[1] != "field T.X string" {
+		t.Errorf("added = %v", added)
+	}
+}
+
+func list(prefix string, lines []string) string {
+	return prefix + " " + strings.Join(lines, "\n"+prefix+" ")
+}
@@ -0,0 +1,160 @@
+// Package attestation - Claim receipts signed with the service attestation key, so partners can prove
+// a user claimed an envelope without trusting (or calling) the API: the statement travels as the exact
+// bytes that were signed and verifies offline against the published ed25519 public key.
+package attestation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Version - Statement format version
+const Version = 1
+
+// Domain - Prefix of every signed message, so an attestation signature can't be replayed as a
+// transaction or any other message signed by the same key
+const Domain = "blockchain-claim-attestation:v1"
+
+var (
+	// ErrUnknownKey - Attestation signed by another key than the published one
+	ErrUnknownKey = errors.New("attestation signed by an unknown key")
+	// ErrInvalidSignature - Signature doesn't match the payload (tampered or truncated)
+	ErrInvalidSignature = errors.New("invalid attestation signature")
+)
+
+// Statement - What the service attests: claimer received amount from envelope in the landed
+// transaction signature at slot
+type Statement struct {
+	Version   int    `json:"version"`
+	Program   string `json:"program"`
+	Envelope  string `json:"envelope"` // Envelope PDA
+	Claimer   string `json:"claimer"`
+	Mint      string `json:"mint,omitempty"` // "" = SOL (lamports)
+	Amount    uint64 `json:"amount"`         // Base units that left the envelope
+	Signature string `json:"signature"`      // Claim transaction
+	Slot      uint64 `json:"slot"`
+	BlockTime *int64 `json:"block_time,omitempty"` // unix seconds
+	IssuedAt  int64  `json:"issued_at"`            // unix seconds
+}
+
+// Attestation - Signed statement. Payload is the base64 JSON that was signed (prefixed with Domain
+// and a newline); Statement is its decoded copy for convenience and is not covered by the signature.
+type Attestation struct {
+	Statement Statement `json:"statement"`
+	Payload   string    `json:"payload"`
+	PublicKey string    `json:"public_key"` // base58 ed25519 key of the signer
+	Signature string    `json:"signature"`  // base58 ed25519 signature
+}
+
+// message - Bytes the signature covers
+func message(payload []byte) []byte {
+	return append([]byte(Domain+"\n"), payload...)
+}
+
+// Signer - Service attestation key
+type Signer struct {
+	key solana.PrivateKey
+}
+
+// NewSigner - Signer for an ed25519 (Solana keypair) private key
+func NewSigner(key solana.PrivateKey) (*Signer, error) {
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid attestation key: %w", err)
+	}
+	return &Signer{key: key}, nil
+}
+
+// ParseSigner - Signer from a base58 private key (ATTESTATION_PRIVATE_KEY); nil when s is empty
+func ParseSigner(s string) (*Signer, error) {
+	if s == "" {
+		return nil, nil
+	}
+	key, err := solana.PrivateKeyFromBase58(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation key: %w", err)
+	}
+	return NewSigner(key)
+}
+
+// PublicKey - base58 key partners verify attestations with
+func (s *Signer) PublicKey() string {
+	return s.key.PublicKey().String()
+}
+
+// Sign - Attestation of st (Version and IssuedAt are filled in when zero)
+func (s *Signer) Sign(st Statement) (*Attestation, error) {
+	if st.Version == 0 {
+		st.Version = Version
+	}
+	if st.IssuedAt == 0 {
+		st.IssuedAt = time.Now().Unix()
+	}
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	signature, err := s.key.Sign(message(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign statement: %w", err)
+	}
+	return &Attestation{
+		Statement: st,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		PublicKey: s.PublicKey(),
+		Signature: signature.String(),
+	}, nil
+}
+
+// Verify - Statement signed in a, checked offline against the published publicKey (base58). The
+// statement is decoded from the signed payload, never taken from a.Statement.
+func Verify(a *Attestation, publicKey string) (*Statement, error) {
+	key, err := solana.PublicKeyFromBase58(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if a.PublicKey != key.String() {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, a.PublicKey)
+	}
+	signature, err := solana.SignatureFromBase58(a.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(a.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if !signature.Verify(key, message(payload)) {
+		return nil, ErrInvalidSignature
+	}
+	var st Statement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if st.Version != Version {
+		return nil, fmt.Errorf("unsupported statement version %d", st.Version)
+	}
+	return &st, nil
+}
+
+// KeyInfo - Body of the public key endpoint
+type KeyInfo struct {
+	PublicKey string `json:"public_key"`
+	Algorithm string `json:"algorithm"`
+	Domain    string `json:"domain"` // Signed message: domain + "\n" + base64-decoded payload
+}
+
+// HandlePublicKey - GET: published attestation public key
+func (s *Signer) HandlePublicKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(KeyInfo{PublicKey: s.PublicKey(), Algorithm: "ed25519", Domain: Domain})
+}
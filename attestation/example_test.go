@@ -0,0 +1,49 @@
+package attestation_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/attestation"
+)
+
+// The service signs; partners verify offline with the published public key (GET /api/attestation-key).
+func ExampleVerify() {
+	// The service key comes from ATTESTATION_PRIVATE_KEY (attestation.ParseSigner); a throwaway key here
+	signer, err := attestation.NewSigner(solana.PrivateKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))))
+	if err != nil {
+		log.Fatal(err)
+	}
+	publicKey := signer.PublicKey()
+
+	att, err := signer.Sign(attestation.Statement{
+		Program:   "8sVfWmonJAzAQnS4nYcxv3GBSs4rDpvmniRrApwrh1QK",
+		Envelope:  "5frbWBphKM4gD9yDJUR3CcJ71hr2sUUAUaBrdR6tXTJB",
+		Claimer:   "3YkzQC2PwFGvJr2GS7FDBopvG5tda4eXdq5pmwEbWeyd",
+		Amount:    250_000_000,
+		Signature: "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW",
+		Slot:      312_000_000,
+		IssuedAt:  1_735_732_800,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	statement, err := attestation.Verify(att, publicKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(statement.Claimer, statement.Amount, statement.Slot)
+
+	// Anything edited after signing fails
+	att.Payload = att.Payload[:len(att.Payload)-4] + "fQ=="
+	_, err = attestation.Verify(att, publicKey)
+	fmt.Println(errors.Is(err, attestation.ErrInvalidSignature))
+	// Output:
+	// 3YkzQC2PwFGvJr2GS7FDBopvG5tda4eXdq5pmwEbWeyd 250000000 312000000
+	// true
+}
@@ -0,0 +1,48 @@
+// Package backoff - Exponential backoff with jitter for polling and retry loops.
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Policy - Delay grows by Multiplier per attempt from Initial up to Max;
+// Jitter (0..1) randomly shortens each delay by up to that fraction so concurrent loops spread out
+type Policy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Default - 500ms, 1s, 2s, 4s, 5s, ... each shortened by up to half at random
+var Default = Policy{
+	Initial:    500 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.5,
+}
+
+// Delay - Wait before the given retry (attempt starts at 1)
+func (p Policy) Delay(attempt int) time.Duration {
+	if p.Initial <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.Initial)
+	for i := 1; i < attempt && (p.Max <= 0 || delay < float64(p.Max)); i++ {
+		delay *= multiplier
+	}
+	if p.Max > 0 {
+		delay = min(delay, float64(p.Max))
+	}
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,203 @@
+// Package backpressure - Refuse work early with 429 and a Retry-After while a bounded resource
+// (submission queue, in-flight requests, RPC rate limiter) is saturated, instead of letting requests
+// pile up behind it and time out slowly.
+package backpressure
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"blockchain/metrics"
+)
+
+// Code - Error code of 429 responses
+const Code = "BACKPRESSURE"
+
+// MinRetryAfter - Smallest Retry-After sent, so clients don't retry in a tight loop
+const MinRetryAfter = time.Second
+
+// Pressure - Load of one bounded resource
+type Pressure struct {
+	Source     string  `json:"source"`
+	Depth      int     `json:"depth"`    // Requests waiting or in flight
+	Capacity   int     `json:"capacity"` // Depth at which the source is saturated
+	Saturated  bool    `json:"saturated"`
+	RetryAfter float64 `json:"retry_after_seconds"` // Expected time until it drains below capacity
+}
+
+// Source - Anything reporting its load
+type Source interface {
+	Pressure() Pressure
+}
+
+// SourceFunc - Source from a function
+type SourceFunc func() Pressure
+
+// Pressure - Implements Source
+func (f SourceFunc) Pressure() Pressure {
+	return f()
+}
+
+// ErrorResponse - Body of 429 responses
+type ErrorResponse struct {
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	Code       string `json:"code"`
+	Source     string `json:"source"`
+	RetryAfter int    `json:"retry_after_seconds"`
+}
+
+// Gate - Refuses requests with 429 while any of its sources is saturated
+type Gate struct {
+	mu      sync.RWMutex
+	sources []Source
+}
+
+// NewGate - Gate over sources; their pressure is published as metric "backpressure_<name>"
+func NewGate(name string, sources ...Source) *Gate {
+	g := &Gate{sources: sources}
+	metrics.Func("backpressure_"+name, func() any { return g.Status() })
+	return g
+}
+
+// Add - Watch another source
+func (g *Gate) Add(sources ...Source) *Gate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sources = append(g.sources, sources...)
+	return g
+}
+
+// Status - Pressure of every source
+func (g *Gate) Status() []Pressure {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	status := make([]Pressure, 0, len(g.sources))
+	for _, s := range g.sources {
+		status = append(status, s.Pressure())
+	}
+	return status
+}
+
+// Check - Saturated source that drains last, if any
+func (g *Gate) Check() (Pressure, bool) {
+	var worst Pressure
+	saturated := false
+	for _, p := range g.Status() {
+		if p.Saturated && (!saturated || p.RetryAfter > worst.RetryAfter) {
+			worst, saturated = p, true
+		}
+	}
+	return worst, saturated
+}
+
+// Wrap - next behind the gate: 429 with Retry-After while a source is saturated
+func (g *Gate) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p, saturated := g.Check(); saturated {
+			Reject(w, p)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Reject - 429 response for a saturated source
+func Reject(w http.ResponseWriter, p Pressure) {
+	metrics.Counter("backpressure_rejected_" + p.Source).Add(1)
+	retryAfter := RetryAfterSeconds(p.RetryAfter)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:      http.StatusText(http.StatusTooManyRequests),
+		Message:    fmt.Sprintf("%s saturated (%d/%d), retry in %ds", p.Source, p.Depth, p.Capacity, retryAfter),
+		Code:       Code,
+		Source:     p.Source,
+		RetryAfter: retryAfter,
+	})
+}
+
+// ParseCapacity - In-flight capacity from env, e.g. MAX_INFLIGHT_SUBMIT=64 ("" = def)
+func ParseCapacity(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid capacity %q (want a positive integer)", s)
+	}
+	return n, nil
+}
+
+// RetryAfterSeconds - Retry-After header value: seconds rounded up, at least MinRetryAfter
+func RetryAfterSeconds(seconds float64) int {
+	return max(int(math.Ceil(seconds)), int(MinRetryAfter/time.Second))
+}
+
+// Inflight - Requests in progress through Wrap; saturated at capacity, Retry-After from the
+// observed request duration
+type Inflight struct {
+	name     string
+	capacity int
+	depth    atomic.Int64
+	avg      atomic.Int64 // EWMA of request durations, nanoseconds
+}
+
+// NewInflight - Inflight named name (metric and 429 source), saturated at capacity requests
+func NewInflight(name string, capacity int) *Inflight {
+	return &Inflight{name: name, capacity: max(capacity, 1)}
+}
+
+// Wrap - Count next's requests
+func (i *Inflight) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		i.depth.Add(1)
+		start := time.Now()
+		defer func() {
+			i.depth.Add(-1)
+			i.Observe(time.Since(start))
+		}()
+		next(w, r)
+	}
+}
+
+// Observe - Record the duration of one request (EWMA, alpha 0.2)
+func (i *Inflight) Observe(d time.Duration) {
+	for {
+		old := i.avg.Load()
+		next := int64(d)
+		if old > 0 {
+			next = old + (int64(d)-old)/5
+		}
+		if i.avg.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Pressure - Implements Source; the backlog drains at capacity requests per average duration
+func (i *Inflight) Pressure() Pressure {
+	depth := int(i.depth.Load())
+	return Pressure{
+		Source:     i.name,
+		Depth:      depth,
+		Capacity:   i.capacity,
+		Saturated:  depth >= i.capacity,
+		RetryAfter: Drain(depth, i.capacity, time.Duration(i.avg.Load())).Seconds(),
+	}
+}
+
+// Drain - Time until depth requests drop below capacity when capacity of them run at once and each takes avg
+func Drain(depth, capacity int, avg time.Duration) time.Duration {
+	if depth < capacity || capacity <= 0 {
+		return 0
+	}
+	return time.Duration(depth/capacity) * avg
+}
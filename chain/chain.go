@@ -0,0 +1,82 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Chain - Transfer API implemented by each chain adapter (chainsol, chainbnb, sandbox)
+type Chain interface {
+	HealthCheck() error
+	GetExplorerURL(txID string) string
+
+	HandleCreateTransaction(w http.ResponseWriter, r *http.Request)
+	HandleSignTransaction(w http.ResponseWriter, r *http.Request) // ⚠️ TESTING ONLY
+	HandleSendTransaction(w http.ResponseWriter, r *http.Request)
+	HandleGetTransactionStatus(w http.ResponseWriter, r *http.Request)
+	HandleGetTransactionHistory(w http.ResponseWriter, r *http.Request)
+	HandleCanary(w http.ResponseWriter, r *http.Request)
+}
+
+// ReceiptProvider - Optional: chains that expose submission receipts
+type ReceiptProvider interface {
+	HandleGetSubmissionReceipt(w http.ResponseWriter, r *http.Request)
+}
+
+// ErrRemediationNotApplicable - Remediation action does not apply to the diagnosed state
+var ErrRemediationNotApplicable = errors.New("remediation not applicable")
+
+// StuckResolver - Optional: chains with the stuck transaction resolver (admin only)
+type StuckResolver interface {
+	HandleDiagnoseStuck(w http.ResponseWriter, r *http.Request)  // GET ?transaction_id=xxx
+	HandleRemediateStuck(w http.ResponseWriter, r *http.Request) // POST dto.RemediationRequest
+}
+
+// EnvelopeAPI - Envelope program API (solprogram.Client, sandbox)
+type EnvelopeAPI interface {
+	HandleCreateEnvelope(w http.ResponseWriter, r *http.Request)
+	HandleClaimEnvelope(w http.ResponseWriter, r *http.Request)
+	HandleRefundEnvelope(w http.ResponseWriter, r *http.Request)
+	HandleSignTransaction(w http.ResponseWriter, r *http.Request) // ⚠️ TESTING ONLY
+	HandleSendTransaction(w http.ResponseWriter, r *http.Request)
+}
+
+// ClaimSubmitter - Optional: envelope APIs with server-side claim retry (POST /api/claim-envelope/submit)
+type ClaimSubmitter interface {
+	HandleSubmitClaim(w http.ResponseWriter, r *http.Request)
+}
+
+// ClaimAttester - Optional: envelope APIs issuing signed claim receipts (GET /api/claim-attestation)
+type ClaimAttester interface {
+	HandleClaimAttestation(w http.ResponseWriter, r *http.Request)
+}
+
+// TransactionPreviewer - Optional: envelope APIs with balance previews of unsigned transactions (POST /api/simulate-preview)
+type TransactionPreviewer interface {
+	HandleSimulatePreview(w http.ResponseWriter, r *http.Request)
+}
+
+// Envelope actions linked to transaction history
+const (
+	EnvelopeActionCreate = "create"
+	EnvelopeActionClaim  = "claim"
+	EnvelopeActionRefund = "refund"
+)
+
+// EnvelopeTransaction - Confirmed transaction and the envelope it belongs to
+type EnvelopeTransaction struct {
+	Chain         ChainID
+	TransactionID string // Service transaction ID ("" for server-signed transactions)
+	Signature     string
+	Action        string // EnvelopeAction*
+	EnvelopeID    uint64
+	Owner         string
+	Signer        string // Fee payer: the owner, or the claimer for claims
+	Amount        uint64 // Base units: create total, claimed, refunded (0 = unknown)
+}
+
+// EnvelopeHistory - Optional: chains that keep envelope transactions in their history
+type EnvelopeHistory interface {
+	RecordEnvelopeTransaction(ctx context.Context, tx EnvelopeTransaction) error
+}
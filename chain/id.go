@@ -0,0 +1,198 @@
+package chain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// ChainID - Supported chain identifier
+type ChainID string
+
+const (
+	Solana ChainID = "solana"
+	BSC    ChainID = "bsc"
+)
+
+// AllChains - Every supported chain
+var AllChains = []ChainID{Solana, BSC}
+
+// ParseChainID - Parse chain id, accepting common aliases ("sol", "bnb", "binance")
+func ParseChainID(s string) (ChainID, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "solana", "sol":
+		return Solana, nil
+	case "bsc", "bnb", "binance", "bnb_chain":
+		return BSC, nil
+	}
+	return "", fmt.Errorf("invalid chain: %q", s)
+}
+
+// String - Implements fmt.Stringer
+func (c ChainID) String() string {
+	return string(c)
+}
+
+// IsValid - Whether c is a known chain
+func (c ChainID) IsValid() bool {
+	_, err := ByChain(c, true, true)
+	return err == nil
+}
+
+// Symbol - Native currency symbol
+func (c ChainID) Symbol() string {
+	s, _ := ByChain(c, "SOL", "BNB")
+	return s
+}
+
+// Decimals - Native currency decimals
+func (c ChainID) Decimals() int {
+	d, _ := ByChain(c, 9, 18)
+	return d
+}
+
+// Networks - Networks available on the chain
+func (c ChainID) Networks() []Network {
+	n, _ := ByChain(c, []Network{Mainnet, Devnet, Testnet, Localnet}, []Network{Mainnet, Testnet})
+	return n
+}
+
+// ByChain - Exhaustive switch: returns the value for c. Adding a chain adds a parameter,
+// so every call site fails to compile until it handles the new chain.
+func ByChain[T any](c ChainID, solana, bsc T) (T, error) {
+	switch c {
+	case Solana:
+		return solana, nil
+	case BSC:
+		return bsc, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("invalid chain: %q", string(c))
+}
+
+// UnmarshalText - Parse with aliases and reject unknown chains in payloads
+func (c *ChainID) UnmarshalText(text []byte) error {
+	parsed, err := ParseChainID(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// Value - Implements driver.Valuer
+func (c ChainID) Value() (driver.Value, error) {
+	return string(c), nil
+}
+
+// Scan - Implements sql.Scanner
+func (c *ChainID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*c = ChainID(v)
+	case []byte:
+		*c = ChainID(v)
+	case nil:
+		*c = ""
+	default:
+		return fmt.Errorf("cannot scan %T into ChainID", value)
+	}
+	return nil
+}
+
+// Network - Deployment network of a chain
+type Network string
+
+const (
+	Mainnet  Network = "mainnet"
+	Devnet   Network = "devnet"
+	Testnet  Network = "testnet"
+	Localnet Network = "localnet"
+)
+
+// ParseNetwork - Parse network, accepting common aliases ("mainnet-beta", "localhost")
+func ParseNetwork(s string) (Network, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "mainnet", "mainnet-beta", "main":
+		return Mainnet, nil
+	case "devnet", "dev":
+		return Devnet, nil
+	case "testnet", "test":
+		return Testnet, nil
+	case "localnet", "localhost", "local":
+		return Localnet, nil
+	}
+	return "", fmt.Errorf("invalid network: %q", s)
+}
+
+// String - Implements fmt.Stringer
+func (n Network) String() string {
+	return string(n)
+}
+
+// IsValid - Whether n is a known network
+func (n Network) IsValid() bool {
+	_, err := ByNetwork(n, true, true, true, true)
+	return err == nil
+}
+
+// IsMainnet - Real funds
+func (n Network) IsMainnet() bool {
+	return n == Mainnet
+}
+
+// ValidFor - Whether the network exists on chain c
+func (n Network) ValidFor(c ChainID) bool {
+	for _, network := range c.Networks() {
+		if network == n {
+			return true
+		}
+	}
+	return false
+}
+
+// ByNetwork - Exhaustive switch over networks (see ByChain)
+func ByNetwork[T any](n Network, mainnet, devnet, testnet, localnet T) (T, error) {
+	switch n {
+	case Mainnet:
+		return mainnet, nil
+	case Devnet:
+		return devnet, nil
+	case Testnet:
+		return testnet, nil
+	case Localnet:
+		return localnet, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("invalid network: %q", string(n))
+}
+
+// UnmarshalText - Parse with aliases and reject unknown networks in payloads
+func (n *Network) UnmarshalText(text []byte) error {
+	parsed, err := ParseNetwork(string(text))
+	if err != nil {
+		return err
+	}
+	*n = parsed
+	return nil
+}
+
+// Value - Implements driver.Valuer
+func (n Network) Value() (driver.Value, error) {
+	return string(n), nil
+}
+
+// Scan - Implements sql.Scanner
+func (n *Network) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*n = Network(v)
+	case []byte:
+		*n = Network(v)
+	case nil:
+		*n = ""
+	default:
+		return fmt.Errorf("cannot scan %T into Network", value)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package chainbnb
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"blockchain/backoff"
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/metrics"
+	"blockchain/txstatus"
+)
+
+const canaryConfirmTimeout = 60 * time.Second
+
+// RunCanary - Execute a zero-value self-transfer with the service wallet through the
+// full create -> sign -> send -> receipt pipeline and measure end-to-end latency
+func (b *BNBChain) RunCanary(ctx context.Context) (*CanaryResult, error) {
+	if b.canaryKey == nil {
+		return nil, fmt.Errorf("canary wallet not configured")
+	}
+	wallet := crypto.PubkeyToAddress(b.canaryKey.PublicKey).Hex()
+
+	start := time.Now()
+	result := &CanaryResult{Chain: chain.BSC, Network: b.network, Wallet: wallet, StartedAt: start}
+	fail := func(step string, err error) (*CanaryResult, error) {
+		result.Step = step
+		result.Error = err.Error()
+		result.LatencyMs = time.Since(start).Milliseconds()
+		metrics.CanaryResult(result.Chain.String(), false, result.LatencyMs)
+		return result, nil
+	}
+
+	// Below any dust minimum on purpose: the transfer only exercises the pipeline
+	created, err := b.createTransaction(TransactionRequest{
+		TransferRequest: dto.TransferRequest{FromAddress: wallet, ToAddress: wallet},
+		Amount:          "0",
+	}, nil)
+	if err != nil {
+		return fail("create", err)
+	}
+
+	signed, err := b.signWithCanaryKey(created.UnsignedTransaction)
+	if err != nil {
+		return fail("sign", err)
+	}
+
+	sent, err := b.SendSignedTransaction(SignedTransactionRequest{
+		TransactionID:     created.TransactionID,
+		SignedTransaction: signed,
+	})
+	if err != nil {
+		return fail("send", err)
+	}
+	result.TxHash = sent.TxHash
+	result.ExplorerURL = sent.ExplorerURL
+
+	// Poll receipt until mined or timeout
+	ctx, cancel := context.WithTimeout(ctx, canaryConfirmTimeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		status, err := b.GetTransactionStatus(sent.TxHash)
+		if err != nil {
+			return fail("status", err)
+		}
+		result.Status = status.Status
+		if status.Status == txstatus.Failed {
+			return fail("confirm", fmt.Errorf("transaction reverted"))
+		}
+		if status.Status.IsSuccess() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fail("confirm", fmt.Errorf("timeout waiting for receipt: %w", ctx.Err()))
+		case <-time.After(backoff.Default.Delay(attempt)):
+		}
+	}
+
+	result.Success = true
+	result.Step = "done"
+	result.LatencyMs = time.Since(start).Milliseconds()
+	metrics.CanaryResult(result.Chain.String(), true, result.LatencyMs)
+	return result, nil
+}
+
+// signWithCanaryKey - Sign hex transaction with the service wallet
+func (b *BNBChain) signWithCanaryKey(unsignedTx string) (string, error) {
+	txBytes, err := hex.DecodeString(unsignedTx)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(txBytes); err != nil {
+		return "", fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(b.chainID)), b.canaryKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	signedBytes, err := signedTx.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize: %w", err)
+	}
+	return hex.EncodeToString(signedBytes), nil
+}
+
+// HandleCanary - POST /api/v1/bnb/admin/canary
+func (b *BNBChain) HandleCanary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := b.RunCanary(r.Context())
+	if err != nil {
+		respondError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusBadGateway
+	}
+	respondJSON(w, result, status)
+}
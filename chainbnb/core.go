@@ -2,28 +2,61 @@ package chainbnb
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"log"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"blockchain/chain"
+	"blockchain/dust"
+	"blockchain/events"
+	"blockchain/explorer"
+	"blockchain/pricing"
+	"blockchain/storage"
 )
 
 type BNBChain struct {
-	client  *ethclient.Client
-	chainID int64
-	network string // mainnet, testnet
+	client    *ethclient.Client
+	chainID   int64
+	network   chain.Network
+	canaryKey *ecdsa.PrivateKey
+	explorer  explorer.Explorer
+	prices    pricing.Source
+	events    events.Emitter
+	history   *storage.Store
+	txStore   *storage.TransactionStore
+	dust      *dust.Policy
 }
 
+var (
+	_ chain.Chain         = (*BNBChain)(nil)
+	_ chain.StuckResolver = (*BNBChain)(nil)
+)
+
 type Config struct {
 	RPCURL  string
 	ChainID int64
-	Network string
+	Network chain.Network
+	// CanaryPrivateKey - Hex service wallet key (without 0x) used by RunCanary (optional)
+	CanaryPrivateKey string
+	// Prices - USD prices for fee estimates (optional, fees are shown in BNB only when nil)
+	Prices pricing.Source
+	// Events - Transfer and transaction status webhook events (optional)
+	Events events.Emitter
+	// History - Store for the audit trail of stuck transaction remediations (optional)
+	History *storage.Store
+	// Transactions - Encrypted store for unsigned and signed payloads (optional, needed by the stuck transaction resolver)
+	Transactions *storage.TransactionStore
+	// Dust - Minimum transfer amount (optional, no check when nil; dust.Default has the documented thresholds)
+	Dust *dust.Policy
 }
 
 // NewBNBChain - Initialize BNB Chain
 func NewBNBChain(config Config) *BNBChain {
 	if config.Network == "" {
-		config.Network = "testnet"
+		config.Network = chain.Testnet
 	}
 	if config.ChainID == 0 {
 		config.ChainID = 97 // BSC Testnet
@@ -34,20 +67,35 @@ func NewBNBChain(config Config) *BNBChain {
 		log.Fatal(err)
 	}
 
-	return &BNBChain{
-		client:  client,
-		chainID: config.ChainID,
-		network: config.Network,
+	bnb := &BNBChain{
+		client:   client,
+		chainID:  config.ChainID,
+		network:  config.Network,
+		explorer: explorer.New(chain.BSC, config.Network, explorer.BscScan),
+		prices:   config.Prices,
+		events:   config.Events,
+		history:  config.History,
+		txStore:  config.Transactions,
+		dust:     config.Dust,
+	}
+	if config.CanaryPrivateKey != "" {
+		key, err := crypto.HexToECDSA(config.CanaryPrivateKey)
+		if err != nil {
+			log.Fatalf("invalid canary private key: %v", err)
+		}
+		bnb.canaryKey = key
 	}
+	return bnb
 }
 
 // GetExplorerURL - Generate explorer URL
 func (b *BNBChain) GetExplorerURL(txHash string) string {
-	baseURL := "https://bscscan.com/tx/"
-	if b.network == "testnet" {
-		baseURL = "https://testnet.bscscan.com/tx/"
-	}
-	return baseURL + txHash
+	return b.explorer.Tx(txHash)
+}
+
+// Explorer - Link builder for addresses, token contracts and contracts
+func (b *BNBChain) Explorer() explorer.Explorer {
+	return b.explorer
 }
 
 // HealthCheck - Check connection to BNB Chain
@@ -0,0 +1,43 @@
+package chainbnb
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"blockchain/chain"
+	"blockchain/events"
+)
+
+// publishSend - transaction.status for a send, plus transfer.sent when it went through (no-op without emitter)
+func (b *BNBChain) publishSend(tx *types.Transaction, result *TransactionResult) {
+	if b.events == nil {
+		return
+	}
+	status := events.TransactionStatus{
+		TransactionID: result.TransactionID,
+		Signature:     tx.Hash().Hex(),
+		Status:        result.Status,
+		ExplorerURL:   result.ExplorerURL,
+	}
+	if !result.Success {
+		status.Error = result.Message
+	}
+	events.Publish(b.events, events.NewTransactionStatusEvent(chain.BSC, status))
+
+	if !result.Success || tx.To() == nil || tx.Value().Sign() == 0 {
+		return
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(b.chainID)), tx)
+	if err != nil {
+		return
+	}
+	events.Publish(b.events, events.NewTransferEvent(chain.BSC, events.Transfer{
+		TransactionID: result.TransactionID,
+		Signature:     tx.Hash().Hex(),
+		FromAddress:   from.Hex(),
+		ToAddress:     tx.To().Hex(),
+		Amount:        tx.Value().String(),
+		Asset:         "BNB",
+	}))
+}
@@ -1,37 +1,36 @@
 package chainbnb
 
-import "time"
+import (
+	"time"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/pricing"
+	"blockchain/txstatus"
+)
 
 // CreateTransactionResponse - Response dari create transaction
 type CreateTransactionResponse struct {
-	TransactionID       string `json:"transaction_id"`
-	UnsignedTransaction string `json:"unsigned_transaction"`
-	Nonce               uint64 `json:"nonce"`
-	GasPrice            string `json:"gas_price"`
-	GasLimit            uint64 `json:"gas_limit"`
+	dto.CreateTransactionResponse
+	Nonce    uint64 `json:"nonce"`
+	GasPrice string `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
 }
 
 // TransactionRequest - Request dari client untuk create transaction
 type TransactionRequest struct {
-	FromAddress string `json:"from_address" binding:"required"`
-	ToAddress   string `json:"to_address" binding:"required"`
-	Amount      string `json:"amount" binding:"required"` // in wei or BNB
+	dto.TransferRequest
+	Amount   string `json:"amount" binding:"required"` // in wei or BNB
+	Encoding string `json:"encoding,omitempty"`        // Unsigned transaction: hex (default) | base64 | base58
 }
 
-// SignedTransactionRequest - Request signed transaction dari client
-type SignedTransactionRequest struct {
-	TransactionID     string `json:"transaction_id" binding:"required"`
-	SignedTransaction string `json:"signed_transaction" binding:"required"` // Hex encoded signed tx
-}
+// SignedTransactionRequest - Request signed transaction dari client (hex encoded signed tx, preflight ignored)
+type SignedTransactionRequest = dto.SignedTransactionRequest
 
 // TransactionResult - Response final setelah send ke blockchain
 type TransactionResult struct {
-	TransactionID string `json:"transaction_id"`
-	TxHash        string `json:"tx_hash"`
-	Success       bool   `json:"success"`
-	Status        string `json:"status"` // pending, confirmed, failed
-	Message       string `json:"message"`
-	ExplorerURL   string `json:"explorer_url,omitempty"`
+	dto.TransactionResult
+	TxHash string `json:"tx_hash"`
 }
 
 // TransactionStatusRequest - Request untuk cek status
@@ -41,39 +40,62 @@ type TransactionStatusRequest struct {
 
 // TransactionStatusResponse - Response status transaction
 type TransactionStatusResponse struct {
-	TxHash        string  `json:"tx_hash"`
-	Status        string  `json:"status"` // pending, confirmed, failed, not_found
-	Confirmations uint64  `json:"confirmations"`
-	BlockNumber   uint64  `json:"block_number"`
-	BlockTime     *uint64 `json:"block_time,omitempty"`
-	GasUsed       uint64  `json:"gas_used"`
-	Error         *string `json:"error,omitempty"`
-	ExplorerURL   string  `json:"explorer_url"`
+	dto.TransactionStatus
+	TxHash      string       `json:"tx_hash"`
+	BlockNumber uint64       `json:"block_number"`
+	BlockTime   *uint64      `json:"block_time,omitempty"`
+	GasUsed     uint64       `json:"gas_used"`
+	Fee         *pricing.Fee `json:"fee,omitempty"` // gas_used * effective gas price
 }
 
 // ErrorResponse - Standard error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+type ErrorResponse = dto.ErrorResponse
+
+// CanaryResult - Result of a canary self-transfer
+type CanaryResult struct {
+	Chain       chain.ChainID   `json:"chain"`
+	Network     chain.Network   `json:"network"`
+	Wallet      string          `json:"wallet"`
+	Success     bool            `json:"success"`
+	Step        string          `json:"step"` // last step reached: create, sign, send, status, confirm, done
+	TxHash      string          `json:"tx_hash,omitempty"`
+	Status      txstatus.Status `json:"status,omitempty"`
+	LatencyMs   int64           `json:"latency_ms"`
+	Error       string          `json:"error,omitempty"`
+	ExplorerURL string          `json:"explorer_url,omitempty"`
+	StartedAt   time.Time       `json:"started_at"`
+}
+
+// StuckDiagnosis - Stuck transaction resolver diagnosis
+type StuckDiagnosis struct {
+	dto.StuckDiagnosis
+	TxHash       string `json:"tx_hash,omitempty"` // Of the signed transaction, when it was sent
+	From         string `json:"from,omitempty"`
+	Nonce        uint64 `json:"nonce"`
+	LatestNonce  uint64 `json:"latest_nonce"`  // Next nonce by mined transactions
+	PendingNonce uint64 `json:"pending_nonce"` // Next nonce including the node's mempool
 }
 
 // TransactionHistory - Model untuk database (optional)
 type TransactionHistory struct {
-	ID            uint       `gorm:"primaryKey" json:"id"`
-	TransactionID string     `gorm:"uniqueIndex;size:64" json:"transaction_id"`
-	FromAddress   string     `gorm:"index;size:42" json:"from_address"`
-	ToAddress     string     `gorm:"index;size:42" json:"to_address"`
-	Amount        string     `json:"amount"`
-	TxHash        string     `gorm:"index;size:66" json:"tx_hash"`
-	Status        string     `gorm:"index;size:20" json:"status"`
-	Nonce         uint64     `json:"nonce"`
-	GasUsed       uint64     `json:"gas_used"`
-	GasPrice      string     `json:"gas_price"`
-	ErrorMessage  string     `gorm:"type:text" json:"error_message,omitempty"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	TransactionID string          `gorm:"uniqueIndex;size:64" json:"transaction_id"`
+	FromAddress   string          `gorm:"index;size:42" json:"from_address"`
+	ToAddress     string          `gorm:"index;size:42" json:"to_address"`
+	Amount        string          `json:"amount"`
+	TxHash        string          `gorm:"index;size:66" json:"tx_hash"`
+	Status        txstatus.Status `gorm:"index;size:20" json:"status"`
+	Nonce         uint64          `json:"nonce"`
+	GasUsed       uint64          `json:"gas_used"`
+	GasPrice      string          `json:"gas_price"`
+	ErrorMessage  string          `gorm:"type:text" json:"error_message,omitempty"`
+	Chain         chain.ChainID   `gorm:"index;size:20" json:"chain"`
+	EnvelopeID    *uint64         `gorm:"index:idx_bnb_history_envelope" json:"envelope_id,omitempty"`
+	OwnerAddress  string          `gorm:"index:idx_bnb_history_envelope;size:42" json:"owner_address,omitempty"` // Envelope owner
+	Action        string          `gorm:"index;size:32" json:"action,omitempty"`                                 // create, claim, refund
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	ConfirmedAt   *time.Time      `json:"confirmed_at,omitempty"`
 }
 
 func (TransactionHistory) TableName() string {
@@ -2,8 +2,11 @@ package chainbnb
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+
+	"blockchain/dust"
+	"blockchain/pagination"
 )
 
 // HandleCreateTransaction - POST /api/v1/bnb/transaction/create
@@ -25,6 +28,10 @@ func (b *BNBChain) HandleCreateTransaction(w http.ResponseWriter, r *http.Reques
 	}
 
 	response, err := b.CreateTransaction(req)
+	if errors.Is(err, dust.ErrBelowMinimum) {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -95,13 +102,10 @@ func (b *BNBChain) HandleGetTransactionHistory(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	limit := 10
-	if l := r.URL.Query().Get("limit"); l != "" {
-		fmt.Sscanf(l, "%d", &limit)
-	}
-
-	if limit > 100 {
-		limit = 100
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"), 10)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	histories, err := b.GetTransactionHistory(address, limit)
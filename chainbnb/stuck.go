@@ -0,0 +1,269 @@
+package chainbnb
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/storage"
+	"blockchain/txstatus"
+)
+
+// keepSignedTransaction - Store a signed transaction about to be broadcast, for rebroadcasts (best effort)
+func (b *BNBChain) keepSignedTransaction(ctx context.Context, transactionID string, txBytes []byte) {
+	if b.txStore == nil || transactionID == "" {
+		return
+	}
+	if err := b.txStore.PutSignedTransaction(ctx, chain.BSC, transactionID, txBytes, storage.SignedTransactionTTL); err != nil {
+		log.Printf("failed to store signed transaction %s: %v", transactionID, err)
+	}
+}
+
+// loadTransaction - Stored signed transaction, or the unsigned one when it was never sent
+func (b *BNBChain) loadTransaction(ctx context.Context, transactionID string) (*types.Transaction, bool, error) {
+	if b.txStore == nil {
+		return nil, false, fmt.Errorf("transaction store not configured")
+	}
+	signed := true
+	txBytes, err := b.txStore.GetSignedTransaction(ctx, transactionID)
+	if errors.Is(err, storage.ErrPayloadNotFound) {
+		signed = false
+		txBytes, err = b.txStore.GetUnsignedTransaction(ctx, transactionID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("transaction %s: %w", transactionID, err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(txBytes); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+	return tx, signed, nil
+}
+
+// DiagnoseStuck - Why transactionID has not landed: never broadcast, dropped, nonce gap, nonce used or still pending
+func (b *BNBChain) DiagnoseStuck(ctx context.Context, transactionID string) (*StuckDiagnosis, error) {
+	tx, signed, err := b.loadTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	diagnosis := &StuckDiagnosis{
+		StuckDiagnosis: dto.StuckDiagnosis{TransactionID: transactionID, Actions: []string{}},
+		Nonce:          tx.Nonce(),
+	}
+	if !signed {
+		// The sender is only known once signed, so there is nothing to check or replace yet
+		diagnosis.State = dto.StuckNeverBroadcast
+		diagnosis.Status = txstatus.NotFound
+		diagnosis.Detail = "created but never sent by the client; it can still be signed while its nonce is free"
+		return diagnosis, nil
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(b.chainID)), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+	diagnosis.TxHash = tx.Hash().Hex()
+	diagnosis.From = from.Hex()
+
+	if receipt, err := b.client.TransactionReceipt(ctx, tx.Hash()); err == nil {
+		diagnosis.State = dto.StuckLanded
+		diagnosis.Status = txstatus.FromEVMReceipt(receipt.Status)
+		diagnosis.Detail = "mined, nothing to remediate"
+		return diagnosis, nil
+	}
+	if diagnosis.LatestNonce, err = b.client.NonceAt(ctx, from, nil); err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	if diagnosis.PendingNonce, err = b.client.PendingNonceAt(ctx, from); err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+	_, _, err = b.client.TransactionByHash(ctx, tx.Hash())
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	inMempool := err == nil
+
+	diagnosis.Status = txstatus.Pending
+	switch {
+	case tx.Nonce() < diagnosis.LatestNonce:
+		diagnosis.State = dto.StuckNonceUsed
+		diagnosis.Status = txstatus.NotFound
+		diagnosis.Detail = fmt.Sprintf("nonce %d was used by another transaction; regenerate with a new nonce", tx.Nonce())
+		diagnosis.Actions = []string{dto.RemediationRegenerate}
+	case tx.Nonce() > diagnosis.LatestNonce:
+		diagnosis.State = dto.StuckNonceGap
+		diagnosis.Detail = fmt.Sprintf("waiting for nonce %d (next mined nonce); cancel fills it with a 0-value self-transfer", diagnosis.LatestNonce)
+		diagnosis.Actions = []string{dto.RemediationCancel}
+		if !inMempool {
+			diagnosis.Actions = append(diagnosis.Actions, dto.RemediationRebroadcast)
+		}
+	case inMempool:
+		diagnosis.State = dto.StuckPending
+		diagnosis.Detail = "in the mempool but not mined, usually underpriced; replace or cancel with a higher gas price"
+		diagnosis.Actions = []string{dto.RemediationReplace, dto.RemediationCancel}
+	default:
+		diagnosis.State = dto.StuckDropped
+		diagnosis.Status = txstatus.NotFound
+		diagnosis.Detail = "sent but dropped from the mempool and its nonce is still free"
+		diagnosis.Actions = []string{dto.RemediationRebroadcast, dto.RemediationReplace, dto.RemediationCancel}
+	}
+	return diagnosis, nil
+}
+
+// RemediateStuck - Apply a remediation offered by DiagnoseStuck; every attempt is audited.
+// Cancel/replace/regenerate return an unsigned transaction for the client to sign.
+func (b *BNBChain) RemediateStuck(ctx context.Context, req dto.RemediationRequest) (result *dto.RemediationResult, err error) {
+	defer func() {
+		outcome := "ok"
+		if err != nil {
+			outcome = "failed: " + err.Error()
+		} else if result.NewTransactionID != "" {
+			outcome = "new transaction " + result.NewTransactionID
+		}
+		b.history.RecordRemediation(ctx, chain.BSC, req.Operator, req.Action, req.TransactionID, outcome)
+	}()
+
+	diagnosis, err := b.DiagnoseStuck(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(diagnosis.Actions, req.Action) {
+		return nil, fmt.Errorf("%w: %s for state %s", chain.ErrRemediationNotApplicable, req.Action, diagnosis.State)
+	}
+	tx, _, err := b.loadTransaction(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.To() == nil && (req.Action == dto.RemediationRegenerate || req.Action == dto.RemediationReplace) {
+		return nil, fmt.Errorf("%w: cannot %s a contract creation", chain.ErrRemediationNotApplicable, req.Action)
+	}
+
+	result = &dto.RemediationResult{TransactionID: req.TransactionID, Action: req.Action}
+	from := common.HexToAddress(diagnosis.From)
+	var replacement *types.Transaction
+	switch req.Action {
+	case dto.RemediationRebroadcast:
+		if err := b.client.SendTransaction(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to rebroadcast: %w", err)
+		}
+		result.Message = "rebroadcast " + tx.Hash().Hex()
+		return result, nil
+	case dto.RemediationRegenerate:
+		gasPrice, err := b.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+		replacement = types.NewTransaction(diagnosis.PendingNonce, *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+		result.Message = fmt.Sprintf("regenerated with nonce %d", diagnosis.PendingNonce)
+	case dto.RemediationReplace:
+		gasPrice, err := b.bumpedGasPrice(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		replacement = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+		result.Message = fmt.Sprintf("replacement for nonce %d at gas price %s", tx.Nonce(), gasPrice)
+	case dto.RemediationCancel:
+		nonce := tx.Nonce()
+		if diagnosis.State == dto.StuckNonceGap {
+			nonce = diagnosis.LatestNonce
+		}
+		gasPrice, err := b.bumpedGasPrice(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		replacement = types.NewTransaction(nonce, from, big.NewInt(0), 21000, gasPrice, nil)
+		result.Message = fmt.Sprintf("0-value self-transfer for nonce %d at gas price %s", nonce, gasPrice)
+	}
+
+	txBytes, err := replacement.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	result.NewTransactionID = fmt.Sprintf("bnb_txn_%d", time.Now().UnixNano())
+	if err := b.txStore.PutUnsignedTransaction(ctx, chain.BSC, result.NewTransactionID, txBytes, storage.UnsignedTransactionTTL); err != nil {
+		return nil, fmt.Errorf("failed to store %s transaction: %w", req.Action, err)
+	}
+	result.UnsignedTransaction = hex.EncodeToString(txBytes)
+	result.Message += "; " + from.Hex() + " must sign and send " + result.NewTransactionID
+	return result, nil
+}
+
+// bumpedGasPrice - Gas price a node accepts for a same-nonce replacement: the original +12.5%
+// (nodes require at least +10%), or the current suggestion when higher
+func (b *BNBChain) bumpedGasPrice(ctx context.Context, tx *types.Transaction) (*big.Int, error) {
+	suggested, err := b.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	bumped := new(big.Int).Mul(tx.GasPrice(), big.NewInt(9))
+	bumped.Div(bumped, big.NewInt(8)).Add(bumped, big.NewInt(1))
+	if suggested.Cmp(bumped) > 0 {
+		return suggested, nil
+	}
+	return bumped, nil
+}
+
+// HandleDiagnoseStuck - GET /api/v1/bnb/admin/stuck?transaction_id=xxx
+func (b *BNBChain) HandleDiagnoseStuck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	transactionID := r.URL.Query().Get("transaction_id")
+	if transactionID == "" {
+		respondError(w, "transaction_id parameter required", http.StatusBadRequest)
+		return
+	}
+	diagnosis, err := b.DiagnoseStuck(r.Context(), transactionID)
+	if errors.Is(err, storage.ErrPayloadNotFound) {
+		respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, diagnosis, http.StatusOK)
+}
+
+// HandleRemediateStuck - POST /api/v1/bnb/admin/stuck/remediate
+func (b *BNBChain) HandleRemediateStuck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req dto.RemediationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.TransactionID == "" || req.Action == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	result, err := b.RemediateStuck(r.Context(), req)
+	switch {
+	case errors.Is(err, storage.ErrPayloadNotFound):
+		respondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, chain.ErrRemediationNotApplicable):
+		respondError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		respondError(w, err.Error(), http.StatusInternalServerError)
+	default:
+		respondJSON(w, result, http.StatusOK)
+	}
+}
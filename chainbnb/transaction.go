@@ -2,17 +2,32 @@ package chainbnb
 
 import (
 	"context"
-	"encoding/hex"
 	"fmt"
+	"log"
 	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/dust"
+	"blockchain/pagination"
+	"blockchain/pricing"
+	"blockchain/storage"
+	"blockchain/txencoding"
+	"blockchain/txstatus"
 )
 
 // CreateTransaction - Step 1: Backend create unsigned transaction
 func (b *BNBChain) CreateTransaction(req TransactionRequest) (*CreateTransactionResponse, error) {
+	return b.createTransaction(req, b.dust)
+}
+
+// createTransaction - CreateTransaction with minimum as the dust policy (nil = no check, the canary's
+// zero-value self-transfer)
+func (b *BNBChain) createTransaction(req TransactionRequest, minimum *dust.Policy) (*CreateTransactionResponse, error) {
 	// Validate addresses
 	if !common.IsHexAddress(req.FromAddress) {
 		return nil, fmt.Errorf("invalid from address")
@@ -20,6 +35,10 @@ func (b *BNBChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 	if !common.IsHexAddress(req.ToAddress) {
 		return nil, fmt.Errorf("invalid to address")
 	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Hex)
+	if err != nil {
+		return nil, err
+	}
 
 	fromAddress := common.HexToAddress(req.FromAddress)
 	toAddress := common.HexToAddress(req.ToAddress)
@@ -30,6 +49,9 @@ func (b *BNBChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 	if !ok {
 		return nil, fmt.Errorf("invalid amount")
 	}
+	if err := minimum.Check(chain.BSC, chain.BSC.Symbol(), "amount", amount); err != nil {
+		return nil, err
+	}
 
 	ctx := context.Background()
 
@@ -58,13 +80,22 @@ func (b *BNBChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 	}
 
 	transactionID := fmt.Sprintf("bnb_txn_%d", time.Now().UnixNano())
+	if b.txStore != nil {
+		if err := b.txStore.PutUnsignedTransaction(ctx, chain.BSC, transactionID, txBytes, storage.UnsignedTransactionTTL); err != nil {
+			log.Printf("failed to store unsigned transaction %s: %v", transactionID, err)
+		}
+	}
 
 	response := &CreateTransactionResponse{
-		TransactionID:       transactionID,
-		UnsignedTransaction: hex.EncodeToString(txBytes),
-		Nonce:               nonce,
-		GasPrice:            gasPrice.String(),
-		GasLimit:            gasLimit,
+		CreateTransactionResponse: dto.CreateTransactionResponse{
+			TransactionID:       transactionID,
+			UnsignedTransaction: encoding.Encode(txBytes),
+			Encoding:            encoding.String(),
+			EstimatedFee:        pricing.FormatFee(ctx, b.prices, chain.BSC, new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))),
+		},
+		Nonce:    nonce,
+		GasPrice: gasPrice.String(),
+		GasLimit: gasLimit,
 	}
 
 	return response, nil
@@ -72,8 +103,12 @@ func (b *BNBChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 
 // SendSignedTransaction - Step 3: Backend send signed transaction ke blockchain
 func (b *BNBChain) SendSignedTransaction(req SignedTransactionRequest) (*TransactionResult, error) {
-	// Decode signed transaction
-	txBytes, err := hex.DecodeString(req.SignedTransaction)
+	// Decode signed transaction (encoding detected unless the client names it)
+	encoding, err := txencoding.Parse(req.Encoding, "")
+	if err != nil {
+		return nil, err
+	}
+	txBytes, _, err := txencoding.DecodeAuto(req.SignedTransaction, encoding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode signed transaction: %w", err)
 	}
@@ -88,23 +123,26 @@ func (b *BNBChain) SendSignedTransaction(req SignedTransactionRequest) (*Transac
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	b.keepSignedTransaction(ctx, req.TransactionID, txBytes)
 	err = b.client.SendTransaction(ctx, tx)
 
-	result := &TransactionResult{
+	result := &TransactionResult{TransactionResult: dto.TransactionResult{
 		TransactionID: req.TransactionID,
 		Success:       err == nil,
-	}
+	}}
 
 	if err != nil {
-		result.Status = "failed"
+		result.Status = txstatus.Failed
 		result.Message = fmt.Sprintf("Failed to send transaction: %v", err)
+		b.publishSend(tx, result)
 		return result, err
 	}
 
 	result.TxHash = tx.Hash().Hex()
-	result.Status = "pending"
+	result.Status = txstatus.Pending
 	result.Message = "Transaction sent successfully"
 	result.ExplorerURL = b.GetExplorerURL(tx.Hash().Hex())
+	b.publishSend(tx, result)
 
 	return result, nil
 }
@@ -117,28 +155,30 @@ func (b *BNBChain) GetTransactionStatus(txHash string) (*TransactionStatusRespon
 	defer cancel()
 
 	response := &TransactionStatusResponse{
-		TxHash:      txHash,
-		ExplorerURL: b.GetExplorerURL(txHash),
+		TransactionStatus: dto.TransactionStatus{ExplorerURL: b.GetExplorerURL(txHash)},
+		TxHash:            txHash,
 	}
 
 	// Get transaction receipt
 	receipt, err := b.client.TransactionReceipt(ctx, hash)
 	if err != nil {
-		response.Status = "not_found"
+		response.Status = txstatus.NotFound
 		return response, nil
 	}
 
 	// Check status
-	if receipt.Status == types.ReceiptStatusSuccessful {
-		response.Status = "confirmed"
-	} else {
-		response.Status = "failed"
+	response.Status = txstatus.FromEVMReceipt(receipt.Status)
+	if response.Status == txstatus.Failed {
 		errMsg := "transaction reverted"
 		response.Error = &errMsg
 	}
 
 	response.BlockNumber = receipt.BlockNumber.Uint64()
 	response.GasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		paid := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		response.Fee = pricing.FormatFee(ctx, b.prices, chain.BSC, paid)
+	}
 
 	// Get block for timestamp
 	block, err := b.client.BlockByNumber(ctx, receipt.BlockNumber)
@@ -161,3 +201,16 @@ func (b *BNBChain) GetTransactionHistory(address string, limit int) ([]Transacti
 	// This would require database implementation
 	return nil, fmt.Errorf("database not configured")
 }
+
+// GetTransactionHistoryPage - One page of history (requires database); cursor is the last id returned
+func (b *BNBChain) GetTransactionHistoryPage(address string, cursor string, limit int) (*pagination.Page[TransactionHistory], error) {
+	// This would require database implementation
+	return nil, fmt.Errorf("database not configured")
+}
+
+// IterTransactionHistory - Iterate address history across pages, newest first
+func (b *BNBChain) IterTransactionHistory(ctx context.Context, address string, pageSize int) *pagination.Iterator[TransactionHistory] {
+	return pagination.New(ctx, func(ctx context.Context, cursor string) (*pagination.Page[TransactionHistory], error) {
+		return b.GetTransactionHistoryPage(address, cursor, pageSize)
+	})
+}
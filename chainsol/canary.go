@@ -0,0 +1,121 @@
+package chainsol
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/metrics"
+)
+
+// canaryLamports - Self-transfer amount (net zero, only the fee is spent)
+const canaryLamports = 1
+
+// RunCanary - Execute a tiny self-transfer with the service wallet through the full
+// create -> sign -> send -> status pipeline and measure end-to-end latency
+func (p *SolChain) RunCanary(ctx context.Context) (*CanaryResult, error) {
+	if p.canaryKey == nil {
+		return nil, fmt.Errorf("canary wallet not configured")
+	}
+	wallet := p.canaryKey.PublicKey().String()
+
+	start := time.Now()
+	result := &CanaryResult{Chain: chain.Solana, Network: p.network, Wallet: wallet, StartedAt: start}
+	fail := func(step string, err error) (*CanaryResult, error) {
+		result.Step = step
+		result.Error = err.Error()
+		result.LatencyMs = time.Since(start).Milliseconds()
+		metrics.CanaryResult(result.Chain.String(), false, result.LatencyMs)
+		return result, nil
+	}
+
+	// Below any dust minimum on purpose: the transfer only exercises the pipeline
+	created, err := p.createTransaction(TransactionRequest{
+		TransferRequest: dto.TransferRequest{FromAddress: wallet, ToAddress: wallet},
+		Amount:          canaryLamports,
+	}, nil)
+	if err != nil {
+		return fail("create", err)
+	}
+
+	signed, err := p.signWithCanaryKey(created.UnsignedTransaction)
+	if err != nil {
+		return fail("sign", err)
+	}
+
+	sent, err := p.SendSignedTransaction(SignedTransactionRequest{
+		TransactionID:     created.TransactionID,
+		SignedTransaction: signed,
+	})
+	if err != nil {
+		return fail("send", err)
+	}
+	result.Signature = sent.Signature
+	result.ExplorerURL = sent.ExplorerURL
+
+	status, err := p.GetTransactionStatus(sent.Signature)
+	if err != nil {
+		return fail("status", err)
+	}
+	result.Status = status.Status
+	if !status.Status.IsSuccess() {
+		return fail("confirm", fmt.Errorf("unexpected status: %s", status.Status))
+	}
+
+	result.Success = true
+	result.Step = "done"
+	result.LatencyMs = time.Since(start).Milliseconds()
+	metrics.CanaryResult(result.Chain.String(), true, result.LatencyMs)
+	return result, nil
+}
+
+// signWithCanaryKey - Sign base64 transaction with the service wallet
+func (p *SolChain) signWithCanaryKey(unsignedTx string) (string, error) {
+	txBytes, err := base64.StdEncoding.DecodeString(unsignedTx)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	var tx solana.Transaction
+	if err := tx.UnmarshalWithDecoder(bin.NewBinDecoder(txBytes)); err != nil {
+		return "", fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+	key := *p.canaryKey
+	if _, err := tx.Sign(func(pub solana.PublicKey) *solana.PrivateKey {
+		if key.PublicKey().Equals(pub) {
+			return &key
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	signedBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signedBytes), nil
+}
+
+// HandleCanary - POST /api/v1/sol/admin/canary
+func (p *SolChain) HandleCanary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, err := p.RunCanary(r.Context())
+	if err != nil {
+		respondError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusBadGateway
+	}
+	respondJSON(w, result, status)
+}
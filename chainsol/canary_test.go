@@ -0,0 +1,64 @@
+package chainsol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/dto"
+	"blockchain/dust"
+)
+
+// fakeRPC - Node answering getLatestBlockhash and failing every other method (sends fail)
+func fakeRPC(t *testing.T) *rpc.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		reply := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "getLatestBlockhash" {
+			reply["result"] = map[string]any{
+				"context": map[string]any{"slot": 1},
+				"value":   map[string]any{"blockhash": solana.HashFromBytes(make([]byte, 32)).String(), "lastValidBlockHeight": 100},
+			}
+		} else {
+			reply["error"] = map[string]any{"code": -32601, "message": "unavailable in test: " + req.Method}
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+func TestCanaryBypassesDustPolicy(t *testing.T) {
+	key := solana.NewWallet().PrivateKey
+	p := &SolChain{http: fakeRPC(t), canaryKey: &key, dust: dust.Default()}
+	wallet := key.PublicKey().String()
+
+	// The same self-transfer from a client is dust
+	if _, err := p.CreateTransaction(TransactionRequest{
+		TransferRequest: dto.TransferRequest{FromAddress: wallet, ToAddress: wallet},
+		Amount:          canaryLamports,
+	}); err == nil {
+		t.Fatal("CreateTransaction accepted a canary-sized transfer under dust.Default()")
+	}
+
+	result, err := p.RunCanary(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Step != "send" || strings.Contains(result.Error, "dust") {
+		t.Fatalf("canary stopped at %s: %s", result.Step, result.Error)
+	}
+}
@@ -12,7 +12,7 @@ import (
 
 // HandleSignTransaction - Function for CLIENT SIDE
 // Private key will NEVER SEND to backend side
-// Reference/example and TESTING PURPOSE ONLY
+// Reference/example and TESTING PURPOSE ONLY (operators: use `ops sign` offline)
 func (p *SolChain) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
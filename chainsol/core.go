@@ -6,56 +6,125 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/rpc/ws"
+
+	"blockchain/chain"
+	"blockchain/dust"
+	"blockchain/events"
+	"blockchain/explorer"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/rpcpool"
+	"blockchain/storage"
 )
 
 type SolChain struct {
-	http    *rpc.Client
-	ws      *ws.Client
-	db      *gorm.DB
-	network string // mainnet, devnet, testnet
+	http      *rpc.Client
+	ws        *ws.Client
+	db        *gorm.DB
+	network   chain.Network
+	canaryKey *solana.PrivateKey
+	preflight *preflight.Policy
+	history   *storage.Store
+	explorer  explorer.Explorer
+	prices    pricing.Source
+	txStore   *storage.TransactionStore
+	events    events.Emitter
+	pools     *rpcpool.Router
+	dust      *dust.Policy
 }
 
+var (
+	_ chain.Chain           = (*SolChain)(nil)
+	_ chain.ReceiptProvider = (*SolChain)(nil)
+	_ chain.EnvelopeHistory = (*SolChain)(nil)
+	_ chain.StuckResolver   = (*SolChain)(nil)
+)
+
 type Config struct {
 	RPCURL  string
 	WSURL   string
-	Network string
+	Network chain.Network
+	// CanaryPrivateKey - Base58 service wallet key used by RunCanary (optional)
+	CanaryPrivateKey string
+	// Preflight - Default preflight mode per tenant (optional, node default when nil)
+	Preflight *preflight.Policy
+	// History - Store for failed submissions, transaction history and the stuck transaction audit trail (optional)
+	History *storage.Store
+	// ExplorerProvider - Explorer used for links (default explorer.solana.com)
+	ExplorerProvider explorer.Provider
+	// Prices - USD prices for fee estimates (optional, fees are shown in SOL only when nil)
+	Prices pricing.Source
+	// Transactions - Encrypted store for unsigned payloads (optional)
+	Transactions *storage.TransactionStore
+	// Events - Transfer and transaction status webhook events (optional)
+	Events events.Emitter
+	// RPCPools - Separate read/write endpoint pools (optional, RPCURL serves everything when nil)
+	RPCPools *rpcpool.Router
+	// Dust - Minimum transfer amount (optional, no check when nil; dust.Default has the documented thresholds)
+	Dust *dust.Policy
 }
 
 // NewSolChain - Initialize Solana
 func NewSolChain(config Config) *SolChain {
 	if config.Network == "" {
-		config.Network = "mainnet"
+		config.Network = chain.Mainnet
 	}
 	http := rpc.New(config.RPCURL)
+	if config.RPCPools != nil {
+		http = config.RPCPools.Client()
+	}
 	wss, err := ws.Connect(context.TODO(), config.WSURL)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	return &SolChain{
-		http:    http,
-		ws:      wss,
-		network: config.Network,
+	sol := &SolChain{
+		http:      http,
+		ws:        wss,
+		network:   config.Network,
+		preflight: config.Preflight,
+		history:   config.History,
+		explorer:  explorer.New(chain.Solana, config.Network, config.ExplorerProvider),
+		prices:    config.Prices,
+		txStore:   config.Transactions,
+		events:    config.Events,
+		pools:     config.RPCPools,
+		dust:      config.Dust,
+	}
+	if config.History != nil {
+		sol.db = config.History.DB()
+		if err := sol.db.AutoMigrate(&TransactionHistory{}); err != nil {
+			log.Fatalf("failed to migrate transaction history: %v", err)
+		}
 	}
+	if config.CanaryPrivateKey != "" {
+		key, err := solana.PrivateKeyFromBase58(config.CanaryPrivateKey)
+		if err != nil {
+			log.Fatalf("invalid canary private key: %v", err)
+		}
+		sol.canaryKey = &key
+	}
+	return sol
 }
 
 // GetExplorerURL - Generate explorer URL
 func (p *SolChain) GetExplorerURL(signature string) string {
-	baseURL := "https://explorer.solana.com/tx/"
-	switch p.network {
-	case "devnet":
-		return baseURL + signature + "?cluster=devnet"
-	case "testnet":
-		return baseURL + signature + "?cluster=testnet"
-	default:
-		return baseURL + signature
-	}
+	return p.explorer.Tx(signature)
+}
+
+// Explorer - Link builder for addresses, token accounts and programs
+func (p *SolChain) Explorer() explorer.Explorer {
+	return p.explorer
 }
 
 // Health check
 func (p *SolChain) HealthCheck() error {
+	if p.pools != nil {
+		return p.pools.Check(context.Background())
+	}
 	_, err := p.http.GetHealth(context.Background())
 	return err
 }
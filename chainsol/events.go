@@ -0,0 +1,73 @@
+package chainsol
+
+import (
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+
+	"blockchain/chain"
+	"blockchain/events"
+)
+
+// publishSend - transaction.status for a send, plus transfer.sent when it went through (no-op without emitter)
+func (p *SolChain) publishSend(tx *solana.Transaction, result *TransactionResult) {
+	if p.events == nil {
+		return
+	}
+	status := events.TransactionStatus{
+		TransactionID: result.TransactionID,
+		Signature:     result.Signature,
+		Status:        result.Status,
+		ExplorerURL:   result.ExplorerURL,
+	}
+	if status.Signature == "" && len(tx.Signatures) > 0 {
+		status.Signature = tx.Signatures[0].String()
+	}
+	if !result.Success {
+		status.Error = result.Message
+	}
+	if result.Receipt != nil {
+		status.Slot = result.Receipt.Slot
+	}
+	events.Publish(p.events, events.NewTransactionStatusEvent(chain.Solana, status))
+
+	if !result.Success {
+		return
+	}
+	for _, transfer := range systemTransfers(tx) {
+		transfer.TransactionID = result.TransactionID
+		transfer.Signature = result.Signature
+		events.Publish(p.events, events.NewTransferEvent(chain.Solana, transfer))
+	}
+}
+
+// systemTransfers - System program transfers in tx
+func systemTransfers(tx *solana.Transaction) []events.Transfer {
+	var transfers []events.Transfer
+	for _, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil || !programID.Equals(solana.SystemProgramID) {
+			continue
+		}
+		accounts, err := ix.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			continue
+		}
+		decoded, err := system.DecodeInstruction(accounts, ix.Data)
+		if err != nil {
+			continue
+		}
+		transfer, ok := decoded.Impl.(*system.Transfer)
+		if !ok || transfer.Lamports == nil {
+			continue
+		}
+		transfers = append(transfers, events.Transfer{
+			FromAddress: transfer.GetFundingAccount().PublicKey.String(),
+			ToAddress:   transfer.GetRecipientAccount().PublicKey.String(),
+			Amount:      strconv.FormatUint(*transfer.Lamports, 10),
+			Asset:       "SOL",
+		})
+	}
+	return transfers
+}
@@ -1,19 +1,25 @@
 package chainsol
 
-import "time"
+import (
+	"time"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/receipt"
+	"blockchain/txstatus"
+)
 
 // CreateTransactionResponse - Response dari create transaction
 type CreateTransactionResponse struct {
-	TransactionID       string `json:"transaction_id"`
-	UnsignedTransaction string `json:"unsigned_transaction"`
-	RecentBlockhash     string `json:"recent_blockhash"`
+	dto.CreateTransactionResponse
+	RecentBlockhash string `json:"recent_blockhash"`
 }
 
 // TransactionRequest - Request dari client untuk create transaction
 type TransactionRequest struct {
-	FromAddress string `json:"from_address" binding:"required" validate:"required"`
-	ToAddress   string `json:"to_address" binding:"required" validate:"required"`
-	Amount      uint64 `json:"amount" binding:"required" validate:"required,gt=0"`
+	dto.TransferRequest
+	Amount   uint64 `json:"amount" binding:"required" validate:"required,gt=0"` // lamports
+	Encoding string `json:"encoding,omitempty"`                                 // Unsigned transaction: base64 (default) | base58 | hex
 }
 
 // UnsignedTransactionResponse - Response unsigned transaction ke client
@@ -28,20 +34,15 @@ type UnsignedTransactionResponse struct {
 	Message         string `json:"message"`
 }
 
-// SignedTransactionRequest - Request signed transaction dari client
-type SignedTransactionRequest struct {
-	TransactionID     string `json:"transaction_id" binding:"required"`
-	SignedTransaction string `json:"signed_transaction" binding:"required"` // Base64 encoded signed tx
-}
+// SignedTransactionRequest - Request signed transaction dari client (base64 encoded signed tx)
+type SignedTransactionRequest = dto.SignedTransactionRequest
 
 // TransactionResult - Response final setelah send ke blockchain
 type TransactionResult struct {
-	TransactionID string `json:"transaction_id"`
-	Signature     string `json:"signature"`
-	Success       bool   `json:"success"`
-	Status        string `json:"status"` // pending, confirmed, failed
-	Message       string `json:"message"`
-	ExplorerURL   string `json:"explorer_url,omitempty"`
+	dto.TransactionResult
+	Signature   string           `json:"signature"`
+	Receipt     *receipt.Receipt `json:"receipt,omitempty"`      // Set once the transaction has landed
+	ProgramLogs []string         `json:"program_logs,omitempty"` // Set on failure, also kept in the history store
 }
 
 // TransactionStatusRequest - Request untuk cek status
@@ -51,38 +52,62 @@ type TransactionStatusRequest struct {
 
 // TransactionStatusResponse - Response status transaction
 type TransactionStatusResponse struct {
-	Signature     string  `json:"signature"`
-	Status        string  `json:"status"` // confirmed, finalized, failed, not_found
-	Confirmations uint64  `json:"confirmations"`
-	Slot          uint64  `json:"slot"`
-	BlockTime     *int64  `json:"block_time,omitempty"`
-	Fee           uint64  `json:"fee"`
-	Error         *string `json:"error,omitempty"`
-	ExplorerURL   string  `json:"explorer_url"`
+	dto.TransactionStatus
+	Signature string `json:"signature"`
+	Slot      uint64 `json:"slot"`
+	BlockTime *int64 `json:"block_time,omitempty"`
+	Fee       uint64 `json:"fee"`
 }
 
 // ErrorResponse - Standard error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+type ErrorResponse = dto.ErrorResponse
+
+// CanaryResult - Result of a canary self-transfer
+type CanaryResult struct {
+	Chain       chain.ChainID   `json:"chain"`
+	Network     chain.Network   `json:"network"`
+	Wallet      string          `json:"wallet"`
+	Success     bool            `json:"success"`
+	Step        string          `json:"step"` // last step reached: create, sign, send, status, confirm, done
+	Signature   string          `json:"signature,omitempty"`
+	Status      txstatus.Status `json:"status,omitempty"`
+	LatencyMs   int64           `json:"latency_ms"`
+	Error       string          `json:"error,omitempty"`
+	ExplorerURL string          `json:"explorer_url,omitempty"`
+	StartedAt   time.Time       `json:"started_at"`
+}
+
+// StuckDiagnosis - Stuck transaction resolver diagnosis
+type StuckDiagnosis struct {
+	dto.StuckDiagnosis
+	Signature       string `json:"signature,omitempty"` // Of the signed transaction, when it was sent
+	RecentBlockhash string `json:"recent_blockhash"`
+	LastFailure     string `json:"last_failure,omitempty"` // Latest recorded send error
 }
 
 // TransactionHistory - Model untuk database (optional)
 type TransactionHistory struct {
-	ID              uint       `gorm:"primaryKey" json:"id"`
-	TransactionID   string     `gorm:"uniqueIndex;size:64" json:"transaction_id"`
-	FromAddress     string     `gorm:"index;size:44" json:"from_address"`
-	ToAddress       string     `gorm:"index;size:44" json:"to_address"`
-	Amount          uint64     `json:"amount"`
-	Signature       string     `gorm:"index;size:88" json:"signature"`
-	Status          string     `gorm:"index;size:20" json:"status"`
-	RecentBlockhash string     `gorm:"size:44" json:"recent_blockhash"`
-	Fee             uint64     `json:"fee"`
-	ErrorMessage    string     `gorm:"type:text" json:"error_message,omitempty"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
-	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	ID              uint            `gorm:"primaryKey" json:"id"`
+	TransactionID   string          `gorm:"uniqueIndex;size:64" json:"transaction_id"`
+	FromAddress     string          `gorm:"index;size:44" json:"from_address"`
+	ToAddress       string          `gorm:"index;size:44" json:"to_address"`
+	Amount          uint64          `json:"amount"`
+	Signature       string          `gorm:"index;size:88" json:"signature"`
+	Status          txstatus.Status `gorm:"index;size:20" json:"status"`
+	RecentBlockhash string          `gorm:"size:44" json:"recent_blockhash"`
+	Fee             uint64          `json:"fee"`
+	Slot            uint64          `json:"slot,omitempty"`
+	BlockTime       *int64          `json:"block_time,omitempty"`
+	PriorityFee     uint64          `json:"priority_fee,omitempty"`
+	ComputeUnits    *uint64         `json:"compute_units_consumed,omitempty"`
+	ErrorMessage    string          `gorm:"type:text" json:"error_message,omitempty"`
+	Chain           chain.ChainID   `gorm:"index;size:20" json:"chain"`
+	EnvelopeID      *uint64         `gorm:"index:idx_history_envelope" json:"envelope_id,omitempty"`
+	OwnerAddress    string          `gorm:"index:idx_history_envelope;size:44" json:"owner_address,omitempty"` // Envelope owner
+	Action          string          `gorm:"index;size:32" json:"action,omitempty"`                             // create, claim, refund
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	ConfirmedAt     *time.Time      `json:"confirmed_at,omitempty"`
 }
 
 func (TransactionHistory) TableName() string {
@@ -2,8 +2,13 @@ package chainsol
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"strconv"
+
+	"blockchain/dust"
+	"blockchain/pagination"
+	"blockchain/receipt"
 )
 
 // HandleCreateTransaction - POST /api/v1/transaction/create
@@ -22,6 +27,10 @@ func (p *SolChain) HandleCreateTransaction(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	response, err := p.CreateTransaction(req)
+	if errors.Is(err, dust.ErrBelowMinimum) {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -44,6 +53,12 @@ func (p *SolChain) HandleSendTransaction(w http.ResponseWriter, r *http.Request)
 		respondError(w, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	mode, err := p.preflight.FromRequest(r, req.Preflight)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Preflight = string(mode)
 	result, err := p.SendSignedTransaction(req)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
@@ -71,30 +86,75 @@ func (p *SolChain) HandleGetTransactionStatus(w http.ResponseWriter, r *http.Req
 	respondJSON(w, result, http.StatusOK)
 }
 
-// HandleGetTransactionHistory - GET /api/v1/transaction/history?address=xxx&limit=10
+// HandleGetSubmissionReceipt - GET /api/v1/sol/transaction/receipt?signature=xxx
+func (p *SolChain) HandleGetSubmissionReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	signature := r.URL.Query().Get("signature")
+	if signature == "" {
+		respondError(w, "signature parameter required", http.StatusBadRequest)
+		return
+	}
+	result, err := p.GetSubmissionReceipt(signature)
+	if errors.Is(err, receipt.ErrNotLanded) {
+		respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetTransactionHistory - GET /api/v1/transaction/history?address=xxx&limit=10&cursor=xxx (next cursor in X-Next-Cursor).
+// ?owner=xxx&envelope_id=N instead returns every transaction of that envelope.
 func (p *SolChain) HandleGetTransactionHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	address := r.URL.Query().Get("address")
-	if address == "" {
-		respondError(w, "address parameter required", http.StatusBadRequest)
+	query := r.URL.Query()
+	address := query.Get("address")
+	envelopeParam := query.Get("envelope_id")
+	if address == "" && envelopeParam == "" {
+		respondError(w, "address or envelope_id parameter required", http.StatusBadRequest)
 		return
 	}
-	limit := 10
-	if l := r.URL.Query().Get("limit"); l != "" {
-		fmt.Sscanf(l, "%d", &limit)
+	var envelopeID uint64
+	if envelopeParam != "" {
+		id, err := strconv.ParseUint(envelopeParam, 10, 64)
+		if err != nil {
+			respondError(w, "invalid envelope_id", http.StatusBadRequest)
+			return
+		}
+		if query.Get("owner") == "" {
+			respondError(w, "owner parameter required with envelope_id", http.StatusBadRequest)
+			return
+		}
+		envelopeID = id
+	}
+	limit, err := pagination.ParseLimit(query.Get("limit"), 10)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if limit > 100 {
-		limit = 100
+	var page *pagination.Page[TransactionHistory]
+	if envelopeParam != "" {
+		page, err = p.GetEnvelopeHistoryPage(query.Get("owner"), envelopeID, query.Get("cursor"), limit)
+	} else {
+		page, err = p.GetTransactionHistoryPage(address, query.Get("cursor"), limit)
 	}
-	histories, err := p.GetTransactionHistory(address, limit)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	respondJSON(w, histories, http.StatusOK)
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	respondJSON(w, page.Items, http.StatusOK)
 }
 
 // Helper functions
@@ -0,0 +1,85 @@
+package chainsol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"blockchain/chain"
+)
+
+// testChain - SolChain over an in-memory history DB holding n transactions of address
+func testChain(t *testing.T, address string, n int) *SolChain {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&TransactionHistory{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if err := db.Create(&TransactionHistory{TransactionID: fmt.Sprintf("tx-%d", i), FromAddress: address, Chain: chain.Solana}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &SolChain{db: db}
+}
+
+func TestHandleGetTransactionHistoryLimit(t *testing.T) {
+	const address = "wallet"
+	p := testChain(t, address, 3)
+
+	for _, tc := range []struct {
+		limit  string
+		status int
+		items  int
+	}{
+		{"", http.StatusOK, 3},
+		{"2", http.StatusOK, 2},
+		{"0", http.StatusOK, 1},
+		{"-5", http.StatusOK, 1},
+		{"1000", http.StatusOK, 3},
+		{"ten", http.StatusBadRequest, 0},
+	} {
+		t.Run("limit="+tc.limit, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/transaction/history?address="+address+"&limit="+tc.limit, nil)
+			rec := httptest.NewRecorder()
+			p.HandleGetTransactionHistory(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var items []TransactionHistory
+			if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tc.items {
+				t.Errorf("got %d items, want %d", len(items), tc.items)
+			}
+		})
+	}
+}
+
+func TestTransactionHistoryPageDefaultLimit(t *testing.T) {
+	const address = "wallet"
+	p := testChain(t, address, DefaultPageSize+1)
+
+	for _, limit := range []int{0, -1} {
+		page, err := p.GetTransactionHistoryPage(address, "", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != DefaultPageSize || page.NextCursor == "" {
+			t.Errorf("limit %d: %d items, next cursor %q; want a full page of %d", limit, len(page.Items), page.NextCursor, DefaultPageSize)
+		}
+	}
+}
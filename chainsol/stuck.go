@@ -0,0 +1,215 @@
+package chainsol
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/storage"
+	"blockchain/txstatus"
+)
+
+// keepSignedTransaction - Store a signed transaction about to be broadcast, for rebroadcasts (best effort)
+func (p *SolChain) keepSignedTransaction(ctx context.Context, transactionID string, txBytes []byte) {
+	if p.txStore == nil || transactionID == "" {
+		return
+	}
+	if err := p.txStore.PutSignedTransaction(ctx, chain.Solana, transactionID, txBytes, storage.SignedTransactionTTL); err != nil {
+		log.Printf("failed to store signed transaction %s: %v", transactionID, err)
+	}
+}
+
+// loadTransaction - Stored signed transaction, or the unsigned one when it was never sent
+func (p *SolChain) loadTransaction(ctx context.Context, transactionID string) (*solana.Transaction, bool, error) {
+	if p.txStore == nil {
+		return nil, false, fmt.Errorf("transaction store not configured")
+	}
+	signed := true
+	txBytes, err := p.txStore.GetSignedTransaction(ctx, transactionID)
+	if errors.Is(err, storage.ErrPayloadNotFound) {
+		signed = false
+		txBytes, err = p.txStore.GetUnsignedTransaction(ctx, transactionID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("transaction %s: %w", transactionID, err)
+	}
+	var tx solana.Transaction
+	if err := tx.UnmarshalWithDecoder(bin.NewBinDecoder(txBytes)); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+	return &tx, signed, nil
+}
+
+// DiagnoseStuck - Why transactionID has not landed: never broadcast, dropped, expired blockhash or still pending
+func (p *SolChain) DiagnoseStuck(ctx context.Context, transactionID string) (*StuckDiagnosis, error) {
+	tx, signed, err := p.loadTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	diagnosis := &StuckDiagnosis{
+		StuckDiagnosis:  dto.StuckDiagnosis{TransactionID: transactionID, Actions: []string{}},
+		RecentBlockhash: tx.Message.RecentBlockhash.String(),
+	}
+	if p.history != nil {
+		if failures, err := p.history.GetSubmissionFailures(ctx, transactionID); err == nil && len(failures) > 0 {
+			diagnosis.LastFailure = failures[len(failures)-1].Message
+		}
+	}
+
+	if signed && len(tx.Signatures) > 0 {
+		diagnosis.Signature = tx.Signatures[0].String()
+		statuses, err := p.http.GetSignatureStatuses(ctx, true, tx.Signatures[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signature status: %w", err)
+		}
+		if len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			diagnosis.Status = txstatus.FromSolana(status.ConfirmationStatus, status.Err)
+			if diagnosis.Status == txstatus.Pending {
+				diagnosis.State = dto.StuckPending
+				diagnosis.Detail = "processed by the node but not confirmed yet"
+			} else {
+				diagnosis.State = dto.StuckLanded
+				diagnosis.Detail = "landed on chain, nothing to remediate"
+			}
+			return diagnosis, nil
+		}
+	}
+
+	valid, err := p.http.IsBlockhashValid(ctx, tx.Message.RecentBlockhash, rpc.CommitmentProcessed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blockhash: %w", err)
+	}
+	diagnosis.Status = txstatus.NotFound
+	switch {
+	case !valid.Value:
+		diagnosis.State = dto.StuckBlockhashExpired
+		diagnosis.Detail = "blockhash expired, the transaction can never land; regenerate and have the client sign again"
+		diagnosis.Actions = []string{dto.RemediationRegenerate}
+	case signed:
+		diagnosis.State = dto.StuckDropped
+		diagnosis.Detail = "sent but unknown to the node and blockhash still valid; rebroadcast the same signed transaction"
+		diagnosis.Actions = []string{dto.RemediationRebroadcast, dto.RemediationRegenerate}
+	default:
+		diagnosis.State = dto.StuckNeverBroadcast
+		diagnosis.Detail = "created but never sent by the client"
+		diagnosis.Actions = []string{dto.RemediationRegenerate}
+	}
+	return diagnosis, nil
+}
+
+// RemediateStuck - Apply a remediation offered by DiagnoseStuck; every attempt is audited
+func (p *SolChain) RemediateStuck(ctx context.Context, req dto.RemediationRequest) (result *dto.RemediationResult, err error) {
+	defer func() {
+		outcome := "ok"
+		if err != nil {
+			outcome = "failed: " + err.Error()
+		} else if result.NewTransactionID != "" {
+			outcome = "new transaction " + result.NewTransactionID
+		}
+		p.history.RecordRemediation(ctx, chain.Solana, req.Operator, req.Action, req.TransactionID, outcome)
+	}()
+
+	diagnosis, err := p.DiagnoseStuck(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(diagnosis.Actions, req.Action) {
+		return nil, fmt.Errorf("%w: %s for state %s", chain.ErrRemediationNotApplicable, req.Action, diagnosis.State)
+	}
+	tx, _, err := p.loadTransaction(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &dto.RemediationResult{TransactionID: req.TransactionID, Action: req.Action}
+	switch req.Action {
+	case dto.RemediationRebroadcast:
+		sig, err := p.http.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{SkipPreflight: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebroadcast: %w", err)
+		}
+		result.Message = "rebroadcast " + sig.String()
+	case dto.RemediationRegenerate:
+		recent, err := p.http.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent blockhash: %w", err)
+		}
+		tx.Message.RecentBlockhash = recent.Value.Blockhash
+		tx.Signatures = nil
+		txBytes, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+		}
+		result.NewTransactionID = fmt.Sprintf("txn_%d", time.Now().UnixNano())
+		if err := p.txStore.PutUnsignedTransaction(ctx, chain.Solana, result.NewTransactionID, txBytes, storage.UnsignedTransactionTTL); err != nil {
+			return nil, fmt.Errorf("failed to store regenerated transaction: %w", err)
+		}
+		result.UnsignedTransaction = base64.StdEncoding.EncodeToString(txBytes)
+		result.Message = "regenerated with a fresh blockhash; the client must sign and send " + result.NewTransactionID
+	}
+	return result, nil
+}
+
+// HandleDiagnoseStuck - GET /api/v1/sol/admin/stuck?transaction_id=xxx
+func (p *SolChain) HandleDiagnoseStuck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	transactionID := r.URL.Query().Get("transaction_id")
+	if transactionID == "" {
+		respondError(w, "transaction_id parameter required", http.StatusBadRequest)
+		return
+	}
+	diagnosis, err := p.DiagnoseStuck(r.Context(), transactionID)
+	if errors.Is(err, storage.ErrPayloadNotFound) {
+		respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, diagnosis, http.StatusOK)
+}
+
+// HandleRemediateStuck - POST /api/v1/sol/admin/stuck/remediate
+func (p *SolChain) HandleRemediateStuck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req dto.RemediationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.TransactionID == "" || req.Action == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	result, err := p.RemediateStuck(r.Context(), req)
+	switch {
+	case errors.Is(err, storage.ErrPayloadNotFound):
+		respondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, chain.ErrRemediationNotApplicable):
+		respondError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		respondError(w, err.Error(), http.StatusInternalServerError)
+	default:
+		respondJSON(w, result, http.StatusOK)
+	}
+}
@@ -2,8 +2,9 @@ package chainsol
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
+	"log"
+	"strconv"
 	"time"
 
 	bin "github.com/gagliardetto/binary"
@@ -11,10 +12,30 @@ import (
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
 	confirm "github.com/gagliardetto/solana-go/rpc/sendAndConfirmTransaction"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/dust"
+	"blockchain/pagination"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/projection"
+	"blockchain/receipt"
+	"blockchain/storage"
+	"blockchain/txencoding"
+	"blockchain/txstatus"
 )
 
 // CreateTransaction - Step 1: Backend create unsigned transaction
 func (p *SolChain) CreateTransaction(req TransactionRequest) (*CreateTransactionResponse, error) {
+	return p.createTransaction(req, p.dust)
+}
+
+// createTransaction - CreateTransaction with minimum as the dust policy (nil = no check, the canary's
+// net-zero self-transfer)
+func (p *SolChain) createTransaction(req TransactionRequest, minimum *dust.Policy) (*CreateTransactionResponse, error) {
 	// Validate addresses
 	accountFrom, err := solana.PublicKeyFromBase58(req.FromAddress)
 	if err != nil {
@@ -24,6 +45,13 @@ func (p *SolChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 	if err != nil {
 		return nil, fmt.Errorf("invalid to address: %w", err)
 	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		return nil, err
+	}
+	if err := minimum.CheckUint64(chain.Solana, chain.Solana.Symbol(), "amount", req.Amount); err != nil {
+		return nil, err
+	}
 	// Get recent block hash
 	ctx := context.Background()
 	recent, err := p.http.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
@@ -53,19 +81,32 @@ func (p *SolChain) CreateTransaction(req TransactionRequest) (*CreateTransaction
 		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
 	}
 	transactionID := fmt.Sprintf("txn_%d", time.Now().UnixNano())
+	if p.txStore != nil {
+		if err := p.txStore.PutUnsignedTransaction(ctx, chain.Solana, transactionID, txBytes, storage.UnsignedTransactionTTL); err != nil {
+			log.Printf("failed to store unsigned transaction %s: %v", transactionID, err)
+		}
+	}
 
 	response := &CreateTransactionResponse{
-		TransactionID:       transactionID,
-		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
-		RecentBlockhash:     recent.Value.Blockhash.String(),
+		CreateTransactionResponse: dto.CreateTransactionResponse{
+			TransactionID:       transactionID,
+			UnsignedTransaction: encoding.Encode(txBytes),
+			Encoding:            encoding.String(),
+			EstimatedFee:        pricing.FormatLamports(ctx, p.prices, receipt.EstimateSolanaFee(ctx, p.http, tx)),
+		},
+		RecentBlockhash: recent.Value.Blockhash.String(),
 	}
 	return response, nil
 }
 
 // SendSignedTransaction - Step 3: Backend send signed transaction ke blockchain
 func (p *SolChain) SendSignedTransaction(req SignedTransactionRequest) (*TransactionResult, error) {
-	// Decode signed transaction
-	txBytes, err := base64.StdEncoding.DecodeString(req.SignedTransaction)
+	// Decode signed transaction (encoding detected unless the client names it)
+	encoding, err := txencoding.Parse(req.Encoding, "")
+	if err != nil {
+		return nil, err
+	}
+	txBytes, _, err := txencoding.DecodeAuto(req.SignedTransaction, encoding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode signed transaction: %w", err)
 	}
@@ -79,32 +120,99 @@ func (p *SolChain) SendSignedTransaction(req SignedTransactionRequest) (*Transac
 	if len(tx.Signatures) == 0 {
 		return nil, fmt.Errorf("transaction is not signed")
 	}
+	mode, err := preflight.Parse(req.Preflight)
+	if err != nil {
+		return nil, err
+	}
+	mode = p.preflight.Resolve("", mode)
+
 	// Send transaction to Solana via Alchemy
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	sig, err := confirm.SendAndConfirmTransaction(
-		ctx,
-		p.http,
-		p.ws,
-		&tx,
-	)
-	result := &TransactionResult{
+	err = preflight.Check(ctx, p.http, &tx, mode)
+	var sig solana.Signature
+	if err == nil {
+		p.keepSignedTransaction(ctx, req.TransactionID, txBytes)
+		sig, err = confirm.SendAndConfirmTransactionWithOpts(
+			ctx,
+			p.http,
+			p.ws,
+			&tx,
+			mode.TransactionOpts(),
+			nil,
+		)
+	}
+	preflight.Record(mode, err)
+	result := &TransactionResult{TransactionResult: dto.TransactionResult{
 		TransactionID: req.TransactionID,
 		Success:       err == nil,
-	}
+	}}
 	if err != nil {
-		result.Status = "failed"
+		result.Status = txstatus.Failed
 		result.Message = fmt.Sprintf("Failed to send transaction: %v", err)
+		result.ProgramLogs = preflight.Logs(err)
+		if key := p.recordFailure(req.TransactionID, tx.Signatures[0].String(), err, result.ProgramLogs); key != "" {
+			result.TransactionID = key
+		}
+		p.publishSend(&tx, result)
 		return result, err
 	}
 	result.Signature = sig.String()
-	result.Status = "pending"
+	result.Status = txstatus.Pending
 	result.Message = "Transaction sent successfully"
 	result.ExplorerURL = p.GetExplorerURL(sig.String())
+	// Best effort: SendAndConfirm already waited, so the receipt is usually available
+	if r, err := p.GetSubmissionReceipt(sig.String()); err == nil {
+		result.Receipt = r
+		result.Status = r.Status
+	}
+	p.publishSend(&tx, result)
 	return result, nil
 }
 
+// recordFailure - Keep error and program logs of a failed send (best effort, no-op without history store);
+// returns the key it was stored under, the signature when the client sent no transaction ID
+func (p *SolChain) recordFailure(transactionID, signature string, err error, logs []string) string {
+	return p.history.ReportSubmissionFailure(&storage.SubmissionFailure{
+		TransactionID: transactionID,
+		Chain:         chain.Solana,
+		Action:        "send",
+		Signature:     signature,
+		Message:       err.Error(),
+		ProgramLogs:   logs,
+	})
+}
+
+// GetSubmissionReceipt - Slot, block time, fees and compute units of a landed transaction;
+// also written to stored history when a database is configured
+func (p *SolChain) GetSubmissionReceipt(signature string) (*receipt.Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r, err := receipt.FetchSolana(ctx, p.http, signature)
+	if err != nil {
+		return nil, err
+	}
+	r.WithPrices(ctx, p.prices)
+	if p.db != nil {
+		updates := map[string]interface{}{
+			"status":        r.Status,
+			"slot":          r.Slot,
+			"block_time":    r.BlockTime,
+			"fee":           r.Fee,
+			"priority_fee":  r.PriorityFee,
+			"compute_units": r.ComputeUnitsConsumed,
+			"confirmed_at":  r.ConfirmedAt,
+		}
+		if r.Error != nil {
+			updates["error_message"] = *r.Error
+		}
+		p.db.Model(&TransactionHistory{}).Where("signature = ?", signature).Updates(updates)
+	}
+	return r, nil
+}
+
 // GetTransactionStatus - Check transaction status
 func (p *SolChain) GetTransactionStatus(signature string) (*TransactionStatusResponse, error) {
 	sig, err := solana.SignatureFromBase58(signature)
@@ -124,23 +232,21 @@ func (p *SolChain) GetTransactionStatus(signature string) (*TransactionStatusRes
 		},
 	)
 	response := &TransactionStatusResponse{
-		Signature:   signature,
-		ExplorerURL: p.GetExplorerURL(signature),
+		TransactionStatus: dto.TransactionStatus{ExplorerURL: p.GetExplorerURL(signature)},
+		Signature:         signature,
 	}
 	if err != nil {
-		response.Status = "not_found"
+		response.Status = txstatus.NotFound
 		return response, nil
 	}
 
 	// Parse result
 	if result != nil {
 		if result.Meta != nil {
+			response.Status = txstatus.FromSolana(rpc.ConfirmationStatusConfirmed, result.Meta.Err)
 			if result.Meta.Err != nil {
 				errMsg := fmt.Sprintf("%v", result.Meta.Err)
-				response.Status = "failed"
 				response.Error = &errMsg
-			} else {
-				response.Status = "confirmed"
 			}
 			response.Fee = result.Meta.Fee
 		}
@@ -172,3 +278,141 @@ func (p *SolChain) GetTransactionHistory(address string, limit int) ([]Transacti
 
 	return histories, err
 }
+
+// DefaultPageSize - History page size when the caller passes no limit
+const DefaultPageSize = 50
+
+// GetTransactionHistoryPage - One page of history, newest first; cursor is the last id returned
+// (limit <= 0 = DefaultPageSize)
+func (p *SolChain) GetTransactionHistoryPage(address string, cursor string, limit int) (*pagination.Page[TransactionHistory], error) {
+	if p.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	return historyPage(p.db.Where("(from_address = ? OR to_address = ?)", address, address), cursor, limit)
+}
+
+// GetEnvelopeHistoryPage - One page of the transactions of envelope #envelopeID of owner, newest first
+func (p *SolChain) GetEnvelopeHistoryPage(owner string, envelopeID uint64, cursor string, limit int) (*pagination.Page[TransactionHistory], error) {
+	if p.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	return historyPage(p.db.Where("owner_address = ? AND envelope_id = ?", owner, envelopeID), cursor, limit)
+}
+
+func historyPage(query *gorm.DB, cursor string, limit int) (*pagination.Page[TransactionHistory], error) {
+	// Limit(0) would return nothing and Limit(-1) everything, neither a page
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if cursor != "" {
+		id, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		query = query.Where("id < ?", id)
+	}
+	var histories []TransactionHistory
+	if err := query.Order("id DESC").Limit(limit).Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	page := &pagination.Page[TransactionHistory]{Items: histories}
+	if len(histories) > 0 && len(histories) == limit {
+		page.NextCursor = strconv.FormatUint(uint64(histories[len(histories)-1].ID), 10)
+	}
+	return page, nil
+}
+
+// RecordEnvelopeTransaction - Store a confirmed envelope transaction with its envelope linkage
+// (post-confirmation hook, e.g. solprogram.USDCEnvelopeClient.OnConfirmed). Receipt fields are
+// filled best effort; the row is keyed by transaction ID, or by signature when there is none.
+func (p *SolChain) RecordEnvelopeTransaction(ctx context.Context, tx chain.EnvelopeTransaction) error {
+	if p.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	envelopeID := tx.EnvelopeID
+	row := TransactionHistory{
+		TransactionID: tx.TransactionID,
+		FromAddress:   tx.Signer,
+		Amount:        tx.Amount,
+		Signature:     tx.Signature,
+		Status:        txstatus.Confirmed,
+		Chain:         chain.Solana,
+		EnvelopeID:    &envelopeID,
+		OwnerAddress:  tx.Owner,
+		Action:        tx.Action,
+	}
+	if row.TransactionID == "" {
+		row.TransactionID = tx.Signature
+	}
+	if r, err := receipt.FetchSolana(ctx, p.http, tx.Signature); err == nil {
+		row.Status = r.Status
+		row.Slot = r.Slot
+		row.BlockTime = r.BlockTime
+		row.Fee = r.Fee
+		row.PriorityFee = r.PriorityFee
+		row.ComputeUnits = r.ComputeUnitsConsumed
+		row.ConfirmedAt = r.ConfirmedAt
+		if r.Error != nil {
+			row.ErrorMessage = *r.Error
+		}
+	}
+
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "transaction_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"amount", "signature", "status", "slot", "block_time", "fee", "priority_fee", "compute_units",
+			"confirmed_at", "error_message", "chain", "envelope_id", "owner_address", "action", "updated_at",
+		}),
+	}).Create(&row).Error
+}
+
+// EnvelopeEvents - projection.Source replaying the envelope transactions persisted by
+// RecordEnvelopeTransaction; failed transactions are skipped
+func (p *SolChain) EnvelopeEvents(ctx context.Context, emit func(projection.Event) error) error {
+	if p.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	var rows []TransactionHistory
+	var emitErr error
+	err := p.db.WithContext(ctx).
+		Where("chain = ? AND envelope_id IS NOT NULL AND status <> ?", chain.Solana, txstatus.Failed).
+		Order("id").
+		FindInBatches(&rows, 500, func(_ *gorm.DB, _ int) error {
+			for _, row := range rows {
+				event := projection.Event{
+					Chain:      row.Chain,
+					Action:     row.Action,
+					Owner:      row.OwnerAddress,
+					EnvelopeID: *row.EnvelopeID,
+					Signature:  row.Signature,
+					Amount:     row.Amount,
+				}
+				if row.Action == chain.EnvelopeActionClaim {
+					event.Actor = row.FromAddress
+				}
+				if row.BlockTime != nil {
+					event.Time = time.Unix(*row.BlockTime, 0)
+				} else if row.ConfirmedAt != nil {
+					event.Time = *row.ConfirmedAt
+				}
+				if emitErr = emit(event); emitErr != nil {
+					return emitErr
+				}
+			}
+			return nil
+		}).Error
+	if emitErr != nil {
+		return emitErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read transaction history: %w", err)
+	}
+	return nil
+}
+
+// IterTransactionHistory - Iterate address history across pages, newest first
+func (p *SolChain) IterTransactionHistory(ctx context.Context, address string, pageSize int) *pagination.Iterator[TransactionHistory] {
+	return pagination.New(ctx, func(ctx context.Context, cursor string) (*pagination.Page[TransactionHistory], error) {
+		return p.GetTransactionHistoryPage(address, cursor, pageSize)
+	})
+}
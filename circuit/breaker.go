@@ -0,0 +1,268 @@
+package circuit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOpen - Returned by Allow when the breaker is open
+var ErrOpen = errors.New("circuit breaker open")
+
+// State - Snapshot of a breaker
+type State struct {
+	Name     string     `json:"name"`
+	Open     bool       `json:"open"`
+	Reason   string     `json:"reason,omitempty"` // Reasons of all causes, "; "-separated
+	Causes   []string   `json:"causes,omitempty"`
+	Held     []string   `json:"held,omitempty"` // Causes Reset leaves in place
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// ManualCause - Cause of trips through Trip (operators, the admin handler)
+const ManualCause = "manual"
+
+type trip struct {
+	reason string
+	held   bool // Cleared only by Clear, not by Reset
+}
+
+// Breaker - Manually/automatically tripped switch guarding one action. Each trip has a cause;
+// the breaker stays open until every cause is cleared. Reset clears the trips of Trip/TripFor;
+// Hold is for conditions that last (a paused program) and only their owner clears them.
+type Breaker struct {
+	name     string
+	mu       sync.RWMutex
+	trips    map[string]trip // By cause
+	openedAt time.Time
+}
+
+// Trip - Open the breaker (ManualCause)
+func (b *Breaker) Trip(reason string) {
+	b.TripFor(ManualCause, reason)
+}
+
+// TripFor - Open the breaker for cause until it is cleared or Reset
+func (b *Breaker) TripFor(cause, reason string) {
+	b.trip(cause, trip{reason: reason})
+}
+
+// Hold - Open the breaker for cause until Clear(cause); Reset doesn't close it
+func (b *Breaker) Hold(cause, reason string) {
+	b.trip(cause, trip{reason: reason, held: true})
+}
+
+func (b *Breaker) trip(cause string, t trip) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.trips) == 0 {
+		b.openedAt = time.Now()
+		b.trips = make(map[string]trip)
+	}
+	b.trips[cause] = t
+}
+
+// Clear - Drop cause; the breaker closes when no other cause holds it open
+func (b *Breaker) Clear(cause string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.trips, cause)
+	if len(b.trips) == 0 {
+		b.openedAt = time.Time{}
+	}
+}
+
+// Reset - Drop every cause that isn't held; the breaker stays open while one is
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for cause, t := range b.trips {
+		if !t.held {
+			delete(b.trips, cause)
+		}
+	}
+	if len(b.trips) == 0 {
+		b.openedAt = time.Time{}
+	}
+}
+
+// IsOpen - Check breaker state
+func (b *Breaker) IsOpen() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.trips) > 0
+}
+
+// Allow - Return ErrOpen (with reason) when the action must be refused
+func (b *Breaker) Allow() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.trips) > 0 {
+		return fmt.Errorf("%w: %s disabled (%s)", ErrOpen, b.name, b.reason())
+	}
+	return nil
+}
+
+// State - Get breaker snapshot
+func (b *Breaker) State() State {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	state := State{Name: b.name, Open: len(b.trips) > 0}
+	if state.Open {
+		state.Reason = b.reason()
+		state.Causes = b.causes()
+		for _, cause := range state.Causes {
+			if b.trips[cause].held {
+				state.Held = append(state.Held, cause)
+			}
+		}
+		openedAt := b.openedAt
+		state.OpenedAt = &openedAt
+	}
+	return state
+}
+
+// causes - Trip causes, sorted (caller holds mu)
+func (b *Breaker) causes() []string {
+	causes := make([]string, 0, len(b.trips))
+	for cause := range b.trips {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+	return causes
+}
+
+// reason - Reasons of all causes (caller holds mu)
+func (b *Breaker) reason() string {
+	causes := b.causes()
+	reasons := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		reasons = append(reasons, b.trips[cause].reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Registry - Named set of breakers
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry - Create registry with the given breakers pre-registered
+func NewRegistry(names ...string) *Registry {
+	r := &Registry{breakers: make(map[string]*Breaker)}
+	for _, name := range names {
+		r.Get(name)
+	}
+	return r
+}
+
+// Get - Get or create breaker by name
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[name]
+	if !ok {
+		b = &Breaker{name: name}
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Allow - Shortcut for Get(name).Allow(); nil registry always allows
+func (r *Registry) Allow(name string) error {
+	if r == nil {
+		return nil
+	}
+	return r.Get(name).Allow()
+}
+
+// TripAll - Open every registered breaker (ManualCause)
+func (r *Registry) TripAll(reason string) {
+	r.TripAllFor(ManualCause, reason)
+}
+
+// TripAllFor - Open every registered breaker for cause
+func (r *Registry) TripAllFor(cause, reason string) {
+	for _, b := range r.all() {
+		b.TripFor(cause, reason)
+	}
+}
+
+// HoldAll - Hold every registered breaker open for cause
+func (r *Registry) HoldAll(cause, reason string) {
+	for _, b := range r.all() {
+		b.Hold(cause, reason)
+	}
+}
+
+// ClearAll - Drop cause from every registered breaker
+func (r *Registry) ClearAll(cause string) {
+	for _, b := range r.all() {
+		b.Clear(cause)
+	}
+}
+
+// ResetAll - Reset every registered breaker (held causes stay)
+func (r *Registry) ResetAll() {
+	for _, b := range r.all() {
+		b.Reset()
+	}
+}
+
+// States - Snapshot of all breakers sorted by name
+func (r *Registry) States() []State {
+	breakers := r.all()
+	states := make([]State, 0, len(breakers))
+	for _, b := range breakers {
+		states = append(states, b.State())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+func (r *Registry) all() []*Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	return breakers
+}
+
+// Handler - GET lists breakers, POST {"name": "...", "open": bool, "reason": "..."} trips/resets one.
+// Resetting leaves held causes in place; the returned state shows whether the breaker is still open.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(r.States())
+		case http.MethodPost:
+			var body struct {
+				Name   string `json:"name"`
+				Open   bool   `json:"open"`
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Name == "" {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			b := r.Get(body.Name)
+			if body.Open {
+				b.Trip(body.Reason)
+			} else {
+				b.Reset()
+			}
+			json.NewEncoder(w).Encode(b.State())
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"blockchain/chain"
 )
 
 const (
-	NetSOL = "solana"
-	NetBSC = "bsc"
+	NetSOL = chain.Solana
+	NetBSC = chain.BSC
 
 	ActionCreate = "create"
 	ActionClaim  = "claim"
@@ -26,19 +28,13 @@ var (
 	baseURL             = "http://localhost:10011"
 )
 
-func initAll(chain string) {
+func initAll(chainID chain.ChainID) {
 	initUser()
-	initNetwork(chain)
+	initNetwork(chainID)
 }
 
-func initNetwork(chain string) {
-	switch chain {
-	case NetSOL:
-		network = Network{Name: "solana", Symbol: "SOL"}
-	case NetBSC:
-		network = Network{Name: "bsc", Symbol: "BNB"}
-	}
-
+func initNetwork(chainID chain.ChainID) {
+	network = Network{Name: chainID, Symbol: chainID.Symbol()}
 }
 
 func initUser() {
@@ -27,7 +27,7 @@ func createTransfer(payload PayloadTransferCreate, from User, flag bool) (envID
 	payloadSignedTx := PayloadSignedTx{
 		RawTransaction: *signedTx,
 		TxHash:         "",
-		Chain:          "solana",
+		Chain:          NetSOL,
 		CacheKey:       unsignedResp.Data.UnsignedTx.CacheKey,
 		Action:         "create",
 	}
@@ -69,7 +69,7 @@ func claimTransfer(payload PayloadTransferClaim, claimer User, flag bool) {
 	//payloadSignedTx := PayloadSignedTx{
 	//	RawTransaction: *signedTx,
 	//	TxHash:         "",
-	//	Chain:          "solana",
+	//	Chain:          NetSOL,
 	//	CacheKey:       unsignedResp.Data.UnsignedTx.CacheKey,
 	//	Action:         "claim",
 	//}
@@ -207,7 +207,7 @@ func transfer() {
 		Token:    "SOL",
 		Amount:   amount,
 		Value:    value,
-		Chain:    "solana",
+		Chain:    NetSOL,
 		Remarks:  "waktu setempat",
 		ToUserID: userB.ID,
 		Expiry:   24,
@@ -218,7 +218,7 @@ func transfer() {
 	claimFlag := true
 	claimUser := userB
 	payloadClaim := PayloadTransferClaim{
-		Chain:      "solana",
+		Chain:      NetSOL,
 		TransferID: transferID,
 	}
 	claimTransfer(payloadClaim, claimUser, claimFlag)
@@ -1,5 +1,7 @@
 package main
 
+import "blockchain/chain"
+
 type User struct {
 	ID         string `json:"id"`
 	Address    string `json:"address"`
@@ -7,6 +9,8 @@ type User struct {
 	PrivateKey string `json:"privateKey"`
 }
 
+// SignedTxRequest - Body of the akachat backend's submit endpoint. Its camelCase shape is owned by
+// that service, so it stays here instead of blockchain/dto.
 type SignedTxRequest struct {
 	CacheKey string `json:"cacheKey"`
 	SignedTx string `json:"signedTx"`
@@ -39,9 +43,9 @@ type Fee struct {
 }
 
 type Meta struct {
-	Action    string `json:"action"`
-	Chain     string `json:"chain"`
-	ExpiresAt int64  `json:"expiresAt"`
+	Action    string        `json:"action"`
+	Chain     chain.ChainID `json:"chain"`
+	ExpiresAt int64         `json:"expiresAt"`
 }
 
 type UnsignedTxData struct {
@@ -65,61 +69,61 @@ type SignedTxResult struct {
 }
 
 type PayloadCreate struct {
-	EnvelopeType        string `json:"envelopeType"`
-	Token               string `json:"token"`
-	TotalClaims         int    `json:"totalClaims"`
-	AmountPerClaimOrPot int    `json:"AmountPerClaimOrPot"`
-	Value               int    `json:"value"`
-	Chain               string `json:"chain"`
-	GroupID             string `json:"groupID"`
-	Remarks             string `json:"remarks"`
-	ThemeID             int    `json:"themeID"`
-	ToUserID            string `json:"toUserID"`
-	UserID              string `json:"userID"`
+	EnvelopeType        string        `json:"envelopeType"`
+	Token               string        `json:"token"`
+	TotalClaims         int           `json:"totalClaims"`
+	AmountPerClaimOrPot int           `json:"AmountPerClaimOrPot"`
+	Value               int           `json:"value"`
+	Chain               chain.ChainID `json:"chain"`
+	GroupID             string        `json:"groupID"`
+	Remarks             string        `json:"remarks"`
+	ThemeID             int           `json:"themeID"`
+	ToUserID            string        `json:"toUserID"`
+	UserID              string        `json:"userID"`
 }
 
 type PayloadClaim struct {
-	Chain          string `json:"chain"`
-	UserID         string `json:"userID"`
-	GroupID        string `json:"groupID"`
-	EnvelopeID     int    `json:"envelopeID"`
-	ConversationID string `json:"conversationID"`
-	Seq            int    `json:"seq"`
-	Status         string `json:"status"`
+	Chain          chain.ChainID `json:"chain"`
+	UserID         string        `json:"userID"`
+	GroupID        string        `json:"groupID"`
+	EnvelopeID     int           `json:"envelopeID"`
+	ConversationID string        `json:"conversationID"`
+	Seq            int           `json:"seq"`
+	Status         string        `json:"status"`
 }
 
 type PayloadRefund struct {
-	UserID          string `json:"userID"`
-	EnvelopeID      int    `json:"envelopeID"`
-	AddressUser     string `json:"addressUser"`
-	Chain           string `json:"chain"`
-	EnvelopeChainID int    `json:"envelopeChainID"`
+	UserID          string        `json:"userID"`
+	EnvelopeID      int           `json:"envelopeID"`
+	AddressUser     string        `json:"addressUser"`
+	Chain           chain.ChainID `json:"chain"`
+	EnvelopeChainID int           `json:"envelopeChainID"`
 }
 
 type PayloadSignedTx struct {
-	RawTransaction string `json:"rawTransaction"`
-	TxHash         string `json:"txHash"`
-	Chain          string `json:"chain"`
-	CacheKey       string `json:"cacheKey"`
-	Action         string `json:"action"`
+	RawTransaction string        `json:"rawTransaction"`
+	TxHash         string        `json:"txHash"`
+	Chain          chain.ChainID `json:"chain"`
+	CacheKey       string        `json:"cacheKey"`
+	Action         string        `json:"action"`
 }
 
 type PayloadTransferCreate struct {
-	Token    string `json:"token"`
-	Amount   int    `json:"Amount"`
-	Value    int    `json:"value"`
-	Chain    string `json:"chain"`
-	Remarks  string `json:"remarks"`
-	Expiry   int    `json:"expiry"`
-	ToUserID string `json:"toUserID"`
+	Token    string        `json:"token"`
+	Amount   int           `json:"Amount"`
+	Value    int           `json:"value"`
+	Chain    chain.ChainID `json:"chain"`
+	Remarks  string        `json:"remarks"`
+	Expiry   int           `json:"expiry"`
+	ToUserID string        `json:"toUserID"`
 }
 
 type PayloadTransferClaim struct {
-	Chain      string `json:"chain"`
-	TransferID int    `json:"transferId"`
+	Chain      chain.ChainID `json:"chain"`
+	TransferID int           `json:"transferId"`
 }
 
 type Network struct {
-	Name   string `json:"name"`
-	Symbol string `json:"symbol"`
+	Name   chain.ChainID `json:"name"`
+	Symbol string        `json:"symbol"`
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"blockchain/attestation"
+)
+
+func runVerifyAttestation(args []string) error {
+	fs := flag.NewFlagSet("verify-attestation", flag.ExitOnError)
+	in := fs.String("in", "-", `attestation JSON file (bare or the /api/claim-attestation response); "-" reads stdin`)
+	key := fs.String("key", "", "published attestation public key (base58)")
+	fs.Parse(args)
+
+	if *key == "" {
+		fs.Usage()
+		return fmt.Errorf("-key is required")
+	}
+	text, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	var wrapped struct {
+		Attestation *attestation.Attestation `json:"attestation"`
+	}
+	if err := json.Unmarshal([]byte(text), &wrapped); err != nil {
+		return fmt.Errorf("invalid attestation JSON: %w", err)
+	}
+	att := wrapped.Attestation
+	if att == nil {
+		att = new(attestation.Attestation)
+		if err := json.Unmarshal([]byte(text), att); err != nil {
+			return fmt.Errorf("invalid attestation JSON: %w", err)
+		}
+	}
+
+	st, err := attestation.Verify(att, *key)
+	if err != nil {
+		return err
+	}
+	asset := "lamports"
+	if st.Mint != "" {
+		asset = "base units of " + st.Mint
+	}
+	fmt.Println("✅ Valid attestation")
+	fmt.Printf("Claimer:   %s\n", st.Claimer)
+	fmt.Printf("Envelope:  %s (program %s)\n", st.Envelope, st.Program)
+	fmt.Printf("Amount:    %d %s\n", st.Amount, asset)
+	fmt.Printf("Signature: %s (slot %d)\n", st.Signature, st.Slot)
+	fmt.Printf("Issued:    %s\n", time.Unix(st.IssuedAt, 0).UTC().Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/solprogram"
+)
+
+func runIDLCheck(args []string) error {
+	fs := flag.NewFlagSet("idl-check", flag.ExitOnError)
+	rpcURL := fs.String("rpc", solprogram.RPCURLDevnet, "Solana RPC URL to fetch the IDL account from")
+	program := fs.String("program", "usdc", `envelope program: "sol" or "usdc"`)
+	network := fs.String("network", string(chain.Devnet), "network of -rpc (USDC mint)")
+	asJSON := fs.Bool("json", false, "print the diff report as JSON")
+	fs.Parse(args)
+
+	rpcClient := rpc.New(*rpcURL)
+	var report *solprogram.IDLReport
+	var err error
+	switch *program {
+	case "sol":
+		client := &solprogram.Client{RPC: rpcClient, ProgramID: solana.MustPublicKeyFromBase58(solprogram.SOLProgramID)}
+		report, err = client.ValidateIDL(context.Background())
+	case "usdc":
+		n, perr := chain.ParseNetwork(*network)
+		if perr != nil {
+			return perr
+		}
+		client, cerr := solprogram.NewUSDCEnvelopeClientWithClients(rpcClient, nil, n)
+		if cerr != nil {
+			return fmt.Errorf("failed to create client: %w", cerr)
+		}
+		report, err = client.ValidateIDL(context.Background())
+	default:
+		return fmt.Errorf("unknown program %q (want sol or usdc)", *program)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	}
+	if err := report.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s matches the instruction builders\n", report.Program)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Command ops - Operator tools that must not run behind the HTTP API.
+//
+//	ops sign -in unsigned.txt -key treasury -out signed.txt
+//	ops pda-vectors -out solprogram/pda_vectors.json
+//	ops rebuild-projections -rpc https://api.mainnet-beta.solana.com -network mainnet -out projections.json
+//	ops verify-attestation -key <attestation public key> -in attestation.json
+//	ops idl-check -program usdc -rpc https://api.devnet.solana.com
+//	ops rotate-keys -db postgres://... -batch 100
+//
+// sign replaces the /sign-transaction test endpoints for real keys: the private key
+// stays on the (air-gapped) machine running this command.
+//
+// rebuild-projections is the recovery path for a corrupted indexer DB: it re-fetches every
+// envelope and claim record from chain and rebuilds the envelope, claim and stats projections
+// deterministically, so two runs over the same chain state produce the same checksums.
+//
+// verify-attestation checks a claim attestation offline against the published public key.
+//
+// idl-check diffs the deployed program's Anchor IDL against the Go instruction builders
+// (the same check the servers run at startup with IDL_CHECK).
+//
+// rotate-keys re-seals every stored payload under the active ENCRYPTION_KEYS/ENCRYPTION_KEYSTORE
+// key; run it after adding a new key and before removing the old one from the keyring.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: ops <command> [flags]
+
+Commands:
+  sign          Decode, review and sign an unsigned transaction with a local keystore key
+  pda-vectors   Print PDA derivation test vectors for client SDK parity tests
+  rebuild-projections
+                Rebuild envelope, claim and stats projections from chain, with checksums
+  verify-attestation
+                Verify a signed claim attestation offline against the published public key
+  idl-check     Diff the deployed program IDL against the instruction builders
+  rotate-keys   Re-seal stored payloads under the active encryption key
+
+Run "ops <command> -h" for the flags of a command.
+`)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "sign":
+		err = runSign(os.Args[2:])
+	case "pda-vectors":
+		err = runPDAVectors(os.Args[2:])
+	case "rebuild-projections":
+		err = runRebuildProjections(os.Args[2:])
+	case "verify-attestation":
+		err = runVerifyAttestation(os.Args[2:])
+	case "idl-check":
+		err = runIDLCheck(os.Args[2:])
+	case "rotate-keys":
+		err = runRotateKeys(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"blockchain/solprogram"
+)
+
+func runPDAVectors(args []string) error {
+	fs := flag.NewFlagSet("pda-vectors", flag.ExitOnError)
+	out := fs.String("out", "-", `vectors file; "-" writes stdout`)
+	fs.Parse(args)
+
+	vectors, err := solprogram.PDATestVectors()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vectors: %w", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write vectors: %w", err)
+	}
+	return nil
+}
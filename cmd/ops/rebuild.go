@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"blockchain/chain"
+	"blockchain/projection"
+	"blockchain/solprogram"
+)
+
+func runRebuildProjections(args []string) error {
+	fs := flag.NewFlagSet("rebuild-projections", flag.ExitOnError)
+	rpcURL := fs.String("rpc", solprogram.RPCURLDevnet, "Solana RPC URL to re-fetch envelope state from")
+	wsURL := fs.String("ws", solprogram.WSURLDevnet, "Solana websocket URL")
+	network := fs.String("network", string(chain.Devnet), "network of -rpc")
+	out := fs.String("out", "-", `projections file (envelopes, claims, stats with checksums); "-" writes stdout`)
+	verify := fs.String("verify", "", "only check the checksums of a projections file written by -out")
+	every := fs.Int("progress", projection.DefaultProgressEvery, "events between progress reports")
+	layoutFlag := fs.String("layout", "", `envelope account layout when the program has no on-chain IDL: "v1" or "v2" (default v2)`)
+	fs.Parse(args)
+
+	if *verify != "" {
+		return verifyProjections(*verify)
+	}
+
+	n, err := chain.ParseNetwork(*network)
+	if err != nil {
+		return err
+	}
+	layout, err := solprogram.ParseEnvelopeLayout(*layoutFlag)
+	if err != nil {
+		return err
+	}
+	client, err := solprogram.NewUSDCEnvelopeClient(*rpcURL, *wsURL, n)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	client.SetEnvelopeLayout(layout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Envelopes are decoded with the layout the deployed IDL declares; -layout only covers programs without one
+	if layout, err = client.DetectEnvelopeLayout(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  envelope layout not read from the IDL (%v), using v%d\n", err, layout)
+	}
+
+	result, err := projection.Rebuild(ctx, client.EnvelopeEvents, projection.Options{
+		ProgressEvery: *every,
+		OnProgress: func(p projection.Progress) {
+			fmt.Fprintf(os.Stderr, "⏳ %d events, %d envelopes, %d claims (%s)\n", p.Events, p.Envelopes, p.Claims, p.Elapsed.Round(time.Millisecond))
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, stats := range result.Stats {
+		fmt.Fprintf(os.Stderr, "✅ %s: %d envelopes (%d open, %d refunded), %d claims, checksum %s\n",
+			stats.Chain, stats.Envelopes, stats.Open, stats.Refunded, stats.Claims, stats.Checksum)
+	}
+	fmt.Fprintf(os.Stderr, "✅ checksum %s\n", result.Checksum())
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode projections: %w", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write projections: %w", err)
+	}
+	return nil
+}
+
+func verifyProjections(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read projections: %w", err)
+	}
+	var result projection.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to decode projections: %w", err)
+	}
+	if err := result.Verify(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ %d envelopes, %d claims, checksum %s\n", len(result.Envelopes), len(result.Claims), result.Checksum())
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"blockchain/encryption"
+	"blockchain/storage"
+)
+
+func runRotateKeys(args []string) error {
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	dsn := fs.String("db", os.Getenv("DATABASE_URL"), "database holding the sealed payloads (default $DATABASE_URL)")
+	batch := fs.Int("batch", 100, "payloads re-sealed per batch")
+	fs.Parse(args)
+
+	if *batch <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+	// Same key config as the servers: the active key is the one payloads are re-sealed under
+	source, err := encryption.KeySourceFromEnv()
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return fmt.Errorf("set %s or %s", encryption.EnvKeys, encryption.EnvKeystore)
+	}
+
+	db, err := storage.Open(*dsn, storage.InstrumentConfig{})
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return fmt.Errorf("-db or DATABASE_URL required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store, err := storage.OpenTransactionStore(ctx, db, source)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for {
+		n, err := store.Rotate(ctx, *batch)
+		total += n
+		if err != nil {
+			return fmt.Errorf("rotation stopped after %d payloads: %w", total, err)
+		}
+		if n == 0 {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "⏳ %d payloads re-sealed\n", total)
+	}
+	fmt.Fprintf(os.Stderr, "✅ %d payloads re-sealed; no payload is left under an old key\n", total)
+	return nil
+}
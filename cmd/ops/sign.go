@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+
+	"blockchain/chain"
+	"blockchain/offline"
+)
+
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	in := fs.String("in", "-", `unsigned payload file, base64 (Solana) or hex (BSC); "-" reads stdin (paste or QR scanner)`)
+	out := fs.String("out", "-", `signed payload file; "-" writes stdout`)
+	keystoreDir := fs.String("keystore", defaultKeystoreDir(), "keystore directory (env OPS_KEYSTORE)")
+	keyName := fs.String("key", "", "keystore entry to sign with (<keystore>/<key>.json)")
+	chainID := fs.Int64("chain-id", 97, "EVM chain ID for legacy transactions without one")
+	yes := fs.Bool("yes", false, "sign without asking for confirmation")
+	fs.Parse(args)
+
+	if *keyName == "" {
+		fs.Usage()
+		return fmt.Errorf("-key is required")
+	}
+
+	text, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+	payload, err := offline.ParsePayload(text)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "=== Transaction ===")
+	for _, line := range payload.Describe() {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	ks, err := offline.OpenKeystore(*keystoreDir)
+	if err != nil {
+		return err
+	}
+	prompt, err := openPrompt()
+	if err != nil && !*yes {
+		return fmt.Errorf("confirmation needs a terminal (or pass -yes): %w", err)
+	}
+	if prompt != nil {
+		defer prompt.Close()
+	}
+
+	var signature string
+	switch payload.Chain {
+	case chain.Solana:
+		key, err := ks.SolanaKey(*keyName)
+		if err != nil {
+			return err
+		}
+		if !*yes && !confirm(prompt, fmt.Sprintf("Sign as %s?", key.PublicKey())) {
+			return fmt.Errorf("aborted")
+		}
+		sig, err := payload.SignSolana(key)
+		if err != nil {
+			return err
+		}
+		signature = sig.String()
+
+	default:
+		if prompt == nil {
+			return fmt.Errorf("passphrase needs a terminal")
+		}
+		fmt.Fprintf(os.Stderr, "Passphrase for %s: ", *keyName)
+		passphrase, err := term.ReadPassword(int(prompt.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		key, err := ks.EVMKey(*keyName, string(passphrase))
+		if err != nil {
+			return err
+		}
+		if !*yes && !confirm(prompt, fmt.Sprintf("Sign as %s?", offline.EVMAddress(key))) {
+			return fmt.Errorf("aborted")
+		}
+		if signature, err = payload.SignEVM(key, *chainID); err != nil {
+			return err
+		}
+	}
+
+	signed, err := payload.Encode()
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(*out, signed); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Signed: %s\n", signature)
+	return nil
+}
+
+func defaultKeystoreDir() string {
+	if dir := os.Getenv("OPS_KEYSTORE"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "keystore"
+	}
+	return filepath.Join(home, ".config", "blockchain", "keystore")
+}
+
+func readInput(path string) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if path == "-" {
+		fmt.Fprintln(os.Stderr, "Paste or scan the unsigned payload, then press Ctrl-D:")
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read payload: %w", err)
+	}
+	return string(content), nil
+}
+
+func writeOutput(path, payload string) error {
+	if path == "-" {
+		_, err := fmt.Fprintln(os.Stdout, payload)
+		return err
+	}
+	// Signed payloads can be broadcast by anyone holding them
+	if err := os.WriteFile(path, []byte(payload+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write signed payload: %w", err)
+	}
+	return nil
+}
+
+// openPrompt - Terminal for confirmation/passphrase; stdin may already carry the payload
+func openPrompt() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+func confirm(prompt *os.File, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(prompt).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
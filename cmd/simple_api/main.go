@@ -1,30 +1,172 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/gagliardetto/solana-go/rpc"
 
+	"blockchain/backpressure"
+	"blockchain/chain"
 	"blockchain/chainbnb"
 	"blockchain/chainsol"
+	"blockchain/dust"
+	"blockchain/encryption"
+	"blockchain/events"
+	"blockchain/explorer"
+	"blockchain/health"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/rpcpool"
+	"blockchain/sandbox"
+	"blockchain/storage"
+	"blockchain/version"
 )
 
 func main() {
-	// Initialize Sol client
-	solChain := chainsol.NewSolChain(chainsol.Config{
-		RPCURL:  rpc.DevNet_RPC,
-		WSURL:   rpc.DevNet_WS,
-		Network: rpc.DevNet.Name,
-	})
+	var solChain, bnbChain chain.Chain
 
-	// Initialize BNB Chain client
-	bnbChain := chainbnb.NewBNBChain(chainbnb.Config{
-		RPCURL:  "https://data-seed-prebsc-1-s1.binance.org:8545/",
-		ChainID: 97,
-		Network: "testnet",
-	})
+	// DUST_MINIMUMS overrides the documented minimums, e.g. "solana:SOL=0.001,bsc:BNB=0.001" ("off" disables them)
+	dustPolicy, err := dust.ParsePolicy(os.Getenv("DUST_MINIMUMS"))
+	if err != nil {
+		log.Fatalf("Invalid dust config: %v", err)
+	}
+
+	// MAX_INFLIGHT_UNSIGNED / MAX_INFLIGHT_SUBMIT bound concurrent transaction building and submissions;
+	// past them, or while the Solana RPC pool rate limiters are backed up, requests get 429 with Retry-After
+	unsignedCapacity, err := backpressure.ParseCapacity(os.Getenv("MAX_INFLIGHT_UNSIGNED"), 256)
+	if err != nil {
+		log.Fatalf("Invalid MAX_INFLIGHT_UNSIGNED: %v", err)
+	}
+	submitCapacity, err := backpressure.ParseCapacity(os.Getenv("MAX_INFLIGHT_SUBMIT"), 64)
+	if err != nil {
+		log.Fatalf("Invalid MAX_INFLIGHT_SUBMIT: %v", err)
+	}
+	unsigned := backpressure.NewInflight("unsigned", unsignedCapacity)
+	submissions := backpressure.NewInflight("submissions", submitCapacity)
+	unsignedGate := backpressure.NewGate("unsigned", unsigned)
+	submitGate := backpressure.NewGate("submit", submissions)
+
+	// DATABASE_URL (postgres://... or sqlite:<path>) enables the off-chain store; every query is
+	// instrumented (db_* metrics), DB_SLOW_QUERY / DB_VERY_SLOW_QUERY tune the slow-query log
+	dbConfig, err := storage.ParseInstrumentConfig(os.Getenv("DB_SLOW_QUERY"), os.Getenv("DB_VERY_SLOW_QUERY"))
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+	var store *storage.Store
+	var txStore *storage.TransactionStore
+
+	sandboxMode := sandbox.Enabled(os.Getenv("SANDBOX"))
+	if sandboxMode {
+		// In-memory chains: instant confirmations, fake balances, works offline
+		solChain = sandbox.NewSolChain(os.Getenv("SANDBOX_SEED"))
+		bnbChain = sandbox.NewBNBChain(os.Getenv("SANDBOX_SEED"))
+	} else {
+		// Preflight defaults, e.g. PREFLIGHT_DEFAULT=simulate PREFLIGHT_TENANTS=botA=skip,botB=skip
+		policy, err := preflight.ParsePolicy(os.Getenv("PREFLIGHT_DEFAULT"), os.Getenv("PREFLIGHT_TENANTS"))
+		if err != nil {
+			log.Fatalf("Invalid preflight config: %v", err)
+		}
+
+		// EXPLORER_PROVIDER=solscan for solscan.io links (BSC always uses BscScan)
+		explorerProvider, err := explorer.ParseProvider(os.Getenv("EXPLORER_PROVIDER"))
+		if err != nil {
+			log.Fatalf("Invalid explorer config: %v", err)
+		}
+
+		// PRICES=coingecko or static "solana=150,bsc=600" for USD fee estimates
+		prices, err := pricing.New(os.Getenv("PRICES"))
+		if err != nil {
+			log.Fatalf("Invalid price config: %v", err)
+		}
+
+		// EVENT_WEBHOOK_URL receives transfer and transaction status events,
+		// EVENT_WEBHOOK_FORMAT=protobuf for binary protobuf instead of JSON
+		emitter, err := events.New(os.Getenv("EVENT_WEBHOOK_URL"), os.Getenv("EVENT_WEBHOOK_FORMAT"))
+		if err != nil {
+			log.Fatalf("Invalid event webhook config: %v", err)
+		}
+
+		// SOL_RPC_READ_URLS / SOL_RPC_WRITE_URLS split reads and submissions across endpoint pools,
+		// SOL_RPC_READ_RPS / SOL_RPC_WRITE_RPS rate limit each endpoint of a pool
+		poolConfig, err := rpcpool.ParseConfig(rpc.DevNet_RPC,
+			os.Getenv("SOL_RPC_READ_URLS"), os.Getenv("SOL_RPC_READ_RPS"),
+			os.Getenv("SOL_RPC_WRITE_URLS"), os.Getenv("SOL_RPC_WRITE_RPS"))
+		if err != nil {
+			log.Fatalf("Invalid RPC pool config: %v", err)
+		}
+		var solPools *rpcpool.Router
+		if poolConfig != nil {
+			if solPools, err = rpcpool.New(*poolConfig); err != nil {
+				log.Fatalf("Invalid RPC pool config: %v", err)
+			}
+			go solPools.Run(context.Background(), 0)
+			unsignedGate.Add(solPools.Pressure(rpcpool.Read))
+			submitGate.Add(solPools.Pressure(rpcpool.Read), solPools.Pressure(rpcpool.Write))
+		}
+
+		store, err = storage.OpenStore(os.Getenv("DATABASE_URL"), dbConfig)
+		if err != nil {
+			log.Fatalf("Invalid database config: %v", err)
+		}
+		if store != nil {
+			// PSEUDONYM_SECRET keys the pseudonyms left by erasure (the privacy routes are off without it),
+			// audit IPs / user agents older than AUDIT_RETENTION (default 90 days, "off" keeps them) are anonymized hourly
+			store.SetPseudonymSecret([]byte(os.Getenv("PSEUDONYM_SECRET")))
+			retention, err := storage.ParseRetention(os.Getenv("AUDIT_RETENTION"))
+			if err != nil {
+				log.Fatalf("Invalid AUDIT_RETENTION: %v", err)
+			}
+			if retention > 0 {
+				go store.RunRetention(context.Background(), retention, 0)
+			}
+
+			// ENCRYPTION_KEYS ("id:base64key,...", first active) or ENCRYPTION_KEYSTORE (keystore file) keeps
+			// unsigned and signed transactions AES-GCM encrypted in the store, for the stuck transaction resolver
+			keySource, err := encryption.KeySourceFromEnv()
+			if err != nil {
+				log.Fatalf("Invalid encryption config: %v", err)
+			}
+			txStore, err = storage.OpenTransactionStore(context.Background(), store.DB(), keySource)
+			if err != nil {
+				log.Fatalf("Invalid encryption config: %v", err)
+			}
+			if txStore != nil {
+				go txStore.RunPurge(context.Background(), 0)
+			}
+		}
+
+		// Initialize Sol client
+		solChain = chainsol.NewSolChain(chainsol.Config{
+			RPCURL:           rpc.DevNet_RPC,
+			WSURL:            rpc.DevNet_WS,
+			Network:          chain.Devnet,
+			CanaryPrivateKey: os.Getenv("SOL_CANARY_PRIVATE_KEY"),
+			Preflight:        policy,
+			History:          store,
+			ExplorerProvider: explorerProvider,
+			Prices:           prices,
+			Transactions:     txStore,
+			Events:           emitter,
+			RPCPools:         solPools,
+			Dust:             dustPolicy,
+		})
+
+		// Initialize BNB Chain client
+		bnbChain = chainbnb.NewBNBChain(chainbnb.Config{
+			RPCURL:           "https://data-seed-prebsc-1-s1.binance.org:8545/",
+			ChainID:          97,
+			Network:          chain.Testnet,
+			CanaryPrivateKey: os.Getenv("BNB_CANARY_PRIVATE_KEY"),
+			History:          store,
+			Transactions:     txStore,
+			Prices:           prices,
+			Events:           emitter,
+			Dust:             dustPolicy,
+		})
+	}
 
 	// Health checks
 	if err := solChain.HealthCheck(); err != nil {
@@ -34,39 +176,110 @@ func main() {
 		log.Fatalf("❌ BNB Chain health check failed: %v", err)
 	}
 
+	buildLimited := func(h http.HandlerFunc) http.HandlerFunc { return unsignedGate.Wrap(unsigned.Wrap(h)) }
+	submitLimited := func(h http.HandlerFunc) http.HandlerFunc { return submitGate.Wrap(submissions.Wrap(h)) }
+
 	// Solana routes
-	http.HandleFunc("/api/v1/sol/transaction/create", solChain.HandleCreateTransaction)
+	http.HandleFunc("/api/v1/sol/transaction/create", buildLimited(solChain.HandleCreateTransaction))
 	http.HandleFunc("/api/v1/sol/transaction/sign", solChain.HandleSignTransaction)
-	http.HandleFunc("/api/v1/sol/transaction/send", solChain.HandleSendTransaction)
+	http.HandleFunc("/api/v1/sol/transaction/send", submitLimited(solChain.HandleSendTransaction))
 	http.HandleFunc("/api/v1/sol/transaction/status", solChain.HandleGetTransactionStatus)
 	http.HandleFunc("/api/v1/sol/transaction/history", solChain.HandleGetTransactionHistory)
+	if rp, ok := solChain.(chain.ReceiptProvider); ok {
+		http.HandleFunc("/api/v1/sol/transaction/receipt", rp.HandleGetSubmissionReceipt)
+	}
 
 	// BNB routes
-	http.HandleFunc("/api/v1/bnb/transaction/create", bnbChain.HandleCreateTransaction)
+	http.HandleFunc("/api/v1/bnb/transaction/create", buildLimited(bnbChain.HandleCreateTransaction))
 	http.HandleFunc("/api/v1/bnb/transaction/sign", bnbChain.HandleSignTransaction)
-	http.HandleFunc("/api/v1/bnb/transaction/send", bnbChain.HandleSendTransaction)
+	http.HandleFunc("/api/v1/bnb/transaction/send", submitLimited(bnbChain.HandleSendTransaction))
 	http.HandleFunc("/api/v1/bnb/transaction/status", bnbChain.HandleGetTransactionStatus)
 	http.HandleFunc("/api/v1/bnb/transaction/history", bnbChain.HandleGetTransactionHistory)
 
+	// Failed submissions with their program logs, by the transaction_id returned on failure
+	if store != nil {
+		http.HandleFunc("/api/v1/submissions/failures", store.HandleGetSubmissionFailures)
+	}
+
+	// Minimum amounts (dust thresholds)
+	http.HandleFunc("/api/v1/limits", dustPolicy.HandleLimits)
+
+	// Event schema (JSON schema, ?format=proto for events.proto)
+	http.HandleFunc("/api/v1/events/schema", events.HandleSchema)
+
+	// Admin routes
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	http.HandleFunc("/api/v1/sol/admin/canary", adminOnly(adminToken, solChain.HandleCanary))
+	http.HandleFunc("/api/v1/bnb/admin/canary", adminOnly(adminToken, bnbChain.HandleCanary))
+	if sr, ok := solChain.(chain.StuckResolver); ok {
+		http.HandleFunc("/api/v1/sol/admin/stuck", adminOnly(adminToken, sr.HandleDiagnoseStuck))
+		http.HandleFunc("/api/v1/sol/admin/stuck/remediate", adminOnly(adminToken, sr.HandleRemediateStuck))
+	}
+	if sr, ok := bnbChain.(chain.StuckResolver); ok {
+		http.HandleFunc("/api/v1/bnb/admin/stuck", adminOnly(adminToken, sr.HandleDiagnoseStuck))
+		http.HandleFunc("/api/v1/bnb/admin/stuck/remediate", adminOnly(adminToken, sr.HandleRemediateStuck))
+	}
+	if store != nil && os.Getenv("PSEUDONYM_SECRET") != "" {
+		http.HandleFunc("/api/v1/privacy/erase", adminOnly(adminToken, store.HandleEraseUserData))
+		http.HandleFunc("/api/v1/privacy/deletions", adminOnly(adminToken, store.HandleGetDeletionRecords))
+	}
+
+	// Version / capabilities
+	http.HandleFunc("/version", version.Handler(version.New(
+		[]chain.ChainID{chain.Solana, chain.BSC},
+		[]string{"transfer_create", "transfer_send", "transaction_status", "transaction_history"},
+		nil,
+	).WithFeature(version.FeatureCanary, adminToken != "").WithFeature(version.FeatureSandbox, sandboxMode)))
+
 	// Health endpoint
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	// Readiness (chain RPCs + database when configured)
+	readiness := health.NewProbe().
+		AddFunc("solana", solChain.HealthCheck).
+		AddFunc("bsc", bnbChain.HealthCheck)
+	if store != nil {
+		readiness.Add("database", store.Ping)
+	}
+	http.HandleFunc("/readyz", readiness.Handler)
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
 	log.Printf("🚀 Simple API Server starting on port %s", port)
-	log.Printf("✅ Solana DevNet connected")
-	log.Printf("✅ BNB Testnet connected")
+	if sandboxMode {
+		log.Printf("🧪 SANDBOX mode - in-memory chains, nothing is sent to any network")
+	} else {
+		log.Printf("✅ Solana DevNet connected")
+		log.Printf("✅ BNB Testnet connected")
+	}
 	log.Printf("📡 Endpoints:")
 	log.Printf("   - SOL: /api/v1/sol/*")
 	log.Printf("   - BNB: /api/v1/bnb/*")
+	log.Printf("   - Failures: /api/v1/submissions/failures (with DATABASE_URL)")
+	log.Printf("   - Admin: /api/v1/{sol,bnb}/admin/canary (X-Admin-Token)")
+	log.Printf("   - Stuck transactions: /api/v1/{sol,bnb}/admin/stuck[/remediate] (X-Admin-Token, with DATABASE_URL and ENCRYPTION_KEYS)")
+	log.Printf("   - Privacy: /api/v1/privacy/{erase,deletions} (X-Admin-Token, with DATABASE_URL and PSEUDONYM_SECRET)")
+	log.Printf("   - Version: /version")
+	log.Printf("   - Readiness: /readyz")
 
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// adminOnly - Reject requests without the admin token (admin routes are closed when token is empty)
+func adminOnly(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || r.Header.Get("X-Admin-Token") != token {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
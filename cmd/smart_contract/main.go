@@ -1,12 +1,31 @@
 package main
 
 import (
-	"github.com/gagliardetto/solana-go/rpc"
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 
+	"blockchain/alert"
+	"blockchain/attestation"
+	"blockchain/backpressure"
+	"blockchain/chain"
+	"blockchain/dust"
+	"blockchain/encryption"
+	"blockchain/explorer"
+	"blockchain/health"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/rpcpool"
+	"blockchain/sandbox"
 	"blockchain/solprogram"
+	"blockchain/storage"
+	"blockchain/version"
 )
 
 func main() {
@@ -15,32 +34,297 @@ func main() {
 		programID = "8sVfWmonJAzAQnS4nYcxv3GBSs4rDpvmniRrApwrh1QK"
 	}
 
-	client, err := solprogram.NewClient(rpc.DevNet_RPC, programID)
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	sandboxMode := sandbox.Enabled(os.Getenv("SANDBOX"))
+
+	// DUST_MINIMUMS overrides the documented minimum per-user shares, e.g. "solana:SOL=0.001" ("off" disables them)
+	dustPolicy, err := dust.ParsePolicy(os.Getenv("DUST_MINIMUMS"))
+	if err != nil {
+		log.Fatalf("Invalid dust config: %v", err)
+	}
+
+	// MAX_INFLIGHT_UNSIGNED / MAX_INFLIGHT_SUBMIT bound concurrent transaction building and submissions;
+	// past them, or while the RPC pool rate limiters are backed up, requests get 429 with Retry-After
+	unsignedCapacity, err := backpressure.ParseCapacity(os.Getenv("MAX_INFLIGHT_UNSIGNED"), 256)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Invalid MAX_INFLIGHT_UNSIGNED: %v", err)
+	}
+	submitCapacity, err := backpressure.ParseCapacity(os.Getenv("MAX_INFLIGHT_SUBMIT"), 64)
+	if err != nil {
+		log.Fatalf("Invalid MAX_INFLIGHT_SUBMIT: %v", err)
+	}
+	unsigned := backpressure.NewInflight("unsigned", unsignedCapacity)
+	submissions := backpressure.NewInflight("submissions", submitCapacity)
+	unsignedGate := backpressure.NewGate("unsigned", unsigned)
+	submitGate := backpressure.NewGate("submit", submissions)
+
+	// DATABASE_URL (postgres://... or sqlite:<path>) enables the off-chain store; every query is
+	// instrumented (db_* metrics), DB_SLOW_QUERY / DB_VERY_SLOW_QUERY tune the slow-query log
+	dbConfig, err := storage.ParseInstrumentConfig(os.Getenv("DB_SLOW_QUERY"), os.Getenv("DB_VERY_SLOW_QUERY"))
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+
+	var api chain.EnvelopeAPI
+	readiness := health.NewProbe()
+	if sandboxMode {
+		// In-memory envelopes: instant confirmations, fake balances, works offline
+		api = sandbox.NewEnvelopes(sandbox.NewSolChain(os.Getenv("SANDBOX_SEED")))
+	} else {
+		// SOL_RPC_READ_URLS / SOL_RPC_WRITE_URLS split reads and submissions across endpoint pools,
+		// SOL_RPC_READ_RPS / SOL_RPC_WRITE_RPS rate limit each endpoint of a pool
+		poolConfig, err := rpcpool.ParseConfig(rpc.DevNet_RPC,
+			os.Getenv("SOL_RPC_READ_URLS"), os.Getenv("SOL_RPC_READ_RPS"),
+			os.Getenv("SOL_RPC_WRITE_URLS"), os.Getenv("SOL_RPC_WRITE_RPS"))
+		if err != nil {
+			log.Fatalf("Invalid RPC pool config: %v", err)
+		}
+		rpcClient := rpc.New(rpc.DevNet_RPC)
+		if poolConfig != nil {
+			pools, err := rpcpool.New(*poolConfig)
+			if err != nil {
+				log.Fatalf("Invalid RPC pool config: %v", err)
+			}
+			go pools.Run(context.Background(), 0)
+			readiness.Add("solana_rpc_pools", pools.Check)
+			unsignedGate.Add(pools.Pressure(rpcpool.Read))
+			submitGate.Add(pools.Pressure(rpcpool.Read), pools.Pressure(rpcpool.Write))
+			rpcClient = pools.Client()
+		}
+		client, err := solprogram.NewClientWithRPC(rpcClient, programID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client.Preflight, err = preflight.ParsePolicy(os.Getenv("PREFLIGHT_DEFAULT"), os.Getenv("PREFLIGHT_TENANTS"))
+		if err != nil {
+			log.Fatalf("Invalid preflight config: %v", err)
+		}
+		explorerProvider, err := explorer.ParseProvider(os.Getenv("EXPLORER_PROVIDER"))
+		if err != nil {
+			log.Fatalf("Invalid explorer config: %v", err)
+		}
+		client.Explorer = explorer.New(chain.Solana, chain.Devnet, explorerProvider)
+		client.Prices, err = pricing.New(os.Getenv("PRICES"))
+		if err != nil {
+			log.Fatalf("Invalid price config: %v", err)
+		}
+		client.ClaimDeadline, err = solprogram.ParseClaimDeadlinePolicy(os.Getenv("CLAIM_MIN_REMAINING"), os.Getenv("CLAIM_DEADLINE_MODE"))
+		if err != nil {
+			log.Fatalf("Invalid claim deadline config: %v", err)
+		}
+		// ENVELOPE_MAX_CLAIMERS=group_fixed=5,group_random=8 lowers the bound on total_users of group
+		// envelopes (the SOL program holds at most SOLMaxClaimers)
+		client.EnvelopeRules, err = solprogram.SOLEnvelopeRules().WithMaxClaimers(os.Getenv("ENVELOPE_MAX_CLAIMERS"))
+		if err != nil {
+			log.Fatalf("Invalid ENVELOPE_MAX_CLAIMERS: %v", err)
+		}
+		client.Dust = dustPolicy
+		var alertArchive alert.WebhookArchive
+		client.History, err = storage.OpenStore(os.Getenv("DATABASE_URL"), dbConfig)
+		if err != nil {
+			log.Fatalf("Invalid database config: %v", err)
+		}
+		if client.History != nil {
+			// Per-group daily claim caps: admins set them at /admin/claim-caps, CLAIM_CAP_DEFAULT_PER_DAY
+			// applies to groups without one (0 = unlimited)
+			claimCapDefault, err := solprogram.ParseClaimCapDefault(os.Getenv("CLAIM_CAP_DEFAULT_PER_DAY"))
+			if err != nil {
+				log.Fatalf("Invalid CLAIM_CAP_DEFAULT_PER_DAY: %v", err)
+			}
+			client.ClaimCaps = solprogram.NewClaimCapPolicy(client.History, claimCapDefault)
+			http.HandleFunc("/admin/claim-caps", adminOnly(adminToken, client.History.HandleClaimCaps))
+			http.HandleFunc("/api/v1/submissions/failures", client.History.HandleGetSubmissionFailures)
+
+			// ENCRYPTION_KEYS ("id:base64key,...", first active) or ENCRYPTION_KEYSTORE (keystore file) keeps
+			// the alert webhook bodies AES-GCM encrypted in the store
+			keySource, err := encryption.KeySourceFromEnv()
+			if err != nil {
+				log.Fatalf("Invalid encryption config: %v", err)
+			}
+			txStore, err := storage.OpenTransactionStore(context.Background(), client.History.DB(), keySource)
+			if err != nil {
+				log.Fatalf("Invalid encryption config: %v", err)
+			}
+			if txStore != nil {
+				go txStore.RunPurge(context.Background(), 0)
+				alertArchive = txStore
+			}
+		}
+		// ATTESTATION_PRIVATE_KEY (base58) signs claim receipts; its public key is served at /api/attestation-key
+		client.Attestor, err = attestation.ParseSigner(os.Getenv("ATTESTATION_PRIVATE_KEY"))
+		if err != nil {
+			log.Fatalf("Invalid attestation config: %v", err)
+		}
+		if client.Attestor != nil {
+			http.HandleFunc("/api/attestation-key", client.Attestor.HandlePublicKey)
+		}
+
+		// IDL_CHECK=fail (default) refuses to start when the deployed IDL disagrees with the
+		// instruction builders, warn only logs the diff, off skips the check
+		idlCheck, err := solprogram.ParseIDLCheckMode(os.Getenv("IDL_CHECK"))
+		if err != nil {
+			log.Fatalf("Invalid IDL_CHECK: %v", err)
+		}
+		if idlCheck != solprogram.IDLCheckOff {
+			if err := idlCheck.Enforce(client.ValidateIDL(context.Background())); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		// Program upgrade / pause monitor
+		monitorCfg := solprogram.ProgramMonitorConfig{
+			ProgramID:     client.ProgramID,
+			TripOnUpgrade: os.Getenv("TRIP_ON_UPGRADE") == "true",
+		}
+		switch addr := os.Getenv("PAUSE_ACCOUNT"); addr {
+		case "":
+		case "config":
+			// Paused flag of the program config PDA
+			configPDA, _, err := solprogram.DeriveConfigPDA(client.ProgramID)
+			if err != nil {
+				log.Fatal(err)
+			}
+			monitorCfg.PauseAccounts = append(monitorCfg.PauseAccounts, solprogram.PauseAccount{
+				Address:          configPDA,
+				PausedFlagOffset: solprogram.ConfigPausedFlagOffset,
+			})
+		default:
+			pauseAccount, err := solana.PublicKeyFromBase58(addr)
+			if err != nil {
+				log.Fatalf("Invalid PAUSE_ACCOUNT: %v", err)
+			}
+			offset, err := strconv.Atoi(os.Getenv("PAUSE_FLAG_OFFSET"))
+			if err != nil || offset < 0 {
+				log.Fatalf("Invalid PAUSE_FLAG_OFFSET %q: must be the byte offset of the paused flag", os.Getenv("PAUSE_FLAG_OFFSET"))
+			}
+			monitorCfg.PauseAccounts = append(monitorCfg.PauseAccounts, solprogram.PauseAccount{
+				Address:          pauseAccount,
+				PausedFlagOffset: offset,
+			})
+		}
+		alerter := alert.New(os.Getenv("ALERT_WEBHOOK_URL"), alertArchive)
+		monitor := solprogram.NewProgramMonitor(client.RPC, monitorCfg, client.Breakers, alerter)
+		go monitor.Run(context.Background())
+
+		// USDC envelope vaults are checked against their remaining amounts every VAULT_CHECK_INTERVAL
+		// (default 10m); mismatches alert and are listed at /api/v1/envelopes/vault-consistency
+		var vaultInterval time.Duration
+		if v := os.Getenv("VAULT_CHECK_INTERVAL"); v != "" {
+			if vaultInterval, err = time.ParseDuration(v); err != nil || vaultInterval <= 0 {
+				log.Fatalf("Invalid VAULT_CHECK_INTERVAL %q", v)
+			}
+		}
+		usdcClient, err := solprogram.NewUSDCEnvelopeClientWithClients(rpcClient, nil, chain.Devnet)
+		if err != nil {
+			log.Fatal(err)
+		}
+		envelopeLayout, err := solprogram.ParseEnvelopeLayout(os.Getenv("ENVELOPE_LAYOUT"))
+		if err != nil {
+			log.Fatalf("Invalid ENVELOPE_LAYOUT: %v", err)
+		}
+		usdcClient.SetEnvelopeLayout(envelopeLayout)
+		// Same IDL_CHECK as the SOL program; it also switches the parser to the layout the USDC IDL declares
+		if idlCheck != solprogram.IDLCheckOff {
+			if err := idlCheck.Enforce(usdcClient.ValidateIDL(context.Background())); err != nil {
+				log.Fatal(err)
+			}
+		}
+		vaultChecker := solprogram.NewVaultChecker(usdcClient, alerter, vaultInterval)
+		go vaultChecker.Run(context.Background())
+		http.HandleFunc("/api/v1/envelopes/vault-consistency", vaultChecker.HandleGetVaultConsistency)
+		http.HandleFunc("/api/v1/envelopes/refund-risk", usdcClient.HandleGetAtRiskRefunds)
+
+		// Signed refunds sent to /api/send-transaction go through a queue, at-risk ones (vault short,
+		// owner wallet closing or low on rent buffer, long overdue) first; repeated failures alert
+		client.RefundQueue = solprogram.NewSubmissionQueue(client.SubmitQueued, alerter, solprogram.SubmissionQueueConfig{})
+		go client.RefundQueue.Run(context.Background())
+		// A refund backlog answers new submissions with 429 until it drains
+		submitGate.Add(client.RefundQueue)
+
+		http.HandleFunc("/admin/breakers", adminOnly(adminToken, client.Breakers.Handler()))
+		http.HandleFunc("/api/pda/verify", client.HandleVerifyPDA)
+		http.HandleFunc("/api/pda/test-vectors", client.HandlePDATestVectors)
+		readiness.Add("solana_rpc", func(ctx context.Context) error {
+			_, err := client.RPC.GetHealth(ctx)
+			return err
+		})
+		if client.History != nil {
+			readiness.Add("database", client.History.Ping)
+		}
+		api = client
 	}
 
 	// Routes
-	http.HandleFunc("/api/create-envelope", client.HandleCreateEnvelope)
-	http.HandleFunc("/api/claim-envelope", client.HandleClaimEnvelope)
-	http.HandleFunc("/api/refund-envelope", client.HandleRefundEnvelope)
-	http.HandleFunc("/api/sign-transaction", client.HandleSignTransaction) // ⚠️ TESTING ONLY
-	http.HandleFunc("/api/send-transaction", client.HandleSendTransaction)
+	buildLimited := func(h http.HandlerFunc) http.HandlerFunc { return unsignedGate.Wrap(unsigned.Wrap(h)) }
+	submitLimited := func(h http.HandlerFunc) http.HandlerFunc { return submitGate.Wrap(submissions.Wrap(h)) }
+	http.HandleFunc("/api/create-envelope", buildLimited(api.HandleCreateEnvelope))
+	http.HandleFunc("/api/claim-envelope", buildLimited(api.HandleClaimEnvelope))
+	http.HandleFunc("/api/refund-envelope", buildLimited(api.HandleRefundEnvelope))
+	http.HandleFunc("/api/sign-transaction", api.HandleSignTransaction) // ⚠️ TESTING ONLY
+	http.HandleFunc("/api/send-transaction", submitLimited(api.HandleSendTransaction))
+	http.HandleFunc("/api/limits", dustPolicy.HandleLimits)
+	if claims, ok := api.(chain.ClaimSubmitter); ok {
+		http.HandleFunc("/api/claim-envelope/submit", submitLimited(claims.HandleSubmitClaim))
+	}
+	if attester, ok := api.(chain.ClaimAttester); ok {
+		http.HandleFunc("/api/claim-attestation", attester.HandleClaimAttestation)
+	}
+	if previews, ok := api.(chain.TransactionPreviewer); ok {
+		http.HandleFunc("/api/simulate-preview", previews.HandleSimulatePreview)
+	}
+
+	// Version / capabilities
+	http.HandleFunc("/version", version.Handler(version.New(
+		[]chain.ChainID{chain.Solana},
+		[]string{"create", "claim", "refund", "send_transaction"},
+		map[string]string{"sol_envelope": programID},
+	).WithFeature(version.FeatureCanary, false).
+		WithFeature(version.FeatureEnvelopeCache, false).
+		WithFeature(version.FeatureSandbox, sandboxMode)))
 
 	// Health
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
 
+	// Readiness (RPC + database when configured)
+	http.HandleFunc("/readyz", readiness.Handler)
+
 	port := "8081"
 	log.Printf("🚀 SPL API running on :%s", port)
-	log.Printf("📦 Program ID: %s", programID)
+	if sandboxMode {
+		log.Printf("🧪 SANDBOX mode - in-memory envelopes, nothing is sent to any network")
+	} else {
+		log.Printf("📦 Program ID: %s", programID)
+	}
 	log.Printf("📡 Endpoints:")
 	log.Printf("   POST /api/create-envelope")
 	log.Printf("   POST /api/claim-envelope")
+	log.Printf("   POST /api/claim-envelope/submit  (signed claim, retried on transient errors)")
 	log.Printf("   POST /api/refund-envelope")
 	log.Printf("   POST /api/sign-transaction   ⚠️  TESTING ONLY")
 	log.Printf("   POST /api/send-transaction")
+	log.Printf("   POST /api/pda/verify         (client PDA parity check)")
+	log.Printf("   GET  /api/pda/test-vectors")
+	log.Printf("   GET  /api/v1/envelopes/vault-consistency")
+	log.Printf("   GET  /api/v1/envelopes/refund-risk?owner=")
+	log.Printf("   GET  /api/v1/submissions/failures  (with DATABASE_URL)")
+	log.Printf("   GET  /version")
+	log.Printf("   GET  /readyz")
+	log.Printf("   GET  /admin/breakers          (X-Admin-Token)")
+	log.Printf("   POST /admin/breakers          (X-Admin-Token)")
+	log.Printf("   GET/PUT/DELETE /admin/claim-caps  (X-Admin-Token, with DATABASE_URL)")
 
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// adminOnly - Reject requests without the admin token (admin routes are closed when token is empty)
+func adminOnly(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || r.Header.Get("X-Admin-Token") != token {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"blockchain/alert"
+	"blockchain/chain"
+	"blockchain/chainsol"
+	"blockchain/encryption"
+	"blockchain/events"
+	"blockchain/explorer"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/rpcpool"
 	"blockchain/solprogram"
+	"blockchain/storage"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
 // Test users (Devnet)
@@ -29,7 +45,14 @@ var (
 )
 
 func main() {
-	fmt.Println("=== Solana USDC Envelope Program Demo ===\n")
+	fork := flag.Bool("fork", false, "Target a local mainnet-fork validator (mainnet program and mint) instead of devnet")
+	forkRPC := flag.String("fork-rpc", solprogram.RPCURLLocalhost, "Fork validator RPC URL")
+	forkWS := flag.String("fork-ws", solprogram.WSURLLocalhost, "Fork validator WebSocket URL")
+	forkEnvelopes := flag.String("fork-envelopes", "", "Mainnet envelopes to clone into the fork (owner:id,...)")
+	forkValidatorArgs := flag.Bool("fork-validator-args", false, "Print the solana-test-validator command for the fork and exit")
+	flag.Parse()
+
+	fmt.Print("=== Solana USDC Envelope Program Demo ===\n\n")
 
 	// =====================================================
 	// TEST CONFIGURATION - Edit these flags to enable/disable tests
@@ -51,23 +74,168 @@ func main() {
 		runUnsignedRefund = false // Demo: Generate unsigned refund transaction (after expiry)
 
 		// Complete Flow Demo (create -> claim -> refund)
-		runCompleteFlow = true // Demo: Complete unsigned transaction flow (create -> wait 2-3s -> claim -> wait 60s -> refund)
+		runCompleteFlow = true // Demo: Complete unsigned transaction flow via FlowOrchestrator (create -> claim -> wait for expiry -> refund)
 	)
 
 	// Setup
 	ctx := context.Background()
 
-	// Create client (Devnet)
-	client, err := solprogram.NewUSDCEnvelopeClient(
-		solprogram.RPCURLDevnet,
-		solprogram.WSURLDevnet,
-		"devnet",
-	)
+	// Create client (Devnet, or a local mainnet fork with --fork)
+	var client *solprogram.USDCEnvelopeClient
+	if *fork || *forkValidatorArgs {
+		envelopes, err := solprogram.ParseForkEnvelopes(*forkEnvelopes)
+		if err != nil {
+			log.Fatalf("Invalid -fork-envelopes: %v", err)
+		}
+		forkConfig := solprogram.ForkConfig{RPCURL: *forkRPC, WSURL: *forkWS, Envelopes: envelopes}
+		if *forkValidatorArgs {
+			args, err := forkConfig.ValidatorArgs()
+			if err != nil {
+				log.Fatalf("Failed to build validator args: %v", err)
+			}
+			fmt.Printf("solana-test-validator %s\n", strings.Join(args, " "))
+			return
+		}
+		client, err = solprogram.NewUSDCEnvelopeForkClient(ctx, forkConfig)
+		if err != nil {
+			log.Fatalf("Failed to create fork client: %v", err)
+		}
+		fundForkUsers(ctx, client.GetClient())
+	} else {
+		// SOL_RPC_READ_URLS / SOL_RPC_WRITE_URLS split reads and submissions across endpoint pools,
+		// SOL_RPC_READ_RPS / SOL_RPC_WRITE_RPS rate limit each endpoint of a pool
+		poolConfig, err := rpcpool.ParseConfig(solprogram.RPCURLDevnet,
+			os.Getenv("SOL_RPC_READ_URLS"), os.Getenv("SOL_RPC_READ_RPS"),
+			os.Getenv("SOL_RPC_WRITE_URLS"), os.Getenv("SOL_RPC_WRITE_RPS"))
+		if err != nil {
+			log.Fatalf("Invalid RPC pool config: %v", err)
+		}
+		rpcClient := rpc.New(solprogram.RPCURLDevnet)
+		if poolConfig != nil {
+			pools, err := rpcpool.New(*poolConfig)
+			if err != nil {
+				log.Fatalf("Invalid RPC pool config: %v", err)
+			}
+			go pools.Run(ctx, 0)
+			rpcClient = pools.Client()
+		}
+		client, err = solprogram.NewUSDCEnvelopeClientWithRPC(rpcClient, solprogram.WSURLDevnet, chain.Devnet)
+		if err != nil {
+			log.Fatalf("Failed to create client: %v", err)
+		}
+	}
+	overrides, err := solprogram.ParseTokenProgramOverrides(os.Getenv("TOKEN_PROGRAM_OVERRIDES"))
 	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
+		log.Fatalf("Invalid TOKEN_PROGRAM_OVERRIDES: %v", err)
+	}
+	client.SetTokenProgramOverrides(overrides)
+	policy, err := preflight.ParsePolicy(os.Getenv("PREFLIGHT_DEFAULT"), os.Getenv("PREFLIGHT_TENANTS"))
+	if err != nil {
+		log.Fatalf("Invalid preflight config: %v", err)
+	}
+	client.SetPreflightPolicy(policy)
+	explorerProvider, err := explorer.ParseProvider(os.Getenv("EXPLORER_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Invalid EXPLORER_PROVIDER: %v", err)
+	}
+	client.SetExplorerProvider(explorerProvider)
+	prices, err := pricing.New(os.Getenv("PRICES"))
+	if err != nil {
+		log.Fatalf("Invalid PRICES: %v", err)
+	}
+	client.SetPriceSource(prices)
+	claimDeadline, err := solprogram.ParseClaimDeadlinePolicy(os.Getenv("CLAIM_MIN_REMAINING"), os.Getenv("CLAIM_DEADLINE_MODE"))
+	if err != nil {
+		log.Fatalf("Invalid claim deadline config: %v", err)
+	}
+	client.SetClaimDeadlinePolicy(claimDeadline)
+	envelopeRules, err := solprogram.ParseEnvelopeRules(os.Getenv("ENVELOPE_MAX_CLAIMERS"))
+	if err != nil {
+		log.Fatalf("Invalid ENVELOPE_MAX_CLAIMERS: %v", err)
+	}
+	client.SetEnvelopeRules(envelopeRules)
+	emitter, err := events.New(os.Getenv("EVENT_WEBHOOK_URL"), os.Getenv("EVENT_WEBHOOK_FORMAT"))
+	if err != nil {
+		log.Fatalf("Invalid event webhook config: %v", err)
+	}
+	if emitter != nil {
+		client.OnConfirmed(events.EnvelopeHook(emitter))
 	}
 
-	fmt.Printf("✅ Connected to Solana Devnet\n")
+	// DATABASE_URL enables the off-chain store: failed submissions, sponsorship decisions and the
+	// envelope-linked transaction history; with ENCRYPTION_KEYS or ENCRYPTION_KEYSTORE the generated
+	// unsigned transactions are kept in it as well, AES-GCM encrypted
+	dbConfig, err := storage.ParseInstrumentConfig(os.Getenv("DB_SLOW_QUERY"), os.Getenv("DB_VERY_SLOW_QUERY"))
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+	store, err := storage.OpenStore(os.Getenv("DATABASE_URL"), dbConfig)
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+	if store != nil {
+		client.SetHistoryStore(store)
+		historyConfig := chainsol.Config{RPCURL: solprogram.RPCURLDevnet, WSURL: solprogram.WSURLDevnet, Network: chain.Devnet, History: store}
+		if *fork {
+			historyConfig = chainsol.Config{RPCURL: *forkRPC, WSURL: *forkWS, Network: chain.Mainnet, History: store}
+		}
+		history := chainsol.NewSolChain(historyConfig)
+		client.OnConfirmed(history.RecordEnvelopeTransaction)
+
+		keySource, err := encryption.KeySourceFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid encryption config: %v", err)
+		}
+		txStore, err := storage.OpenTransactionStore(ctx, store.DB(), keySource)
+		if err != nil {
+			log.Fatalf("Invalid encryption config: %v", err)
+		}
+		if txStore != nil {
+			client.SetTransactionStore(txStore)
+		}
+	}
+
+	// ENVELOPE_LAYOUT=v1|v2 (default v2) is the Envelope account layout of the deployed program;
+	// the IDL check below replaces it with the layout the IDL declares
+	layout, err := solprogram.ParseEnvelopeLayout(os.Getenv("ENVELOPE_LAYOUT"))
+	if err != nil {
+		log.Fatalf("Invalid ENVELOPE_LAYOUT: %v", err)
+	}
+	client.SetEnvelopeLayout(layout)
+
+	// IDL_CHECK=fail (default) stops before sending anything when the deployed IDL disagrees with
+	// the instruction builders, warn only logs the diff, off skips the check
+	idlCheck, err := solprogram.ParseIDLCheckMode(os.Getenv("IDL_CHECK"))
+	if err != nil {
+		log.Fatalf("Invalid IDL_CHECK: %v", err)
+	}
+	if idlCheck != solprogram.IDLCheckOff {
+		if err := idlCheck.Enforce(client.ValidateIDL(ctx)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Fee sponsorship for claims: SPONSOR_PRIVATE_KEY pays, RISK_PROVIDER_URL adds an external score
+	if key := os.Getenv("SPONSOR_PRIVATE_KEY"); key != "" {
+		sponsorKey, err := solana.PrivateKeyFromBase58(key)
+		if err != nil {
+			log.Fatalf("Invalid SPONSOR_PRIVATE_KEY: %v", err)
+		}
+		var provider solprogram.WalletRiskProvider
+		if u := os.Getenv("RISK_PROVIDER_URL"); u != "" {
+			provider = solprogram.HTTPRiskProvider{URL: u}
+		}
+		client.SetFeeSponsor(&solprogram.FeeSponsor{
+			Key:    sponsorKey,
+			Scorer: solprogram.NewWalletRiskScorer(client.GetClient(), solprogram.DefaultWalletRiskConfig, provider),
+		})
+	}
+
+	if *fork {
+		fmt.Printf("✅ Connected to local mainnet fork at %s\n", *forkRPC)
+	} else {
+		fmt.Printf("✅ Connected to Solana Devnet\n")
+	}
 	fmt.Printf("Program ID: %s\n\n", client.GetProgramID().String())
 
 	// Display users
@@ -91,24 +259,12 @@ func main() {
 	if runGroupFixed {
 		fmt.Println("\n--- Example 2: Create GroupFixed Envelope ---")
 		groupFixedEnvelopeID = demonstrateCreateGroupFixed(ctx, client)
-
-		// If claim is also enabled, wait extra time for RPC to sync
-		if runClaim {
-			fmt.Println("\n⏳ Waiting for RPC to sync envelope state before claiming...")
-			time.Sleep(5 * time.Second)
-		}
 	}
 
 	// Example 3: Create DirectFixed Envelope
 	if runDirectFixed {
 		fmt.Println("\n--- Example 3: Create DirectFixed Envelope ---")
 		directFixedEnvelopeID, lastTxSignature = demonstrateCreateDirectFixed(ctx, client)
-
-		// If GetEnvelopeInfo or refund is also enabled, ensure envelope exists before proceeding
-		if runGetEnvelopeInfo || runWaitAndRefund {
-			fmt.Println("\n⏳ Waiting for RPC to sync envelope state...")
-			time.Sleep(5 * time.Second)
-		}
 	}
 
 	// Example 4: Get Envelope Info
@@ -131,16 +287,10 @@ func main() {
 			log.Fatal("❌ Error: No GroupFixed envelope created. Set runGroupFixed=true first!")
 		}
 
-		// Verify envelope exists before claiming
+		// Verify envelope exists before claiming (the read waits for the create's slot)
 		fmt.Printf("Verifying envelope %d exists...\n", groupFixedEnvelopeID)
-		_, err := client.GetEnvelopeInfo(ctx, User1PublicKey, groupFixedEnvelopeID)
-		if err != nil {
-			fmt.Printf("⚠️  Envelope not ready yet. Waiting 5 more seconds...\n")
-			time.Sleep(5 * time.Second)
-			_, err = client.GetEnvelopeInfo(ctx, User1PublicKey, groupFixedEnvelopeID)
-			if err != nil {
-				log.Fatalf("❌ Error: Envelope %d not found: %v", groupFixedEnvelopeID, err)
-			}
+		if _, err := client.GetEnvelopeInfo(ctx, User1PublicKey, groupFixedEnvelopeID); err != nil {
+			log.Fatalf("❌ Error: Envelope %d not found: %v", groupFixedEnvelopeID, err)
 		}
 		fmt.Println("✅ Envelope verified!")
 
@@ -191,8 +341,6 @@ func main() {
 		if unsignedEnvelopeID == 0 {
 			log.Fatal("❌ Error: No envelope created via unsigned transaction. Set runUnsignedCreate=true first!")
 		}
-		fmt.Println("Waiting 5 seconds for envelope to be confirmed...")
-		time.Sleep(5 * time.Second)
 		demonstrateUnsignedClaim(ctx, client, unsignedEnvelopeID)
 	}
 
@@ -242,7 +390,7 @@ func demonstrateInitUserState(ctx context.Context, client *solprogram.USDCEnvelo
 
 	// Wait for confirmation
 	fmt.Println("Waiting for confirmation...")
-	err = client.WaitForConfirmation(ctx, result.Signature, 30) // 30 second timeout
+	err = waitForConfirmation(ctx, client, result.Signature)
 	if err != nil {
 		fmt.Printf("❌ Confirmation failed: %v\n", err)
 		return
@@ -308,13 +456,11 @@ func demonstrateCreateGroupFixed(ctx context.Context, client *solprogram.USDCEnv
 
 	// Wait for confirmation
 	fmt.Println("\nWaiting for confirmation...")
-	err = client.WaitForConfirmation(ctx, response.Signature, 30)
+	err = waitForConfirmation(ctx, client, response.Signature)
 	if err != nil {
 		fmt.Printf("⚠️  Warning: Confirmation failed: %v\n", err)
 	} else {
 		fmt.Println("✅ Transaction confirmed!")
-		// Give RPC time to update account state
-		time.Sleep(3 * time.Second)
 	}
 
 	return response.EnvelopeID
@@ -357,13 +503,11 @@ func demonstrateCreateDirectFixed(ctx context.Context, client *solprogram.USDCEn
 
 	// Wait for confirmation
 	fmt.Println("\nWaiting for confirmation...")
-	err = client.WaitForConfirmation(ctx, response.Signature, 30)
+	err = waitForConfirmation(ctx, client, response.Signature)
 	if err != nil {
 		fmt.Printf("⚠️  Warning: Confirmation failed: %v\n", err)
 	} else {
 		fmt.Println("✅ Transaction confirmed!")
-		// Give RPC time to update account state
-		time.Sleep(3 * time.Second)
 	}
 
 	return response.EnvelopeID, response.Signature
@@ -374,17 +518,12 @@ func demonstrateGetEnvelopeInfo(ctx context.Context, client *solprogram.USDCEnve
 	fmt.Printf("Fetching envelope info for User 1: %s\n", User1PublicKey.String())
 	fmt.Printf("Envelope ID: %d\n", envelopeID)
 
+	// Waits for the slot of the create confirmation instead of sleeping
 	info, err := client.GetEnvelopeInfo(ctx, User1PublicKey, envelopeID)
 	if err != nil {
-		// Retry once after 5 seconds
-		fmt.Printf("⚠️  Envelope not ready yet. Waiting 5 more seconds...\n")
-		time.Sleep(5 * time.Second)
-		info, err = client.GetEnvelopeInfo(ctx, User1PublicKey, envelopeID)
-		if err != nil {
-			fmt.Printf("❌ Error: Envelope not found: %v\n", err)
-			fmt.Println("Note: Make sure envelope was created successfully")
-			return
-		}
+		fmt.Printf("❌ Error: Envelope not found: %v\n", err)
+		fmt.Println("Note: Make sure envelope was created successfully")
+		return
 	}
 
 	// Pretty print envelope info
@@ -398,6 +537,9 @@ func demonstrateGetEnvelopeInfo(ctx context.Context, client *solprogram.USDCEnve
 	fmt.Printf("  Remaining: %.6f USDC\n", float64(info.RemainingAmount)/1_000_000)
 	fmt.Printf("  Expired: %v\n", info.IsExpired)
 	fmt.Printf("  Expiry Time: %s\n", info.ExpiryTime.Format(time.RFC3339))
+	if info.StartTime != nil {
+		fmt.Printf("  Claimable From: %s (started: %v)\n", info.StartTime.Format(time.RFC3339), info.IsStarted)
+	}
 }
 
 // demonstrateClaim - Claim from envelope
@@ -453,7 +595,7 @@ func demonstrateClaim(ctx context.Context, client *solprogram.USDCEnvelopeClient
 
 	// Wait for confirmation
 	fmt.Println("\nWaiting for confirmation...")
-	err = client.WaitForConfirmation(ctx, response.Signature, 30)
+	err = waitForConfirmation(ctx, client, response.Signature)
 	if err != nil {
 		fmt.Printf("⚠️  Warning: Confirmation failed: %v\n", err)
 		return
@@ -497,7 +639,7 @@ func demonstrateRefund(ctx context.Context, client *solprogram.USDCEnvelopeClien
 
 	// Wait for confirmation
 	fmt.Println("\nWaiting for confirmation...")
-	err = client.WaitForConfirmation(ctx, response.Signature, 30)
+	err = waitForConfirmation(ctx, client, response.Signature)
 	if err != nil {
 		fmt.Printf("⚠️  Warning: Confirmation failed: %v\n", err)
 		return
@@ -689,8 +831,9 @@ func demonstrateUnsignedClaim(ctx context.Context, client *solprogram.USDCEnvelo
 		ClaimerTokenAccount: claimerTokenAccount,
 	}
 
-	// Step 1: Backend generates unsigned transaction
-	response, err := client.GenerateUnsignedClaim(params)
+	// Step 1: Backend generates unsigned transaction (fee paid by the sponsor when SPONSOR_PRIVATE_KEY is set
+	// and the claimer passes the risk check)
+	response, err := client.GenerateSponsoredClaim(params)
 	if err != nil {
 		fmt.Printf("❌ Error generating unsigned transaction: %v\n", err)
 		return
@@ -698,6 +841,9 @@ func demonstrateUnsignedClaim(ctx context.Context, client *solprogram.USDCEnvelo
 
 	fmt.Printf("\n✅ Unsigned transaction generated!\n")
 	fmt.Printf("Transaction ID: %s\n", response.TransactionID)
+	if response.Sponsorship != nil {
+		fmt.Printf("Fee sponsored: %v %s\n", response.Sponsorship.Sponsored, response.Sponsorship.Reason)
+	}
 
 	// Step 2: Simulate signing by User2
 	fmt.Println("\n--- Simulating Frontend Signing (User2) ---")
@@ -779,39 +925,15 @@ func demonstrateUnsignedRefund(ctx context.Context, client *solprogram.USDCEnvel
 	fmt.Printf("Explorer: %s\n", result.ExplorerURL)
 }
 
-// demonstrateCompleteFlow - Complete flow: create -> wait -> claim -> wait -> refund
+// demonstrateCompleteFlow - Complete flow via solprogram.FlowOrchestrator: create -> claim -> wait for expiry -> refund
 func demonstrateCompleteFlow(ctx context.Context, client *solprogram.USDCEnvelopeClient) {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("🚀 COMPLETE UNSIGNED TRANSACTION FLOW DEMONSTRATION")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("Flow: Create Envelope → Wait 2-3s → Claim → Wait 60s → Refund")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-
-	// ========================================
-	// STEP 1: Create Envelope (Unsigned Transaction)
-	// ========================================
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║  STEP 1: CREATE ENVELOPE (Unsigned Transaction Pattern)   ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	fmt.Println("Flow: Create Envelope → Claim → Wait for expiry → Refund")
+	fmt.Print("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
-	// Get user state
-	userState, err := client.GetUserState(ctx, User1PublicKey)
-	if err != nil {
-		fmt.Printf("❌ Error getting user state: %v\n", err)
-		fmt.Println("\n💡 TIP: Run with runInitUserState=true first to initialize user state")
-		return
-	}
-
-	envelopeID := userState.LastEnvelopeID + 1
-
-	// Get user's USDC token account
-	userTokenAccount, err := client.GetUSDCTokenAddress(User1PublicKey)
-	if err != nil {
-		fmt.Printf("❌ Error deriving token account: %v\n", err)
-		return
-	}
-
-	// Create params for GroupFixed envelope (anyone can claim, up to 3 users)
+	// GroupFixed envelope (anyone can claim, up to 3 users)
 	params := solprogram.CreateEnvelopeParams{
 		EnvelopeType: solprogram.EnvelopeTypeData{
 			Type:           solprogram.EnvelopeTypeGroupFixed,
@@ -823,220 +945,51 @@ func demonstrateCompleteFlow(ctx context.Context, client *solprogram.USDCEnvelop
 	}
 
 	fmt.Printf("📋 Envelope Configuration:\n")
-	fmt.Printf("   Envelope ID: %d\n", envelopeID)
 	fmt.Printf("   Type: GroupFixed\n")
 	fmt.Printf("   Owner: %s\n", User1PublicKey.String())
-	fmt.Printf("   Total Users: %d (Anyone can claim)\n", params.TotalUsers)
-	fmt.Printf("   Total Amount: %.2f USDC\n", float64(params.TotalAmount)/1_000_000)
-	fmt.Printf("   Amount per User: %.2f USDC\n", float64(params.TotalAmount)/float64(params.TotalUsers)/1_000_000)
-	fmt.Printf("   Expiry: %d seconds\n\n", params.ExpirySeconds)
-
-	// Generate unsigned transaction (backend)
-	fmt.Println("🔧 Backend: Generating unsigned transaction...")
-	unsignedResp, err := client.GenerateUnsignedCreateEnvelope(
-		User1PublicKey,
-		userTokenAccount,
-		params,
-		envelopeID,
-	)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Unsigned transaction generated\n")
-	fmt.Printf("   Transaction ID: %s\n", unsignedResp.TransactionID)
-	fmt.Printf("   Recent Blockhash: %s\n\n", unsignedResp.RecentBlockhash)
-
-	// Sign transaction (frontend - simulated)
-	fmt.Println("🔐 Frontend: Signing transaction with User1's private key...")
-	signedTx, err := signTransactionDemo(unsignedResp.UnsignedTransaction, User1PrivateKey)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Transaction signed\n\n")
-
-	// Submit signed transaction (backend)
-	fmt.Println("📤 Backend: Submitting signed transaction...")
-	signedReq := solprogram.SignedTransactionRequest{
-		TransactionID:     unsignedResp.TransactionID,
-		SignedTransaction: signedTx,
-	}
-
-	createResult, err := client.SubmitSignedTransaction(signedReq)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Envelope created successfully!\n")
-	fmt.Printf("   Signature: %s\n", createResult.Signature)
-	fmt.Printf("   Explorer: %s\n\n", createResult.ExplorerURL)
-
-	// ========================================
-	// STEP 2: Wait for confirmation
-	// ========================================
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║  STEP 2: WAIT FOR CONFIRMATION (2-3 seconds)              ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println("⏳ Waiting 3 seconds for transaction to be confirmed...")
-	time.Sleep(3 * time.Second)
-	fmt.Println("✅ Wait complete\n")
-
-	// ========================================
-	// STEP 3: Claim Envelope (Unsigned Transaction)
-	// ========================================
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║  STEP 3: CLAIM ENVELOPE (Unsigned Transaction Pattern)    ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-
-	// Get claimer's USDC token account
-	claimerTokenAccount, err := client.GetUSDCTokenAddress(User2PublicKey)
-	if err != nil {
-		fmt.Printf("❌ Error deriving token account: %v\n", err)
-		return
-	}
-
-	claimParams := solprogram.ClaimEnvelopeParams{
-		Owner:               User1PublicKey,
-		EnvelopeID:          envelopeID,
-		Claimer:             User2PublicKey,
-		ClaimerTokenAccount: claimerTokenAccount,
-	}
-
-	fmt.Printf("📋 Claim Configuration:\n")
-	fmt.Printf("   Envelope ID: %d\n", envelopeID)
 	fmt.Printf("   Claimer: %s\n", User2PublicKey.String())
-	fmt.Printf("   Amount per Claim: %.2f USDC (1/%d share)\n\n", float64(params.TotalAmount)/float64(params.TotalUsers)/1_000_000, params.TotalUsers)
-
-	// Generate unsigned claim transaction (backend)
-	fmt.Println("🔧 Backend: Generating unsigned claim transaction...")
-	unsignedClaimResp, err := client.GenerateUnsignedClaim(claimParams)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Unsigned claim transaction generated\n")
-	fmt.Printf("   Transaction ID: %s\n\n", unsignedClaimResp.TransactionID)
-
-	// Sign transaction (frontend - simulated with User2's key)
-	fmt.Println("🔐 Frontend: Signing transaction with User2's private key...")
-	signedClaimTx, err := signTransactionDemo(unsignedClaimResp.UnsignedTransaction, User2PrivateKey)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Transaction signed\n\n")
+	fmt.Printf("   Total Amount: %.2f USDC (%d users)\n", float64(params.TotalAmount)/1_000_000, params.TotalUsers)
+	fmt.Printf("   Expiry: %d seconds\n\n", params.ExpirySeconds)
 
-	// Submit signed claim transaction (backend)
-	fmt.Println("📤 Backend: Submitting signed claim transaction...")
-	signedClaimReq := solprogram.SignedTransactionRequest{
-		TransactionID:     unsignedClaimResp.TransactionID,
-		SignedTransaction: signedClaimTx,
+	// Signing is simulated with the test users' keys (FOR DEMO ONLY, wallets sign in production)
+	flow := solprogram.NewFlowOrchestrator(client, solprogram.KeySigner(User1PrivateKey, User2PrivateKey))
+	// The refund goes through the submission queue: at-risk refunds jump ahead, repeated failures alert
+	flow.Queue = solprogram.NewSubmissionQueue(client.SubmitQueued, alert.New(os.Getenv("ALERT_WEBHOOK_URL"), nil), solprogram.SubmissionQueueConfig{})
+	go flow.Queue.Run(ctx)
+	flow.Hooks = solprogram.FlowHooks{
+		BeforeStep: func(step string, attempt int) {
+			if attempt > 1 {
+				fmt.Printf("🔁 %s: attempt %d\n", step, attempt)
+				return
+			}
+			fmt.Printf("▶️  %s...\n", step)
+		},
+		AfterStep: func(result solprogram.FlowStepResult) {
+			if result.Err != nil {
+				fmt.Printf("❌ %s failed: %v\n\n", result.Step, result.Err)
+				return
+			}
+			fmt.Printf("✅ %s done in %s\n\n", result.Step, result.Elapsed.Round(time.Millisecond))
+		},
 	}
 
-	claimResult, err := client.SubmitSignedTransaction(signedClaimReq)
+	result, err := flow.Run(ctx, nil, flow.CompleteFlow(User1PublicKey, params, User2PublicKey)...)
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Envelope claimed successfully!\n")
-	fmt.Printf("   Signature: %s\n", claimResult.Signature)
-	fmt.Printf("   Explorer: %s\n\n", claimResult.ExplorerURL)
-
-	// ========================================
-	// STEP 4: Wait for envelope to expire
-	// ========================================
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║  STEP 4: WAIT FOR EXPIRY (60 seconds)                     ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println("⏳ Waiting 60 seconds for envelope to expire...")
-	fmt.Println("   (In production, you would check expiry time instead of fixed wait)")
-
-	// Progress indicator
-	for i := 1; i <= 60; i++ {
-		time.Sleep(1 * time.Second)
-		if i%10 == 0 {
-			fmt.Printf("   ⏱️  %d seconds elapsed...\n", i)
+		if result.State.EnvelopeID == 0 {
+			fmt.Println("\n💡 TIP: Run with runInitUserState=true first to initialize user state")
 		}
-	}
-	fmt.Println("✅ Envelope expired\n")
-
-	// ========================================
-	// STEP 5: Refund Envelope (Unsigned Transaction)
-	// ========================================
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║  STEP 5: REFUND ENVELOPE (Unsigned Transaction Pattern)   ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-
-	// Get owner's USDC token account
-	ownerTokenAccount, err := client.GetUSDCTokenAddress(User1PublicKey)
-	if err != nil {
-		fmt.Printf("❌ Error deriving token account: %v\n", err)
-		return
-	}
-
-	refundParams := solprogram.RefundParams{
-		Owner:             User1PublicKey,
-		EnvelopeID:        envelopeID,
-		OwnerTokenAccount: ownerTokenAccount,
-	}
-
-	fmt.Printf("📋 Refund Configuration:\n")
-	fmt.Printf("   Envelope ID: %d\n", envelopeID)
-	fmt.Printf("   Owner: %s\n", User1PublicKey.String())
-	fmt.Printf("   Refund to: %s\n\n", ownerTokenAccount.String())
-
-	// Generate unsigned refund transaction (backend)
-	fmt.Println("🔧 Backend: Generating unsigned refund transaction...")
-	unsignedRefundResp, err := client.GenerateUnsignedRefund(refundParams)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
 		return
 	}
-	fmt.Printf("✅ Unsigned refund transaction generated\n")
-	fmt.Printf("   Transaction ID: %s\n\n", unsignedRefundResp.TransactionID)
 
-	// Sign transaction (frontend - simulated with User1's key)
-	fmt.Println("🔐 Frontend: Signing transaction with User1's private key...")
-	signedRefundTx, err := signTransactionDemo(unsignedRefundResp.UnsignedTransaction, User1PrivateKey)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Transaction signed\n\n")
-
-	// Submit signed refund transaction (backend)
-	fmt.Println("📤 Backend: Submitting signed refund transaction...")
-	signedRefundReq := solprogram.SignedTransactionRequest{
-		TransactionID:     unsignedRefundResp.TransactionID,
-		SignedTransaction: signedRefundTx,
-	}
-
-	refundResult, err := client.SubmitSignedTransaction(signedRefundReq)
-	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		return
-	}
-	fmt.Printf("✅ Envelope refunded successfully!\n")
-	fmt.Printf("   Signature: %s\n", refundResult.Signature)
-	fmt.Printf("   Explorer: %s\n\n", refundResult.ExplorerURL)
-
-	// ========================================
-	// SUMMARY
-	// ========================================
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║  ✅ COMPLETE FLOW FINISHED SUCCESSFULLY!                   ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println("\n📊 Flow Summary:")
-	fmt.Printf("   1. ✅ Created GroupFixed Envelope #%d (3 USDC total, 3 users max, 60s expiry)\n", envelopeID)
-	fmt.Printf("   2. ✅ Waited 3 seconds for confirmation\n")
-	fmt.Printf("   3. ✅ Claimed by User2 (received 1 USDC, 1/3 share)\n")
-	fmt.Printf("   4. ✅ Waited 60 seconds for expiry\n")
-	fmt.Printf("   5. ✅ Refunded remaining 2 USDC (2/3 unclaimed) to User1\n")
+	fmt.Printf("\n📊 Envelope #%d, total time %s\n", result.State.EnvelopeID, result.Elapsed.Round(time.Second))
 	fmt.Println("\n🔗 Transaction Links:")
-	fmt.Printf("   Create:  %s\n", createResult.ExplorerURL)
-	fmt.Printf("   Claim:   %s\n", claimResult.ExplorerURL)
-	fmt.Printf("   Refund:  %s\n", refundResult.ExplorerURL)
+	for _, step := range slices.Sorted(maps.Keys(result.State.Results)) {
+		fmt.Printf("   %s: %s\n", step, client.Explorer().Tx(result.State.Results[step]))
+	}
 	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 }
 
@@ -1054,7 +1007,8 @@ func signTransactionDemo(unsignedTxBase64 string, privateKey solana.PrivateKey)
 		return "", fmt.Errorf("failed to unmarshal transaction: %w", err)
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+	// Partial: a sponsored claim already carries the sponsor's fee payer signature
+	_, err = tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
 		if privateKey.PublicKey().Equals(key) {
 			return &privateKey
 		}
@@ -1071,3 +1025,33 @@ func signTransactionDemo(unsignedTxBase64 string, privateKey solana.PrivateKey)
 
 	return base64.StdEncoding.EncodeToString(signedBytes), nil
 }
+
+// waitForConfirmation - Wait up to 30s for confirmation, printing each poll
+func waitForConfirmation(ctx context.Context, client *solprogram.USDCEnvelopeClient, signature string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := client.WaitForConfirmation(ctx, signature, solprogram.WaitOptions{
+		OnProgress: func(p solprogram.ConfirmationProgress) {
+			level := string(p.Level)
+			if level == "" {
+				level = "not seen yet"
+			}
+			fmt.Printf("   ⏳ #%d %s: %s\n", p.Attempt, p.Elapsed.Round(time.Second), level)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("   Slot: %d (%s after %d polls)\n", result.Slot, result.Level, result.Attempts)
+	return nil
+}
+
+// fundForkUsers - Airdrop SOL for fees to the test users on the fork (the local validator has a faucet)
+func fundForkUsers(ctx context.Context, client *rpc.Client) {
+	for _, user := range []solana.PublicKey{User1PublicKey, User2PublicKey, User3PublicKey} {
+		if _, err := client.RequestAirdrop(ctx, user, 2*solana.LAMPORTS_PER_SOL, rpc.CommitmentConfirmed); err != nil {
+			fmt.Printf("⚠️  Airdrop to %s failed: %v\n", user, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package dto - HTTP request/response shapes shared by every chain API.
+//
+// Chain packages alias the shared types (chainsol.SignedTransactionRequest = dto.SignedTransactionRequest)
+// or embed them and add their own fields (signature vs tx_hash, slot vs block_number), so common
+// fields are declared once and can't drift between chains.
+package dto
+
+import (
+	"blockchain/pricing"
+	"blockchain/txstatus"
+)
+
+// ErrorResponse - Standard error response
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// TransferRequest - Parties of a transfer; chains add Amount in their own unit
+type TransferRequest struct {
+	FromAddress string `json:"from_address" binding:"required" validate:"required"`
+	ToAddress   string `json:"to_address" binding:"required" validate:"required"`
+}
+
+// CreateTransactionResponse - Unsigned transaction handed to the client for signing
+type CreateTransactionResponse struct {
+	TransactionID       string       `json:"transaction_id"`
+	UnsignedTransaction string       `json:"unsigned_transaction"`    // Base64 (Solana) or hex (EVM) unless requested otherwise
+	Encoding            string       `json:"encoding"`                // base64 | base58 | hex
+	EstimatedFee        *pricing.Fee `json:"estimated_fee,omitempty"` // Network fee the signer will pay
+}
+
+// SignedTransactionRequest - Signed transaction sent back by the client
+type SignedTransactionRequest struct {
+	TransactionID     string `json:"transaction_id" binding:"required"`
+	SignedTransaction string `json:"signed_transaction" binding:"required"` // Base64 (Solana) or hex (EVM), or see Encoding
+	Encoding          string `json:"encoding,omitempty"`                    // base64 | base58 | hex ("" = detected)
+	Preflight         string `json:"preflight,omitempty"`                   // Solana only: simulate | skip | default
+}
+
+// TransactionResult - Result of submitting a signed transaction
+type TransactionResult struct {
+	TransactionID string          `json:"transaction_id"`
+	Success       bool            `json:"success"`
+	Status        txstatus.Status `json:"status"` // pending, confirmed, failed
+	Message       string          `json:"message"`
+	ExplorerURL   string          `json:"explorer_url,omitempty"`
+}
+
+// TransactionStatus - Common part of a transaction status lookup
+type TransactionStatus struct {
+	Status        txstatus.Status `json:"status"` // pending, confirmed, finalized, failed, not_found
+	Confirmations uint64          `json:"confirmations"`
+	Error         *string         `json:"error,omitempty"`
+	ExplorerURL   string          `json:"explorer_url"`
+}
+
+// Stuck transaction states (StuckDiagnosis.State)
+const (
+	StuckNeverBroadcast   = "never_broadcast"   // Created, no signed transaction was ever sent
+	StuckDropped          = "dropped"           // Sent, unknown to the node, still valid
+	StuckBlockhashExpired = "blockhash_expired" // Solana: sent or not, can no longer land
+	StuckNonceGap         = "nonce_gap"         // EVM: waits behind a missing lower nonce
+	StuckNonceUsed        = "nonce_used"        // EVM: nonce taken by another transaction, can no longer land
+	StuckPending          = "pending"           // In flight, may still land
+	StuckLanded           = "landed"            // Not stuck: confirmed or failed on chain
+)
+
+// Remediation actions (StuckDiagnosis.Actions, RemediationRequest.Action)
+const (
+	RemediationRebroadcast = "rebroadcast" // Send the stored signed transaction again
+	RemediationRegenerate  = "regenerate"  // New unsigned transaction (fresh blockhash / nonce) for the client to sign
+	RemediationCancel      = "cancel"      // EVM: unsigned 0-value self-transfer at the blocking nonce
+	RemediationReplace     = "replace"     // EVM: unsigned copy at the same nonce with a bumped gas price
+)
+
+// StuckDiagnosis - Why a transaction has not landed and what can be done about it
+type StuckDiagnosis struct {
+	TransactionID string          `json:"transaction_id"`
+	State         string          `json:"state"`
+	Status        txstatus.Status `json:"status,omitempty"`
+	Detail        string          `json:"detail"`
+	Actions       []string        `json:"actions"` // Remediations that apply to State
+}
+
+// RemediationRequest - Admin remediation of a stuck transaction
+type RemediationRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	Action        string `json:"action" binding:"required"`
+	Operator      string `json:"operator"` // Recorded in the audit trail
+}
+
+// RemediationResult - Outcome of a remediation; regenerate/cancel/replace return a new
+// unsigned transaction, signed and sent by the client like any other
+type RemediationResult struct {
+	TransactionID       string `json:"transaction_id"`
+	Action              string `json:"action"`
+	Message             string `json:"message"`
+	NewTransactionID    string `json:"new_transaction_id,omitempty"`
+	UnsignedTransaction string `json:"unsigned_transaction,omitempty"`
+}
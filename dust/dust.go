@@ -0,0 +1,214 @@
+// Package dust - Minimum amount per chain and token below which transfers and envelope shares are
+// refused: a claim of 0.000001 USDC or a transfer of 1 wei costs more in fees than it delivers.
+package dust
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	"blockchain/chain"
+	"blockchain/pricing"
+)
+
+// BelowMinimum - Service error code for amounts under the dust threshold
+const BelowMinimum = "AMOUNT_BELOW_DUST_THRESHOLD"
+
+// USDC - Token symbol of the USDC envelope program (6 decimals)
+const USDC = "USDC"
+
+// ErrBelowMinimum - Amount under the dust threshold of its chain and token
+var ErrBelowMinimum = errors.New("amount below dust threshold")
+
+// Threshold - Minimum amount of one token on one chain
+type Threshold struct {
+	Chain    chain.ChainID `json:"chain"`
+	Token    string        `json:"token"` // Native symbol (SOL, BNB) or token symbol (USDC)
+	Decimals int           `json:"decimals"`
+	Min      string        `json:"min"`            // In token units, e.g. "0.01"
+	MinUnits string        `json:"min_base_units"` // Lamports, wei, USDC base units
+	min      *big.Int
+}
+
+// Error - Amount refused by the policy
+type Error struct {
+	Code      string    `json:"code"`
+	Subject   string    `json:"subject"` // What the amount is, e.g. "amount", "per-user share"
+	Amount    string    `json:"amount"`  // In token units
+	Threshold Threshold `json:"threshold"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s %s %s is below the minimum %s %s on %s",
+		e.Code, e.Subject, e.Amount, e.Threshold.Token, e.Threshold.Min, e.Threshold.Token, e.Threshold.Chain)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrBelowMinimum
+}
+
+// AsError - Error in err's chain, if any
+func AsError(err error) (*Error, bool) {
+	var dustErr *Error
+	ok := errors.As(err, &dustErr)
+	return dustErr, ok
+}
+
+// Policy - Thresholds by chain and token; tokens without one aren't checked (nil policy: no check)
+type Policy struct {
+	thresholds map[string]Threshold
+}
+
+func key(c chain.ChainID, token string) string {
+	return string(c) + ":" + strings.ToUpper(token)
+}
+
+// Defaults - Documented default thresholds, roughly 20x the network fee of a claim or transfer:
+//   - solana SOL:  0.0001 SOL
+//   - solana USDC: 0.01 USDC
+//   - bsc BNB:     0.0001 BNB
+var Defaults = map[string]string{
+	"solana:SOL":  "0.0001",
+	"solana:USDC": "0.01",
+	"bsc:BNB":     "0.0001",
+}
+
+// decimals - Decimals of the tokens thresholds can be set for
+func decimals(c chain.ChainID, token string) (int, bool) {
+	if strings.EqualFold(token, c.Symbol()) {
+		return c.Decimals(), true
+	}
+	if c == chain.Solana && strings.EqualFold(token, USDC) {
+		return 6, true
+	}
+	return 0, false
+}
+
+// Default - Policy with the Defaults thresholds
+func Default() *Policy {
+	p, err := ParsePolicy("")
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// ParsePolicy - Defaults overridden by DUST_MINIMUMS, e.g. "solana:USDC=0.05,bsc:BNB=0.001"
+// (amounts in token units, 0 removes a threshold); "off" disables the policy (nil)
+func ParsePolicy(s string) (*Policy, error) {
+	if strings.EqualFold(strings.TrimSpace(s), "off") {
+		return nil, nil
+	}
+	p := &Policy{thresholds: make(map[string]Threshold)}
+	entries := make([]string, 0, len(Defaults))
+	for k, v := range Defaults {
+		entries = append(entries, k+"="+v)
+	}
+	sort.Strings(entries)
+	entries = append(entries, strings.Split(s, ",")...)
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		chainName, token, ok2 := strings.Cut(strings.TrimSpace(name), ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid dust minimum %q (want chain:token=amount)", entry)
+		}
+		c, err := chain.ParseChainID(chainName)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.ToUpper(strings.TrimSpace(token))
+		d, ok := decimals(c, token)
+		if !ok {
+			return nil, fmt.Errorf("unknown token %s on %s", token, c)
+		}
+		min, err := parseUnits(strings.TrimSpace(value), d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dust minimum for %s:%s: %w", c, token, err)
+		}
+		if min.Sign() == 0 {
+			delete(p.thresholds, key(c, token))
+			continue
+		}
+		p.thresholds[key(c, token)] = Threshold{
+			Chain:    c,
+			Token:    token,
+			Decimals: d,
+			Min:      pricing.FormatUnits(min, d),
+			MinUnits: min.String(),
+			min:      min,
+		}
+	}
+	return p, nil
+}
+
+// Check - *Error when amount (base units) of token on c is below its threshold
+func (p *Policy) Check(c chain.ChainID, token, subject string, amount *big.Int) error {
+	if p == nil {
+		return nil
+	}
+	t, ok := p.thresholds[key(c, token)]
+	if !ok || amount.Cmp(t.min) >= 0 {
+		return nil
+	}
+	return &Error{Code: BelowMinimum, Subject: subject, Amount: pricing.FormatUnits(amount, t.Decimals), Threshold: t}
+}
+
+// CheckUint64 - Check for amounts that fit a uint64 (lamports, USDC base units)
+func (p *Policy) CheckUint64(c chain.ChainID, token, subject string, amount uint64) error {
+	return p.Check(c, token, subject, new(big.Int).SetUint64(amount))
+}
+
+// Thresholds - Every threshold, by chain then token
+func (p *Policy) Thresholds() []Threshold {
+	if p == nil {
+		return []Threshold{}
+	}
+	result := make([]Threshold, 0, len(p.thresholds))
+	for _, t := range p.thresholds {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return key(result[i].Chain, result[i].Token) < key(result[j].Chain, result[j].Token)
+	})
+	return result
+}
+
+// Limits - Body of the limits endpoint
+type Limits struct {
+	Dust []Threshold `json:"dust"` // Minimum transfer amount / per-user envelope share
+}
+
+// HandleLimits - GET /limits: documented dust thresholds
+func (p *Policy) HandleLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Limits{Dust: p.Thresholds()})
+}
+
+// parseUnits - Decimal token amount as base units
+func parseUnits(s string, decimals int) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || strings.HasPrefix(s, "-") {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > decimals {
+		return nil, fmt.Errorf("%q has more than %d decimals", s, decimals)
+	}
+	n, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", decimals-len(frac)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return n, nil
+}
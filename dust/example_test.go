@@ -0,0 +1,44 @@
+package dust_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"blockchain/chain"
+	"blockchain/dust"
+)
+
+func ExamplePolicy_CheckUint64() {
+	policy := dust.Default()
+
+	// 100 USDC split across 20,000 claimers: 0.005 USDC each
+	err := policy.CheckUint64(chain.Solana, dust.USDC, "per-user share", 100_000_000/20_000)
+	fmt.Println(errors.Is(err, dust.ErrBelowMinimum))
+	if dustErr, ok := dust.AsError(err); ok {
+		fmt.Println(dustErr.Code, dustErr.Amount, "<", dustErr.Threshold.Min)
+	}
+
+	fmt.Println(policy.CheckUint64(chain.Solana, dust.USDC, "per-user share", 100_000_000/2_000))
+	// Output:
+	// true
+	// AMOUNT_BELOW_DUST_THRESHOLD 0.005 < 0.01
+	// <nil>
+}
+
+func ExampleParsePolicy() {
+	// Raise the BNB minimum, drop the SOL one
+	policy, err := dust.ParsePolicy("bsc:BNB=0.001,solana:SOL=0")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range policy.Thresholds() {
+		fmt.Println(t.Chain, t.Token, t.Min, t.MinUnits)
+	}
+	fmt.Println(policy.Check(chain.BSC, "BNB", "amount", big.NewInt(1)))
+	// Output:
+	// bsc BNB 0.001 1000000000000000
+	// solana USDC 0.01 10000
+	// AMOUNT_BELOW_DUST_THRESHOLD: amount 0.000000000000000001 BNB is below the minimum 0.001 BNB on bsc
+}
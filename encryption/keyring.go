@@ -0,0 +1,128 @@
+// Package encryption - AES-256-GCM encryption at rest with key IDs for rotation.
+//
+// Sealed values look like "v1:<key id>:<base64(nonce|ciphertext)>". New values are sealed with
+// the active key; any key still in the ring can open old ones, so rotating is: add the new key,
+// make it active, re-seal stored values (see Keyring.Reseal), then drop the old key.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize - AES-256 key length
+const KeySize = 32
+
+const sealedVersion = "v1"
+
+// ErrUnknownKey - Sealed with a key that is no longer in the ring
+var ErrUnknownKey = errors.New("encryption key not in keyring")
+
+// Keyring - Data keys by ID, one of them active for sealing
+type Keyring struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// NewKeyring - Ring of 32-byte keys; active must be one of them
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q not in keyring", active)
+	}
+	k := &Keyring{active: active, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// ActiveKeyID - Key new values are sealed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Seal - Encrypt with the active key; aad (e.g. the row reference) must be passed again to Open
+func (k *Keyring) Seal(plaintext, aad []byte) (string, error) {
+	aead := k.aeads[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, aad)
+	return sealedVersion + ":" + k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open - Decrypt a value sealed with any key in the ring
+func (k *Keyring) Open(sealed string, aad []byte) ([]byte, error) {
+	keyID, data, err := parseSealed(sealed)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// KeyID - Key a value was sealed with
+func KeyID(sealed string) (string, error) {
+	keyID, _, err := parseSealed(sealed)
+	return keyID, err
+}
+
+// NeedsReseal - Sealed with a key other than the active one
+func (k *Keyring) NeedsReseal(sealed string) bool {
+	keyID, err := KeyID(sealed)
+	return err == nil && keyID != k.active
+}
+
+// Reseal - Re-encrypt with the active key (no-op when already active)
+func (k *Keyring) Reseal(sealed string, aad []byte) (string, error) {
+	if !k.NeedsReseal(sealed) {
+		return sealed, nil
+	}
+	plaintext, err := k.Open(sealed, aad)
+	if err != nil {
+		return "", err
+	}
+	return k.Seal(plaintext, aad)
+}
+
+func parseSealed(sealed string) (string, []byte, error) {
+	parts := strings.SplitN(sealed, ":", 3)
+	if len(parts) != 3 || parts[0] != sealedVersion {
+		return "", nil, fmt.Errorf("not a sealed value")
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid sealed value: %w", err)
+	}
+	return parts[1], data, nil
+}
@@ -0,0 +1,138 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySource - Where data keys come from
+type KeySource interface {
+	Keyring(ctx context.Context) (*Keyring, error)
+}
+
+var (
+	_ KeySource = EnvKeySource{}
+	_ KeySource = FileKeySource{}
+	_ KeySource = KMSKeySource{}
+)
+
+// Environment variables read by KeySourceFromEnv
+const (
+	EnvKeys     = "ENCRYPTION_KEYS"
+	EnvKeystore = "ENCRYPTION_KEYSTORE"
+)
+
+// KeySourceFromEnv - EnvKeySource when ENCRYPTION_KEYS is set, FileKeySource when ENCRYPTION_KEYSTORE
+// names a keystore file; nil when neither is set
+func KeySourceFromEnv() (KeySource, error) {
+	keys, keystore := os.Getenv(EnvKeys), os.Getenv(EnvKeystore)
+	switch {
+	case keys != "" && keystore != "":
+		return nil, fmt.Errorf("set either %s or %s, not both", EnvKeys, EnvKeystore)
+	case keys != "":
+		return EnvKeySource{Var: EnvKeys}, nil
+	case keystore != "":
+		return FileKeySource{Path: keystore}, nil
+	}
+	return nil, nil
+}
+
+// ParseKeys - "id2:base64key,id1:base64key", first entry active (e.g. ENCRYPTION_KEYS)
+func ParseKeys(spec string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	active := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q (want id:base64)", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if active == "" {
+			active = id
+		}
+		keys[id] = key
+	}
+	if active == "" {
+		return nil, fmt.Errorf("no encryption keys")
+	}
+	return NewKeyring(active, keys)
+}
+
+// EnvKeySource - Keys from an environment variable in ParseKeys format
+type EnvKeySource struct {
+	Var string
+}
+
+// Keyring - Implements KeySource
+func (s EnvKeySource) Keyring(context.Context) (*Keyring, error) {
+	return ParseKeys(os.Getenv(s.Var))
+}
+
+// keystoreFile - {"active": "k2", "keys": {"k2": "<base64>", "k1": "<base64>"}}
+type keystoreFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// FileKeySource - Keys from a keystore file (keep it 0600, outside the repo)
+type FileKeySource struct {
+	Path string
+}
+
+// Keyring - Implements KeySource
+func (s FileKeySource) Keyring(context.Context) (*Keyring, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keystore: %w", err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(file.Active, keys)
+}
+
+// KMSDecrypter - Unwraps a data key with the KMS (AWS KMS Decrypt, GCP KMS, Vault transit, ...)
+type KMSDecrypter func(ctx context.Context, wrapped []byte) ([]byte, error)
+
+// KMSKeySource - Data keys stored wrapped by a KMS and unwrapped at startup
+type KMSKeySource struct {
+	Active  string
+	Wrapped map[string][]byte // Key ID -> KMS ciphertext of the data key
+	Decrypt KMSDecrypter
+}
+
+// Keyring - Implements KeySource
+func (s KMSKeySource) Keyring(ctx context.Context) (*Keyring, error) {
+	if s.Decrypt == nil {
+		return nil, fmt.Errorf("KMS decrypter not configured")
+	}
+	keys := make(map[string][]byte, len(s.Wrapped))
+	for id, wrapped := range s.Wrapped {
+		key, err := s.Decrypt(ctx, wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(s.Active, keys)
+}
@@ -0,0 +1,166 @@
+// Package events - Versioned webhook events (envelope lifecycle, transfers, transaction status)
+// with a protobuf definition (events.proto) and a JSON schema (events.schema.json) for
+// downstream consumers in other languages
+package events
+
+import (
+	"context"
+	_ "embed"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blockchain/chain"
+	"blockchain/metrics"
+	"blockchain/txstatus"
+)
+
+// Schema - Protobuf package of the events, sent as X-Event-Schema
+const Schema = "blockchain.events.v1"
+
+// SchemaVersion - Major version of Schema; fields are only added within a version
+const SchemaVersion = 1
+
+// Event types
+const (
+	TypeEnvelopeCreated   = "envelope.created"
+	TypeEnvelopeClaimed   = "envelope.claimed"
+	TypeEnvelopeRefunded  = "envelope.refunded"
+	TypeTransferSent      = "transfer.sent"
+	TypeTransactionStatus = "transaction.status"
+)
+
+// Proto - events.proto, for consumers generating their own types
+//
+//go:embed events.proto
+var Proto []byte
+
+// JSONSchema - events.schema.json, the JSON content type of Event
+//
+//go:embed events.schema.json
+var JSONSchema []byte
+
+var (
+	published = metrics.Counter("events_published")
+	failed    = metrics.Counter("events_failed")
+)
+
+// Event - Webhook body (blockchain.events.v1.Event); exactly one payload is set.
+// JSON follows the proto3 JSON mapping: 64-bit integers are strings.
+type Event struct {
+	ID                string             `json:"id"`
+	Type              string             `json:"type"`
+	SchemaVersion     uint32             `json:"schema_version"`
+	Time              time.Time          `json:"time"`
+	Chain             chain.ChainID      `json:"chain"`
+	Envelope          *EnvelopeLifecycle `json:"envelope,omitempty"`
+	Transfer          *Transfer          `json:"transfer,omitempty"`
+	TransactionStatus *TransactionStatus `json:"transaction_status,omitempty"`
+}
+
+// EnvelopeLifecycle - Confirmed envelope program transaction
+type EnvelopeLifecycle struct {
+	Action        string `json:"action"`
+	EnvelopeID    uint64 `json:"envelope_id,string"`
+	Owner         string `json:"owner"`
+	Signer        string `json:"signer,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Signature     string `json:"signature,omitempty"`
+}
+
+// Transfer - Native transfer submitted through the transfer API
+type Transfer struct {
+	TransactionID string `json:"transaction_id,omitempty"`
+	Signature     string `json:"signature"`
+	FromAddress   string `json:"from_address"`
+	ToAddress     string `json:"to_address"`
+	Amount        string `json:"amount"` // Base units, decimal
+	Asset         string `json:"asset"`
+}
+
+// TransactionStatus - Status of a submitted transaction changed
+type TransactionStatus struct {
+	TransactionID string          `json:"transaction_id,omitempty"`
+	Signature     string          `json:"signature,omitempty"`
+	Status        txstatus.Status `json:"status"`
+	Error         string          `json:"error,omitempty"`
+	Slot          uint64          `json:"slot,omitempty,string"`
+	ExplorerURL   string          `json:"explorer_url,omitempty"`
+}
+
+func newEvent(eventType string, c chain.ChainID) Event {
+	return Event{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		Time:          time.Now().UTC(),
+		Chain:         c,
+	}
+}
+
+// NewEnvelopeEvent - envelope.created/claimed/refunded for a confirmed envelope transaction
+func NewEnvelopeEvent(tx chain.EnvelopeTransaction) Event {
+	eventType := TypeEnvelopeCreated
+	switch tx.Action {
+	case chain.EnvelopeActionClaim:
+		eventType = TypeEnvelopeClaimed
+	case chain.EnvelopeActionRefund:
+		eventType = TypeEnvelopeRefunded
+	}
+	event := newEvent(eventType, tx.Chain)
+	event.Envelope = &EnvelopeLifecycle{
+		Action:        tx.Action,
+		EnvelopeID:    tx.EnvelopeID,
+		Owner:         tx.Owner,
+		Signer:        tx.Signer,
+		TransactionID: tx.TransactionID,
+		Signature:     tx.Signature,
+	}
+	return event
+}
+
+// NewTransferEvent - transfer.sent
+func NewTransferEvent(c chain.ChainID, transfer Transfer) Event {
+	event := newEvent(TypeTransferSent, c)
+	event.Transfer = &transfer
+	return event
+}
+
+// NewTransactionStatusEvent - transaction.status
+func NewTransactionStatusEvent(c chain.ChainID, status TransactionStatus) Event {
+	event := newEvent(TypeTransactionStatus, c)
+	event.TransactionStatus = &status
+	return event
+}
+
+// Emitter - Event sink
+type Emitter interface {
+	Emit(ctx context.Context, e Event) error
+}
+
+// Publish - Emit in the background so webhooks never slow down the request path (nil emitter: no-op)
+func Publish(emitter Emitter, e Event) {
+	if emitter == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := emitter.Emit(ctx, e); err != nil {
+			failed.Add(1)
+			log.Printf("failed to emit %s event %s: %v", e.Type, e.ID, err)
+			return
+		}
+		published.Add(1)
+	}()
+}
+
+// EnvelopeHook - Post-confirmation hook emitting envelope lifecycle events
+// (e.g. solprogram.USDCEnvelopeClient.OnConfirmed)
+func EnvelopeHook(emitter Emitter) func(ctx context.Context, tx chain.EnvelopeTransaction) error {
+	return func(_ context.Context, tx chain.EnvelopeTransaction) error {
+		Publish(emitter, NewEnvelopeEvent(tx))
+		return nil
+	}
+}
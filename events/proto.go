@@ -0,0 +1,100 @@
+package events
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers from events.proto
+const (
+	fieldEventID                = 1
+	fieldEventType              = 2
+	fieldEventSchemaVersion     = 3
+	fieldEventTime              = 4
+	fieldEventChain             = 5
+	fieldEventEnvelope          = 10
+	fieldEventTransfer          = 11
+	fieldEventTransactionStatus = 12
+)
+
+// MarshalProto - Binary protobuf encoding of blockchain.events.v1.Event.
+// Zero values are omitted, as proto3 does.
+func (e *Event) MarshalProto() []byte {
+	var b []byte
+	b = appendString(b, fieldEventID, e.ID)
+	b = appendString(b, fieldEventType, e.Type)
+	b = appendUint(b, fieldEventSchemaVersion, uint64(e.SchemaVersion))
+	if !e.Time.IsZero() {
+		// google.protobuf.Timestamp: seconds = 1, nanos = 2
+		var ts []byte
+		ts = appendUint(ts, 1, uint64(e.Time.Unix()))
+		ts = appendUint(ts, 2, uint64(e.Time.Nanosecond()))
+		b = appendMessage(b, fieldEventTime, ts)
+	}
+	b = appendString(b, fieldEventChain, string(e.Chain))
+	if e.Envelope != nil {
+		b = appendMessage(b, fieldEventEnvelope, e.Envelope.marshalProto())
+	}
+	if e.Transfer != nil {
+		b = appendMessage(b, fieldEventTransfer, e.Transfer.marshalProto())
+	}
+	if e.TransactionStatus != nil {
+		b = appendMessage(b, fieldEventTransactionStatus, e.TransactionStatus.marshalProto())
+	}
+	return b
+}
+
+func (l *EnvelopeLifecycle) marshalProto() []byte {
+	var b []byte
+	b = appendString(b, 1, l.Action)
+	b = appendUint(b, 2, l.EnvelopeID)
+	b = appendString(b, 3, l.Owner)
+	b = appendString(b, 4, l.Signer)
+	b = appendString(b, 5, l.TransactionID)
+	b = appendString(b, 6, l.Signature)
+	return b
+}
+
+func (t *Transfer) marshalProto() []byte {
+	var b []byte
+	b = appendString(b, 1, t.TransactionID)
+	b = appendString(b, 2, t.Signature)
+	b = appendString(b, 3, t.FromAddress)
+	b = appendString(b, 4, t.ToAddress)
+	b = appendString(b, 5, t.Amount)
+	b = appendString(b, 6, t.Asset)
+	return b
+}
+
+func (s *TransactionStatus) marshalProto() []byte {
+	var b []byte
+	b = appendString(b, 1, s.TransactionID)
+	b = appendString(b, 2, s.Signature)
+	b = appendString(b, 3, string(s.Status))
+	b = appendString(b, 4, s.Error)
+	b = appendUint(b, 5, s.Slot)
+	b = appendString(b, 6, s.ExplorerURL)
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendUint - uint32/uint64/int64 fields (int64 as two's complement, which is how int64 varints are encoded)
+func appendUint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendMessage - Embedded messages are always written when set, even if empty
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
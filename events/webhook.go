@@ -0,0 +1,127 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook content types
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var (
+	_ Emitter = (*WebhookEmitter)(nil)
+	_ Emitter = Multi(nil)
+)
+
+// ParseContentType - Content type from EVENT_WEBHOOK_FORMAT ("" | "json" | "protobuf")
+func ParseContentType(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		return ContentTypeJSON, nil
+	case "protobuf", "proto":
+		return ContentTypeProtobuf, nil
+	default:
+		return "", fmt.Errorf("unknown event webhook format %q (json | protobuf)", format)
+	}
+}
+
+// WebhookEmitter - POST events as JSON or binary protobuf
+type WebhookEmitter struct {
+	URL         string
+	ContentType string // ContentTypeJSON (default) or ContentTypeProtobuf
+	Client      *http.Client
+}
+
+// Emit - Implements Emitter
+func (w *WebhookEmitter) Emit(ctx context.Context, e Event) error {
+	body, contentType, err := w.encode(e)
+	if err != nil {
+		return err
+	}
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Event-Schema", Schema)
+	req.Header.Set("X-Event-Type", e.Type)
+	req.Header.Set("X-Event-Id", e.ID)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *WebhookEmitter) encode(e Event) ([]byte, string, error) {
+	switch w.ContentType {
+	case "", ContentTypeJSON:
+		body, err := json.Marshal(e)
+		return body, ContentTypeJSON, err
+	case ContentTypeProtobuf:
+		// messageType lets generic consumers pick the decoder
+		return e.MarshalProto(), ContentTypeProtobuf + "; messageType=" + Schema + ".Event", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported event content type %q", w.ContentType)
+	}
+}
+
+// Multi - Fan out to several emitters
+type Multi []Emitter
+
+// Emit - Implements Emitter
+func (m Multi) Emit(ctx context.Context, e Event) error {
+	var errs []error
+	for _, emitter := range m {
+		if err := emitter.Emit(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// New - Webhook emitter for EVENT_WEBHOOK_URL / EVENT_WEBHOOK_FORMAT, nil when url is empty
+func New(url, format string) (Emitter, error) {
+	if url == "" {
+		return nil, nil
+	}
+	contentType, err := ParseContentType(format)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookEmitter{URL: url, ContentType: contentType}, nil
+}
+
+// HandleSchema - Serve events.proto (?format=proto) or the JSON schema (default)
+func HandleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("X-Event-Schema", Schema)
+	switch r.URL.Query().Get("format") {
+	case "proto", "protobuf":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(Proto)
+	default:
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(JSONSchema)
+	}
+}
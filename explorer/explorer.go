@@ -0,0 +1,109 @@
+// Package explorer - Block explorer links for transactions, addresses, token accounts and programs.
+package explorer
+
+import (
+	"fmt"
+	"strings"
+
+	"blockchain/chain"
+)
+
+// Provider - Block explorer
+type Provider string
+
+const (
+	SolanaExplorer Provider = "solana_explorer" // explorer.solana.com (Solana default)
+	Solscan        Provider = "solscan"         // solscan.io
+	BscScan        Provider = "bscscan"         // bscscan.com (BSC default)
+)
+
+// ParseProvider - Parse provider name ("" = chain default)
+func ParseProvider(s string) (Provider, error) {
+	switch p := Provider(strings.ToLower(strings.TrimSpace(s))); p {
+	case "", SolanaExplorer, Solscan, BscScan:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown explorer provider: %q", s)
+}
+
+// Explorer - Link builder for one chain/network/provider
+type Explorer struct {
+	Chain    chain.ChainID
+	Network  chain.Network
+	Provider Provider
+}
+
+// New - Explorer for chain and network; empty or foreign provider falls back to the chain default
+func New(c chain.ChainID, network chain.Network, provider Provider) Explorer {
+	e := Explorer{Chain: c, Network: network, Provider: provider}
+	switch {
+	case c == chain.BSC:
+		e.Provider = BscScan
+	case provider != Solscan:
+		e.Provider = SolanaExplorer
+	}
+	// Solscan has no localnet view
+	if e.Provider == Solscan && network == chain.Localnet {
+		e.Provider = SolanaExplorer
+	}
+	return e
+}
+
+// Tx - Transaction link
+func (e Explorer) Tx(id string) string {
+	return e.link("tx", id)
+}
+
+// Address - Wallet / generic account link (PDAs included)
+func (e Explorer) Address(address string) string {
+	return e.link("address", address)
+}
+
+// TokenAccount - Token account link (shows balance and owner)
+func (e Explorer) TokenAccount(address string) string {
+	return e.link("address", address)
+}
+
+// Token - Token mint / contract link
+func (e Explorer) Token(mint string) string {
+	return e.link("token", mint)
+}
+
+// Program - Program / contract link
+func (e Explorer) Program(address string) string {
+	return e.link("address", address)
+}
+
+func (e Explorer) link(kind, id string) string {
+	switch e.Provider {
+	case BscScan:
+		base := "https://bscscan.com/"
+		if e.Network == chain.Testnet {
+			base = "https://testnet.bscscan.com/"
+		}
+		return base + kind + "/" + id
+
+	case Solscan:
+		if kind == "address" {
+			kind = "account"
+		}
+		return "https://solscan.io/" + kind + "/" + id + e.solanaCluster()
+
+	default:
+		if kind == "token" {
+			kind = "address" // explorer.solana.com has no separate token page
+		}
+		return "https://explorer.solana.com/" + kind + "/" + id + e.solanaCluster()
+	}
+}
+
+func (e Explorer) solanaCluster() string {
+	switch e.Network {
+	case chain.Devnet, chain.Testnet:
+		return "?cluster=" + e.Network.String()
+	case chain.Localnet:
+		return "?cluster=custom"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,115 @@
+// Package health - Readiness probe aggregating dependency checks (chains, database, ...).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout - Per-check timeout when the probe has none
+const DefaultTimeout = 3 * time.Second
+
+// Check - Dependency probe; nil error = ready
+type Check func(ctx context.Context) error
+
+// Result - Outcome of one check
+type Result struct {
+	Status    string `json:"status"` // "ok" or "fail"
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report - Response of GET /readyz
+type Report struct {
+	Status string            `json:"status"` // "ready" or "not_ready"
+	Checks map[string]Result `json:"checks"`
+}
+
+// Probe - Named set of readiness checks, run in parallel
+type Probe struct {
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// NewProbe - Empty probe (ready until a check is added)
+func NewProbe() *Probe {
+	return &Probe{Timeout: DefaultTimeout, checks: make(map[string]Check)}
+}
+
+// Add - Register or replace a named check
+func (p *Probe) Add(name string, check Check) *Probe {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks[name] = check
+	return p
+}
+
+// AddFunc - Register a context-less check such as chain.Chain.HealthCheck
+func (p *Probe) AddFunc(name string, check func() error) *Probe {
+	return p.Add(name, func(context.Context) error { return check() })
+}
+
+// Run - Run all checks; ready only when every check passes
+func (p *Probe) Run(ctx context.Context) Report {
+	p.mu.RLock()
+	names := make([]string, 0, len(p.checks))
+	for name := range p.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = p.checks[name]
+	}
+	p.mu.RUnlock()
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			results[i] = Result{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Status = "fail"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ready", Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != "ok" {
+			report.Status = "not_ready"
+		}
+	}
+	return report
+}
+
+// Handler - GET /readyz (200 when ready, 503 otherwise)
+func (p *Probe) Handler(w http.ResponseWriter, r *http.Request) {
+	report := p.Run(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"expvar"
+	"sync"
+)
+
+// Metrics are published through expvar, so any binary using http.DefaultServeMux
+// exposes them at /debug/vars without extra wiring.
+
+var mu sync.Mutex
+
+// Counter - Get or create a monotonically increasing counter
+func Counter(name string) *expvar.Int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Int); ok {
+		return v
+	}
+	return expvar.NewInt(name)
+}
+
+// Gauge - Get or create a gauge (use Set to update the current value)
+func Gauge(name string) *expvar.Int {
+	return Counter(name)
+}
+
+// CanaryResult - Record outcome of a canary run for a chain
+func CanaryResult(chain string, success bool, latencyMs int64) {
+	Counter("canary_runs_" + chain).Add(1)
+	if !success {
+		Counter("canary_failures_" + chain).Add(1)
+	}
+	Gauge("canary_last_latency_ms_" + chain).Set(latencyMs)
+	if success {
+		Gauge("canary_last_success_" + chain).Set(1)
+	} else {
+		Gauge("canary_last_success_" + chain).Set(0)
+	}
+}
+
+// PreflightResult - Record a submission sent with the given preflight mode, for failure-rate comparison
+func PreflightResult(mode string, failed bool) {
+	Counter("preflight_submissions_" + mode).Add(1)
+	if failed {
+		Counter("preflight_failures_" + mode).Add(1)
+	}
+}
+
+// Func - Publish a value computed on every read (e.g. pool stats); first registration wins
+func Func(name string, f func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, expvar.Func(f))
+}
@@ -0,0 +1,244 @@
+package offline
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/pricing"
+	"blockchain/solprogram"
+)
+
+var (
+	computeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+	ataProgramID           = solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+	token2022ProgramID     = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EHFLC1PHnBqCXEpPxuEb")
+	memoProgramID          = solana.MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+
+	solEnvelopeProgramID  = solana.MustPublicKeyFromBase58(solprogram.SOLProgramID)
+	usdcEnvelopeProgramID = solana.MustPublicKeyFromBase58(solprogram.USDCProgramID)
+)
+
+var programNames = map[solana.PublicKey]string{
+	solprogram.SystemProgramID: "System",
+	solprogram.TokenProgramID:  "SPL Token",
+	token2022ProgramID:         "SPL Token-2022",
+	ataProgramID:               "Associated Token Account",
+	computeBudgetProgramID:     "Compute Budget",
+	memoProgramID:              "Memo",
+	solEnvelopeProgramID:       "SOL Envelope",
+	usdcEnvelopeProgramID:      "USDC Envelope",
+}
+
+var envelopeInstructions = map[string]string{
+	string(solprogram.DiscriminatorInitUserState): "init_user_state",
+	string(solprogram.DiscriminatorCreate):        "create",
+	string(solprogram.DiscriminatorClaim):         "claim",
+	string(solprogram.DiscriminatorRefund):        "refund",
+	string(solprogram.DiscriminatorPartialRefund): "partial_refund",
+	string(solprogram.DiscriminatorCancel):        "cancel",
+	string(solprogram.DiscriminatorClose):         "close",
+}
+
+// Describe - Human-readable breakdown of what signing the payload authorizes
+func (p *Payload) Describe() []string {
+	if p.EVM != nil {
+		return describeEVM(p)
+	}
+	return describeSolana(p.Solana)
+}
+
+func describeSolana(tx *solana.Transaction) []string {
+	msg := tx.Message
+	keys := msg.AccountKeys
+	lines := []string{
+		fmt.Sprintf("Chain:            %s", chain.Solana),
+		fmt.Sprintf("Version:          %s", messageVersion(msg)),
+		fmt.Sprintf("Recent blockhash: %s", msg.RecentBlockhash),
+	}
+	if len(keys) > 0 {
+		lines = append(lines, fmt.Sprintf("Fee payer:        %s", keys[0]))
+	}
+	lines = append(lines, "Required signers:")
+	for i := 0; i < int(msg.Header.NumRequiredSignatures) && i < len(keys); i++ {
+		state := "missing"
+		if i < len(tx.Signatures) && !tx.Signatures[i].IsZero() {
+			state = "signed"
+		}
+		lines = append(lines, fmt.Sprintf("  %d. %s (%s)", i+1, keys[i], state))
+	}
+
+	lines = append(lines, fmt.Sprintf("Instructions (%d):", len(msg.Instructions)))
+	for i, inst := range msg.Instructions {
+		account := func(n int) string {
+			if n >= len(inst.Accounts) || int(inst.Accounts[n]) >= len(keys) {
+				return "?"
+			}
+			return keys[inst.Accounts[n]].String()
+		}
+		programID := solana.PublicKey{}
+		if int(inst.ProgramIDIndex) < len(keys) {
+			programID = keys[inst.ProgramIDIndex]
+		}
+		name, known := programNames[programID]
+		if !known {
+			name = "Unknown program " + programID.String()
+		}
+		var signers []string
+		for _, idx := range inst.Accounts {
+			if int(idx) < int(msg.Header.NumRequiredSignatures) && int(idx) < len(keys) {
+				signers = append(signers, keys[idx].String())
+			}
+		}
+		lines = append(lines, fmt.Sprintf("  #%d %s: %s", i+1, name, describeInstruction(programID, inst.Data, account, strings.Join(signers, ", "))))
+	}
+	if msg.IsVersioned() && msg.NumLookups() > 0 {
+		lines = append(lines, "⚠️  Uses address lookup tables: some accounts are not shown")
+	}
+	return lines
+}
+
+func describeInstruction(programID solana.PublicKey, data []byte, account func(int) string, signers string) string {
+	switch programID {
+	case solprogram.SystemProgramID:
+		if len(data) >= 12 && binary.LittleEndian.Uint32(data) == 2 {
+			lamports := binary.LittleEndian.Uint64(data[4:])
+			return fmt.Sprintf("transfer %s SOL from %s to %s", formatUnits(lamports, 9), account(0), account(1))
+		}
+
+	case solprogram.TokenProgramID, token2022ProgramID:
+		if len(data) >= 9 && data[0] == 3 {
+			return fmt.Sprintf("transfer %d base units from %s to %s (authority %s)",
+				binary.LittleEndian.Uint64(data[1:]), account(0), account(1), account(2))
+		}
+		if len(data) >= 10 && data[0] == 12 {
+			return fmt.Sprintf("transfer %s of mint %s from %s to %s (authority %s)",
+				formatUnits(binary.LittleEndian.Uint64(data[1:]), int(data[9])), account(1), account(0), account(2), account(3))
+		}
+
+	case ataProgramID:
+		return fmt.Sprintf("create token account for wallet %s, mint %s", account(2), account(3))
+
+	case computeBudgetProgramID:
+		if len(data) >= 5 && data[0] == 2 {
+			return fmt.Sprintf("compute unit limit %d", binary.LittleEndian.Uint32(data[1:]))
+		}
+		if len(data) >= 9 && data[0] == 3 {
+			return fmt.Sprintf("compute unit price %d micro-lamports", binary.LittleEndian.Uint64(data[1:]))
+		}
+
+	case memoProgramID:
+		return fmt.Sprintf("memo %q", string(data))
+
+	case solEnvelopeProgramID, usdcEnvelopeProgramID:
+		if len(data) < 8 {
+			break
+		}
+		name, ok := envelopeInstructions[string(data[:8])]
+		if !ok {
+			break
+		}
+		if name == "create" {
+			decimals := 9
+			if programID == usdcEnvelopeProgramID {
+				decimals = 6
+			}
+			return describeCreate(data[8:], decimals, signers)
+		}
+		return fmt.Sprintf("%s (signer %s)", name, signers)
+	}
+	return fmt.Sprintf("%d accounts, %d bytes of data", countAccounts(account), len(data))
+}
+
+// describeCreate - envelope_type(1 [+32 | +4+40n]) + total_amount(8) + total_users(8) + expiry_hours(8)
+func describeCreate(data []byte, decimals int, signers string) string {
+	if len(data) < 1 {
+		return "create (malformed)"
+	}
+	kind, rest := data[0], data[1:]
+	kindName := map[byte]string{0: "DirectFixed", 1: "GroupFixed", 2: "GroupRandom", 3: "CustomSplit"}[kind]
+	switch kind {
+	case 0:
+		if len(rest) < 32 {
+			return "create (malformed)"
+		}
+		kindName += " to " + solana.PublicKeyFromBytes(rest[:32]).String()
+		rest = rest[32:]
+	case 3:
+		// Vec<(Pubkey, u64)>: u32 length + 40 bytes per recipient
+		if len(rest) < 4 {
+			return "create (malformed)"
+		}
+		n := int(binary.LittleEndian.Uint32(rest))
+		if len(rest) < 4+40*n {
+			return "create (malformed)"
+		}
+		kindName += fmt.Sprintf(" to %d recipients", n)
+		rest = rest[4+40*n:]
+	}
+	if len(rest) < 24 {
+		return "create (malformed)"
+	}
+	return fmt.Sprintf("create %s envelope of %s for %d users, expiry %dh (signer %s)",
+		kindName,
+		formatUnits(binary.LittleEndian.Uint64(rest), decimals),
+		binary.LittleEndian.Uint64(rest[8:]),
+		binary.LittleEndian.Uint64(rest[16:]),
+		signers)
+}
+
+func countAccounts(account func(int) string) int {
+	n := 0
+	for account(n) != "?" {
+		n++
+	}
+	return n
+}
+
+func messageVersion(msg solana.Message) string {
+	if msg.IsVersioned() {
+		return "v0"
+	}
+	return "legacy"
+}
+
+func describeEVM(p *Payload) []string {
+	tx := p.EVM
+	to := "(contract creation)"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	lines := []string{
+		fmt.Sprintf("Chain:     %s", p.Chain),
+		fmt.Sprintf("Type:      %d", tx.Type()),
+		fmt.Sprintf("To:        %s", to),
+		fmt.Sprintf("Value:     %s BNB", formatWei(tx.Value())),
+		fmt.Sprintf("Nonce:     %d", tx.Nonce()),
+		fmt.Sprintf("Gas limit: %d", tx.Gas()),
+		fmt.Sprintf("Gas price: %s gwei", pricing.FormatUnits(tx.GasPrice(), 9)),
+		fmt.Sprintf("Max fee:   %s BNB", formatWei(new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())))),
+	}
+	if tx.ChainId() != nil && tx.ChainId().Sign() > 0 {
+		lines = append(lines, fmt.Sprintf("Chain ID:  %s", tx.ChainId()))
+	}
+	if data := tx.Data(); len(data) > 0 {
+		selector := data
+		if len(selector) > 4 {
+			selector = selector[:4]
+		}
+		lines = append(lines, fmt.Sprintf("Data:      %d bytes, method selector 0x%x", len(data), selector))
+	}
+	return lines
+}
+
+func formatWei(wei *big.Int) string {
+	return pricing.FormatUnits(wei, 18)
+}
+
+func formatUnits(amount uint64, decimals int) string {
+	return pricing.FormatUnits(new(big.Int).SetUint64(amount), decimals)
+}
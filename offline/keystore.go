@@ -0,0 +1,117 @@
+package offline
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+)
+
+// Keystore - Directory of named signing keys, one <name>.json file each:
+//   - Solana: keypair file as written by `solana-keygen` (JSON byte array)
+//   - EVM:    encrypted geth keystore file (passphrase required)
+type Keystore struct {
+	Dir string
+}
+
+// Entry - Key file found in the keystore
+type Entry struct {
+	Name  string
+	Chain chain.ChainID
+	Path  string
+}
+
+// OpenKeystore - Use dir as keystore (must exist)
+func OpenKeystore(dir string) (*Keystore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keystore: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("keystore %s is not a directory", dir)
+	}
+	return &Keystore{Dir: dir}, nil
+}
+
+// List - All entries, sorted by name
+func (k *Keystore) List() ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(k.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	entries := make([]Entry, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		entries = append(entries, Entry{
+			Name:  strings.TrimSuffix(filepath.Base(path), ".json"),
+			Chain: entryChain(content),
+			Path:  path,
+		})
+	}
+	return entries, nil
+}
+
+// Get - Entry by name
+func (k *Keystore) Get(name string) (*Entry, []byte, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, nil, fmt.Errorf("invalid key name: %q", name)
+	}
+	path := filepath.Join(k.Dir, name+".json")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("key %q not found in keystore: %w", name, err)
+	}
+	return &Entry{Name: name, Chain: entryChain(content), Path: path}, content, nil
+}
+
+// SolanaKey - Load a Solana keypair entry
+func (k *Keystore) SolanaKey(name string) (solana.PrivateKey, error) {
+	entry, content, err := k.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Chain != chain.Solana {
+		return nil, fmt.Errorf("key %q is not a Solana keypair", name)
+	}
+	return solana.PrivateKeyFromSolanaKeygenFileBytes(content)
+}
+
+// EVMKey - Decrypt an EVM keystore entry
+func (k *Keystore) EVMKey(name, passphrase string) (*ecdsa.PrivateKey, error) {
+	entry, content, err := k.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Chain != chain.BSC {
+		return nil, fmt.Errorf("key %q is not an EVM keystore file", name)
+	}
+	key, err := keystore.DecryptKey(content, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key %q: %w", name, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// entryChain - Solana keypairs are JSON arrays, geth keystore files JSON objects
+func entryChain(content []byte) chain.ChainID {
+	switch trimmed := bytes.TrimSpace(content); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return chain.Solana
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return chain.BSC
+	}
+	return ""
+}
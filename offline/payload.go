@@ -0,0 +1,77 @@
+// Package offline - Decode, inspect and sign unsigned transaction payloads away from the API
+// (air-gapped operator machines). Nothing here talks to an RPC node.
+package offline
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+)
+
+// Encoding - Text encoding of a payload
+type Encoding string
+
+const (
+	Base64 Encoding = "base64" // Solana
+	Hex    Encoding = "hex"    // EVM (RLP)
+)
+
+// Payload - Decoded unsigned (or partially signed) transaction
+type Payload struct {
+	Chain    chain.ChainID
+	Encoding Encoding
+	Solana   *solana.Transaction
+	EVM      *types.Transaction
+}
+
+// ParsePayload - Decode a payload as produced by the create endpoints: hex (optional 0x) is EVM, base64 is Solana.
+// Whitespace and line breaks (QR scanners, wrapped files) are ignored.
+func ParsePayload(text string) (*Payload, error) {
+	text = strings.Join(strings.Fields(text), "")
+	if text == "" {
+		return nil, fmt.Errorf("empty payload")
+	}
+
+	if raw, err := hex.DecodeString(strings.TrimPrefix(text, "0x")); err == nil {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("failed to decode EVM transaction: %w", err)
+		}
+		return &Payload{Chain: chain.BSC, Encoding: Hex, EVM: tx}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("payload is neither hex nor base64")
+	}
+	tx, err := solana.TransactionFromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Solana transaction: %w", err)
+	}
+	return &Payload{Chain: chain.Solana, Encoding: Base64, Solana: tx}, nil
+}
+
+// Encode - Payload in its original encoding
+func (p *Payload) Encode() (string, error) {
+	switch {
+	case p.Solana != nil:
+		raw, err := p.Solana.MarshalBinary()
+		if err != nil {
+			return "", fmt.Errorf("failed to serialize transaction: %w", err)
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	case p.EVM != nil:
+		raw, err := p.EVM.MarshalBinary()
+		if err != nil {
+			return "", fmt.Errorf("failed to serialize transaction: %w", err)
+		}
+		return hex.EncodeToString(raw), nil
+	}
+	return "", fmt.Errorf("empty payload")
+}
@@ -0,0 +1,75 @@
+package offline
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Signers - Addresses the payload expects a signature from, for a confirmation prompt
+func (p *Payload) Signers() []string {
+	if p.Solana == nil {
+		return nil
+	}
+	msg := p.Solana.Message
+	signers := make([]string, 0, msg.Header.NumRequiredSignatures)
+	for i := 0; i < int(msg.Header.NumRequiredSignatures) && i < len(msg.AccountKeys); i++ {
+		signers = append(signers, msg.AccountKeys[i].String())
+	}
+	return signers
+}
+
+// SignSolana - Add key's signature; other signer slots are left as they are (partial signing)
+func (p *Payload) SignSolana(key solana.PrivateKey) (solana.Signature, error) {
+	if p.Solana == nil {
+		return solana.Signature{}, fmt.Errorf("payload is not a Solana transaction")
+	}
+	pub := key.PublicKey()
+
+	slot := -1
+	for i, signer := range p.Signers() {
+		if signer == pub.String() {
+			slot = i
+		}
+	}
+	if slot < 0 {
+		return solana.Signature{}, fmt.Errorf("key %s is not a required signer of this transaction", pub)
+	}
+
+	signatures, err := p.Solana.PartialSign(func(k solana.PublicKey) *solana.PrivateKey {
+		if k.Equals(pub) {
+			return &key
+		}
+		return nil
+	})
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signatures[slot], nil
+}
+
+// SignEVM - Sign with key; chainID is used only for legacy transactions that don't carry one
+func (p *Payload) SignEVM(key *ecdsa.PrivateKey, chainID int64) (string, error) {
+	if p.EVM == nil {
+		return "", fmt.Errorf("payload is not an EVM transaction")
+	}
+	id := p.EVM.ChainId()
+	if id == nil || id.Sign() == 0 {
+		id = big.NewInt(chainID)
+	}
+	signed, err := types.SignTx(p.EVM, types.LatestSignerForChainID(id), key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	p.EVM = signed
+	return signed.Hash().Hex(), nil
+}
+
+// EVMAddress - Address of an EVM key, for the confirmation prompt
+func EVMAddress(key *ecdsa.PrivateKey) string {
+	return crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
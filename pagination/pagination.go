@@ -0,0 +1,123 @@
+// Package pagination - Cursor-following iterators for list endpoints.
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// MaxLimit - Largest page size list endpoints serve
+const MaxLimit = 100
+
+// ParseLimit - Page size from a ?limit= value: def when empty, clamped to 1..MaxLimit; error when not an integer
+func ParseLimit(s string, def int) (int, error) {
+	limit := def
+	if s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid limit %q", s)
+		}
+		limit = n
+	}
+	return min(max(limit, 1), MaxLimit), nil
+}
+
+// Page - One page of results; NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// FetchFunc - Fetch the page starting at cursor ("" = first page)
+type FetchFunc[T any] func(ctx context.Context, cursor string) (*Page[T], error)
+
+// Iterator - Walks every item across pages, fetching lazily.
+//
+//	it := client.IterEnvelopesByOwner(ctx, owner)
+//	for it.Next() {
+//		env := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx    context.Context
+	fetch  FetchFunc[T]
+	buf    []T
+	cur    T
+	cursor string
+	done   bool
+	err    error
+}
+
+// New - Iterator over fetch starting at the first page
+func New[T any](ctx context.Context, fetch FetchFunc[T]) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, fetch: fetch}
+}
+
+// Next - Advance to the next item; false when exhausted or on error
+func (it *Iterator[T]) Next() bool {
+	for len(it.buf) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		page, err := it.fetch(it.ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.buf = page.Items
+		it.cursor = page.NextCursor
+		it.done = page.NextCursor == ""
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Value - Current item (valid after Next returned true)
+func (it *Iterator[T]) Value() T {
+	return it.cur
+}
+
+// Err - First error encountered, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Cursor - Cursor of the page after the one buffered, not of the current item: resuming from it while
+// buffered items are unconsumed skips them. It is a resume point only after Next returned false on an
+// error (the page that failed); "" once every page has been read.
+func (it *Iterator[T]) Cursor() string {
+	return it.cursor
+}
+
+// All - range-over-func form; yields (zero, err) once on failure
+func (it *Iterator[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for it.Next() {
+			if !yield(it.Value(), nil) {
+				return
+			}
+		}
+		if it.err != nil {
+			var zero T
+			yield(zero, it.err)
+		}
+	}
+}
+
+// Collect - Drain iterator into a slice (max <= 0 = no limit)
+func Collect[T any](it *Iterator[T], max int) ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Value())
+		if max > 0 && len(items) >= max {
+			break
+		}
+	}
+	return items, it.Err()
+}
@@ -0,0 +1,191 @@
+// Package preflight - Per-request control over sendTransaction preflight simulation,
+// and balance previews of unsigned transactions (SimulatePreview).
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+
+	"blockchain/metrics"
+)
+
+// Mode - Preflight behaviour of a submission
+type Mode string
+
+const (
+	Default  Mode = "default"  // tenant/service default; node preflight when none is configured
+	Simulate Mode = "simulate" // explicit simulateTransaction with logs, then send without node preflight
+	Skip     Mode = "skip"     // skipPreflight=true, fastest, failures only visible on-chain
+)
+
+// TenantHeader - Header identifying the tenant for per-tenant defaults
+const TenantHeader = "X-Tenant-ID"
+
+// Parse - Parse mode ("" = Default)
+func Parse(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", Default:
+		return Default, nil
+	case Simulate:
+		return Simulate, nil
+	case Skip:
+		return Skip, nil
+	}
+	return "", fmt.Errorf("invalid preflight mode: %q (want simulate, skip or default)", s)
+}
+
+// TransactionOpts - sendTransaction options for the mode
+func (m Mode) TransactionOpts() rpc.TransactionOpts {
+	return rpc.TransactionOpts{
+		SkipPreflight:       m == Skip || m == Simulate, // Simulate already ran its own simulation
+		PreflightCommitment: rpc.CommitmentConfirmed,
+	}
+}
+
+// SimulationError - Simulation rejected the transaction
+type SimulationError struct {
+	Err  interface{}
+	Logs []string
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("transaction simulation failed: %v", e.Err)
+}
+
+// Check - Run simulateTransaction when mode is Simulate (no-op otherwise)
+func Check(ctx context.Context, client *rpc.Client, tx *solana.Transaction, m Mode) error {
+	if m != Simulate {
+		return nil
+	}
+	out, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:  true,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+	if out.Value != nil && out.Value.Err != nil {
+		return &SimulationError{Err: out.Value.Err, Logs: out.Value.Logs}
+	}
+	return nil
+}
+
+// Send - Check + sendTransaction with the mode's options, recorded in metrics
+func Send(ctx context.Context, client *rpc.Client, tx *solana.Transaction, m Mode) (solana.Signature, error) {
+	if err := Check(ctx, client, tx, m); err != nil {
+		Record(m, err)
+		return solana.Signature{}, err
+	}
+	sig, err := client.SendTransactionWithOpts(ctx, tx, m.TransactionOpts())
+	Record(m, err)
+	return sig, err
+}
+
+// Record - Count submission outcome per mode (preflight_submissions_<mode>, preflight_failures_<mode>)
+func Record(m Mode, err error) {
+	metrics.PreflightResult(string(m), err != nil)
+}
+
+// Policy - Service-wide default plus per-tenant defaults; an explicit request mode always wins
+type Policy struct {
+	mu      sync.RWMutex
+	def     Mode
+	tenants map[string]Mode
+}
+
+// NewPolicy - Policy with the given default
+func NewPolicy(def Mode) *Policy {
+	return &Policy{def: def, tenants: make(map[string]Mode)}
+}
+
+// ParsePolicy - Policy from config strings: def "skip", tenants "tenantA=skip,tenantB=simulate"
+func ParsePolicy(def string, tenants string) (*Policy, error) {
+	mode, err := Parse(def)
+	if err != nil {
+		return nil, err
+	}
+	p := NewPolicy(mode)
+	for _, pair := range strings.Split(tenants, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		tenant, modeStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tenant preflight %q (want tenant=mode)", pair)
+		}
+		mode, err := Parse(modeStr)
+		if err != nil {
+			return nil, err
+		}
+		p.SetTenant(strings.TrimSpace(tenant), mode)
+	}
+	return p, nil
+}
+
+// SetTenant - Set tenant default
+func (p *Policy) SetTenant(tenant string, m Mode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tenants[tenant] = m
+}
+
+// Resolve - Requested mode, else tenant default, else service default. Nil policy resolves to Default.
+func (p *Policy) Resolve(tenant string, requested Mode) Mode {
+	if requested != "" && requested != Default {
+		return requested
+	}
+	if p == nil {
+		return Default
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if m, ok := p.tenants[tenant]; ok {
+		return m
+	}
+	return p.def
+}
+
+// FromRequest - Resolve mode for an HTTP request given the body's "preflight" value
+func (p *Policy) FromRequest(r *http.Request, requested string) (Mode, error) {
+	mode, err := Parse(requested)
+	if err != nil {
+		return "", err
+	}
+	if requested == "" {
+		mode = ""
+	}
+	return p.Resolve(r.Header.Get(TenantHeader), mode), nil
+}
+
+// Logs - Program logs carried by a send/simulate error (nil when none)
+func Logs(err error) []string {
+	var simErr *SimulationError
+	if errors.As(err, &simErr) {
+		return simErr.Logs
+	}
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return nil
+	}
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	raw, _ := data["logs"].([]interface{})
+	logs := make([]string, 0, len(raw))
+	for _, l := range raw {
+		if s, ok := l.(string); ok {
+			logs = append(logs, s)
+		}
+	}
+	return logs
+}
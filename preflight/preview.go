@@ -0,0 +1,167 @@
+package preflight
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/pricing"
+	"blockchain/receipt"
+)
+
+// BalanceChange - Balance of one account before and after the simulated transaction
+type BalanceChange struct {
+	Account  string `json:"account"`
+	Owner    string `json:"owner,omitempty"` // Token accounts: wallet owning them
+	Mint     string `json:"mint,omitempty"`  // "" = SOL (lamports)
+	Decimals uint8  `json:"decimals"`
+	Pre      uint64 `json:"pre"`
+	Post     uint64 `json:"post"`
+	Delta    int64  `json:"delta"`
+	Signer   bool   `json:"signer,omitempty"` // SOL rows: account signs the transaction
+}
+
+// Preview - Outcome of an unsigned transaction as a wallet shows it before asking for a signature
+type Preview struct {
+	Success       bool            `json:"success"`
+	Error         interface{}     `json:"error,omitempty"` // Simulation error, the transaction would fail
+	Logs          []string        `json:"logs,omitempty"`
+	UnitsConsumed *uint64         `json:"units_consumed,omitempty"`
+	FeePayer      string          `json:"fee_payer"`
+	Fee           *pricing.Fee    `json:"fee,omitempty"` // Network fee the fee payer pays on top of the changes below
+	Slot          uint64          `json:"slot"`          // Slot the simulation ran at
+	Balances      []BalanceChange `json:"balances"`      // Writable accounts: SOL rows that change or sign, every token account
+}
+
+// SimulatePreview - Simulate tx (unsigned: signatures aren't verified and the blockhash is replaced) and
+// compare SOL and SPL token balances of its writable accounts before and after. The pre state is read
+// just before the simulation at the same commitment, so a transaction landing in between can skew it.
+func SimulatePreview(ctx context.Context, client *rpc.Client, prices pricing.Source, tx *solana.Transaction) (*Preview, error) {
+	var writable []solana.PublicKey
+	for _, key := range tx.Message.AccountKeys {
+		if ok, err := tx.Message.IsWritable(key); err == nil && ok {
+			writable = append(writable, key)
+		}
+	}
+
+	pre, err := client.GetMultipleAccountsWithOpts(ctx, writable, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	out, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentConfirmed,
+		Accounts:               &rpc.SimulateTransactionAccountsOpts{Encoding: solana.EncodingBase64, Addresses: writable},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+
+	preview := &Preview{
+		Success:  out.Value.Err == nil,
+		Error:    out.Value.Err,
+		Logs:     out.Value.Logs,
+		FeePayer: tx.Message.AccountKeys[0].String(),
+		Fee:      pricing.FormatLamports(ctx, prices, receipt.EstimateSolanaFee(ctx, client, tx)),
+		Slot:     out.Context.Slot,
+		Balances: []BalanceChange{},
+
+		UnitsConsumed: out.Value.UnitsConsumed,
+	}
+	if !preview.Success {
+		return preview, nil // No post state to compare
+	}
+
+	var tokenRows []BalanceChange
+	var tokenMints []solana.PublicKey
+	mints := make(map[solana.PublicKey]uint8)
+	for i, key := range writable {
+		var before, after *rpc.Account
+		if i < len(pre.Value) {
+			before = pre.Value[i]
+		}
+		if i < len(out.Value.Accounts) {
+			after = out.Value.Accounts[i]
+		}
+
+		row := BalanceChange{Account: key.String(), Decimals: 9, Pre: lamports(before), Post: lamports(after), Signer: tx.IsSigner(key)}
+		if row.Pre != row.Post || row.Signer {
+			row.Delta = int64(row.Post) - int64(row.Pre)
+			preview.Balances = append(preview.Balances, row)
+		}
+
+		preToken, preOK := parseTokenAccount(before)
+		postToken, postOK := parseTokenAccount(after)
+		if !preOK && !postOK {
+			continue
+		}
+		token := postToken
+		if !postOK {
+			token = preToken // Closed by the transaction
+		}
+		mints[token.mint] = 0
+		tokenMints = append(tokenMints, token.mint)
+		tokenRows = append(tokenRows, BalanceChange{
+			Account: key.String(),
+			Owner:   token.owner.String(),
+			Mint:    token.mint.String(),
+			Pre:     preToken.amount,
+			Post:    postToken.amount,
+			Delta:   int64(postToken.amount) - int64(preToken.amount),
+		})
+	}
+
+	// Decimals from the mints, so wallets can render token amounts
+	if len(mints) > 0 {
+		keys := make([]solana.PublicKey, 0, len(mints))
+		for mint := range mints {
+			keys = append(keys, mint)
+		}
+		if accounts, err := client.GetMultipleAccountsWithOpts(ctx, keys, &rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed}); err == nil {
+			for i, acc := range accounts.Value {
+				// Mint layout: mint_authority option (36) + supply (8) + decimals (1)
+				if acc != nil && len(acc.Data.GetBinary()) > 44 {
+					mints[keys[i]] = acc.Data.GetBinary()[44]
+				}
+			}
+		}
+		for i := range tokenRows {
+			tokenRows[i].Decimals = mints[tokenMints[i]]
+		}
+	}
+	preview.Balances = append(preview.Balances, tokenRows...)
+	return preview, nil
+}
+
+func lamports(acc *rpc.Account) uint64 {
+	if acc == nil {
+		return 0
+	}
+	return acc.Lamports
+}
+
+type tokenAccount struct {
+	mint   solana.PublicKey
+	owner  solana.PublicKey
+	amount uint64
+}
+
+// parseTokenAccount - SPL Token / Token-2022 account: mint (32) + owner (32) + amount (8) + ...
+func parseTokenAccount(acc *rpc.Account) (tokenAccount, bool) {
+	if acc == nil || !(acc.Owner.Equals(solana.TokenProgramID) || acc.Owner.Equals(solana.Token2022ProgramID)) {
+		return tokenAccount{}, false
+	}
+	data := acc.Data.GetBinary()
+	// Token-2022 accounts with extensions are longer, byte 165 tells accounts (2) from mints (1)
+	if len(data) < 165 || len(data) > 165 && data[165] != 2 {
+		return tokenAccount{}, false // Mint or multisig, not a token account
+	}
+	return tokenAccount{
+		mint:   solana.PublicKeyFromBytes(data[0:32]),
+		owner:  solana.PublicKeyFromBytes(data[32:64]),
+		amount: binary.LittleEndian.Uint64(data[64:72]),
+	}, true
+}
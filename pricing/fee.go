@@ -0,0 +1,82 @@
+package pricing
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain/chain"
+)
+
+// priceLookupTimeout - Fees are decoration; never hold a response up for a price
+const priceLookupTimeout = 2 * time.Second
+
+// Fee - Fee in base units, native currency and approximate USD
+type Fee struct {
+	BaseUnits string `json:"base_units"`    // lamports / wei
+	Amount    string `json:"amount"`        // e.g. "0.000005"
+	Currency  string `json:"currency"`      // SOL, BNB
+	USD       string `json:"usd,omitempty"` // approximate, empty when no price is available
+}
+
+// FormatFee - Express baseUnits of c's native currency as a Fee; source may be nil (no USD)
+func FormatFee(ctx context.Context, source Source, c chain.ChainID, baseUnits *big.Int) *Fee {
+	if baseUnits == nil {
+		baseUnits = new(big.Int)
+	}
+	fee := &Fee{
+		BaseUnits: baseUnits.String(),
+		Amount:    FormatUnits(baseUnits, c.Decimals()),
+		Currency:  c.Symbol(),
+	}
+	if source == nil {
+		return fee
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, priceLookupTimeout)
+	defer cancel()
+	price, err := source.USDPrice(ctx, c)
+	if err != nil {
+		return fee
+	}
+	amount, _ := new(big.Float).SetString(fee.Amount)
+	usd, _ := new(big.Float).Mul(amount, big.NewFloat(price)).Float64()
+	fee.USD = formatUSD(usd)
+	return fee
+}
+
+// FormatLamports - FormatFee for a Solana fee
+func FormatLamports(ctx context.Context, source Source, lamports uint64) *Fee {
+	return FormatFee(ctx, source, chain.Solana, new(big.Int).SetUint64(lamports))
+}
+
+// FormatUnits - Fixed-point decimal string of amount with trailing zeros trimmed
+func FormatUnits(amount *big.Int, decimals int) string {
+	s := new(big.Int).Abs(amount).String()
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals <= 0 {
+		return sign + s
+	}
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	whole, frac := s[:len(s)-decimals], strings.TrimRight(s[len(s)-decimals:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// formatUSD - Cents for normal amounts, 2 significant digits below a cent (fees are usually tiny)
+func formatUSD(usd float64) string {
+	if usd >= 0.01 {
+		return strconv.FormatFloat(usd, 'f', 2, 64)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(usd, 'g', 2, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
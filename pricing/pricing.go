@@ -0,0 +1,172 @@
+// Package pricing - Approximate USD prices of native chain currencies, and fee formatting
+// (lamports / wei as SOL / BNB decimal strings with a USD estimate).
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain/chain"
+)
+
+// Source - USD price of a chain's native currency
+type Source interface {
+	USDPrice(ctx context.Context, c chain.ChainID) (float64, error)
+}
+
+// Static - Fixed prices (config, tests, sandbox)
+type Static map[chain.ChainID]float64
+
+var (
+	_ Source = Static(nil)
+	_ Source = (*CoinGecko)(nil)
+	_ Source = (*Cache)(nil)
+)
+
+// USDPrice - Implements Source
+func (s Static) USDPrice(_ context.Context, c chain.ChainID) (float64, error) {
+	price, ok := s[c]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", c)
+	}
+	return price, nil
+}
+
+// ParseStatic - Parse "solana=150.5,bsc=600"
+func ParseStatic(spec string) (Static, error) {
+	prices := Static{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price entry %q (want chain=usd)", entry)
+		}
+		c, err := chain.ParseChainID(name)
+		if err != nil {
+			return nil, err
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid price for %s: %q", c, value)
+		}
+		prices[c] = price
+	}
+	return prices, nil
+}
+
+// CoinGeckoURL - Public simple price endpoint
+const CoinGeckoURL = "https://api.coingecko.com/api/v3/simple/price"
+
+var coinGeckoIDs = map[chain.ChainID]string{
+	chain.Solana: "solana",
+	chain.BSC:    "binancecoin",
+}
+
+// CoinGecko - Prices from the CoinGecko simple price API (wrap in a Cache)
+type CoinGecko struct {
+	URL    string // Default CoinGeckoURL
+	Client *http.Client
+}
+
+// USDPrice - Implements Source
+func (g *CoinGecko) USDPrice(ctx context.Context, c chain.ChainID) (float64, error) {
+	id, ok := coinGeckoIDs[c]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", c)
+	}
+	url := g.URL
+	if url == "" {
+		url = CoinGeckoURL
+	}
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"?vs_currencies=usd&ids="+id, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get price: status %d", resp.StatusCode)
+	}
+
+	var body map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode price: %w", err)
+	}
+	price, ok := body[id]["usd"]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s in response", c)
+	}
+	return price, nil
+}
+
+// Cache - Source wrapper keeping prices for ttl; a stale price is served when the source fails
+type Cache struct {
+	source Source
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	prices map[chain.ChainID]cachedPrice
+}
+
+type cachedPrice struct {
+	price     float64
+	fetchedAt time.Time
+}
+
+// NewCache - Cache source for ttl (default 1m)
+func NewCache(source Source, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &Cache{source: source, ttl: ttl, prices: make(map[chain.ChainID]cachedPrice)}
+}
+
+// USDPrice - Implements Source
+func (c *Cache) USDPrice(ctx context.Context, id chain.ChainID) (float64, error) {
+	c.mu.Lock()
+	cached, ok := c.prices[id]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) <= c.ttl {
+		return cached.price, nil
+	}
+
+	price, err := c.source.USDPrice(ctx, id)
+	if err != nil {
+		if ok {
+			return cached.price, nil
+		}
+		return 0, err
+	}
+	c.mu.Lock()
+	c.prices[id] = cachedPrice{price: price, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return price, nil
+}
+
+// New - Source from config: "" (none), "coingecko", or static prices "solana=150,bsc=600"
+func New(spec string) (Source, error) {
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case "":
+		return nil, nil
+	case "coingecko":
+		return NewCache(&CoinGecko{}, time.Minute), nil
+	}
+	return ParseStatic(spec)
+}
@@ -0,0 +1,368 @@
+// Package projection - Envelope read models (envelope state, claim lists, per-chain stats) built
+// deterministically from raw envelope events, so a corrupted indexer DB can be rebuilt from the
+// persisted transaction history or from a fresh chain snapshot.
+package projection
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"blockchain/chain"
+)
+
+// ErrChecksumMismatch - Stored projections don't match the checksum recorded when they were built
+var ErrChecksumMismatch = errors.New("projection checksum mismatch")
+
+// Event - Raw envelope event; zero values mean the source doesn't know the field
+type Event struct {
+	Chain      chain.ChainID `json:"chain"`
+	Action     string        `json:"action"` // chain.EnvelopeAction*
+	Owner      string        `json:"owner"`
+	EnvelopeID uint64        `json:"envelope_id"`
+	Actor      string        `json:"actor,omitempty"` // Claimer of a claim
+	Signature  string        `json:"signature,omitempty"`
+	Time       time.Time     `json:"time"`             // Block time (claim records: claimed_at)
+	Amount     uint64        `json:"amount,omitempty"` // create: total, claim: claimed, refund: refunded
+
+	// Create only
+	EnvelopeType string    `json:"envelope_type,omitempty"`
+	TotalUsers   uint64    `json:"total_users,omitempty"`
+	ExpiryTime   time.Time `json:"expiry_time"`
+}
+
+// Envelope - Envelope state projection
+type Envelope struct {
+	Chain           chain.ChainID `gorm:"primaryKey;size:20" json:"chain"`
+	Owner           string        `gorm:"primaryKey;size:64" json:"owner"`
+	EnvelopeID      uint64        `gorm:"primaryKey;autoIncrement:false" json:"envelope_id"`
+	EnvelopeType    string        `gorm:"size:20" json:"envelope_type,omitempty"`
+	TotalAmount     uint64        `json:"total_amount"`
+	TotalUsers      uint64        `json:"total_users"`
+	ClaimedCount    uint64        `json:"claimed_count"`
+	ClaimedAmount   uint64        `json:"claimed_amount"`
+	RefundedAmount  uint64        `json:"refunded_amount"`
+	RemainingAmount uint64        `json:"remaining_amount"` // 0 when TotalAmount is unknown
+	Refunded        bool          `gorm:"index" json:"refunded"`
+	CreateSignature string        `gorm:"size:88" json:"create_signature,omitempty"`
+	CreateTime      *time.Time    `json:"create_time,omitempty"`
+	ExpiryTime      *time.Time    `gorm:"index" json:"expiry_time,omitempty"`
+}
+
+func (Envelope) TableName() string {
+	return "envelope_projections"
+}
+
+// Claim - Claim list projection, one row per claimer of an envelope
+type Claim struct {
+	Chain      chain.ChainID `gorm:"primaryKey;size:20" json:"chain"`
+	Owner      string        `gorm:"primaryKey;size:64" json:"owner"`
+	EnvelopeID uint64        `gorm:"primaryKey;autoIncrement:false" json:"envelope_id"`
+	Claimer    string        `gorm:"primaryKey;size:64" json:"claimer"`
+	Amount     uint64        `json:"amount"`
+	Signature  string        `gorm:"size:88" json:"signature,omitempty"`
+	ClaimedAt  *time.Time    `json:"claimed_at,omitempty"`
+}
+
+func (Claim) TableName() string {
+	return "claim_projections"
+}
+
+// Stats - Per-chain totals plus the checksum of the chain's envelope and claim projections
+type Stats struct {
+	Chain          chain.ChainID `gorm:"primaryKey;size:20" json:"chain"`
+	Envelopes      int64         `json:"envelopes"`
+	Open           int64         `json:"open"` // Not refunded, funds left
+	Refunded       int64         `json:"refunded"`
+	Claims         int64         `json:"claims"`
+	TotalAmount    uint64        `json:"total_amount"`
+	ClaimedAmount  uint64        `json:"claimed_amount"`
+	RefundedAmount uint64        `json:"refunded_amount"`
+	Checksum       string        `gorm:"size:64" json:"checksum"`
+}
+
+func (Stats) TableName() string {
+	return "envelope_stats"
+}
+
+type envelopeKey struct {
+	chain      chain.ChainID
+	owner      string
+	envelopeID uint64
+}
+
+type claimKey struct {
+	envelopeKey
+	claimer string
+}
+
+// Projector - Folds events into projections. The result doesn't depend on event order and
+// replaying an event twice changes nothing, so any source can be replayed from scratch.
+type Projector struct {
+	envelopes map[envelopeKey]*Envelope
+	claims    map[claimKey]*Claim
+	refunds   map[envelopeKey]map[string]uint64 // Refund amount by signature ("" = source without signatures)
+	events    int
+}
+
+// NewProjector - Empty projector
+func NewProjector() *Projector {
+	return &Projector{
+		envelopes: make(map[envelopeKey]*Envelope),
+		claims:    make(map[claimKey]*Claim),
+		refunds:   make(map[envelopeKey]map[string]uint64),
+	}
+}
+
+// Apply - Fold one event
+func (p *Projector) Apply(e Event) error {
+	if e.Chain == "" || e.Owner == "" {
+		return fmt.Errorf("event %s of envelope #%d: chain and owner are required", e.Action, e.EnvelopeID)
+	}
+	key := envelopeKey{e.Chain, e.Owner, e.EnvelopeID}
+	envelope := p.envelope(key)
+
+	switch e.Action {
+	case chain.EnvelopeActionCreate:
+		envelope.EnvelopeType = maxString(envelope.EnvelopeType, e.EnvelopeType)
+		envelope.TotalAmount = max(envelope.TotalAmount, e.Amount)
+		envelope.TotalUsers = max(envelope.TotalUsers, e.TotalUsers)
+		envelope.CreateSignature = minString(envelope.CreateSignature, e.Signature)
+		envelope.CreateTime = earliest(envelope.CreateTime, e.Time)
+		envelope.ExpiryTime = latest(envelope.ExpiryTime, e.ExpiryTime)
+	case chain.EnvelopeActionClaim:
+		if e.Actor == "" {
+			return fmt.Errorf("claim of envelope #%d: actor (claimer) is required", e.EnvelopeID)
+		}
+		ck := claimKey{key, e.Actor}
+		claim, ok := p.claims[ck]
+		if !ok {
+			claim = &Claim{Chain: e.Chain, Owner: e.Owner, EnvelopeID: e.EnvelopeID, Claimer: e.Actor}
+			p.claims[ck] = claim
+		}
+		// A claimer claims an envelope once; several events are the same claim seen by different sources
+		claim.Amount = max(claim.Amount, e.Amount)
+		claim.Signature = minString(claim.Signature, e.Signature)
+		claim.ClaimedAt = earliest(claim.ClaimedAt, e.Time)
+	case chain.EnvelopeActionRefund:
+		refunds, ok := p.refunds[key]
+		if !ok {
+			refunds = make(map[string]uint64)
+			p.refunds[key] = refunds
+		}
+		refunds[e.Signature] = max(refunds[e.Signature], e.Amount)
+	default:
+		return fmt.Errorf("unknown envelope action %q", e.Action)
+	}
+	p.events++
+	return nil
+}
+
+func (p *Projector) envelope(key envelopeKey) *Envelope {
+	envelope, ok := p.envelopes[key]
+	if !ok {
+		envelope = &Envelope{Chain: key.chain, Owner: key.owner, EnvelopeID: key.envelopeID}
+		p.envelopes[key] = envelope
+	}
+	return envelope
+}
+
+// Result - Sorted projections with per-chain stats and checksums
+func (p *Projector) Result() *Result {
+	result := &Result{Events: p.events}
+
+	claimed := make(map[envelopeKey][2]uint64) // count, amount
+	for _, claim := range p.claims {
+		key := envelopeKey{claim.Chain, claim.Owner, claim.EnvelopeID}
+		c := claimed[key]
+		claimed[key] = [2]uint64{c[0] + 1, c[1] + claim.Amount}
+		result.Claims = append(result.Claims, *claim)
+	}
+	for key, envelope := range p.envelopes {
+		e := *envelope
+		e.ClaimedCount, e.ClaimedAmount = claimed[key][0], claimed[key][1]
+		e.RefundedAmount = 0
+		for _, amount := range p.refunds[key] {
+			e.RefundedAmount += amount
+		}
+		e.Refunded = len(p.refunds[key]) > 0
+		if e.TotalAmount > e.ClaimedAmount+e.RefundedAmount {
+			e.RemainingAmount = e.TotalAmount - e.ClaimedAmount - e.RefundedAmount
+		}
+		result.Envelopes = append(result.Envelopes, e)
+	}
+	result.sort()
+	result.Stats = computeStats(result.Envelopes, result.Claims)
+	return result
+}
+
+// Result - Output of a rebuild
+type Result struct {
+	Envelopes []Envelope `json:"envelopes"`
+	Claims    []Claim    `json:"claims"`
+	Stats     []Stats    `json:"stats"`  // One row per chain, with its checksum
+	Events    int        `json:"events"` // Events applied (0 when loaded from storage)
+}
+
+func (r *Result) sort() {
+	sort.Slice(r.Envelopes, func(i, j int) bool {
+		a, b := r.Envelopes[i], r.Envelopes[j]
+		if a.Chain != b.Chain {
+			return a.Chain < b.Chain
+		}
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		return a.EnvelopeID < b.EnvelopeID
+	})
+	sort.Slice(r.Claims, func(i, j int) bool {
+		a, b := r.Claims[i], r.Claims[j]
+		if a.Chain != b.Chain {
+			return a.Chain < b.Chain
+		}
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		if a.EnvelopeID != b.EnvelopeID {
+			return a.EnvelopeID < b.EnvelopeID
+		}
+		return a.Claimer < b.Claimer
+	})
+}
+
+// Checksum - Checksum over the checksums of every chain
+func (r *Result) Checksum() string {
+	h := sha256.New()
+	for _, stats := range r.Stats {
+		fmt.Fprintf(h, "%s:%s\n", stats.Chain, stats.Checksum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify - Recompute stats and checksums from the envelopes and claims and compare them with r.Stats
+func (r *Result) Verify() error {
+	r.sort()
+	want := make(map[chain.ChainID]Stats, len(r.Stats))
+	for _, stats := range r.Stats {
+		want[stats.Chain] = stats
+	}
+	got := computeStats(r.Envelopes, r.Claims)
+	if len(got) != len(want) {
+		return fmt.Errorf("%w: stats for %d chains, projections for %d", ErrChecksumMismatch, len(want), len(got))
+	}
+	for _, stats := range got {
+		if want[stats.Chain] != stats {
+			return fmt.Errorf("%w: %s: recorded %s, computed %s", ErrChecksumMismatch, stats.Chain, want[stats.Chain].Checksum, stats.Checksum)
+		}
+	}
+	return nil
+}
+
+// computeStats - Stats per chain of sorted projections
+func computeStats(envelopes []Envelope, claims []Claim) []Stats {
+	byChain := make(map[chain.ChainID]*Stats)
+	hashes := make(map[chain.ChainID]hashWriter)
+	get := func(c chain.ChainID) (*Stats, hashWriter) {
+		if _, ok := byChain[c]; !ok {
+			byChain[c] = &Stats{Chain: c}
+			hashes[c] = hashWriter{sha256.New()}
+		}
+		return byChain[c], hashes[c]
+	}
+	for _, e := range envelopes {
+		stats, h := get(e.Chain)
+		stats.Envelopes++
+		stats.TotalAmount += e.TotalAmount
+		stats.RefundedAmount += e.RefundedAmount
+		if e.Refunded {
+			stats.Refunded++
+		} else if e.RemainingAmount > 0 {
+			stats.Open++
+		}
+		h.row("envelope", e)
+	}
+	for _, c := range claims {
+		stats, h := get(c.Chain)
+		stats.Claims++
+		stats.ClaimedAmount += c.Amount
+		h.row("claim", c)
+	}
+
+	result := make([]Stats, 0, len(byChain))
+	for c, stats := range byChain {
+		stats.Checksum = hex.EncodeToString(hashes[c].Sum(nil))
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Chain < result[j].Chain })
+	return result
+}
+
+// hashWriter - sha256 over canonical JSON rows (times in UTC, so storage round trips hash the same)
+type hashWriter struct {
+	hash interface {
+		Write([]byte) (int, error)
+		Sum([]byte) []byte
+	}
+}
+
+func (h hashWriter) row(kind string, v any) {
+	switch row := v.(type) {
+	case Envelope:
+		row.CreateTime, row.ExpiryTime = utc(row.CreateTime), utc(row.ExpiryTime)
+		v = row
+	case Claim:
+		row.ClaimedAt = utc(row.ClaimedAt)
+		v = row
+	}
+	data, _ := json.Marshal(v)
+	h.hash.Write([]byte(kind))
+	h.hash.Write(data)
+	h.hash.Write([]byte{'\n'})
+}
+
+func (h hashWriter) Sum(b []byte) []byte {
+	return h.hash.Sum(b)
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC().Truncate(time.Second)
+	return &u
+}
+
+func earliest(current *time.Time, t time.Time) *time.Time {
+	if t.IsZero() || (current != nil && !t.Before(*current)) {
+		return current
+	}
+	t = t.UTC().Truncate(time.Second)
+	return &t
+}
+
+func latest(current *time.Time, t time.Time) *time.Time {
+	if t.IsZero() || (current != nil && !t.After(*current)) {
+		return current
+	}
+	t = t.UTC().Truncate(time.Second)
+	return &t
+}
+
+// minString - Smallest non-empty string
+func minString(a, b string) string {
+	if a == "" || (b != "" && b < a) {
+		return b
+	}
+	return a
+}
+
+func maxString(a, b string) string {
+	if b > a {
+		return b
+	}
+	return a
+}
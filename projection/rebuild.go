@@ -0,0 +1,136 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blockchain/chain"
+)
+
+// DefaultProgressEvery - Events between progress reports
+const DefaultProgressEvery = 1000
+
+// Source - Raw envelope events, in any order: persisted history (chainsol.SolChain.EnvelopeEvents)
+// or a chain snapshot (solprogram.USDCEnvelopeClient.EnvelopeEvents)
+type Source func(ctx context.Context, emit func(Event) error) error
+
+// Progress - Rebuild progress report
+type Progress struct {
+	Events    int           `json:"events"`
+	Envelopes int           `json:"envelopes"`
+	Claims    int           `json:"claims"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Done      bool          `json:"done"`
+}
+
+// Options - Rebuild options
+type Options struct {
+	ProgressEvery int            // Events between reports (DefaultProgressEvery when <= 0)
+	OnProgress    func(Progress) // Optional
+}
+
+// Rebuild - Replay every event of source into fresh projections
+func Rebuild(ctx context.Context, source Source, opts Options) (*Result, error) {
+	if opts.ProgressEvery <= 0 {
+		opts.ProgressEvery = DefaultProgressEvery
+	}
+	start := time.Now()
+	p := NewProjector()
+	report := func(done bool) {
+		if opts.OnProgress != nil {
+			opts.OnProgress(Progress{Events: p.events, Envelopes: len(p.envelopes), Claims: len(p.claims), Elapsed: time.Since(start), Done: done})
+		}
+	}
+
+	err := source(ctx, func(e Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.Apply(e); err != nil {
+			return err
+		}
+		if p.events%opts.ProgressEvery == 0 {
+			report(false)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replay stopped after %d events: %w", p.events, err)
+	}
+	report(true)
+	return p.Result(), nil
+}
+
+// AutoMigrate - Create/upgrade the projection tables
+func AutoMigrate(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return db.AutoMigrate(&Envelope{}, &Claim{}, &Stats{})
+}
+
+// Save - Replace the projections of every chain in result, in one transaction
+func Save(ctx context.Context, db *gorm.DB, result *Result) error {
+	if db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stats := range result.Stats {
+			for _, model := range []any{&Envelope{}, &Claim{}, &Stats{}} {
+				if err := tx.Where("chain = ?", stats.Chain).Delete(model).Error; err != nil {
+					return fmt.Errorf("failed to clear %s projections: %w", stats.Chain, err)
+				}
+			}
+		}
+		if len(result.Envelopes) > 0 {
+			if err := tx.CreateInBatches(result.Envelopes, 500).Error; err != nil {
+				return fmt.Errorf("failed to save envelope projections: %w", err)
+			}
+		}
+		if len(result.Claims) > 0 {
+			if err := tx.CreateInBatches(result.Claims, 500).Error; err != nil {
+				return fmt.Errorf("failed to save claim projections: %w", err)
+			}
+		}
+		if len(result.Stats) > 0 {
+			if err := tx.Create(result.Stats).Error; err != nil {
+				return fmt.Errorf("failed to save stats: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Load - Stored projections of a chain
+func Load(ctx context.Context, db *gorm.DB, c chain.ChainID) (*Result, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	result := &Result{}
+	// One statement per query: a shared chain would carry the first query's table into the next
+	query := func() *gorm.DB {
+		return db.Session(&gorm.Session{Context: ctx}).Where("chain = ?", c)
+	}
+	if err := query().Find(&result.Envelopes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load envelope projections: %w", err)
+	}
+	if err := query().Find(&result.Claims).Error; err != nil {
+		return nil, fmt.Errorf("failed to load claim projections: %w", err)
+	}
+	if err := query().Find(&result.Stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stats: %w", err)
+	}
+	return result, nil
+}
+
+// Verify - Integrity check of the stored projections of a chain against the checksum recorded by Save
+func Verify(ctx context.Context, db *gorm.DB, c chain.ChainID) error {
+	result, err := Load(ctx, db, c)
+	if err != nil {
+		return err
+	}
+	return result.Verify()
+}
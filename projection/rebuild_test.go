@@ -0,0 +1,57 @@
+package projection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"blockchain/chain"
+)
+
+func TestSaveLoadVerify(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Unix(1_700_000_000, 0)
+	events := []Event{
+		{Chain: chain.Solana, Action: chain.EnvelopeActionCreate, Owner: "owner", EnvelopeID: 1, Amount: 3_000_000, TotalUsers: 3, Time: created},
+		{Chain: chain.Solana, Action: chain.EnvelopeActionClaim, Owner: "owner", EnvelopeID: 1, Actor: "alice", Amount: 1_000_000, Time: created.Add(time.Minute)},
+		{Chain: chain.Solana, Action: chain.EnvelopeActionClaim, Owner: "owner", EnvelopeID: 1, Actor: "bob", Amount: 1_000_000, Time: created.Add(2 * time.Minute)},
+	}
+	result, err := Rebuild(context.Background(), func(_ context.Context, emit func(Event) error) error {
+		for _, e := range events {
+			if err := emit(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(context.Background(), db, result); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(context.Background(), db, chain.Solana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Envelopes) != 1 || len(loaded.Claims) != 2 || len(loaded.Stats) != 1 {
+		t.Fatalf("loaded %d envelopes, %d claims, %d stats; want 1, 2, 1", len(loaded.Envelopes), len(loaded.Claims), len(loaded.Stats))
+	}
+	if got := loaded.Envelopes[0].RemainingAmount; got != 1_000_000 {
+		t.Errorf("remaining amount = %d, want 1000000", got)
+	}
+	if err := Verify(context.Background(), db, chain.Solana); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
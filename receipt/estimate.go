@@ -0,0 +1,44 @@
+package receipt
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// EstimateSolanaFee - Lamports the transaction will be charged: getFeeForMessage when the node
+// answers, otherwise signatures * 5000 plus the requested priority fee
+func EstimateSolanaFee(ctx context.Context, client *rpc.Client, tx *solana.Transaction) uint64 {
+	if client != nil {
+		if msg, err := tx.Message.MarshalBinary(); err == nil {
+			result, err := client.GetFeeForMessage(ctx, base64.StdEncoding.EncodeToString(msg), rpc.CommitmentConfirmed)
+			if err == nil && result != nil && result.Value != nil {
+				return *result.Value
+			}
+		}
+	}
+
+	fee := uint64(tx.Message.Header.NumRequiredSignatures) * LamportsPerSignature
+	price, limit := computeBudget(tx)
+	if limit == 0 {
+		limit = defaultComputeUnitLimit(tx)
+	}
+	// Priority fee is micro-lamports per CU, rounded up
+	fee += (price*uint64(limit) + 999_999) / 1_000_000
+	return fee
+}
+
+// defaultComputeUnitLimit - 200k CU per non-compute-budget instruction (runtime default)
+func defaultComputeUnitLimit(tx *solana.Transaction) uint32 {
+	var n uint32
+	for _, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err == nil && programID.Equals(solana.ComputeBudget) {
+			continue
+		}
+		n++
+	}
+	return min(n*200_000, 1_400_000)
+}
@@ -0,0 +1,132 @@
+// Package receipt - Landed-transaction details (slot, block time, fees, compute) for submitted transactions.
+package receipt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/pricing"
+	"blockchain/txstatus"
+)
+
+// LamportsPerSignature - Solana base fee per signature
+const LamportsPerSignature uint64 = 5000
+
+// Compute budget instruction tags
+const (
+	computeBudgetSetLimit = 2
+	computeBudgetSetPrice = 3
+)
+
+// ErrNotLanded - Transaction not (yet) found at the requested commitment
+var ErrNotLanded = errors.New("transaction not landed yet")
+
+// Receipt - What a submission actually cost and where it landed
+type Receipt struct {
+	Signature            string          `json:"signature"`
+	Status               txstatus.Status `json:"status"`
+	Slot                 uint64          `json:"slot"`
+	BlockTime            *int64          `json:"block_time,omitempty"` // unix seconds
+	ConfirmedAt          *time.Time      `json:"confirmed_at,omitempty"`
+	Fee                  uint64          `json:"fee"`                          // total lamports paid
+	BaseFee              uint64          `json:"base_fee"`                     // signatures * 5000
+	PriorityFee          uint64          `json:"priority_fee"`                 // fee - base_fee
+	ComputeUnitPrice     uint64          `json:"compute_unit_price,omitempty"` // micro-lamports per CU
+	ComputeUnitLimit     uint32          `json:"compute_unit_limit,omitempty"`
+	ComputeUnitsConsumed *uint64         `json:"compute_units_consumed,omitempty"`
+	Error                *string         `json:"error,omitempty"`
+	FeeDetail            *pricing.Fee    `json:"fee_detail,omitempty"` // fee in SOL (and USD with WithPrices)
+}
+
+// WithPrices - Add the approximate USD value of the fee (nil-safe, source may be nil)
+func (r *Receipt) WithPrices(ctx context.Context, source pricing.Source) *Receipt {
+	if r != nil {
+		r.FeeDetail = pricing.FormatLamports(ctx, source, r.Fee)
+	}
+	return r
+}
+
+// FetchSolana - Build receipt from getTransaction at confirmed commitment
+func FetchSolana(ctx context.Context, client *rpc.Client, signature string) (*Receipt, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	maxVersion := uint64(0)
+	result, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil, ErrNotLanded
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if result == nil {
+		return nil, ErrNotLanded
+	}
+	return FromSolanaTransaction(signature, result), nil
+}
+
+// FromSolanaTransaction - Build receipt from an already fetched getTransaction result
+func FromSolanaTransaction(signature string, result *rpc.GetTransactionResult) *Receipt {
+	r := &Receipt{
+		Signature: signature,
+		Slot:      result.Slot,
+		Status:    txstatus.Confirmed,
+	}
+	if result.BlockTime != nil {
+		blockTime := int64(*result.BlockTime)
+		confirmedAt := time.Unix(blockTime, 0).UTC()
+		r.BlockTime = &blockTime
+		r.ConfirmedAt = &confirmedAt
+	}
+	if result.Meta != nil {
+		r.Fee = result.Meta.Fee
+		r.ComputeUnitsConsumed = result.Meta.ComputeUnitsConsumed
+		r.Status = txstatus.FromSolana(rpc.ConfirmationStatusConfirmed, result.Meta.Err)
+		if result.Meta.Err != nil {
+			errMsg := fmt.Sprintf("%v", result.Meta.Err)
+			r.Error = &errMsg
+		}
+	}
+
+	if tx, err := result.Transaction.GetTransaction(); err == nil && tx != nil {
+		r.BaseFee = uint64(tx.Message.Header.NumRequiredSignatures) * LamportsPerSignature
+		r.ComputeUnitPrice, r.ComputeUnitLimit = computeBudget(tx)
+	}
+	if r.Fee > r.BaseFee {
+		r.PriorityFee = r.Fee - r.BaseFee
+	}
+	r.FeeDetail = pricing.FormatLamports(context.Background(), nil, r.Fee)
+	return r
+}
+
+// computeBudget - Requested CU price/limit from ComputeBudget instructions
+func computeBudget(tx *solana.Transaction) (price uint64, limit uint32) {
+	for _, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil || !programID.Equals(solana.ComputeBudget) || len(ix.Data) == 0 {
+			continue
+		}
+		switch ix.Data[0] {
+		case computeBudgetSetLimit:
+			if len(ix.Data) >= 5 {
+				limit = binary.LittleEndian.Uint32(ix.Data[1:5])
+			}
+		case computeBudgetSetPrice:
+			if len(ix.Data) >= 9 {
+				price = binary.LittleEndian.Uint64(ix.Data[1:9])
+			}
+		}
+	}
+	return price, limit
+}
@@ -0,0 +1,393 @@
+// Package rpcpool - Solana JSON-RPC endpoints split into a read pool (accounts, balances, statuses)
+// and a write pool (submissions), each with its own rate limit and health checks.
+//
+// Router implements rpc.JSONRPCClient, so a *rpc.Client built on it (Router.Client) routes every
+// call by method name and the code using it doesn't change.
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"golang.org/x/time/rate"
+
+	"blockchain/backpressure"
+	"blockchain/metrics"
+)
+
+// DefaultHealthInterval - Time between getHealth rounds of Run
+const DefaultHealthInterval = 15 * time.Second
+
+// SaturationWait - Rate limiter queueing delay at which a pool reports saturation (Pressure)
+const SaturationWait = 2 * time.Second
+
+// Role - Traffic served by a pool
+type Role string
+
+const (
+	Read  Role = "read"  // Everything that isn't a write
+	Write Role = "write" // WriteMethods
+)
+
+// WriteMethods - Methods routed to the write pool. simulateTransaction goes with sendTransaction so
+// preflight sees the same node state as the submission that follows it.
+var WriteMethods = []string{"sendTransaction", "simulateTransaction", "requestAirdrop"}
+
+// ErrNoEndpoints - Pool configured without any URL
+var ErrNoEndpoints = errors.New("rpc pool has no endpoints")
+
+// PoolConfig - Endpoints of one pool and the rate limit each of them gets
+type PoolConfig struct {
+	URLs []string
+	RPS  float64 // Requests per second per endpoint (0 = unlimited)
+}
+
+// Config - Read and write pools
+type Config struct {
+	Read  PoolConfig
+	Write PoolConfig
+}
+
+// ParseConfig - Pools from env, e.g. readURLs "https://a,https://b", readRPS "50"; a pool without URLs
+// uses defaultURL. nil when neither pool is configured (defaultURL serves everything, as before).
+func ParseConfig(defaultURL, readURLs, readRPS, writeURLs, writeRPS string) (*Config, error) {
+	if strings.TrimSpace(readURLs) == "" && strings.TrimSpace(writeURLs) == "" {
+		return nil, nil
+	}
+	read, err := parsePoolConfig(Read, defaultURL, readURLs, readRPS)
+	if err != nil {
+		return nil, err
+	}
+	write, err := parsePoolConfig(Write, defaultURL, writeURLs, writeRPS)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Read: read, Write: write}, nil
+}
+
+func parsePoolConfig(role Role, defaultURL, urls, rps string) (PoolConfig, error) {
+	var config PoolConfig
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			config.URLs = append(config.URLs, u)
+		}
+	}
+	if len(config.URLs) == 0 && defaultURL != "" {
+		config.URLs = []string{defaultURL}
+	}
+	if rps = strings.TrimSpace(rps); rps != "" {
+		n, err := strconv.ParseFloat(rps, 64)
+		if err != nil || n < 0 {
+			return config, fmt.Errorf("invalid %s rps %q", role, rps)
+		}
+		config.RPS = n
+	}
+	return config, nil
+}
+
+// EndpointStatus - Health of one endpoint (URL without path or query, they often carry API keys)
+type EndpointStatus struct {
+	Role      Role      `json:"role"`
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	LastError string    `json:"last_error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type endpoint struct {
+	role    Role
+	url     string
+	client  jsonrpc.RPCClient
+	limiter *rate.Limiter // nil = unlimited
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lastErr   string
+	checkedAt time.Time
+}
+
+func (e *endpoint) record(err error) {
+	e.healthy.Store(err == nil)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastErr = ""
+	if err != nil {
+		e.lastErr = strings.ReplaceAll(err.Error(), e.url, redact(e.url))
+	}
+	e.checkedAt = time.Now()
+}
+
+func (e *endpoint) status() EndpointStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EndpointStatus{Role: e.role, URL: redact(e.url), Healthy: e.healthy.Load(), LastError: e.lastErr, CheckedAt: e.checkedAt}
+}
+
+// pool - Endpoints of one role, used round-robin
+type pool struct {
+	role      Role
+	endpoints []*endpoint
+	next      atomic.Uint64
+	waiting   atomic.Int64 // Calls queued on a rate limiter
+}
+
+func newPool(role Role, config PoolConfig) (*pool, error) {
+	if len(config.URLs) == 0 {
+		return nil, fmt.Errorf("%s: %w", role, ErrNoEndpoints)
+	}
+	p := &pool{role: role}
+	for _, u := range config.URLs {
+		e := &endpoint{role: role, url: u, client: jsonrpc.NewClient(u)}
+		if config.RPS > 0 {
+			e.limiter = rate.NewLimiter(rate.Limit(config.RPS), max(1, int(config.RPS)))
+		}
+		e.healthy.Store(true) // Until the first check says otherwise
+		p.endpoints = append(p.endpoints, e)
+	}
+	return p, nil
+}
+
+// order - Healthy endpoints starting at the round-robin position, unhealthy ones last as a fallback
+func (p *pool) order() []*endpoint {
+	start := int(p.next.Add(1) % uint64(len(p.endpoints)))
+	healthy := make([]*endpoint, 0, len(p.endpoints))
+	var unhealthy []*endpoint
+	for i := range p.endpoints {
+		e := p.endpoints[(start+i)%len(p.endpoints)]
+		if e.healthy.Load() {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// do - Run call on the first endpoint that answers. A JSON-RPC error means the node answered and is
+// returned as is; transport and HTTP errors move on to the next endpoint.
+func (p *pool) do(ctx context.Context, call func(jsonrpc.RPCClient) error) error {
+	metrics.Counter("rpc_requests_" + string(p.role)).Add(1)
+	var err error
+	for i, e := range p.order() {
+		if i > 0 {
+			metrics.Counter("rpc_failovers_" + string(p.role)).Add(1)
+		}
+		if e.limiter != nil {
+			p.waiting.Add(1)
+			err := e.limiter.Wait(ctx)
+			p.waiting.Add(-1)
+			if err != nil {
+				return err
+			}
+		}
+		err = call(e.client)
+		var rpcErr *jsonrpc.RPCError
+		if err == nil || errors.As(err, &rpcErr) || ctx.Err() != nil {
+			return err
+		}
+		var httpErr *jsonrpc.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.Code != http.StatusTooManyRequests {
+			// Rate limited isn't unhealthy; anything else is until the next health check
+			e.record(err)
+		}
+	}
+	return err
+}
+
+// pressure - Delay a new call would wait for the least loaded endpoint's limiter (healthy ones when
+// there are any): reservations drive the tokens negative, each missing token is 1/RPS of queueing
+func (p *pool) pressure() backpressure.Pressure {
+	now := time.Now()
+	wait := time.Duration(-1)
+	capacity := 0
+	for _, healthy := range []bool{true, false} {
+		for _, e := range p.endpoints {
+			if e.healthy.Load() != healthy {
+				continue
+			}
+			if e.limiter == nil {
+				wait, capacity = 0, 0 // Unlimited endpoint, never saturated
+				break
+			}
+			capacity += int(float64(e.limiter.Limit()) * SaturationWait.Seconds())
+			delay := time.Duration(0)
+			if tokens := e.limiter.TokensAt(now); tokens < 1 {
+				delay = time.Duration((1 - tokens) / float64(e.limiter.Limit()) * float64(time.Second))
+			}
+			if wait < 0 || delay < wait {
+				wait = delay
+			}
+		}
+		if wait >= 0 {
+			break
+		}
+	}
+	depth := int(p.waiting.Load())
+	if capacity == 0 {
+		return backpressure.Pressure{Source: "rpc_" + string(p.role), Depth: depth}
+	}
+	return backpressure.Pressure{
+		Source:     "rpc_" + string(p.role),
+		Depth:      depth,
+		Capacity:   capacity,
+		Saturated:  wait >= SaturationWait,
+		RetryAfter: wait.Seconds(),
+	}
+}
+
+// check - getHealth on every endpoint; error when none is healthy
+func (p *pool) check(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out any
+			e.record(e.client.CallForInto(ctx, &out, "getHealth", nil))
+		}()
+	}
+	wg.Wait()
+	for _, e := range p.endpoints {
+		if e.healthy.Load() {
+			return nil
+		}
+	}
+	return fmt.Errorf("no healthy %s rpc endpoint", p.role)
+}
+
+// Router - rpc.JSONRPCClient sending WriteMethods to the write pool and everything else to the read pool
+type Router struct {
+	read  *pool
+	write *pool
+	// writeMethods - WriteMethods as a set
+	writeMethods map[string]bool
+}
+
+var _ rpc.JSONRPCClient = (*Router)(nil)
+
+// New - Router for the configured pools
+func New(config Config) (*Router, error) {
+	read, err := newPool(Read, config.Read)
+	if err != nil {
+		return nil, err
+	}
+	write, err := newPool(Write, config.Write)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{read: read, write: write, writeMethods: make(map[string]bool, len(WriteMethods))}
+	for _, method := range WriteMethods {
+		r.writeMethods[method] = true
+	}
+	metrics.Func("rpc_pools", func() any { return r.Status() })
+	return r, nil
+}
+
+// Client - Solana RPC client routed through the pools
+func (r *Router) Client() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(r)
+}
+
+func (r *Router) route(method string) *pool {
+	if r.writeMethods[method] {
+		return r.write
+	}
+	return r.read
+}
+
+// CallForInto - Implements rpc.JSONRPCClient
+func (r *Router) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return r.route(method).do(ctx, func(c jsonrpc.RPCClient) error {
+		return c.CallForInto(ctx, out, method, params)
+	})
+}
+
+// CallWithCallback - Implements rpc.JSONRPCClient
+func (r *Router) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return r.route(method).do(ctx, func(c jsonrpc.RPCClient) error {
+		return c.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+// CallBatch - Implements rpc.JSONRPCClient; a batch containing any write goes to the write pool
+func (r *Router) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	target := r.read
+	for _, request := range requests {
+		if r.writeMethods[request.Method] {
+			target = r.write
+			break
+		}
+	}
+	var responses jsonrpc.RPCResponses
+	err := target.do(ctx, func(c jsonrpc.RPCClient) error {
+		var err error
+		responses, err = c.CallBatch(ctx, requests)
+		return err
+	})
+	return responses, err
+}
+
+// Check - Health check both pools now; error when one of them has no healthy endpoint
+func (r *Router) Check(ctx context.Context) error {
+	return errors.Join(r.read.check(ctx), r.write.check(ctx))
+}
+
+// Run - Health check every interval (DefaultHealthInterval when <= 0) until ctx is cancelled;
+// unhealthy endpoints only get traffic when their whole pool is down
+func (r *Router) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Check(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  rpc pools: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pressure - Rate limiter saturation of the role's pool, for backpressure.Gate
+func (r *Router) Pressure(role Role) backpressure.Source {
+	if role == Write {
+		return backpressure.SourceFunc(r.write.pressure)
+	}
+	return backpressure.SourceFunc(r.read.pressure)
+}
+
+// Status - Health of every endpoint, read pool first
+func (r *Router) Status() []EndpointStatus {
+	statuses := make([]EndpointStatus, 0, len(r.read.endpoints)+len(r.write.endpoints))
+	for _, p := range []*pool{r.read, r.write} {
+		for _, e := range p.endpoints {
+			statuses = append(statuses, e.status())
+		}
+	}
+	return statuses
+}
+
+// redact - scheme://host of an endpoint URL
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,311 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"blockchain/chain"
+	"blockchain/chainbnb"
+	"blockchain/dto"
+	"blockchain/pricing"
+	"blockchain/txencoding"
+	"blockchain/txstatus"
+)
+
+// Sandbox BNB defaults (amounts in wei, kept within uint64)
+const (
+	BNBDefaultBalance uint64 = 10_000_000_000_000_000_000 // 10 BNB per unseen address
+	BNBGasLimit       uint64 = 21000
+	BNBGasPrice       uint64 = 1_000_000_000 // 1 gwei
+)
+
+// BNBChain - In-memory stand-in for chainbnb.BNBChain with the same request/response shapes
+type BNBChain struct {
+	ledger *Ledger
+}
+
+// NewBNBChain - Sandbox BNB chain
+func NewBNBChain(seed string) *BNBChain {
+	return &BNBChain{ledger: NewLedger("bnb:"+seed, BNBDefaultBalance, BNBGasLimit*BNBGasPrice)}
+}
+
+// Ledger - Underlying in-memory ledger
+func (b *BNBChain) Ledger() *Ledger {
+	return b.ledger
+}
+
+// HealthCheck - Always healthy
+func (b *BNBChain) HealthCheck() error {
+	return nil
+}
+
+// GetExplorerURL - Sandbox transactions are not on any explorer
+func (b *BNBChain) GetExplorerURL(txHash string) string {
+	return "sandbox://bnb/tx/" + txHash
+}
+
+func (b *BNBChain) encodeHash(sig []byte) string {
+	return "0x" + hex.EncodeToString(sig[:32])
+}
+
+// CreateTransaction - Build sandbox transfer
+func (b *BNBChain) CreateTransaction(req chainbnb.TransactionRequest) (*chainbnb.CreateTransactionResponse, error) {
+	if !common.IsHexAddress(req.FromAddress) {
+		return nil, fmt.Errorf("invalid from address")
+	}
+	if !common.IsHexAddress(req.ToAddress) {
+		return nil, fmt.Errorf("invalid to address")
+	}
+	amount, err := strconv.ParseUint(req.Amount, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Hex)
+	if err != nil {
+		return nil, err
+	}
+	id := b.ledger.newID("bnb")
+	return &chainbnb.CreateTransactionResponse{
+		CreateTransactionResponse: dto.CreateTransactionResponse{
+			TransactionID: id,
+			Encoding:      encoding.String(),
+			UnsignedTransaction: encodeTxAs(encoding, fakeTx{
+				Sandbox: true,
+				ID:      id,
+				Kind:    "transfer",
+				From:    common.HexToAddress(req.FromAddress).Hex(),
+				To:      common.HexToAddress(req.ToAddress).Hex(),
+				Amount:  amount,
+			}),
+			EstimatedFee: bnbFee(),
+		},
+		Nonce:    b.ledger.Slot(),
+		GasPrice: strconv.FormatUint(BNBGasPrice, 10),
+		GasLimit: BNBGasLimit,
+	}, nil
+}
+
+// SendSignedTransaction - Apply transfer instantly (confirmed)
+func (b *BNBChain) SendSignedTransaction(req chainbnb.SignedTransactionRequest) (*chainbnb.TransactionResult, error) {
+	tx, err := decodeTx(req.SignedTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signed transaction: %w", err)
+	}
+	rec, err := b.ledger.Submit(tx, b.encodeHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &chainbnb.TransactionResult{
+		TransactionResult: dto.TransactionResult{
+			TransactionID: req.TransactionID,
+			Success:       rec.status.IsSuccess(),
+			Status:        rec.status,
+			Message:       "Transaction sent successfully",
+			ExplorerURL:   b.GetExplorerURL(rec.sig),
+		},
+		TxHash: rec.sig,
+	}
+	if rec.err != "" {
+		result.Message = rec.err
+	}
+	return result, nil
+}
+
+// GetTransactionStatus - Status of sandbox transaction
+func (b *BNBChain) GetTransactionStatus(txHash string) (*chainbnb.TransactionStatusResponse, error) {
+	response := &chainbnb.TransactionStatusResponse{
+		TransactionStatus: dto.TransactionStatus{Status: txstatus.NotFound, ExplorerURL: b.GetExplorerURL(txHash)},
+		TxHash:            txHash,
+	}
+	rec, ok := b.ledger.Get(txHash)
+	if !ok {
+		return response, nil
+	}
+	blockTime := uint64(rec.createdAt.Unix())
+	response.Status = rec.status
+	response.BlockNumber = rec.slot
+	response.BlockTime = &blockTime
+	response.GasUsed = BNBGasLimit
+	response.Fee = bnbFee()
+	response.Confirmations = b.ledger.Slot() - rec.slot + 1
+	if rec.err != "" {
+		response.Error = &rec.err
+	}
+	return response, nil
+}
+
+// bnbFee - Fixed sandbox fee (gas limit * gas price)
+func bnbFee() *pricing.Fee {
+	return pricing.FormatFee(context.Background(), nil, chain.BSC, new(big.Int).SetUint64(BNBGasLimit*BNBGasPrice))
+}
+
+// GetTransactionHistory - Sandbox history (no database needed)
+func (b *BNBChain) GetTransactionHistory(address string, limit int) ([]chainbnb.TransactionHistory, error) {
+	recs := b.ledger.History(common.HexToAddress(address).Hex(), limit)
+	histories := make([]chainbnb.TransactionHistory, 0, len(recs))
+	for i, rec := range recs {
+		confirmedAt := rec.createdAt
+		histories = append(histories, chainbnb.TransactionHistory{
+			ID:            uint(i + 1),
+			TransactionID: rec.tx.ID,
+			FromAddress:   rec.tx.From,
+			ToAddress:     rec.tx.To,
+			Amount:        strconv.FormatUint(rec.tx.Amount, 10),
+			TxHash:        rec.sig,
+			Status:        rec.status,
+			GasUsed:       BNBGasLimit,
+			GasPrice:      strconv.FormatUint(BNBGasPrice, 10),
+			ErrorMessage:  rec.err,
+			CreatedAt:     rec.createdAt,
+			UpdatedAt:     rec.createdAt,
+			ConfirmedAt:   &confirmedAt,
+		})
+	}
+	return histories, nil
+}
+
+// RunCanary - Sandbox self-transfer (always succeeds)
+func (b *BNBChain) RunCanary(ctx context.Context) (*chainbnb.CanaryResult, error) {
+	start := time.Now()
+	wallet := "0x000000000000000000000000000000000000cAFE"
+	created, _ := b.CreateTransaction(chainbnb.TransactionRequest{
+		TransferRequest: dto.TransferRequest{FromAddress: wallet, ToAddress: wallet},
+		Amount:          "0",
+	})
+	signed, _ := signFakeTx(created.UnsignedTransaction, encodeTxHex)
+	result := &chainbnb.CanaryResult{
+		Chain:     chain.BSC,
+		Network:   chain.Localnet,
+		Wallet:    wallet,
+		Step:      "done",
+		StartedAt: start,
+	}
+	sent, err := b.SendSignedTransaction(chainbnb.SignedTransactionRequest{TransactionID: created.TransactionID, SignedTransaction: signed})
+	if err != nil {
+		result.Step = "send"
+		result.Error = err.Error()
+	} else {
+		result.Success = sent.Success
+		result.TxHash = sent.TxHash
+		result.Status = sent.Status
+		result.ExplorerURL = sent.ExplorerURL
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// HandleCreateTransaction - POST /api/v1/bnb/transaction/create
+func (b *BNBChain) HandleCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req chainbnb.TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.FromAddress == "" || req.ToAddress == "" || req.Amount == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	response, err := b.CreateTransaction(req)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, response, http.StatusOK)
+}
+
+// HandleSignTransaction - Marks the sandbox transaction signed (private key ignored)
+func (b *BNBChain) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	signed, err := signFakeTx(req.UnsignedTransaction, encodeTxHex)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respondJSON(w, map[string]string{
+		"signed_transaction": signed,
+		"warning":            "⚠️ SANDBOX - transaction is not a real BSC transaction",
+	}, http.StatusOK)
+}
+
+// HandleSendTransaction - POST /api/v1/bnb/transaction/send
+func (b *BNBChain) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req chainbnb.SignedTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SignedTransaction == "" || req.TransactionID == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	result, err := b.SendSignedTransaction(req)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetTransactionStatus - GET /api/v1/bnb/transaction/status?tx_hash=xxx
+func (b *BNBChain) HandleGetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	txHash := r.URL.Query().Get("tx_hash")
+	if txHash == "" {
+		respondError(w, "tx_hash parameter required", http.StatusBadRequest)
+		return
+	}
+	result, _ := b.GetTransactionStatus(txHash)
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetTransactionHistory - GET /api/v1/bnb/transaction/history?address=xxx&limit=10
+func (b *BNBChain) HandleGetTransactionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		respondError(w, "address parameter required", http.StatusBadRequest)
+		return
+	}
+	histories, _ := b.GetTransactionHistory(address, historyLimit(r))
+	respondJSON(w, histories, http.StatusOK)
+}
+
+// HandleCanary - POST /api/v1/bnb/admin/canary
+func (b *BNBChain) HandleCanary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, _ := b.RunCanary(r.Context())
+	respondJSON(w, result, http.StatusOK)
+}
@@ -0,0 +1,407 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/dust"
+	"blockchain/solprogram"
+	"blockchain/txencoding"
+)
+
+// Envelope errors, worded like the on-chain program
+var (
+	ErrEnvelopeNotFound = errors.New("envelope not found")
+	ErrExpired          = errors.New("Envelope has expired")
+	ErrNotExpired       = errors.New("Envelope not expired yet")
+	ErrAlreadyClaimed   = errors.New("Already claimed by this address")
+	ErrNotAllowed       = errors.New("Not allowed to claim this envelope")
+	ErrQuotaFull        = errors.New("Quota full - all claims taken")
+	ErrNothingToRefund  = errors.New("Nothing to refund")
+	ErrRefundTooLarge   = errors.New("Refund amount exceeds remaining balance")
+	ErrInvalidOwner     = errors.New("Invalid owner")
+)
+
+// Envelope - In-memory envelope mirroring EnvelopeAccount
+type Envelope struct {
+	Owner          string                         `json:"owner"`
+	EnvelopeID     uint64                         `json:"envelope_id"`
+	EnvelopeType   solprogram.EnvelopeTypeRequest `json:"envelope_type"`
+	AllowedAddress string                         `json:"allowed_address,omitempty"`
+	TotalUsers     uint64                         `json:"total_users"`
+	Amount         uint64                         `json:"amount"`
+	TotalClaimed   uint64                         `json:"total_claimed"`
+	Expiry         time.Time                      `json:"expiry"`
+	ClaimedUsers   []string                       `json:"claimed_users"`
+	Refunded       bool                           `json:"refunded"`
+}
+
+// Envelopes - In-memory stand-in for solprogram.Client (SOL envelope program)
+type Envelopes struct {
+	chain *SolChain
+
+	mu        sync.Mutex
+	lastID    map[string]uint64
+	envelopes map[string]*Envelope // owner:id
+}
+
+// NewEnvelopes - Sandbox envelope API sharing balances with chain
+func NewEnvelopes(chain *SolChain) *Envelopes {
+	return &Envelopes{
+		chain:     chain,
+		lastID:    make(map[string]uint64),
+		envelopes: make(map[string]*Envelope),
+	}
+}
+
+func envelopeKey(owner string, id uint64) string {
+	return fmt.Sprintf("%s:%d", owner, id)
+}
+
+// Get - Envelope by owner and id
+func (e *Envelopes) Get(owner string, id uint64) (*Envelope, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	env, ok := e.envelopes[envelopeKey(owner, id)]
+	if !ok {
+		return nil, false
+	}
+	cp := *env
+	cp.ClaimedUsers = append([]string(nil), env.ClaimedUsers...)
+	return &cp, true
+}
+
+// claimAmount - Same split rules as the program; GroupRandom uses the deterministic tx digest instead of clock
+func claimAmount(env *Envelope, claimer string, digest []byte) (uint64, error) {
+	claimed := uint64(len(env.ClaimedUsers))
+	switch env.EnvelopeType {
+	case solprogram.RequestTypeDirectFixed:
+		if claimer != env.AllowedAddress {
+			return 0, ErrNotAllowed
+		}
+		return env.Amount, nil
+	case solprogram.RequestTypeGroupFixed:
+		if claimed >= env.TotalUsers {
+			return 0, ErrQuotaFull
+		}
+		return env.Amount / env.TotalUsers, nil
+	default:
+		if claimed >= env.TotalUsers {
+			return 0, ErrQuotaFull
+		}
+		remainingUsers := env.TotalUsers - claimed
+		remaining := env.Amount - env.TotalClaimed
+		if remainingUsers == 1 {
+			return remaining, nil
+		}
+		maxPerUser := remaining / remainingUsers
+		if maxPerUser == 0 {
+			return 0, nil
+		}
+		seed := uint64(digest[0])<<8 | uint64(digest[1])
+		return min(seed%maxPerUser+1, remaining), nil
+	}
+}
+
+// apply - Execute envelope instruction; called by Ledger.Submit under the ledger lock
+func (e *Envelopes) apply(tx *fakeTx) func() error {
+	return func() error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		switch tx.Kind {
+		case "envelope_create":
+			var env Envelope
+			if err := json.Unmarshal(tx.Payload, &env); err != nil {
+				return ErrInvalidTransaction
+			}
+			if env.EnvelopeID != e.lastID[tx.From]+1 {
+				return fmt.Errorf("envelope id %d already used, request a new transaction", env.EnvelopeID)
+			}
+			e.lastID[tx.From] = env.EnvelopeID
+			e.envelopes[envelopeKey(tx.From, env.EnvelopeID)] = &env
+			return nil
+
+		case "envelope_claim", "envelope_refund":
+			var ref struct {
+				Owner      string `json:"owner"`
+				EnvelopeID uint64 `json:"envelope_id"`
+				Amount     uint64 `json:"amount,omitempty"` // Partial refund
+			}
+			if err := json.Unmarshal(tx.Payload, &ref); err != nil {
+				return ErrInvalidTransaction
+			}
+			env, ok := e.envelopes[envelopeKey(ref.Owner, ref.EnvelopeID)]
+			if !ok {
+				return ErrEnvelopeNotFound
+			}
+			if tx.Kind == "envelope_refund" {
+				return e.refund(env, tx.From, ref.Amount)
+			}
+			return e.claim(env, tx)
+		}
+		return ErrInvalidTransaction
+	}
+}
+
+func (e *Envelopes) claim(env *Envelope, tx *fakeTx) error {
+	if !time.Now().Before(env.Expiry) {
+		return ErrExpired
+	}
+	for _, u := range env.ClaimedUsers {
+		if u == tx.From {
+			return ErrAlreadyClaimed
+		}
+	}
+	amount, err := claimAmount(env, tx.From, e.chain.ledger.signature(tx.ID))
+	if err != nil {
+		return err
+	}
+	env.TotalClaimed += amount
+	env.ClaimedUsers = append(env.ClaimedUsers, tx.From)
+	e.chain.ledger.credit(tx.From, amount)
+	return nil
+}
+
+// refund - Refund amount (0 = everything remaining); Refunded is set once nothing is left
+func (e *Envelopes) refund(env *Envelope, owner string, amount uint64) error {
+	if owner != env.Owner {
+		return ErrInvalidOwner
+	}
+	if time.Now().Before(env.Expiry) {
+		return ErrNotExpired
+	}
+	remaining := env.Amount - env.TotalClaimed
+	if remaining == 0 || env.Refunded {
+		return ErrNothingToRefund
+	}
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return ErrRefundTooLarge
+	}
+	env.TotalClaimed += amount
+	env.Refunded = env.TotalClaimed == env.Amount
+	e.chain.ledger.credit(owner, amount)
+	return nil
+}
+
+func (e *Envelopes) buildTx(encoding txencoding.Encoding, kind, payer string, amount uint64, payload interface{}) string {
+	raw, _ := json.Marshal(payload)
+	return encodeTxAs(encoding, fakeTx{
+		Sandbox: true,
+		ID:      e.chain.ledger.newID(kind),
+		Kind:    kind,
+		From:    payer,
+		Amount:  amount,
+		Payload: raw,
+	})
+}
+
+func writeResponse(w http.ResponseWriter, resp solprogram.Response) {
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleCreateEnvelope - POST /api/create-envelope
+func (e *Envelopes) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req solprogram.CreateEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	switch {
+	case req.UserAddress == "":
+		writeResponse(w, solprogram.Response{Success: false, Message: "user_address is required"})
+		return
+	case req.EnvelopeType == "":
+		writeResponse(w, solprogram.Response{Success: false, Message: "envelope_type is required"})
+		return
+	case req.TotalAmount == 0:
+		writeResponse(w, solprogram.Response{Success: false, Message: "total_amount must be greater than 0"})
+		return
+	case req.TotalUsers == 0:
+		writeResponse(w, solprogram.Response{Success: false, Message: "total_users must be greater than 0"})
+		return
+	}
+
+	env := Envelope{
+		Owner:        req.UserAddress,
+		EnvelopeType: req.EnvelopeType,
+		TotalUsers:   req.TotalUsers,
+		Amount:       req.TotalAmount,
+		Expiry:       time.Now().Add(time.Duration(req.ExpiryHours) * time.Hour),
+	}
+	// Same per-type rules as the real API
+	envelopeType, err := req.EnvelopeType.EnvelopeType()
+	if err == nil && envelopeType == solprogram.EnvelopeTypeCustomSplit {
+		err = fmt.Errorf("%s: envelope_type is not supported by the SOL program", req.EnvelopeType)
+	}
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error(), Code: solprogram.InvalidEnvelopeParams})
+		return
+	}
+	var allowedAddress *solana.PublicKey
+	if req.AllowedAddress != nil && *req.AllowedAddress != "" {
+		address, err := solana.PublicKeyFromBase58(*req.AllowedAddress)
+		if err != nil {
+			writeResponse(w, solprogram.Response{Success: false, Message: fmt.Sprintf("%s: allowed_address is not a valid address: %v", req.EnvelopeType, err), Code: solprogram.InvalidEnvelopeParams})
+			return
+		}
+		allowedAddress = &address
+		env.AllowedAddress = *req.AllowedAddress
+	}
+	if err := solprogram.SOLEnvelopeRules().Validate(envelopeType, req.TotalAmount, req.TotalUsers, allowedAddress); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error(), Code: solprogram.InvalidEnvelopeParams})
+		return
+	}
+	share := solprogram.MinShare(envelopeType, req.TotalAmount, req.TotalUsers, nil)
+	if err := dust.Default().CheckUint64(chain.Solana, chain.Solana.Symbol(), "per-user share", share); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error(), Code: dust.BelowMinimum})
+		return
+	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	e.mu.Lock()
+	env.EnvelopeID = e.lastID[req.UserAddress] + 1
+	e.mu.Unlock()
+
+	writeResponse(w, solprogram.Response{
+		Success: true,
+		Message: fmt.Sprintf("%s envelope #%d created (%.9f SOL, %d users) [sandbox]",
+			req.EnvelopeType, env.EnvelopeID, float64(req.TotalAmount)/1e9, req.TotalUsers),
+		UnsignedTx: e.buildTx(encoding, "envelope_create", req.UserAddress, req.TotalAmount, env),
+		Encoding:   encoding.String(),
+		EnvelopeID: env.EnvelopeID,
+	})
+}
+
+// HandleClaimEnvelope - POST /api/claim-envelope
+func (e *Envelopes) HandleClaimEnvelope(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req solprogram.ClaimEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+	if _, ok := e.Get(req.OwnerAddress, req.EnvelopeID); !ok {
+		writeResponse(w, solprogram.Response{Success: false, Message: ErrEnvelopeNotFound.Error()})
+		return
+	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+	writeResponse(w, solprogram.Response{
+		Success:    true,
+		Message:    fmt.Sprintf("Claim envelope #%d transaction created. Sign on client side.", req.EnvelopeID),
+		UnsignedTx: e.buildTx(encoding, "envelope_claim", req.ClaimerAddress, 0, map[string]interface{}{"owner": req.OwnerAddress, "envelope_id": req.EnvelopeID}),
+		Encoding:   encoding.String(),
+	})
+}
+
+// HandleRefundEnvelope - POST /api/refund-envelope
+func (e *Envelopes) HandleRefundEnvelope(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req solprogram.RefundEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+	env, ok := e.Get(req.OwnerAddress, req.EnvelopeID)
+	if !ok {
+		writeResponse(w, solprogram.Response{Success: false, Message: ErrEnvelopeNotFound.Error()})
+		return
+	}
+	if req.Amount > env.Amount-env.TotalClaimed {
+		writeResponse(w, solprogram.Response{Success: false, Message: ErrRefundTooLarge.Error()})
+		return
+	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+	payload := map[string]interface{}{"owner": req.OwnerAddress, "envelope_id": req.EnvelopeID}
+	if req.Amount > 0 {
+		payload["amount"] = req.Amount
+	}
+	writeResponse(w, solprogram.Response{
+		Success:    true,
+		Message:    fmt.Sprintf("Refund envelope #%d transaction created. Sign on client side.", req.EnvelopeID),
+		UnsignedTx: e.buildTx(encoding, "envelope_refund", req.OwnerAddress, 0, payload),
+		Encoding:   encoding.String(),
+	})
+}
+
+// HandleSignTransaction - Marks the sandbox transaction signed (private key ignored)
+func (e *Envelopes) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req solprogram.SignTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(solprogram.SignTransactionResponse{Success: false, Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	signed, err := signFakeTx(req.UnsignedTransaction, encodeTx)
+	if err != nil {
+		json.NewEncoder(w).Encode(solprogram.SignTransactionResponse{Success: false, Message: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(solprogram.SignTransactionResponse{
+		Success:           true,
+		Message:           "⚠️ SANDBOX - transaction is not a real Solana transaction",
+		SignedTransaction: signed,
+	})
+}
+
+// HandleSendTransaction - POST /api/send-transaction
+func (e *Envelopes) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req solprogram.SendTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	tx, err := decodeTx(req.SignedTransaction)
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	var apply func() error
+	if tx.Kind != "transfer" {
+		apply = e.apply(tx)
+	}
+	rec, err := e.chain.ledger.Submit(tx, e.chain.encodeSig, apply)
+	if err != nil {
+		writeResponse(w, solprogram.Response{Success: false, Message: err.Error()})
+		return
+	}
+	if rec.err != "" {
+		writeResponse(w, solprogram.Response{Success: false, Message: rec.err, TransactionSig: rec.sig})
+		return
+	}
+	writeResponse(w, solprogram.Response{
+		Success:        true,
+		Message:        "Transaction sent successfully",
+		TransactionSig: rec.sig,
+	})
+}
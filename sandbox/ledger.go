@@ -0,0 +1,222 @@
+package sandbox
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"blockchain/txencoding"
+	"blockchain/txstatus"
+)
+
+var (
+	ErrInvalidTransaction = errors.New("sandbox: invalid transaction")
+	ErrNotSigned          = errors.New("sandbox: transaction is not signed")
+	ErrAlreadySubmitted   = errors.New("sandbox: transaction already submitted")
+	ErrInsufficientFunds  = errors.New("sandbox: insufficient funds")
+)
+
+// fakeTx - Wire format of sandbox transactions (base64 JSON instead of real chain encoding)
+type fakeTx struct {
+	Sandbox  bool            `json:"sandbox"`
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"` // transfer, envelope_create, envelope_claim, envelope_refund
+	From     string          `json:"from"`
+	To       string          `json:"to,omitempty"`
+	Amount   uint64          `json:"amount"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	SignedBy string          `json:"signed_by,omitempty"`
+}
+
+func encodeTx(tx fakeTx) string {
+	return encodeTxAs(txencoding.Base64, tx)
+}
+
+// encodeTxHex - EVM flavour (chainbnb sends hex)
+func encodeTxHex(tx fakeTx) string {
+	return encodeTxAs(txencoding.Hex, tx)
+}
+
+// encodeTxAs - Encoding requested by the client (encoding parameter of create)
+func encodeTxAs(e txencoding.Encoding, tx fakeTx) string {
+	b, _ := json.Marshal(tx)
+	return e.Encode(b)
+}
+
+// decodeTx - Accepts base64, base58 or hex (with or without 0x). Short JSON payloads can fit
+// more than one alphabet, so every encoding is tried, the detected one first.
+func decodeTx(s string) (*fakeTx, error) {
+	detected := txencoding.Detect(s)
+	var err error
+	for _, e := range append([]txencoding.Encoding{detected}, txencoding.All...) {
+		var b []byte
+		if b, err = e.Decode(s); err != nil {
+			continue
+		}
+		var tx fakeTx
+		if json.Unmarshal(b, &tx) == nil && tx.Sandbox {
+			return &tx, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+	return nil, ErrInvalidTransaction
+}
+
+// record - Submitted transaction
+type record struct {
+	tx        fakeTx
+	sig       string
+	status    txstatus.Status
+	err       string
+	slot      uint64
+	createdAt time.Time
+}
+
+// Ledger - In-memory balances and transactions; every send confirms instantly
+type Ledger struct {
+	mu             sync.Mutex
+	seed           string
+	defaultBalance uint64
+	fee            uint64
+	balances       map[string]uint64
+	txs            map[string]*record // by signature
+	submitted      map[string]string  // transaction id -> signature
+	byAddress      map[string][]*record
+	slot           uint64
+	nonce          uint64
+}
+
+// NewLedger - Ledger where unseen addresses start with defaultBalance; seed makes signatures deterministic per run config
+func NewLedger(seed string, defaultBalance, fee uint64) *Ledger {
+	return &Ledger{
+		seed:           seed,
+		defaultBalance: defaultBalance,
+		fee:            fee,
+		balances:       make(map[string]uint64),
+		txs:            make(map[string]*record),
+		submitted:      make(map[string]string),
+		byAddress:      make(map[string][]*record),
+	}
+}
+
+// Balance - Current balance of address
+func (l *Ledger) Balance(address string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.balanceLocked(address)
+}
+
+// SetBalance - Fund an address (tests/fixtures)
+func (l *Ledger) SetBalance(address string, amount uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.balances[address] = amount
+}
+
+func (l *Ledger) balanceLocked(address string) uint64 {
+	b, ok := l.balances[address]
+	if !ok {
+		b = l.defaultBalance
+		l.balances[address] = b
+	}
+	return b
+}
+
+// newID - Deterministic transaction id
+func (l *Ledger) newID(prefix string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nonce++
+	return fmt.Sprintf("%s_sandbox_%d", prefix, l.nonce)
+}
+
+// signature - Deterministic digest of the transaction id
+func (l *Ledger) signature(txID string) []byte {
+	h := sha256.Sum256([]byte(l.seed + ":" + txID))
+	h2 := sha256.Sum256(h[:])
+	return append(h[:], h2[:]...)
+}
+
+// Submit - Apply transaction instantly; apply runs under the ledger lock for non-transfer kinds
+func (l *Ledger) Submit(tx *fakeTx, encodeSig func([]byte) string, apply func() error) (*record, error) {
+	if tx.SignedBy == "" {
+		return nil, ErrNotSigned
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.submitted[tx.ID]; ok {
+		return nil, ErrAlreadySubmitted
+	}
+
+	l.slot++
+	rec := &record{
+		tx:        *tx,
+		sig:       encodeSig(l.signature(tx.ID)),
+		status:    txstatus.Finalized,
+		slot:      l.slot,
+		createdAt: time.Now(),
+	}
+
+	var err error
+	if from := l.balanceLocked(tx.From); from < tx.Amount+l.fee {
+		err = ErrInsufficientFunds
+	} else if apply != nil {
+		err = apply()
+	}
+	if err != nil {
+		rec.status = txstatus.Failed
+		rec.err = err.Error()
+	} else {
+		l.balances[tx.From] -= tx.Amount + l.fee
+		if tx.To != "" {
+			l.balances[tx.To] = l.balanceLocked(tx.To) + tx.Amount
+		}
+	}
+
+	l.submitted[tx.ID] = rec.sig
+	l.txs[rec.sig] = rec
+	l.byAddress[tx.From] = append(l.byAddress[tx.From], rec)
+	if tx.To != "" && tx.To != tx.From {
+		l.byAddress[tx.To] = append(l.byAddress[tx.To], rec)
+	}
+	return rec, nil
+}
+
+// credit - Add funds (envelope payouts); caller must hold the lock (used inside apply)
+func (l *Ledger) credit(address string, amount uint64) {
+	l.balances[address] = l.balanceLocked(address) + amount
+}
+
+// Get - Transaction by signature
+func (l *Ledger) Get(sig string) (*record, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec, ok := l.txs[sig]
+	return rec, ok
+}
+
+// History - Latest transactions touching address
+func (l *Ledger) History(address string, limit int) []*record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	recs := append([]*record(nil), l.byAddress[address]...)
+	sort.Slice(recs, func(i, j int) bool { return recs[i].slot > recs[j].slot })
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// Slot - Current fake slot/block height
+func (l *Ledger) Slot() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.slot
+}
@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"blockchain/dto"
+)
+
+// ErrorResponse - Standard error response (same shape as chainsol/chainbnb)
+type ErrorResponse = dto.ErrorResponse
+
+func respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, message string, status int) {
+	respondJSON(w, ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	}, status)
+}
+
+// historyLimit - ?limit= parsed with the same bounds as the real chains
+func historyLimit(r *http.Request) int {
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return limit
+}
+
+// signRequest - Body of HandleSignTransaction; the key is accepted but never used
+type signRequest struct {
+	UnsignedTransaction string `json:"unsigned_transaction"`
+	PrivateKey          string `json:"private_key"`
+}
+
+// signFakeTx - Mark a sandbox transaction as signed by its payer, keeping its encoding
+func signFakeTx(unsigned string, encode func(fakeTx) string) (string, error) {
+	tx, err := decodeTx(unsigned)
+	if err != nil {
+		return "", err
+	}
+	tx.SignedBy = tx.From
+	return encode(*tx), nil
+}
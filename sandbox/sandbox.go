@@ -0,0 +1,18 @@
+// Package sandbox - In-memory fake chains for offline development.
+// Transactions are base64/hex JSON, confirmations are instant, signatures are
+// deterministic for a given seed, and every unseen address starts funded.
+package sandbox
+
+import "blockchain/chain"
+
+var (
+	_ chain.Chain           = (*SolChain)(nil)
+	_ chain.ReceiptProvider = (*SolChain)(nil)
+	_ chain.Chain           = (*BNBChain)(nil)
+	_ chain.EnvelopeAPI     = (*Envelopes)(nil)
+)
+
+// Enabled - Sandbox mode switch (SANDBOX=true)
+func Enabled(env string) bool {
+	return env == "true" || env == "1"
+}
@@ -0,0 +1,341 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/chainsol"
+	"blockchain/dto"
+	"blockchain/pricing"
+	"blockchain/receipt"
+	"blockchain/txencoding"
+	"blockchain/txstatus"
+)
+
+// Sandbox SOL defaults
+const (
+	SolDefaultBalance uint64 = 10_000_000_000 // 10 SOL per unseen address
+	SolFee            uint64 = 5_000          // lamports per signature
+)
+
+// SolChain - In-memory stand-in for chainsol.SolChain with the same request/response shapes
+type SolChain struct {
+	ledger *Ledger
+}
+
+// NewSolChain - Sandbox Solana chain
+func NewSolChain(seed string) *SolChain {
+	return &SolChain{ledger: NewLedger("sol:"+seed, SolDefaultBalance, SolFee)}
+}
+
+// Ledger - Underlying in-memory ledger
+func (p *SolChain) Ledger() *Ledger {
+	return p.ledger
+}
+
+// HealthCheck - Always healthy
+func (p *SolChain) HealthCheck() error {
+	return nil
+}
+
+// GetExplorerURL - Sandbox transactions are not on any explorer
+func (p *SolChain) GetExplorerURL(signature string) string {
+	return "sandbox://sol/tx/" + signature
+}
+
+func (p *SolChain) blockhash() string {
+	var slot [8]byte
+	binary.LittleEndian.PutUint64(slot[:], p.ledger.Slot())
+	h := sha256.Sum256(slot[:])
+	return solana.HashFromBytes(h[:]).String()
+}
+
+func (p *SolChain) encodeSig(b []byte) string {
+	return solana.SignatureFromBytes(b).String()
+}
+
+// CreateTransaction - Build sandbox transfer
+func (p *SolChain) CreateTransaction(req chainsol.TransactionRequest) (*chainsol.CreateTransactionResponse, error) {
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		return nil, err
+	}
+	id := p.ledger.newID("sol")
+	return &chainsol.CreateTransactionResponse{
+		CreateTransactionResponse: dto.CreateTransactionResponse{
+			TransactionID: id,
+			Encoding:      encoding.String(),
+			UnsignedTransaction: encodeTxAs(encoding, fakeTx{
+				Sandbox: true,
+				ID:      id,
+				Kind:    "transfer",
+				From:    req.FromAddress,
+				To:      req.ToAddress,
+				Amount:  req.Amount,
+			}),
+			EstimatedFee: pricing.FormatLamports(context.Background(), nil, SolFee),
+		},
+		RecentBlockhash: p.blockhash(),
+	}, nil
+}
+
+// SendSignedTransaction - Apply transfer instantly (finalized)
+func (p *SolChain) SendSignedTransaction(req chainsol.SignedTransactionRequest) (*chainsol.TransactionResult, error) {
+	result := &chainsol.TransactionResult{TransactionResult: dto.TransactionResult{TransactionID: req.TransactionID}}
+	tx, err := decodeTx(req.SignedTransaction)
+	if err != nil {
+		result.Status = txstatus.Failed
+		result.Message = err.Error()
+		return result, err
+	}
+	rec, err := p.ledger.Submit(tx, p.encodeSig, nil)
+	if err != nil {
+		result.Status = txstatus.Failed
+		result.Message = err.Error()
+		return result, err
+	}
+	result.Signature = rec.sig
+	result.Status = rec.status
+	result.Success = rec.status.IsSuccess()
+	result.Message = "Transaction sent successfully"
+	if rec.err != "" {
+		result.Message = rec.err
+	}
+	result.ExplorerURL = p.GetExplorerURL(rec.sig)
+	return result, nil
+}
+
+// GetTransactionStatus - Status of sandbox transaction
+func (p *SolChain) GetTransactionStatus(signature string) (*chainsol.TransactionStatusResponse, error) {
+	response := &chainsol.TransactionStatusResponse{
+		TransactionStatus: dto.TransactionStatus{Status: txstatus.NotFound, ExplorerURL: p.GetExplorerURL(signature)},
+		Signature:         signature,
+	}
+	rec, ok := p.ledger.Get(signature)
+	if !ok {
+		return response, nil
+	}
+	blockTime := rec.createdAt.Unix()
+	response.Status = rec.status
+	response.Slot = rec.slot
+	response.BlockTime = &blockTime
+	response.Fee = SolFee
+	response.Confirmations = p.ledger.Slot() - rec.slot
+	if rec.err != "" {
+		response.Error = &rec.err
+	}
+	return response, nil
+}
+
+// GetSubmissionReceipt - Receipt of sandbox transaction (no priority fee, fixed compute)
+func (p *SolChain) GetSubmissionReceipt(signature string) (*receipt.Receipt, error) {
+	rec, ok := p.ledger.Get(signature)
+	if !ok {
+		return nil, receipt.ErrNotLanded
+	}
+	blockTime := rec.createdAt.Unix()
+	confirmedAt := rec.createdAt.UTC()
+	units := uint64(150)
+	r := &receipt.Receipt{
+		Signature:            signature,
+		Status:               rec.status,
+		Slot:                 rec.slot,
+		BlockTime:            &blockTime,
+		ConfirmedAt:          &confirmedAt,
+		Fee:                  SolFee,
+		BaseFee:              SolFee,
+		ComputeUnitsConsumed: &units,
+	}
+	if rec.err != "" {
+		r.Error = &rec.err
+	}
+	return r.WithPrices(context.Background(), nil), nil
+}
+
+// GetTransactionHistory - Sandbox history (no database needed)
+func (p *SolChain) GetTransactionHistory(address string, limit int) ([]chainsol.TransactionHistory, error) {
+	recs := p.ledger.History(address, limit)
+	histories := make([]chainsol.TransactionHistory, 0, len(recs))
+	for i, rec := range recs {
+		confirmedAt := rec.createdAt
+		histories = append(histories, chainsol.TransactionHistory{
+			ID:            uint(i + 1),
+			TransactionID: rec.tx.ID,
+			FromAddress:   rec.tx.From,
+			ToAddress:     rec.tx.To,
+			Amount:        rec.tx.Amount,
+			Signature:     rec.sig,
+			Status:        rec.status,
+			Fee:           SolFee,
+			ErrorMessage:  rec.err,
+			CreatedAt:     rec.createdAt,
+			UpdatedAt:     rec.createdAt,
+			ConfirmedAt:   &confirmedAt,
+		})
+	}
+	return histories, nil
+}
+
+// RunCanary - Sandbox self-transfer (always succeeds)
+func (p *SolChain) RunCanary(ctx context.Context) (*chainsol.CanaryResult, error) {
+	start := time.Now()
+	wallet := "SandboxCanary1111111111111111111111111111111"
+	created, _ := p.CreateTransaction(chainsol.TransactionRequest{
+		TransferRequest: dto.TransferRequest{FromAddress: wallet, ToAddress: wallet},
+		Amount:          1,
+	})
+	signed, _ := signFakeTx(created.UnsignedTransaction, encodeTx)
+	sent, err := p.SendSignedTransaction(chainsol.SignedTransactionRequest{TransactionID: created.TransactionID, SignedTransaction: signed})
+	result := &chainsol.CanaryResult{
+		Chain:     chain.Solana,
+		Network:   chain.Localnet,
+		Wallet:    wallet,
+		Step:      "done",
+		StartedAt: start,
+	}
+	if err != nil {
+		result.Step = "send"
+		result.Error = err.Error()
+	} else {
+		result.Success = sent.Success
+		result.Signature = sent.Signature
+		result.Status = sent.Status
+		result.ExplorerURL = sent.ExplorerURL
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// HandleCreateTransaction - POST /api/v1/sol/transaction/create
+func (p *SolChain) HandleCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req chainsol.TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.FromAddress == "" || req.ToAddress == "" || req.Amount == 0 {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	response, err := p.CreateTransaction(req)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, response, http.StatusOK)
+}
+
+// HandleSignTransaction - Marks the sandbox transaction signed (private key ignored)
+func (p *SolChain) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	signed, err := signFakeTx(req.UnsignedTransaction, encodeTx)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respondJSON(w, map[string]string{
+		"signed_transaction": signed,
+		"warning":            "⚠️ SANDBOX - transaction is not a real Solana transaction",
+	}, http.StatusOK)
+}
+
+// HandleSendTransaction - POST /api/v1/sol/transaction/send
+func (p *SolChain) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req chainsol.SignedTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SignedTransaction == "" || req.TransactionID == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	result, err := p.SendSignedTransaction(req)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetTransactionStatus - GET /api/v1/sol/transaction/status?signature=xxx
+func (p *SolChain) HandleGetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	signature := r.URL.Query().Get("signature")
+	if signature == "" {
+		respondError(w, "signature parameter required", http.StatusBadRequest)
+		return
+	}
+	result, _ := p.GetTransactionStatus(signature)
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetSubmissionReceipt - GET /api/v1/sol/transaction/receipt?signature=xxx
+func (p *SolChain) HandleGetSubmissionReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	signature := r.URL.Query().Get("signature")
+	if signature == "" {
+		respondError(w, "signature parameter required", http.StatusBadRequest)
+		return
+	}
+	result, err := p.GetSubmissionReceipt(signature)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	respondJSON(w, result, http.StatusOK)
+}
+
+// HandleGetTransactionHistory - GET /api/v1/sol/transaction/history?address=xxx&limit=10
+func (p *SolChain) HandleGetTransactionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		respondError(w, "address parameter required", http.StatusBadRequest)
+		return
+	}
+	histories, _ := p.GetTransactionHistory(address, historyLimit(r))
+	respondJSON(w, histories, http.StatusOK)
+}
+
+// HandleCanary - POST /api/v1/sol/admin/canary
+func (p *SolChain) HandleCanary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, _ := p.RunCanary(r.Context())
+	respondJSON(w, result, http.StatusOK)
+}
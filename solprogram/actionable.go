@@ -0,0 +1,155 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/storage"
+)
+
+// DefaultExpiringWithin - Window used when GetActionableEnvelopes gets no expiry window
+const DefaultExpiringWithin = 24 * time.Hour
+
+// EnvelopeAction - What the owner should do with an envelope
+type EnvelopeAction string
+
+const (
+	ActionRefund       EnvelopeAction = "refund"        // expired with funds left
+	ActionExpiringSoon EnvelopeAction = "expiring_soon" // remind recipients before it expires
+	ActionClose        EnvelopeAction = "close"         // nothing left, close to reclaim rent
+)
+
+// actionOrder - Most urgent first
+var actionOrder = map[EnvelopeAction]int{
+	ActionRefund:       0,
+	ActionExpiringSoon: 1,
+	ActionClose:        2,
+}
+
+// ActionableEnvelope - Envelope that needs the owner's attention
+type ActionableEnvelope struct {
+	Envelope  *EnvelopeInfo     `json:"envelope"`
+	Action    EnvelopeAction    `json:"action"`
+	Reason    string            `json:"reason"`
+	ExpiresIn int64             `json:"expires_in_seconds,omitempty"` // expiring_soon only
+	Metadata  *EnvelopeMetadata `json:"metadata,omitempty"`           // from the indexer, when configured
+	Links     *ExplorerLinks    `json:"links,omitempty"`
+}
+
+// EnvelopeMetadata - Off-chain details the indexer keeps for an envelope
+type EnvelopeMetadata struct {
+	Signature string    `json:"signature,omitempty"`
+	GroupID   string    `json:"group_id,omitempty"`
+	Remarks   string    `json:"remarks,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetActionableEnvelopes - Owner's envelopes that need attention (refund, expiring within the window, closable),
+// most urgent first. State is read on-chain; indexer metadata is attached when a history store is set.
+func (c *USDCEnvelopeClient) GetActionableEnvelopes(ctx context.Context, owner solana.PublicKey, expiringWithin time.Duration) ([]*ActionableEnvelope, error) {
+	if expiringWithin <= 0 {
+		expiringWithin = DefaultExpiringWithin
+	}
+	metadata := c.indexedEnvelopes(ctx, owner)
+
+	now := time.Now()
+	var result []*ActionableEnvelope
+	it := c.IterEnvelopesByOwner(ctx, owner)
+	for it.Next() {
+		envelope := it.Value()
+		item := suggestAction(envelope, now, expiringWithin)
+		if item == nil {
+			continue
+		}
+		item.Metadata = metadata[envelope.EnvelopeID]
+		item.Links, _ = c.EnvelopeLinks(owner, envelope.EnvelopeID, nil)
+		result = append(result, item)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list envelopes: %w", err)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if actionOrder[result[i].Action] != actionOrder[result[j].Action] {
+			return actionOrder[result[i].Action] < actionOrder[result[j].Action]
+		}
+		return result[i].Envelope.ExpiryTime.Before(result[j].Envelope.ExpiryTime)
+	})
+	return result, nil
+}
+
+// suggestAction - Action for one envelope (nil = nothing to do)
+func suggestAction(envelope *EnvelopeInfo, now time.Time, expiringWithin time.Duration) *ActionableEnvelope {
+	expired := envelope.IsExpired || !now.Before(envelope.ExpiryTime)
+	switch {
+	case envelope.RemainingAmount == 0:
+		reason := "Fully claimed"
+		if envelope.IsCancelled {
+			reason = "Refunded"
+		}
+		return &ActionableEnvelope{
+			Envelope: envelope,
+			Action:   ActionClose,
+			Reason:   reason + ", close it to reclaim the account rent",
+		}
+
+	case envelope.IsCancelled:
+		return nil
+
+	case expired:
+		return &ActionableEnvelope{
+			Envelope: envelope,
+			Action:   ActionRefund,
+			Reason: fmt.Sprintf("Expired with %s USDC unclaimed (%d/%d claimed)",
+				formatUSDC(envelope.RemainingAmount), envelope.ClaimedCount, envelope.TotalUsers),
+		}
+
+	case envelope.ExpiryTime.Sub(now) <= expiringWithin:
+		expiresIn := envelope.ExpiryTime.Sub(now)
+		return &ActionableEnvelope{
+			Envelope:  envelope,
+			Action:    ActionExpiringSoon,
+			ExpiresIn: int64(expiresIn.Seconds()),
+			Reason: fmt.Sprintf("Expires in %s with %s USDC unclaimed (%d/%d claimed)",
+				expiresIn.Round(time.Minute), formatUSDC(envelope.RemainingAmount), envelope.ClaimedCount, envelope.TotalUsers),
+		}
+	}
+	return nil
+}
+
+// indexedEnvelopes - Indexer metadata by envelope id (empty without a history store)
+func (c *USDCEnvelopeClient) indexedEnvelopes(ctx context.Context, owner solana.PublicKey) map[uint64]*EnvelopeMetadata {
+	result := make(map[uint64]*EnvelopeMetadata)
+	if c.history == nil {
+		return result
+	}
+	rows, err := c.history.GetEnvelopeMetadataByOwner(ctx, chain.Solana, owner.String())
+	if err != nil {
+		// On-chain state is enough to answer; metadata is a nice-to-have
+		log.Printf("⚠️  failed to read envelope index for %s: %v", owner, err)
+		return result
+	}
+	for i := range rows {
+		result[rows[i].EnvelopeID] = envelopeMetadata(&rows[i])
+	}
+	return result
+}
+
+func envelopeMetadata(row *storage.EnvelopeMetadata) *EnvelopeMetadata {
+	return &EnvelopeMetadata{
+		Signature: row.Signature,
+		GroupID:   row.GroupID,
+		Remarks:   row.Remarks,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func formatUSDC(amount uint64) string {
+	return fmt.Sprintf("%.2f", float64(amount)/1_000_000)
+}
@@ -0,0 +1,199 @@
+package solprogram
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/metrics"
+)
+
+// ErrInstructionNotAllowed - Submitted transaction invokes a program or instruction outside the allow-list
+var ErrInstructionNotAllowed = errors.New("instruction not allowed")
+
+// Envelope action of init_user_state transactions (the other actions are chain.EnvelopeAction*)
+const EnvelopeActionInit = "init"
+
+var allowListRejections = metrics.Counter("solprogram_submissions_rejected_allowlist")
+
+// AllowedHelperPrograms - Programs besides the envelope program a submitted transaction may invoke
+var AllowedHelperPrograms = []solana.PublicKey{
+	SystemProgramID,
+	TokenProgramID,
+	solana.Token2022ProgramID,
+	AssociatedTokenProgID,
+	solana.MemoProgramID,
+	solana.ComputeBudget,
+}
+
+// InstructionAllowList - Envelope program and its instruction discriminators per action
+type InstructionAllowList struct {
+	Program solana.PublicKey
+	Actions map[string][][]byte // Action -> allowed discriminators
+}
+
+// USDCAllowList - USDC envelope program instructions per action
+func USDCAllowList(programID solana.PublicKey) *InstructionAllowList {
+	return &InstructionAllowList{
+		Program: programID,
+		Actions: map[string][][]byte{
+			EnvelopeActionInit:         {DiscriminatorInitUserState},
+			chain.EnvelopeActionCreate: {DiscriminatorInitUserState, DiscriminatorCreate},
+			chain.EnvelopeActionClaim:  {DiscriminatorClaim},
+			chain.EnvelopeActionRefund: {DiscriminatorRefund, DiscriminatorPartialRefund},
+		},
+	}
+}
+
+// SOLAllowList - SOL envelope program instructions per action
+func SOLAllowList(programID solana.PublicKey) *InstructionAllowList {
+	return &InstructionAllowList{
+		Program: programID,
+		Actions: map[string][][]byte{
+			EnvelopeActionInit:         {InitUserStateDisc[:]},
+			chain.EnvelopeActionCreate: {InitUserStateDisc[:], CreateDisc[:]},
+			chain.EnvelopeActionClaim:  {ClaimDisc[:]},
+			chain.EnvelopeActionRefund: {RefundDisc[:]},
+		},
+	}
+}
+
+// DeclaredAction - Action encoded in a transaction ID issued by this server ("usdc_claim_<n>" -> "claim"),
+// "" if none. Only meaningful once the ID is known to be server-issued: clients can send any string.
+func DeclaredAction(transactionID string) string {
+	_, rest, ok := strings.Cut(transactionID, "_")
+	if !ok {
+		return ""
+	}
+	action, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return ""
+	}
+	return action
+}
+
+// Validate - Every instruction must target the envelope program or an allowed helper program, envelope
+// instructions must be allowed for action ("" = any envelope action), and at least one must be present.
+// A nil allow-list accepts everything.
+func (a *InstructionAllowList) Validate(tx *solana.Transaction, action string) error {
+	if a == nil {
+		return nil
+	}
+	allowed, err := a.discriminators(action)
+	if err != nil {
+		return a.reject(err)
+	}
+
+	envelopeInstructions := 0
+	for i, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil {
+			return a.reject(fmt.Errorf("%w: instruction %d: %v", ErrInstructionNotAllowed, i, err))
+		}
+		if !programID.Equals(a.Program) {
+			if !isHelperProgram(programID) {
+				return a.reject(fmt.Errorf("%w: instruction %d invokes program %s", ErrInstructionNotAllowed, i, programID))
+			}
+			continue
+		}
+
+		envelopeInstructions++
+		if !hasDiscriminator(allowed, ix.Data) {
+			return a.reject(fmt.Errorf("%w: instruction %d is not a %s instruction of the envelope program", ErrInstructionNotAllowed, i, actionName(action)))
+		}
+	}
+	if envelopeInstructions == 0 {
+		return a.reject(fmt.Errorf("%w: no envelope program instruction", ErrInstructionNotAllowed))
+	}
+	return nil
+}
+
+// ActionOf - Envelope action of tx's envelope program instructions: the narrowest action allowing all of
+// them (init_user_state + create = create). Errors when they belong to no single action or there are none.
+func (a *InstructionAllowList) ActionOf(tx *solana.Transaction) (string, error) {
+	var envelopeData [][]byte
+	for i, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil {
+			return "", a.reject(fmt.Errorf("%w: instruction %d: %v", ErrInstructionNotAllowed, i, err))
+		}
+		if programID.Equals(a.Program) {
+			envelopeData = append(envelopeData, ix.Data)
+		}
+	}
+	if len(envelopeData) == 0 {
+		return "", a.reject(fmt.Errorf("%w: no envelope program instruction", ErrInstructionNotAllowed))
+	}
+
+	actions := make([]string, 0, len(a.Actions))
+	for action := range a.Actions {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		ni, nj := len(a.Actions[actions[i]]), len(a.Actions[actions[j]])
+		return ni < nj || (ni == nj && actions[i] < actions[j])
+	})
+	for _, action := range actions {
+		allowsAll := true
+		for _, data := range envelopeData {
+			if !hasDiscriminator(a.Actions[action], data) {
+				allowsAll = false
+				break
+			}
+		}
+		if allowsAll {
+			return action, nil
+		}
+	}
+	return "", a.reject(fmt.Errorf("%w: envelope instructions do not belong to a single action", ErrInstructionNotAllowed))
+}
+
+func (a *InstructionAllowList) discriminators(action string) ([][]byte, error) {
+	if action != "" {
+		allowed, ok := a.Actions[action]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown action %q", ErrInstructionNotAllowed, action)
+		}
+		return allowed, nil
+	}
+	var all [][]byte
+	for _, discriminators := range a.Actions {
+		all = append(all, discriminators...)
+	}
+	return all, nil
+}
+
+func (a *InstructionAllowList) reject(err error) error {
+	allowListRejections.Add(1)
+	return err
+}
+
+func isHelperProgram(programID solana.PublicKey) bool {
+	for _, helper := range AllowedHelperPrograms {
+		if programID.Equals(helper) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDiscriminator(allowed [][]byte, data []byte) bool {
+	for _, discriminator := range allowed {
+		if len(data) >= len(discriminator) && bytes.Equal(data[:len(discriminator)], discriminator) {
+			return true
+		}
+	}
+	return false
+}
+
+func actionName(action string) string {
+	if action == "" {
+		return "known"
+	}
+	return action
+}
@@ -0,0 +1,171 @@
+package solprogram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/attestation"
+	"blockchain/receipt"
+)
+
+var (
+	// ErrAttestationDisabled - No attestation key configured
+	ErrAttestationDisabled = errors.New("claim attestations are not enabled")
+	// ErrNotClaim - Transaction has no claim instruction of the envelope program
+	ErrNotClaim = errors.New("transaction is not a claim of this program")
+)
+
+// ClaimAttestationResponse - Signed claim receipt (GET /api/claim-attestation?signature=...)
+type ClaimAttestationResponse struct {
+	Success     bool                     `json:"success"`
+	Message     string                   `json:"message,omitempty"`
+	Attestation *attestation.Attestation `json:"attestation,omitempty"`
+}
+
+// claimStatement - Statement of a finalized claim of programID: the claim instruction's envelope
+// (account 0) and claimer, and the amount that left the envelope. SOL envelopes pay lamports out of
+// the envelope account; USDC envelopes pay into the claimer token account (account 2).
+func claimStatement(ctx context.Context, client *rpc.Client, programID solana.PublicKey, signature string, usdc bool) (*attestation.Statement, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	// Finalized only: an attestation must not outlive a rolled back fork
+	maxVersion := uint64(0)
+	result, err := client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if errors.Is(err, rpc.ErrNotFound) || err == nil && (result == nil || result.Transaction == nil) {
+		return nil, receipt.ErrNotLanded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if result.Meta == nil {
+		return nil, fmt.Errorf("transaction %s has no status metadata", signature)
+	}
+	if result.Meta.Err != nil {
+		return nil, fmt.Errorf("claim transaction failed: %v", result.Meta.Err)
+	}
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+
+	// Static keys, then keys loaded from lookup tables (writable first), as meta indexes them
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, result.Meta.LoadedAddresses.Writable...)
+	keys = append(keys, result.Meta.LoadedAddresses.ReadOnly...)
+
+	disc := ClaimDisc[:]
+	claimerIndex := 1
+	if usdc {
+		disc, claimerIndex = DiscriminatorClaim, 4
+	}
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(keys) || !keys[inst.ProgramIDIndex].Equals(programID) ||
+			!bytes.HasPrefix(inst.Data, disc) || len(inst.Accounts) <= claimerIndex {
+			continue
+		}
+		envelope, claimer := inst.Accounts[0], inst.Accounts[claimerIndex]
+		if int(envelope) >= len(keys) || int(claimer) >= len(keys) {
+			continue
+		}
+
+		st := &attestation.Statement{
+			Program:   programID.String(),
+			Envelope:  keys[envelope].String(),
+			Claimer:   keys[claimer].String(),
+			Signature: signature,
+			Slot:      result.Slot,
+		}
+		if result.BlockTime != nil {
+			blockTime := int64(*result.BlockTime)
+			st.BlockTime = &blockTime
+		}
+		if usdc {
+			st.Mint, st.Amount = tokenReceived(result.Meta, inst.Accounts[2])
+		} else if int(envelope) < len(result.Meta.PreBalances) && int(envelope) < len(result.Meta.PostBalances) &&
+			result.Meta.PreBalances[envelope] > result.Meta.PostBalances[envelope] {
+			st.Amount = result.Meta.PreBalances[envelope] - result.Meta.PostBalances[envelope]
+		}
+		return st, nil
+	}
+	return nil, ErrNotClaim
+}
+
+// tokenReceived - Mint and amount a token account gained in the transaction (created accounts start at 0)
+func tokenReceived(meta *rpc.TransactionMeta, index uint16) (string, uint64) {
+	balance := func(balances []rpc.TokenBalance) (string, uint64) {
+		for _, b := range balances {
+			if b.AccountIndex == index && b.UiTokenAmount != nil {
+				amount, _ := strconv.ParseUint(b.UiTokenAmount.Amount, 10, 64)
+				return b.Mint.String(), amount
+			}
+		}
+		return "", 0
+	}
+	_, pre := balance(meta.PreTokenBalances)
+	mint, post := balance(meta.PostTokenBalances)
+	if post < pre {
+		return mint, 0
+	}
+	return mint, post - pre
+}
+
+// AttestClaim - Attestation of a finalized claim transaction signed with the service attestation key
+func (c *Client) AttestClaim(ctx context.Context, signature string) (*attestation.Attestation, error) {
+	if c.Attestor == nil {
+		return nil, ErrAttestationDisabled
+	}
+	st, err := claimStatement(ctx, c.RPC, c.ProgramID, signature, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.Attestor.Sign(*st)
+}
+
+// SetAttestor - Service attestation key for AttestClaim (attestations disabled when nil)
+func (c *USDCEnvelopeClient) SetAttestor(signer *attestation.Signer) {
+	c.attestor = signer
+}
+
+// AttestClaim - Attestation of a finalized USDC claim transaction signed with the service attestation key
+func (c *USDCEnvelopeClient) AttestClaim(ctx context.Context, signature string) (*attestation.Attestation, error) {
+	if c.attestor == nil {
+		return nil, ErrAttestationDisabled
+	}
+	st, err := claimStatement(ctx, c.rpcClient, c.programID, signature, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.attestor.Sign(*st)
+}
+
+// HandleClaimAttestation handles signed claim receipts (GET /api/claim-attestation?signature=...)
+func (c *Client) HandleClaimAttestation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	signature := r.URL.Query().Get("signature")
+	if signature == "" {
+		json.NewEncoder(w).Encode(ClaimAttestationResponse{Success: false, Message: "signature is required"})
+		return
+	}
+
+	att, err := c.AttestClaim(r.Context(), signature)
+	if err != nil {
+		json.NewEncoder(w).Encode(ClaimAttestationResponse{Success: false, Message: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(ClaimAttestationResponse{Success: true, Attestation: att})
+}
@@ -0,0 +1,316 @@
+package solprogram
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/storage"
+	"blockchain/txencoding"
+)
+
+// Claim cap error codes
+const (
+	ClaimCapExceeded    = "CLAIM_CAP_EXCEEDED"    // wallet reached the group's daily limit
+	ClaimCapUnavailable = "CLAIM_CAP_UNAVAILABLE" // limit could not be checked, claim refused
+)
+
+// ErrClaimMismatch - Signed claim transaction does not claim the envelope or claimer it was submitted for
+var ErrClaimMismatch = errors.New("signed transaction does not match the claim")
+
+// ClaimCapError - Claim refused by the per-group daily cap
+type ClaimCapError struct {
+	Code     string    `json:"code"`
+	GroupID  string    `json:"group_id"`
+	Limit    int       `json:"limit,omitempty"`
+	Used     int       `json:"used,omitempty"`
+	ResetsAt time.Time `json:"resets_at,omitempty"`
+	Err      error     `json:"-"`
+}
+
+func (e *ClaimCapError) Error() string {
+	if e.Code == ClaimCapExceeded {
+		return fmt.Sprintf("%s: daily claim limit of %d reached in group %s (resets at %s)",
+			e.Code, e.Limit, e.GroupID, e.ResetsAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s: %v", e.Code, e.Err)
+}
+
+func (e *ClaimCapError) Unwrap() error {
+	return e.Err
+}
+
+// AsClaimCapError - ClaimCapError in err's chain, if any
+func AsClaimCapError(err error) (*ClaimCapError, bool) {
+	var capErr *ClaimCapError
+	ok := errors.As(err, &capErr)
+	return capErr, ok
+}
+
+// ClaimCapPolicy - At most N claimed envelopes per wallet, group and UTC day.
+// Groups come from the envelope index (group_id given on create, see IndexEnvelope); caps are set by admins per group (storage.GroupClaimCap),
+// DefaultPerDay applies to groups without one. Envelopes outside a group are not limited.
+type ClaimCapPolicy struct {
+	store         *storage.Store
+	chain         chain.ChainID
+	DefaultPerDay int // 0 = unlimited
+	now           func() time.Time
+}
+
+// NewClaimCapPolicy - Create policy backed by the metadata store
+func NewClaimCapPolicy(store *storage.Store, defaultPerDay int) *ClaimCapPolicy {
+	return &ClaimCapPolicy{
+		store:         store,
+		chain:         chain.Solana,
+		DefaultPerDay: defaultPerDay,
+		now:           time.Now,
+	}
+}
+
+// Check - Refuse the claim when claimer reached the cap of the envelope's group (nil-safe)
+func (p *ClaimCapPolicy) Check(ctx context.Context, owner solana.PublicKey, envelopeID uint64, claimer solana.PublicKey) error {
+	if p == nil {
+		return nil
+	}
+	groupID, err := p.store.GetEnvelopeGroup(ctx, p.chain, owner.String(), envelopeID)
+	if err != nil {
+		return &ClaimCapError{Code: ClaimCapUnavailable, Err: fmt.Errorf("failed to look up envelope group: %w", err)}
+	}
+	if groupID == "" {
+		return nil
+	}
+
+	limit, err := p.limit(ctx, groupID)
+	if err != nil {
+		return &ClaimCapError{Code: ClaimCapUnavailable, GroupID: groupID, Err: err}
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	day, resetsAt := p.day()
+	used, err := p.store.GetGroupClaimCount(ctx, groupID, claimer.String(), day)
+	if err != nil {
+		return &ClaimCapError{Code: ClaimCapUnavailable, GroupID: groupID, Err: fmt.Errorf("failed to count claims: %w", err)}
+	}
+	if used >= limit {
+		return &ClaimCapError{Code: ClaimCapExceeded, GroupID: groupID, Limit: limit, Used: used, ResetsAt: resetsAt}
+	}
+	return nil
+}
+
+// Reserve - Atomically count the claim against the cap of the envelope's group, refusing it when the
+// cap is reached (nil-safe). release gives the reservation back when the claim does not land; it is
+// never nil and must be called at most once.
+func (p *ClaimCapPolicy) Reserve(ctx context.Context, owner solana.PublicKey, envelopeID uint64, claimer solana.PublicKey) (release func(), err error) {
+	release = func() {}
+	if p == nil {
+		return release, nil
+	}
+	groupID, err := p.store.GetEnvelopeGroup(ctx, p.chain, owner.String(), envelopeID)
+	if err != nil {
+		return release, &ClaimCapError{Code: ClaimCapUnavailable, Err: fmt.Errorf("failed to look up envelope group: %w", err)}
+	}
+	if groupID == "" {
+		return release, nil
+	}
+
+	limit, err := p.limit(ctx, groupID)
+	if err != nil {
+		return release, &ClaimCapError{Code: ClaimCapUnavailable, GroupID: groupID, Err: err}
+	}
+	if limit == 0 {
+		return release, nil
+	}
+
+	day, resetsAt := p.day()
+	used, ok, err := p.store.ReserveGroupClaimCount(ctx, groupID, claimer.String(), day, limit)
+	if err != nil {
+		return release, &ClaimCapError{Code: ClaimCapUnavailable, GroupID: groupID, Err: fmt.Errorf("failed to count claims: %w", err)}
+	}
+	if !ok {
+		return release, &ClaimCapError{Code: ClaimCapExceeded, GroupID: groupID, Limit: limit, Used: used, ResetsAt: resetsAt}
+	}
+
+	return func() {
+		// The caller's context may already be done when the submission failed
+		if err := p.store.ReleaseGroupClaimCount(context.Background(), groupID, claimer.String(), day); err != nil {
+			log.Printf("⚠️  failed to release claim of %s on %s #%d: %v", claimer, owner, envelopeID, err)
+		}
+	}, nil
+}
+
+// IndexEnvelope - Record the group of a new envelope so its claims count against that group's cap
+// (nil-safe; "" = not in a group, which also clears the group of an earlier create that never landed)
+func (p *ClaimCapPolicy) IndexEnvelope(ctx context.Context, owner solana.PublicKey, envelopeID uint64, groupID string) error {
+	if p == nil {
+		return nil
+	}
+	return p.store.IndexEnvelope(ctx, &storage.EnvelopeMetadata{
+		Chain:        p.chain,
+		OwnerAddress: owner.String(),
+		EnvelopeID:   envelopeID,
+		GroupID:      groupID,
+	})
+}
+
+// ParseClaimCapDefault - Daily cap of groups without an admin cap from env, e.g. CLAIM_CAP_DEFAULT_PER_DAY=3
+// ("" or 0 = unlimited)
+func ParseClaimCapDefault(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid daily claim cap %q", s)
+	}
+	return n, nil
+}
+
+func (p *ClaimCapPolicy) limit(ctx context.Context, groupID string) (int, error) {
+	groupCap, found, err := p.store.GetGroupClaimCap(ctx, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get group cap: %w", err)
+	}
+	if found {
+		return groupCap.MaxPerDay, nil
+	}
+	return p.DefaultPerDay, nil
+}
+
+// day - Current UTC day key and when it ends
+func (p *ClaimCapPolicy) day() (string, time.Time) {
+	now := p.now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+}
+
+// signedClaim - Envelope and claimer of the claim instruction in a signed transaction
+type signedClaim struct {
+	envelope solana.PublicKey
+	claimer  solana.PublicKey
+}
+
+// findSignedClaim - The claim instruction (disc) of programID in tx with its envelope and claimer account
+// indexes resolved; nil when tx claims nothing. The claimer must have signed, and a transaction may claim
+// at most once so that one reservation covers it.
+func findSignedClaim(tx *solana.Transaction, programID solana.PublicKey, disc []byte, envelopeIndex, claimerIndex int) (*signedClaim, error) {
+	var claim *signedClaim
+	for i, ix := range tx.Message.Instructions {
+		program, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: instruction %d: %v", ErrClaimMismatch, i, err)
+		}
+		if !program.Equals(programID) || !hasDiscriminator([][]byte{disc}, ix.Data) {
+			continue
+		}
+		if claim != nil {
+			return nil, fmt.Errorf("%w: more than one claim instruction", ErrClaimMismatch)
+		}
+		accounts, err := ix.ResolveInstructionAccounts(&tx.Message)
+		if err != nil || len(accounts) <= max(envelopeIndex, claimerIndex) {
+			return nil, fmt.Errorf("%w: instruction %d has invalid accounts", ErrClaimMismatch, i)
+		}
+		if !accounts[claimerIndex].IsSigner {
+			return nil, fmt.Errorf("%w: claimer %s does not sign", ErrClaimMismatch, accounts[claimerIndex].PublicKey)
+		}
+		claim = &signedClaim{envelope: accounts[envelopeIndex].PublicKey, claimer: accounts[claimerIndex].PublicKey}
+	}
+	if claim != nil {
+		if err := tx.VerifySignatures(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrClaimMismatch, err)
+		}
+	}
+	return claim, nil
+}
+
+// matches - Claim is of owner's envelope envelopeID (PDA derived by derive) by claimer
+func (s *signedClaim) matches(derive func(solana.PublicKey, uint64) (solana.PublicKey, uint8, error), owner solana.PublicKey, envelopeID uint64, claimer solana.PublicKey) error {
+	envelope, _, err := derive(owner, envelopeID)
+	if err != nil {
+		return err
+	}
+	if !s.envelope.Equals(envelope) {
+		return fmt.Errorf("%w: transaction claims envelope %s, not %s #%d", ErrClaimMismatch, s.envelope, owner, envelopeID)
+	}
+	if !s.claimer.Equals(claimer) {
+		return fmt.Errorf("%w: transaction is signed by claimer %s, not %s", ErrClaimMismatch, s.claimer, claimer)
+	}
+	return nil
+}
+
+// claimTarget - Owner and id of the envelope claim targets, read from the envelope account (both programs
+// start it with discriminator, owner, envelope_id) and checked against the PDA derive gives for them
+func claimTarget(ctx context.Context, rpcClient *rpc.Client, claim *signedClaim, derive func(solana.PublicKey, uint64) (solana.PublicKey, uint8, error)) (solana.PublicKey, uint64, error) {
+	account, err := rpcClient.GetAccountInfo(ctx, claim.envelope)
+	if err != nil {
+		return solana.PublicKey{}, 0, fmt.Errorf("failed to get envelope: %w", err)
+	}
+	data := account.GetBinary()
+	if len(data) < 8+32+8 {
+		return solana.PublicKey{}, 0, fmt.Errorf("%w: %s is not an envelope", ErrClaimMismatch, claim.envelope)
+	}
+	owner := solana.PublicKeyFromBytes(data[8:40])
+	envelopeID := binary.LittleEndian.Uint64(data[40:48])
+	if err := claim.matches(derive, owner, envelopeID, claim.claimer); err != nil {
+		return solana.PublicKey{}, 0, err
+	}
+	return owner, envelopeID, nil
+}
+
+// reserveClaim - Reserve the claim caps for the claim in a signed transaction sent through the generic
+// send path (nil-safe, release is a no-op when the transaction claims nothing)
+func (c *Client) reserveClaim(ctx context.Context, signedTxBase64 string) (func(), error) {
+	if c.ClaimCaps == nil {
+		return func() {}, nil
+	}
+	txBytes, err := txencoding.Base64.Decode(signedTxBase64)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := solana.TransactionFromBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+	claim, err := findSignedClaim(tx, c.ProgramID, ClaimDisc[:], 0, 1)
+	if err != nil || claim == nil {
+		return func() {}, err
+	}
+	owner, envelopeID, err := claimTarget(ctx, c.RPC, claim, c.deriveEnvelopePDA)
+	if err != nil {
+		return nil, err
+	}
+	return c.ClaimCaps.Reserve(ctx, owner, envelopeID, claim.claimer)
+}
+
+// checkSignedClaim - Signed transaction claims owner's envelope envelopeID and is signed by claimer
+func (c *Client) checkSignedClaim(signedTxBase64 string, owner solana.PublicKey, envelopeID uint64, claimer solana.PublicKey) error {
+	txBytes, err := txencoding.Base64.Decode(signedTxBase64)
+	if err != nil {
+		return err
+	}
+	tx, err := solana.TransactionFromBytes(txBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse transaction: %w", err)
+	}
+	claim, err := findSignedClaim(tx, c.ProgramID, ClaimDisc[:], 0, 1)
+	if err != nil {
+		return err
+	}
+	if claim == nil {
+		return fmt.Errorf("%w: no claim instruction", ErrClaimMismatch)
+	}
+	return claim.matches(c.deriveEnvelopePDA, owner, envelopeID, claimer)
+}
+
+func (c *Client) deriveEnvelopePDA(owner solana.PublicKey, envelopeID uint64) (solana.PublicKey, uint8, error) {
+	return DeriveEnvelopePDA(c.ProgramID, owner, envelopeID)
+}
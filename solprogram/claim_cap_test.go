@@ -0,0 +1,121 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"blockchain/storage"
+)
+
+func TestCheckSignedClaim(t *testing.T) {
+	c := &Client{ProgramID: solana.MustPublicKeyFromBase58("8sVfWmonJAzAQnS4nYcxv3GBSs4rDpvmniRrApwrh1QK")}
+	owner := solana.NewWallet().PublicKey()
+	claimerKey := solana.NewWallet().PrivateKey
+	claimer := claimerKey.PublicKey()
+
+	instruction, err := BuildClaimInstruction(c.ProgramID, owner, claimer, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := solana.NewTransaction([]solana.Instruction{instruction}, solana.Hash{}, solana.TransactionPayer(claimer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &claimerKey }); err != nil {
+		t.Fatal(err)
+	}
+	signedTx := tx.MustToBase64()
+
+	if err := c.checkSignedClaim(signedTx, owner, 7, claimer); err != nil {
+		t.Fatalf("matching claim: %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"other claimer":  func() error { return c.checkSignedClaim(signedTx, owner, 7, solana.NewWallet().PublicKey()) },
+		"other envelope": func() error { return c.checkSignedClaim(signedTx, owner, 8, claimer) },
+		"other owner":    func() error { return c.checkSignedClaim(signedTx, solana.NewWallet().PublicKey(), 7, claimer) },
+	} {
+		if err := check(); !errors.Is(err, ErrClaimMismatch) {
+			t.Errorf("%s: err = %v, want ErrClaimMismatch", name, err)
+		}
+	}
+
+	// Two claims in one transaction would be counted once
+	tx, err = solana.NewTransaction([]solana.Instruction{instruction, instruction}, solana.Hash{}, solana.TransactionPayer(claimer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &claimerKey }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.checkSignedClaim(tx.MustToBase64(), owner, 7, claimer); !errors.Is(err, ErrClaimMismatch) {
+		t.Errorf("double claim: err = %v, want ErrClaimMismatch", err)
+	}
+}
+
+func TestClaimCapPolicyRefusesOverGroupCap(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "caps.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := store.SetGroupClaimCap(ctx, &storage.GroupClaimCap{GroupID: "chat-1", MaxPerDay: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := NewClaimCapPolicy(store, 0)
+	policy.now = func() time.Time { return time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC) }
+	owner := solana.NewWallet().PublicKey()
+	claimer := solana.NewWallet().PublicKey()
+	// Envelope 1 and 2 are sent to chat-1, envelope 3 to no group
+	for id, group := range map[uint64]string{1: "chat-1", 2: "chat-1", 3: ""} {
+		if err := policy.IndexEnvelope(ctx, owner, id, group); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := policy.Reserve(ctx, owner, 1, claimer); err != nil {
+		t.Fatalf("first claim in group: %v", err)
+	}
+	if err := policy.Check(ctx, owner, 2, claimer); !isCapExceeded(err) {
+		t.Errorf("Check of second claim in group: err = %v, want %s", err, ClaimCapExceeded)
+	}
+	_, err = policy.Reserve(ctx, owner, 2, claimer)
+	if !isCapExceeded(err) {
+		t.Fatalf("Reserve of second claim in group: err = %v, want %s", err, ClaimCapExceeded)
+	}
+	if capErr, _ := AsClaimCapError(err); capErr.GroupID != "chat-1" || capErr.Limit != 1 || capErr.Used != 1 {
+		t.Errorf("cap error = %+v", capErr)
+	}
+
+	// Other wallets and envelopes outside the group are not limited
+	if _, err := policy.Reserve(ctx, owner, 2, solana.NewWallet().PublicKey()); err != nil {
+		t.Errorf("claim of another wallet: %v", err)
+	}
+	if _, err := policy.Reserve(ctx, owner, 3, claimer); err != nil {
+		t.Errorf("claim outside any group: %v", err)
+	}
+
+	// Re-creating envelope 2 outside the group (the first create never landed) lifts the cap
+	if err := policy.IndexEnvelope(ctx, owner, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Check(ctx, owner, 2, claimer); err != nil {
+		t.Errorf("claim after envelope left the group: %v", err)
+	}
+}
+
+func isCapExceeded(err error) bool {
+	capErr, ok := AsClaimCapError(err)
+	return ok && capErr.Code == ClaimCapExceeded
+}
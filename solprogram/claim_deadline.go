@@ -0,0 +1,138 @@
+package solprogram
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ClaimTooCloseToExpiry - Service error code for claims refused by the deadline guard
+const ClaimTooCloseToExpiry = "CLAIM_TOO_CLOSE_TO_EXPIRY"
+
+// DefaultClaimMinRemaining - A claim needs a wallet round trip plus landing time;
+// with less validity left it usually fails on chain with Expired
+const DefaultClaimMinRemaining = 30 * time.Second
+
+// DefaultClaimDeadlinePolicy - Refuse claims with less than DefaultClaimMinRemaining left
+var DefaultClaimDeadlinePolicy = &ClaimDeadlinePolicy{MinRemaining: DefaultClaimMinRemaining}
+
+// ClaimDeadlineError - Claim refused because the envelope expires too soon
+type ClaimDeadlineError struct {
+	Code         string        `json:"code"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	Remaining    time.Duration `json:"remaining"`
+	MinRemaining time.Duration `json:"min_remaining"`
+}
+
+func (e *ClaimDeadlineError) Error() string {
+	if e.Remaining <= 0 {
+		return fmt.Sprintf("%s: envelope expired at %s (cluster clock)", e.Code, e.ExpiresAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s: envelope expires in %s (cluster clock), claims need at least %s",
+		e.Code, e.Remaining.Round(time.Second), e.MinRemaining)
+}
+
+// AsClaimDeadlineError - ClaimDeadlineError in err's chain, if any
+func AsClaimDeadlineError(err error) (*ClaimDeadlineError, bool) {
+	var deadlineErr *ClaimDeadlineError
+	ok := errors.As(err, &deadlineErr)
+	return deadlineErr, ok
+}
+
+// ClaimDeadlinePolicy - Refuse (or with WarnOnly, only warn about) unsigned claims whose envelope
+// has less than MinRemaining left. Remaining validity is measured against the cluster clock
+// (Clock sysvar), which is what the program compares the expiry with, not local time.
+// Expired envelopes are always refused.
+type ClaimDeadlinePolicy struct {
+	MinRemaining time.Duration
+	WarnOnly     bool
+}
+
+// ParseClaimDeadlinePolicy - Policy from CLAIM_MIN_REMAINING (duration, "" = default, "0" = off)
+// and CLAIM_DEADLINE_MODE ("refuse" (default) | "warn")
+func ParseClaimDeadlinePolicy(minRemaining, mode string) (*ClaimDeadlinePolicy, error) {
+	policy := *DefaultClaimDeadlinePolicy
+	if minRemaining = strings.TrimSpace(minRemaining); minRemaining != "" {
+		d, err := time.ParseDuration(minRemaining)
+		if err != nil {
+			return nil, fmt.Errorf("invalid claim min remaining %q: %w", minRemaining, err)
+		}
+		if d <= 0 {
+			return nil, nil
+		}
+		policy.MinRemaining = d
+	}
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "refuse":
+	case "warn":
+		policy.WarnOnly = true
+	default:
+		return nil, fmt.Errorf("unknown claim deadline mode %q", mode)
+	}
+	return &policy, nil
+}
+
+// Check - Warning for a claim on an envelope expiring at expiry, or a *ClaimDeadlineError (nil policy: no check)
+func (p *ClaimDeadlinePolicy) Check(ctx context.Context, client *rpc.Client, expiry time.Time) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+
+	now, err := ChainTime(ctx, client)
+	if err != nil {
+		log.Printf("claim deadline: cluster clock unavailable, using local time: %v", err)
+		now = time.Now()
+	}
+	remaining := expiry.Sub(now)
+	if remaining >= p.MinRemaining && remaining > 0 {
+		return "", nil
+	}
+
+	deadlineErr := &ClaimDeadlineError{
+		Code:         ClaimTooCloseToExpiry,
+		ExpiresAt:    expiry,
+		Remaining:    max(remaining, 0),
+		MinRemaining: p.MinRemaining,
+	}
+	if p.WarnOnly && remaining > 0 {
+		return deadlineErr.Error() + "; sign and submit immediately", nil
+	}
+	return "", deadlineErr
+}
+
+// ChainTime - Cluster unix time from the Clock sysvar
+func ChainTime(ctx context.Context, client *rpc.Client) (time.Time, error) {
+	account, err := client.GetAccountInfoWithOpts(ctx, solana.SysVarClockPubkey, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read clock sysvar: %w", err)
+	}
+	// slot(8) + epoch_start_timestamp(8) + epoch(8) + leader_schedule_epoch(8) + unix_timestamp(8)
+	data := account.GetBinary()
+	if len(data) < 40 {
+		return time.Time{}, fmt.Errorf("invalid clock sysvar length: %d", len(data))
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint64(data[32:40])), 0), nil
+}
+
+// SetClaimDeadlinePolicy - Deadline guard for unsigned claims (nil disables it)
+func (c *USDCEnvelopeClient) SetClaimDeadlinePolicy(policy *ClaimDeadlinePolicy) {
+	c.claimDeadline = policy
+}
+
+// claimDeadlineResponse - Failed response for a claim refused by the deadline guard
+func claimDeadlineResponse(err error) Response {
+	response := Response{Success: false, Message: err.Error()}
+	if deadlineErr, ok := AsClaimDeadlineError(err); ok {
+		response.Code = deadlineErr.Code
+	}
+	return response
+}
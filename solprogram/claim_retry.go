@@ -0,0 +1,290 @@
+package solprogram
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/preflight"
+	"blockchain/pricing"
+)
+
+// FailureClass - How a failed submission can be recovered
+type FailureClass string
+
+const (
+	FailureTransient FailureClass = "transient" // node behind, 429, timeouts: resend the same signed tx
+	FailureExpired   FailureClass = "expired"   // blockhash gone: rebuild and re-sign
+	FailurePermanent FailureClass = "permanent" // program error, bad signature, ...: surface to user
+)
+
+var (
+	expiredPatterns = []string{
+		"BlockhashNotFound",
+		"Blockhash not found",
+		"block height exceeded",
+	}
+	transientPatterns = []string{
+		"429",
+		"Too Many Requests",
+		"Node is behind",
+		"node is behind",
+		"NodeUnhealthy",
+		"-32005",
+		"context deadline exceeded",
+		"connection reset",
+		"connection refused",
+		"EOF",
+		"502 Bad Gateway",
+		"503 Service Unavailable",
+		"504 Gateway Timeout",
+	}
+)
+
+// ClassifyFailure - Classify a send error
+func ClassifyFailure(err error) FailureClass {
+	if err == nil {
+		return ""
+	}
+	errStr := err.Error()
+	for _, p := range expiredPatterns {
+		if strings.Contains(errStr, p) {
+			return FailureExpired
+		}
+	}
+	// Program errors are final even when the RPC wraps them in a retryable-looking message
+	if ExtractErrorCode(err) != nil {
+		return FailurePermanent
+	}
+	for _, p := range transientPatterns {
+		if strings.Contains(errStr, p) {
+			return FailureTransient
+		}
+	}
+	return FailurePermanent
+}
+
+// ClaimRetryPolicy - Limits of the claim orchestrator
+type ClaimRetryPolicy struct {
+	MaxSendAttempts int           // sends of the same signed tx (transient failures)
+	MaxResigns      int           // fresh unsigned txs handed back per claim (expired blockhash)
+	BaseDelay       time.Duration // first resend delay, doubled per attempt
+	MaxDelay        time.Duration
+	ResignWindow    time.Duration // how long the re-sign count of a claim is remembered
+}
+
+// DefaultClaimRetryPolicy - 3 sends, 2 re-signs
+var DefaultClaimRetryPolicy = ClaimRetryPolicy{
+	MaxSendAttempts: 3,
+	MaxResigns:      2,
+	BaseDelay:       500 * time.Millisecond,
+	MaxDelay:        4 * time.Second,
+	ResignWindow:    10 * time.Minute,
+}
+
+// ClaimOutcome - Result of an orchestrated claim submission
+type ClaimOutcome string
+
+const (
+	ClaimSent           ClaimOutcome = "sent"
+	ClaimResignRequired ClaimOutcome = "resign_required" // sign UnsignedTx and submit again
+	ClaimFailed         ClaimOutcome = "failed"
+)
+
+// ClaimSubmission - Signed claim sent through the orchestrator
+type ClaimSubmission struct {
+	Owner             solana.PublicKey
+	Claimer           solana.PublicKey
+	EnvelopeID        uint64
+	SignedTransaction string
+	Preflight         preflight.Mode
+}
+
+// ClaimSubmitResult - Orchestrator response (field names match Response)
+type ClaimSubmitResult struct {
+	Success        bool         `json:"success"`
+	Outcome        ClaimOutcome `json:"outcome"`
+	Message        string       `json:"message,omitempty"`
+	TransactionSig string       `json:"transaction_sig,omitempty"`
+	UnsignedTx     string       `json:"unsigned_tx,omitempty"` // set when Outcome is resign_required
+	Encoding       string       `json:"encoding,omitempty"`    // Encoding of unsigned_tx (same as the submitted transaction)
+	Attempts       int          `json:"attempts"`
+	Resigns        int          `json:"resigns"`
+	FailureClass   FailureClass `json:"failure_class,omitempty"`
+	ErrorCode      *int         `json:"error_code,omitempty"`
+	ProgramLogs    []string     `json:"program_logs,omitempty"`
+	TransactionID  string       `json:"transaction_id,omitempty"` // Key of the stored failure
+	EstimatedFee   *pricing.Fee `json:"estimated_fee,omitempty"`  // Network fee of unsigned_tx
+}
+
+// ClaimSendFunc - Send a signed transaction (Client.SendTransactionWithPreflight)
+type ClaimSendFunc func(signedTxBase64 string, mode preflight.Mode) (*SendTransactionResult, error)
+
+// ClaimBuildFunc - Build a fresh unsigned claim transaction (Client.BuildClaimTransaction)
+type ClaimBuildFunc func(owner, claimer solana.PublicKey, envelopeID uint64) (string, error)
+
+type resignEntry struct {
+	count     int
+	updatedAt time.Time
+}
+
+// ClaimOrchestrator - Retries transient claim failures and hands back re-sign requests on expiry,
+// surfacing an error only once the policy is exhausted
+type ClaimOrchestrator struct {
+	policy ClaimRetryPolicy
+	send   ClaimSendFunc
+	build  ClaimBuildFunc
+
+	mu      sync.Mutex
+	resigns map[string]resignEntry
+}
+
+// NewClaimOrchestrator - Create orchestrator
+func NewClaimOrchestrator(send ClaimSendFunc, build ClaimBuildFunc, policy ClaimRetryPolicy) *ClaimOrchestrator {
+	if policy.MaxSendAttempts <= 0 {
+		policy.MaxSendAttempts = 1
+	}
+	return &ClaimOrchestrator{
+		policy:  policy,
+		send:    send,
+		build:   build,
+		resigns: make(map[string]resignEntry),
+	}
+}
+
+// Submit - Send a signed claim following the retry policy
+func (o *ClaimOrchestrator) Submit(ctx context.Context, sub ClaimSubmission) *ClaimSubmitResult {
+	key := fmt.Sprintf("%s:%d:%s", sub.Owner, sub.EnvelopeID, sub.Claimer)
+	result := &ClaimSubmitResult{Resigns: o.resignCount(key)}
+
+	var err error
+	var sent *SendTransactionResult
+	for attempt := 1; attempt <= o.policy.MaxSendAttempts; attempt++ {
+		result.Attempts = attempt
+		sent, err = o.send(sub.SignedTransaction, sub.Preflight)
+		if err == nil {
+			o.forget(key)
+			result.Success = true
+			result.Outcome = ClaimSent
+			result.TransactionSig = sent.Signature
+			result.Message = "Claim sent successfully"
+			return result
+		}
+
+		// A resend of a transaction that landed on an earlier attempt
+		if attempt > 1 && strings.Contains(err.Error(), "already been processed") {
+			if sig, ok := firstSignature(sub.SignedTransaction); ok {
+				o.forget(key)
+				result.Success = true
+				result.Outcome = ClaimSent
+				result.TransactionSig = sig
+				result.Message = "Claim sent successfully"
+				return result
+			}
+		}
+
+		result.FailureClass = ClassifyFailure(err)
+		if result.FailureClass != FailureTransient || attempt == o.policy.MaxSendAttempts {
+			break
+		}
+		if !sleepCtx(ctx, o.backoff(attempt)) {
+			err = ctx.Err()
+			break
+		}
+	}
+
+	if sent != nil {
+		result.ErrorCode = sent.ErrorCode
+		result.ProgramLogs = sent.ProgramLogs
+	}
+
+	if result.FailureClass == FailureExpired && result.Resigns < o.policy.MaxResigns {
+		unsignedTx, buildErr := o.build(sub.Owner, sub.Claimer, sub.EnvelopeID)
+		if buildErr == nil {
+			result.Resigns = o.recordResign(key)
+			result.Outcome = ClaimResignRequired
+			result.UnsignedTx = unsignedTx
+			result.Message = "Transaction expired before it landed. Please sign the refreshed transaction."
+			return result
+		}
+		err = fmt.Errorf("failed to rebuild claim: %w", buildErr)
+	}
+
+	o.forget(key)
+	result.Outcome = ClaimFailed
+	result.Message = ParseSolanaError(err)
+	return result
+}
+
+func (o *ClaimOrchestrator) backoff(attempt int) time.Duration {
+	delay := o.policy.BaseDelay << (attempt - 1)
+	if o.policy.MaxDelay > 0 && delay > o.policy.MaxDelay {
+		delay = o.policy.MaxDelay
+	}
+	return delay
+}
+
+func (o *ClaimOrchestrator) resignCount(key string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entry, ok := o.resigns[key]
+	if !ok || time.Since(entry.updatedAt) > o.policy.ResignWindow {
+		return 0
+	}
+	return entry.count
+}
+
+func (o *ClaimOrchestrator) recordResign(key string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// Drop stale entries opportunistically so the map doesn't grow forever
+	now := time.Now()
+	for k, entry := range o.resigns {
+		if now.Sub(entry.updatedAt) > o.policy.ResignWindow {
+			delete(o.resigns, k)
+		}
+	}
+	entry := o.resigns[key]
+	entry.count++
+	entry.updatedAt = now
+	o.resigns[key] = entry
+	return entry.count
+}
+
+func (o *ClaimOrchestrator) forget(key string) {
+	o.mu.Lock()
+	delete(o.resigns, key)
+	o.mu.Unlock()
+}
+
+// firstSignature - Fee payer signature of a base64 signed transaction
+func firstSignature(signedTxBase64 string) (string, bool) {
+	txBytes, err := base64.StdEncoding.DecodeString(signedTxBase64)
+	if err != nil {
+		return "", false
+	}
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(txBytes))
+	if err != nil || len(tx.Signatures) == 0 {
+		return "", false
+	}
+	return tx.Signatures[0].String(), true
+}
+
+// sleepCtx - Sleep unless ctx is done first (false when interrupted)
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,57 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ClaimNotStarted - Service error code for claims before the envelope's start time
+const ClaimNotStarted = "CLAIM_NOT_STARTED"
+
+// ClaimNotStartedError - Claim refused because the envelope isn't claimable yet, with the countdown
+type ClaimNotStartedError struct {
+	Code            string    `json:"code"`
+	StartsAt        time.Time `json:"starts_at"`
+	StartsInSeconds int64     `json:"starts_in_seconds"` // Cluster clock
+}
+
+func (e *ClaimNotStartedError) Error() string {
+	return fmt.Sprintf("%s: envelope claimable in %s (at %s, cluster clock)",
+		e.Code, time.Duration(e.StartsInSeconds)*time.Second, e.StartsAt.Format(time.RFC3339))
+}
+
+// AsClaimNotStartedError - ClaimNotStartedError in err's chain, if any
+func AsClaimNotStartedError(err error) (*ClaimNotStartedError, bool) {
+	var notStartedErr *ClaimNotStartedError
+	ok := errors.As(err, &notStartedErr)
+	return notStartedErr, ok
+}
+
+// ValidateClaimWindow - *ClaimNotStartedError while now is before the envelope's start time
+func (e *EnvelopeInfo) ValidateClaimWindow(now time.Time) error {
+	if e.StartTime == nil || !now.Before(*e.StartTime) {
+		return nil
+	}
+	return &ClaimNotStartedError{
+		Code:            ClaimNotStarted,
+		StartsAt:        *e.StartTime,
+		StartsInSeconds: int64(e.StartTime.Sub(now).Round(time.Second) / time.Second),
+	}
+}
+
+// checkClaimWindow - ValidateClaimWindow against the cluster clock, which the program compares
+// the start time with (local time when the Clock sysvar is unavailable)
+func (c *USDCEnvelopeClient) checkClaimWindow(ctx context.Context, envelope *EnvelopeInfo) error {
+	if envelope.StartTime == nil {
+		return nil
+	}
+	now, err := ChainTime(ctx, c.rpcClient)
+	if err != nil {
+		log.Printf("claim window: cluster clock unavailable, using local time: %v", err)
+		now = time.Now()
+	}
+	return envelope.ValidateClaimWindow(now)
+}
@@ -11,14 +11,52 @@ import (
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/attestation"
+	"blockchain/chain"
+	"blockchain/circuit"
+	"blockchain/dust"
+	"blockchain/explorer"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/receipt"
+	"blockchain/storage"
 )
 
 // Client wraps Sol RPC client
 type Client struct {
 	RPC       *rpc.Client
 	ProgramID solana.PublicKey
+	Breakers  *circuit.Registry
+	Preflight *preflight.Policy // Per-tenant preflight defaults (node default when nil)
+	Config    *ProgramConfigCache
+	Claims    *ClaimOrchestrator
+	History   *storage.Store // Failed submissions with program logs (optional)
+	Explorer  explorer.Explorer
+	ClaimCaps *ClaimCapPolicy // Per-group daily claim caps (unlimited when nil)
+	// ClaimDeadline - Refuse claims close to expiry (DefaultClaimDeadlinePolicy, no check when nil)
+	ClaimDeadline *ClaimDeadlinePolicy
+	// AllowList - Programs/instructions a submitted transaction may invoke (SOLAllowList, no check when nil)
+	AllowList *InstructionAllowList
+	// EnvelopeRules - Per-type validation matrix for new envelopes (SOLEnvelopeRules, no check when nil)
+	EnvelopeRules EnvelopeRules
+	// Dust - Minimum per-user share of new envelopes in lamports (dust.Default, no check when nil)
+	Dust   *dust.Policy
+	Prices pricing.Source // USD prices for fee estimates (SOL only when nil)
+	// Attestor - Service attestation key signing claim receipts (AttestClaim disabled when nil)
+	Attestor *attestation.Signer
+	// RefundQueue - Refunds sent through HandleSendTransaction wait here, prioritized by refund risk
+	// (sent directly when nil; its SubmitFunc is usually Client.SubmitQueued)
+	RefundQueue *SubmissionQueue
+
+	issued *issuedTransactions // IDs of unsigned transactions handed out by the Handle* builders
 }
 
+var (
+	_ chain.EnvelopeAPI    = (*Client)(nil)
+	_ chain.ClaimSubmitter = (*Client)(nil)
+)
+
 // SendTransactionResult contains transaction result and parsed error
 type SendTransactionResult struct {
 	Signature   string
@@ -28,17 +66,52 @@ type SendTransactionResult struct {
 
 // NewClient creates new Sol program client
 func NewClient(rpcURL string, programID string) (*Client, error) {
-	rpcClient := rpc.New(rpcURL)
+	return NewClientWithRPC(rpc.New(rpcURL), programID)
+}
 
+// NewClientWithRPC creates new Sol program client on an existing RPC client, e.g. rpcpool.Router.Client()
+func NewClientWithRPC(rpcClient *rpc.Client, programID string) (*Client, error) {
 	programPubkey, err := solana.PublicKeyFromBase58(programID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid program ID: %w", err)
 	}
 
-	return &Client{
+	c := &Client{
 		RPC:       rpcClient,
 		ProgramID: programPubkey,
-	}, nil
+		Breakers:  NewBreakerRegistry(),
+		Config:    NewProgramConfigCache(rpcClient, programPubkey, DefaultSOLProgramConfig, DefaultProgramConfigTTL),
+		Explorer:  explorer.New(chain.Solana, chain.Mainnet, explorer.SolanaExplorer),
+
+		ClaimDeadline: DefaultClaimDeadlinePolicy,
+		AllowList:     SOLAllowList(programPubkey),
+		EnvelopeRules: SOLEnvelopeRules(),
+		Dust:          dust.Default(),
+
+		issued: newIssuedTransactions(),
+	}
+	c.Claims = NewClaimOrchestrator(func(signedTxBase64 string, mode preflight.Mode) (*SendTransactionResult, error) {
+		return c.SendActionWithPreflight(signedTxBase64, chain.EnvelopeActionClaim, mode)
+	}, c.BuildClaimTransaction, DefaultClaimRetryPolicy)
+	return c, nil
+}
+
+// EstimateFee estimates the network fee of a base64 unsigned transaction (nil if it can't be decoded)
+func (c *Client) EstimateFee(ctx context.Context, unsignedTxBase64 string) *pricing.Fee {
+	tx, err := solana.TransactionFromBase64(unsignedTxBase64)
+	if err != nil {
+		return nil
+	}
+	return pricing.FormatLamports(ctx, c.Prices, receipt.EstimateSolanaFee(ctx, c.RPC, tx))
+}
+
+// BuildClaimTransaction creates unsigned claim transaction paid by the claimer
+func (c *Client) BuildClaimTransaction(owner, claimer solana.PublicKey, envelopeID uint64) (string, error) {
+	instruction, err := BuildClaimInstruction(c.ProgramID, owner, claimer, envelopeID)
+	if err != nil {
+		return "", err
+	}
+	return c.CreateTransaction(instruction, claimer)
 }
 
 // CreateTransaction creates unsigned transaction for single instruction
@@ -81,6 +154,19 @@ func (c *Client) CreateTransactionWithInstructions(
 
 // SendTransaction sends signed transaction
 func (c *Client) SendTransaction(signedTxBase64 string) (*SendTransactionResult, error) {
+	return c.SendTransactionWithPreflight(signedTxBase64, c.Preflight.Resolve("", preflight.Default))
+}
+
+// SendTransactionWithPreflight sends signed transaction with the given preflight mode, allowing any
+// envelope instruction (for transactions the caller built itself; client submissions go through
+// SendActionWithPreflight with the action of their envelope instructions)
+func (c *Client) SendTransactionWithPreflight(signedTxBase64 string, mode preflight.Mode) (*SendTransactionResult, error) {
+	return c.SendActionWithPreflight(signedTxBase64, "", mode)
+}
+
+// SendActionWithPreflight sends signed transaction with the given preflight mode, allowing only the
+// envelope instructions of action ("" = any envelope action)
+func (c *Client) SendActionWithPreflight(signedTxBase64, action string, mode preflight.Mode) (*SendTransactionResult, error) {
 	// Decode
 	txBytes, err := base64.StdEncoding.DecodeString(signedTxBase64)
 	if err != nil {
@@ -92,9 +178,12 @@ func (c *Client) SendTransaction(signedTxBase64 string) (*SendTransactionResult,
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse transaction: %w", err)
 	}
+	if err := c.AllowList.Validate(tx, action); err != nil {
+		return nil, err
+	}
 
 	// Send
-	sig, err := c.RPC.SendTransaction(context.Background(), tx)
+	sig, err := preflight.Send(context.Background(), c.RPC, tx, mode)
 	if err != nil {
 		fmt.Printf("=== RAW ERROR ===\n%+v\n=================\n", err)
 
@@ -102,6 +191,9 @@ func (c *Client) SendTransaction(signedTxBase64 string) (*SendTransactionResult,
 		result := &SendTransactionResult{
 			ProgramLogs: ExtractLogMessages(err),
 		}
+		if logs := preflight.Logs(err); len(logs) > 0 {
+			result.ProgramLogs = logs
+		}
 		errStr := err.Error()
 
 		// Pattern 1: "Custom": 6002
@@ -130,6 +222,23 @@ func (c *Client) SendTransaction(signedTxBase64 string) (*SendTransactionResult,
 	}, nil
 }
 
+// recordFailure persists a failed submission for support and returns the key it was stored under
+// (the transaction ID, or the fee payer signature when the client sent none). No-op without History.
+func (c *Client) recordFailure(transactionID, signedTxBase64, action, message string, errorCode *int, logs []string) string {
+	failure := &storage.SubmissionFailure{
+		TransactionID: transactionID,
+		Chain:         chain.Solana,
+		Action:        action,
+		ErrorCode:     errorCode,
+		Message:       message,
+		ProgramLogs:   logs,
+	}
+	if sig, ok := firstSignature(signedTxBase64); ok {
+		failure.Signature = sig
+	}
+	return c.History.ReportSubmissionFailure(failure)
+}
+
 // SendTransactionSimple : Legacy function for backward compatibility (if needed)
 func (c *Client) SendTransactionSimple(signedTxBase64 string) (string, error) {
 	result, err := c.SendTransaction(signedTxBase64)
@@ -163,3 +272,16 @@ func (c *Client) SendTransactionWithRetry(signedTxBase64 string, maxRetries int)
 
 	return nil, fmt.Errorf("max retries exceeded")
 }
+
+// getEnvelopeInfo - Fetch amounts and expiry of a SOL program envelope (refund and claim checks)
+func (c *Client) getEnvelopeInfo(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*EnvelopeInfo, error) {
+	envelopePDA, _, err := DeriveEnvelopePDA(c.ProgramID, owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := c.RPC.GetAccountInfo(ctx, envelopePDA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope: %w", err)
+	}
+	return parseSOLEnvelopeData(account.GetBinary())
+}
@@ -0,0 +1,177 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultProgramConfigTTL - How long a fetched config account stays fresh.
+// Limits change only by admin instruction, so this can be much longer than the envelope cache.
+const DefaultProgramConfigTTL = 30 * time.Second
+
+// Config account layout: discriminator(8) + admin(32) + max_create_amount(8) + min_amount_per_user(8) + paused(1) + bump(1)
+const (
+	programConfigLen = 58
+	// ConfigPausedFlagOffset - Offset of the paused flag, usable as PauseAccount.PausedFlagOffset for the config PDA
+	ConfigPausedFlagOffset = 56
+)
+
+var (
+	// ErrConfigNotFound - Config PDA not initialized on this deployment
+	ErrConfigNotFound = errors.New("program config account not found")
+	// ErrProgramPaused - Program config has the paused flag set
+	ErrProgramPaused = errors.New("program is paused")
+	// ErrExceedMaxCreate - Envelope total above the program maximum
+	ErrExceedMaxCreate = errors.New("amount exceeds program maximum")
+	// ErrBelowMinPerUser - Share per user below the program minimum
+	ErrBelowMinPerUser = errors.New("amount per user below program minimum")
+)
+
+// ProgramConfig - Program-level limits stored in the config PDA
+type ProgramConfig struct {
+	Admin            solana.PublicKey `json:"admin"`
+	MaxCreateAmount  uint64           `json:"max_create_amount"`
+	MinAmountPerUser uint64           `json:"min_amount_per_user"`
+	Paused           bool             `json:"paused"`
+	OnChain          bool             `json:"on_chain"` // false when using built-in defaults (no config PDA deployed)
+}
+
+// Built-in limits, used only when the deployed program has no config PDA
+var (
+	DefaultSOLProgramConfig = ProgramConfig{
+		MaxCreateAmount:  MaxCreateAmountSOL,
+		MinAmountPerUser: MinAmountPerUserSOL,
+	}
+	DefaultUSDCProgramConfig = ProgramConfig{
+		MaxCreateAmount:  MaxCreateAmountUSDC,
+		MinAmountPerUser: MinAmountPerUserUSDC,
+	}
+)
+
+// ValidateCreate - Check a new envelope against the program limits
+func (cfg *ProgramConfig) ValidateCreate(totalAmount, totalUsers uint64) error {
+	if cfg.Paused {
+		return ErrProgramPaused
+	}
+	if cfg.MaxCreateAmount > 0 && totalAmount > cfg.MaxCreateAmount {
+		return fmt.Errorf("%w: %d > %d", ErrExceedMaxCreate, totalAmount, cfg.MaxCreateAmount)
+	}
+	if totalUsers > 0 && totalAmount/totalUsers < cfg.MinAmountPerUser {
+		return fmt.Errorf("%w: %d < %d", ErrBelowMinPerUser, totalAmount/totalUsers, cfg.MinAmountPerUser)
+	}
+	return nil
+}
+
+// DeriveConfigPDA - Derive program config PDA
+func DeriveConfigPDA(programID solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return solana.FindProgramAddress([][]byte{SeedConfig}, programID)
+}
+
+// FetchProgramConfig - Read config PDA (ErrConfigNotFound when not initialized)
+func FetchProgramConfig(ctx context.Context, client *rpc.Client, programID solana.PublicKey) (*ProgramConfig, error) {
+	configPDA, _, err := DeriveConfigPDA(programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive config PDA: %w", err)
+	}
+	account, err := client.GetAccountInfo(ctx, configPDA)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && (account == nil || account.Value == nil)) {
+		return nil, ErrConfigNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config account: %w", err)
+	}
+	if !account.Value.Owner.Equals(programID) {
+		return nil, fmt.Errorf("config account %s not owned by program", configPDA)
+	}
+	return parseProgramConfigData(account.Value.Data.GetBinary())
+}
+
+// ProgramConfigCache - Cached view of the config PDA with fallback to built-in defaults
+type ProgramConfigCache struct {
+	client    *rpc.Client
+	programID solana.PublicKey
+	defaults  ProgramConfig
+	ttl       time.Duration
+
+	mu        sync.RWMutex
+	cfg       *ProgramConfig
+	fetchedAt time.Time
+	group     singleflight.Group
+}
+
+// NewProgramConfigCache - Create config cache; defaults apply only while no config PDA exists
+func NewProgramConfigCache(client *rpc.Client, programID solana.PublicKey, defaults ProgramConfig, ttl time.Duration) *ProgramConfigCache {
+	return &ProgramConfigCache{
+		client:    client,
+		programID: programID,
+		defaults:  defaults,
+		ttl:       ttl,
+	}
+}
+
+// Get - Current program config. On RPC failure the last known config is served (if any).
+func (c *ProgramConfigCache) Get(ctx context.Context) (*ProgramConfig, error) {
+	c.mu.RLock()
+	cached, fetchedAt := c.cfg, c.fetchedAt
+	c.mu.RUnlock()
+	if cached != nil && time.Since(fetchedAt) <= c.ttl {
+		cfg := *cached
+		return &cfg, nil
+	}
+
+	ch := c.group.DoChan("config", func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), envelopeFetchTimeout)
+		defer cancel()
+
+		cfg, err := FetchProgramConfig(fetchCtx, c.client, c.programID)
+		if errors.Is(err, ErrConfigNotFound) {
+			defaults := c.defaults
+			cfg, err = &defaults, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.cfg, c.fetchedAt = cfg, time.Now()
+		c.mu.Unlock()
+		return cfg, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			if cached != nil {
+				cfg := *cached
+				return &cfg, nil
+			}
+			return nil, res.Err
+		}
+		cfg := *res.Val.(*ProgramConfig)
+		return &cfg, nil
+	}
+}
+
+// Invalidate - Force the next Get to re-read the account (e.g. after an admin update)
+func (c *ProgramConfigCache) Invalidate() {
+	c.mu.Lock()
+	c.fetchedAt = time.Time{}
+	c.mu.Unlock()
+}
+
+// ValidateCreate - Get config and validate a new envelope against it
+func (c *ProgramConfigCache) ValidateCreate(ctx context.Context, totalAmount, totalUsers uint64) error {
+	cfg, err := c.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load program config: %w", err)
+	}
+	return cfg.ValidateCreate(totalAmount, totalUsers)
+}
@@ -0,0 +1,100 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/backoff"
+	"blockchain/txstatus"
+)
+
+// ConfirmationProgress - Reported after every poll
+type ConfirmationProgress struct {
+	Attempt int
+	Elapsed time.Duration
+	Level   rpc.ConfirmationStatusType // "" while the transaction is not seen yet
+	Slot    uint64
+	Err     error // RPC error of this poll (polling continues)
+}
+
+// WaitOptions - WaitForConfirmation settings; the deadline comes from ctx
+type WaitOptions struct {
+	Commitment rpc.ConfirmationStatusType // Level to wait for (default confirmed)
+	Backoff    *backoff.Policy            // Delay between polls (default backoff.Default)
+	OnProgress func(ConfirmationProgress) // Optional, called after every poll
+}
+
+// ConfirmationResult - Outcome of WaitForConfirmation (also returned with the error on timeout)
+type ConfirmationResult struct {
+	Signature     string                     `json:"signature"`
+	Status        TransactionStatus          `json:"status"`
+	Level         rpc.ConfirmationStatusType `json:"level,omitempty"` // Highest level reached
+	Slot          uint64                     `json:"slot,omitempty"`
+	Confirmations *uint64                    `json:"confirmations,omitempty"`
+	Attempts      int                        `json:"attempts"`
+	Elapsed       time.Duration              `json:"elapsed"`
+}
+
+var confirmationRank = map[rpc.ConfirmationStatusType]int{
+	rpc.ConfirmationStatusProcessed: 1,
+	rpc.ConfirmationStatusConfirmed: 2,
+	rpc.ConfirmationStatusFinalized: 3,
+}
+
+// WaitForConfirmation - Poll until the transaction reaches opts.Commitment, fails, or ctx is done.
+// Polls back off exponentially with jitter and go through the client's StatusPoller, so concurrent
+// waits share one getSignatureStatuses call per batch window.
+func (c *USDCEnvelopeClient) WaitForConfirmation(ctx context.Context, signature string, opts WaitOptions) (*ConfirmationResult, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.ConfirmationStatusConfirmed
+	}
+	policy := backoff.Default
+	if opts.Backoff != nil {
+		policy = *opts.Backoff
+	}
+
+	start := time.Now()
+	result := &ConfirmationResult{Signature: signature, Status: StatusPending}
+	for {
+		result.Attempts++
+		txStatus, err := c.statusPoller.Status(ctx, sig)
+		result.Elapsed = time.Since(start)
+
+		if err == nil && txStatus != nil {
+			result.Level = txStatus.ConfirmationStatus
+			result.Slot = txStatus.Slot
+			result.Confirmations = txStatus.Confirmations
+			result.Status = txstatus.FromSolana(txStatus.ConfirmationStatus, txStatus.Err)
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(ConfirmationProgress{
+				Attempt: result.Attempts,
+				Elapsed: result.Elapsed,
+				Level:   result.Level,
+				Slot:    result.Slot,
+				Err:     err,
+			})
+		}
+
+		if result.Status == StatusFailed {
+			return result, fmt.Errorf("transaction failed: %v", txStatus.Err)
+		}
+		if confirmationRank[result.Level] >= confirmationRank[opts.Commitment] {
+			c.confirmed(ctx, signature, "", signature, result.Slot, nil)
+			return result, nil
+		}
+
+		if !sleepCtx(ctx, policy.Delay(result.Attempts)) {
+			return result, fmt.Errorf("waiting for %s confirmation after %s (reached %q): %w",
+				opts.Commitment, result.Elapsed.Round(time.Millisecond), result.Level, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+)
+
+// envelopeTxTTL - How long the envelope linkage of an unconfirmed transaction is remembered
+const envelopeTxTTL = 10 * time.Minute
+
+// ConfirmationHook - Called after an envelope transaction is confirmed, e.g.
+// chainsol.SolChain.RecordEnvelopeTransaction to link it into transaction history
+type ConfirmationHook func(ctx context.Context, tx chain.EnvelopeTransaction) error
+
+// OnConfirmed - Register a post-confirmation hook. Hooks run for transactions built by
+// GenerateUnsigned{Create,Claim,Refund} once submitted through SubmitSignedTransaction, and for
+// server-signed Create/Claim/RefundEnvelope once WaitForConfirmation sees them confirmed.
+func (c *USDCEnvelopeClient) OnConfirmed(hook ConfirmationHook) {
+	c.confirmHooks = append(c.confirmHooks, hook)
+}
+
+// trackEnvelopeTx - Remember envelope linkage under a transaction ID or signature
+// (for the hooks and the min context slot of later reads). amount is what the transaction
+// moves when known up front; otherwise (0) it is measured on confirmation as the token gain of
+// the received account (claimer or owner token account).
+func (c *USDCEnvelopeClient) trackEnvelopeTx(key, action string, owner solana.PublicKey, envelopeID uint64, signer solana.PublicKey, amount uint64, received solana.PublicKey) {
+	c.envelopeTxs.add(key, trackedEnvelopeTx{
+		tx: chain.EnvelopeTransaction{
+			Chain:      chain.Solana,
+			Action:     action,
+			EnvelopeID: envelopeID,
+			Owner:      owner.String(),
+			Signer:     signer.String(),
+			Amount:     amount,
+		},
+		received: received,
+	})
+}
+
+// confirmed - Record the confirmation slot and run hooks for a tracked transaction (key = transaction ID or signature).
+// sent is the transaction as submitted when key came from the client: it must act on the tracked envelope,
+// or a transaction ID reused for an unrelated transaction would be linked to that envelope's history.
+func (c *USDCEnvelopeClient) confirmed(ctx context.Context, key, transactionID, signature string, slot uint64, sent *solana.Transaction) {
+	tracked, ok := c.envelopeTxs.take(key)
+	if !ok {
+		return
+	}
+	tx := tracked.tx
+	if sent != nil && !c.actsOnEnvelope(sent, tx) {
+		log.Printf("confirmation hooks: %s doesn't act on envelope #%d of %s tracked under %s, not linked", signature, tx.EnvelopeID, tx.Owner, key)
+		return
+	}
+	c.recordMinContextSlot(tx.Owner, tx.EnvelopeID, slot)
+	tx.TransactionID = transactionID
+	tx.Signature = signature
+	if tx.Amount == 0 && !tracked.received.IsZero() {
+		amount, err := c.amountReceived(ctx, signature, tracked.received)
+		if err != nil {
+			log.Printf("confirmation hooks: amount of %s unknown: %v", signature, err)
+		}
+		tx.Amount = amount
+	}
+	for _, hook := range c.confirmHooks {
+		if err := hook(ctx, tx); err != nil {
+			log.Printf("confirmation hook failed for %s (envelope #%d %s): %v", signature, tx.EnvelopeID, tx.Action, err)
+		}
+	}
+}
+
+// actsOnEnvelope - Whether sent has a program instruction on the envelope PDA of tracked
+func (c *USDCEnvelopeClient) actsOnEnvelope(sent *solana.Transaction, tracked chain.EnvelopeTransaction) bool {
+	owner, err := solana.PublicKeyFromBase58(tracked.Owner)
+	if err != nil {
+		return false
+	}
+	envelopePDA, _, err := c.DeriveEnvelopePDA(owner, tracked.EnvelopeID)
+	if err != nil {
+		return false
+	}
+	for _, inst := range sent.Message.Instructions {
+		programID, err := sent.Message.Program(inst.ProgramIDIndex)
+		if err != nil || !programID.Equals(c.programID) {
+			continue
+		}
+		accounts, err := inst.ResolveInstructionAccounts(&sent.Message)
+		if err != nil {
+			continue
+		}
+		for _, account := range accounts {
+			if account.PublicKey.Equals(envelopePDA) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// envelopeTxs - Envelope linkage of transactions in flight
+type envelopeTxs struct {
+	mu  sync.Mutex
+	txs map[string]trackedEnvelopeTx
+}
+
+type trackedEnvelopeTx struct {
+	tx        chain.EnvelopeTransaction
+	received  solana.PublicKey // Token account whose gain is the amount (zero when tx.Amount is known)
+	createdAt time.Time
+}
+
+func newEnvelopeTxs() *envelopeTxs {
+	return &envelopeTxs{txs: make(map[string]trackedEnvelopeTx)}
+}
+
+func (e *envelopeTxs) add(key string, tx trackedEnvelopeTx) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	for k, tracked := range e.txs {
+		if now.Sub(tracked.createdAt) > envelopeTxTTL {
+			delete(e.txs, k)
+		}
+	}
+	tx.createdAt = now
+	e.txs[key] = tx
+}
+
+func (e *envelopeTxs) take(key string) (trackedEnvelopeTx, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	tracked, ok := e.txs[key]
+	delete(e.txs, key)
+	if !ok || time.Since(tracked.createdAt) > envelopeTxTTL {
+		return trackedEnvelopeTx{}, false
+	}
+	return tracked, true
+}
+
+// amountReceived - Token gain of account in the confirmed transaction signature
+func (c *USDCEnvelopeClient) amountReceived(ctx context.Context, signature string, account solana.PublicKey) (uint64, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return 0, err
+	}
+	maxVersion := uint64(0)
+	result, err := c.rpcClient.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if result == nil || result.Meta == nil || result.Transaction == nil {
+		return 0, fmt.Errorf("transaction %s has no status metadata", signature)
+	}
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	for i, key := range tx.Message.AccountKeys {
+		if key.Equals(account) {
+			_, amount := tokenReceived(result.Meta, uint16(i))
+			return amount, nil
+		}
+	}
+	return 0, fmt.Errorf("account %s not in transaction %s", account, signature)
+}
@@ -0,0 +1,63 @@
+package solprogram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+)
+
+func TestConfirmedChecksTrackedEnvelope(t *testing.T) {
+	c, err := NewUSDCEnvelopeClientWithClients(rpc.New("http://127.0.0.1:0"), nil, chain.Devnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTokenProgramOverride(c.usdcMint, TokenProgramID)
+	var linked []chain.EnvelopeTransaction
+	c.OnConfirmed(func(_ context.Context, tx chain.EnvelopeTransaction) error {
+		linked = append(linked, tx)
+		return nil
+	})
+	owner, ownerToken := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	send := func(inst solana.Instruction) *solana.Transaction {
+		t.Helper()
+		tx, err := solana.NewTransaction([]solana.Instruction{inst}, solana.Hash{}, solana.TransactionPayer(owner))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	// A transaction ID tracked for envelope #3 but submitted with an unrelated transaction
+	c.trackEnvelopeTx("tx-1", chain.EnvelopeActionRefund, owner, 3, owner, 100, solana.PublicKey{})
+	transfer := system.NewTransferInstruction(1, owner, solana.NewWallet().PublicKey()).Build()
+	c.confirmed(context.Background(), "tx-1", "tx-1", "sig-1", 0, send(transfer))
+	if len(linked) != 0 {
+		t.Fatalf("unrelated transaction linked to the envelope: %+v", linked)
+	}
+
+	// A refund of another envelope of the same owner isn't linked either
+	other, err := c.BuildRefundInstruction(RefundParams{EnvelopeID: 4, Owner: owner, OwnerTokenAccount: ownerToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.trackEnvelopeTx("tx-2", chain.EnvelopeActionRefund, owner, 3, owner, 100, solana.PublicKey{})
+	c.confirmed(context.Background(), "tx-2", "tx-2", "sig-2", 0, send(other))
+	if len(linked) != 0 {
+		t.Fatalf("refund of envelope #4 linked to #3: %+v", linked)
+	}
+
+	refund, err := c.BuildRefundInstruction(RefundParams{EnvelopeID: 3, Owner: owner, OwnerTokenAccount: ownerToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.trackEnvelopeTx("tx-3", chain.EnvelopeActionRefund, owner, 3, owner, 100, solana.PublicKey{})
+	c.confirmed(context.Background(), "tx-3", "tx-3", "sig-3", 0, send(refund))
+	if len(linked) != 1 || linked[0].EnvelopeID != 3 || linked[0].TransactionID != "tx-3" || linked[0].Signature != "sig-3" {
+		t.Errorf("linked = %+v, want envelope #3 under tx-3", linked)
+	}
+}
@@ -23,9 +23,10 @@ var (
 	SeedEnvelope      = []byte("envelope")
 	SeedEnvelopeVault = []byte("envelope_vault")
 	SeedClaim         = []byte("claim")
+	SeedConfig        = []byte("config")
 )
 
-// Limits
+// Limits - Fallback only; the deployed values live in the config PDA (see ProgramConfigCache)
 const (
 	// Max amount per envelope: 100 USDC
 	MaxCreateAmountUSDC = 100_000_000 // 100 USDC (6 decimals)
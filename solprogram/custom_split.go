@@ -0,0 +1,128 @@
+package solprogram
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MaxTransactionSize - Packet limit of a serialized transaction, signatures included
+const MaxTransactionSize = 1232
+
+// MaxSplitRecipients - Most CustomSplit recipients whose create transaction fits in MaxTransactionSize
+// (TestCustomSplitTransactionSize finds it with checkTransactionSize)
+const MaxSplitRecipients = 19
+
+// ErrNotRecipient - Claimer isn't entitled to anything from the envelope (program error NotAllowed)
+var ErrNotRecipient = errors.New("claimer is not a recipient of this envelope")
+
+// CustomSplitParams - CreateEnvelopeParams of a CustomSplit envelope paying each address exactly its
+// amount; total_amount and total_users follow from the map, recipients are sorted by address
+func CustomSplitParams(amounts map[string]uint64, expirySeconds uint64) (CreateEnvelopeParams, error) {
+	params := CreateEnvelopeParams{
+		EnvelopeType:  EnvelopeTypeData{Type: EnvelopeTypeCustomSplit},
+		TotalUsers:    uint64(len(amounts)),
+		ExpirySeconds: expirySeconds,
+	}
+	for address, amount := range amounts {
+		pubkey, err := solana.PublicKeyFromBase58(address)
+		if err != nil {
+			return params, &EnvelopeRuleError{Type: RequestTypeCustomSplit, Field: "recipients", Reason: fmt.Sprintf("invalid address %q", address)}
+		}
+		if params.TotalAmount+amount < params.TotalAmount {
+			return params, &EnvelopeRuleError{Type: RequestTypeCustomSplit, Field: "recipients", Reason: "amounts overflow u64"}
+		}
+		params.TotalAmount += amount
+		params.EnvelopeType.Recipients = append(params.EnvelopeType.Recipients, SplitRecipient{Address: pubkey, Amount: amount})
+	}
+	sort.Slice(params.EnvelopeType.Recipients, func(i, j int) bool {
+		return bytes.Compare(params.EnvelopeType.Recipients[i].Address[:], params.EnvelopeType.Recipients[j].Address[:]) < 0
+	})
+	return params, nil
+}
+
+// ValidateCustomSplit - Recipients of a CustomSplit envelope: one per user, distinct wallet addresses,
+// every amount above 0 and summing to totalAmount (*EnvelopeRuleError wrapping ErrInvalidEnvelopeParams)
+func ValidateCustomSplit(envelopeType EnvelopeType, recipients []SplitRecipient, totalAmount, totalUsers uint64) error {
+	fail := func(format string, args ...any) error {
+		return &EnvelopeRuleError{Type: envelopeType.Request(), Field: "recipients", Reason: fmt.Sprintf(format, args...)}
+	}
+	if envelopeType != EnvelopeTypeCustomSplit {
+		return fail("are only valid for %s", RequestTypeCustomSplit)
+	}
+	if len(recipients) == 0 {
+		return fail("are required")
+	}
+	if uint64(len(recipients)) != totalUsers {
+		return fail("count %d doesn't match total_users %d", len(recipients), totalUsers)
+	}
+	if len(recipients) > MaxSplitRecipients {
+		return fail("count %d is over the limit of %d (the create transaction must fit in %d bytes)", len(recipients), MaxSplitRecipients, MaxTransactionSize)
+	}
+
+	seen := make(map[solana.PublicKey]bool, len(recipients))
+	var sum uint64
+	for _, recipient := range recipients {
+		switch {
+		case seen[recipient.Address]:
+			return fail("%s is listed twice", recipient.Address)
+		case !recipient.Address.IsOnCurve():
+			// PDAs can't sign the claim, their share would sit there until refunded
+			return fail("%s is not a wallet address (off curve)", recipient.Address)
+		case recipient.Amount == 0:
+			return fail("amount of %s must be greater than 0", recipient.Address)
+		case sum+recipient.Amount < sum:
+			return fail("amounts overflow u64")
+		}
+		seen[recipient.Address] = true
+		sum += recipient.Amount
+	}
+	if sum != totalAmount {
+		return fail("amounts sum to %d, total_amount is %d", sum, totalAmount)
+	}
+	return nil
+}
+
+// Entitlement - Amount claimer can claim from the envelope by its type (0 for GroupRandom, the program
+// draws it at claim time); ErrNotRecipient when the envelope names its recipients and claimer isn't one
+func (e *EnvelopeInfo) Entitlement(claimer solana.PublicKey) (uint64, error) {
+	switch e.EnvelopeType {
+	case "DirectFixed":
+		if e.AllowedAddress == nil || *e.AllowedAddress != claimer.String() {
+			return 0, ErrNotRecipient
+		}
+		return e.TotalAmount, nil
+	case "GroupFixed":
+		if e.TotalUsers == 0 {
+			return 0, nil
+		}
+		return e.TotalAmount / e.TotalUsers, nil
+	case "CustomSplit":
+		for _, recipient := range e.Recipients {
+			if recipient.Address.Equals(claimer) {
+				return recipient.Amount, nil
+			}
+		}
+		return 0, ErrNotRecipient
+	}
+	return 0, nil
+}
+
+// checkTransactionSize - Refuse a create transaction that won't fit in a packet once signed
+// (*EnvelopeRuleError wrapping ErrInvalidEnvelopeParams)
+func checkTransactionSize(tx *solana.Transaction, envelopeType EnvelopeType) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	// compact-u16 signature count (1 byte below 128) + 64 bytes per required signature
+	signers := int(tx.Message.Header.NumRequiredSignatures)
+	if size := 1 + 64*signers + len(message); size > MaxTransactionSize {
+		return &EnvelopeRuleError{Type: envelopeType.Request(), Field: "recipients",
+			Reason: fmt.Sprintf("make the transaction %d bytes, over the limit of %d", size, MaxTransactionSize)}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package solprogram
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+)
+
+// borshCustomSplitEnvelope - Envelope account as Borsh writes envelopeAccountFields for a CustomSplit:
+// the fields after the recipients vec follow it directly
+func borshCustomSplitEnvelope(owner solana.PublicKey, envelopeID uint64, recipients []SplitRecipient, total, withdrawn, claimed uint64, expiry, startTime int64) []byte {
+	data := make([]byte, 8, 256) // discriminator
+	data = append(data, owner.Bytes()...)
+	data = binary.LittleEndian.AppendUint64(data, envelopeID)
+	data = append(data, byte(EnvelopeTypeCustomSplit))
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(recipients)))
+	for _, recipient := range recipients {
+		data = append(data, recipient.Address.Bytes()...)
+		data = binary.LittleEndian.AppendUint64(data, recipient.Amount)
+	}
+	data = binary.LittleEndian.AppendUint64(data, total)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(recipients)))
+	data = binary.LittleEndian.AppendUint64(data, withdrawn)
+	data = binary.LittleEndian.AppendUint64(data, claimed)
+	data = binary.LittleEndian.AppendUint64(data, uint64(expiry))
+	data = append(data, 0) // is_cancelled
+	return binary.LittleEndian.AppendUint64(data, uint64(startTime))
+}
+
+func TestParseCustomSplitEnvelope(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	recipients := []SplitRecipient{
+		{Address: solana.NewWallet().PublicKey(), Amount: 1_500_000},
+		{Address: solana.NewWallet().PublicKey(), Amount: 2_500_000},
+		{Address: solana.NewWallet().PublicKey(), Amount: 500_000},
+	}
+	data := borshCustomSplitEnvelope(owner, 7, recipients, 4_500_000, 1_500_000, 1, 1_900_000_000, 1_800_000_000)
+
+	split, err := parseEnvelopeData(data, EnvelopeLayoutV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split.Recipients) != len(recipients) {
+		t.Fatalf("recipients = %+v, want %+v", split.Recipients, recipients)
+	}
+	for i, want := range recipients {
+		if split.Recipients[i] != want {
+			t.Errorf("recipient %d = %+v, want %+v", i, split.Recipients[i], want)
+		}
+	}
+	if split.Owner != owner || split.EnvelopeID != 7 || split.EnvelopeType != "CustomSplit" ||
+		split.TotalAmount != 4_500_000 || split.TotalUsers != 3 || split.WithdrawnAmount != 1_500_000 ||
+		split.RemainingAmount != 3_000_000 || split.ClaimedCount != 1 || split.ExpiryTime.Unix() != 1_900_000_000 ||
+		split.IsCancelled || split.StartTime == nil || split.StartTime.Unix() != 1_800_000_000 {
+		t.Errorf("CustomSplit parsed as %+v", split)
+	}
+
+	if _, err := parseEnvelopeData(data[:len(data)-8], EnvelopeLayoutV2); err == nil {
+		t.Error("CustomSplit account without start_time parsed as V2")
+	}
+	if _, err := parseEnvelopeData(data[:len(data)-8-1-8], EnvelopeLayoutV1); err == nil {
+		t.Error("truncated CustomSplit account parsed")
+	}
+
+	// V1 never reads start_time, whatever follows is_cancelled
+	v1, err := parseEnvelopeData(data, EnvelopeLayoutV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.StartTime != nil || !v1.IsStarted || v1.TotalAmount != 4_500_000 {
+		t.Errorf("V1 CustomSplit parsed as %+v", v1)
+	}
+}
+
+func TestCompareEnvelopeAccountLayout(t *testing.T) {
+	// Anchor 0.30+ IDL: tuples can't be declared, (Pubkey, u64) recipients are a defined struct
+	idl, err := ParseIDL([]byte(`{
+		"metadata": {"name": "usdc_envelope"},
+		"instructions": [],
+		"accounts": [{"name": "Envelope", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8]}],
+		"types": [
+			{"name": "Envelope", "type": {"kind": "struct", "fields": [
+				{"name": "owner", "type": "pubkey"},
+				{"name": "envelope_id", "type": "u64"},
+				{"name": "envelope_type", "type": {"defined": {"name": "EnvelopeType"}}},
+				{"name": "total_amount", "type": "u64"},
+				{"name": "total_users", "type": "u64"},
+				{"name": "withdrawn_amount", "type": "u64"},
+				{"name": "claimed_count", "type": "u64"},
+				{"name": "expiry", "type": "i64"},
+				{"name": "is_cancelled", "type": "bool"},
+				{"name": "start_time", "type": "i64"}
+			]}},
+			{"name": "EnvelopeType", "type": {"kind": "enum", "variants": [
+				{"name": "DirectFixed", "fields": [{"name": "allowed_address", "type": "pubkey"}]},
+				{"name": "GroupFixed"},
+				{"name": "GroupRandom"},
+				{"name": "CustomSplit", "fields": [{"name": "recipients", "type": {"vec": {"defined": {"name": "SplitRecipient"}}}}]}
+			]}},
+			{"name": "SplitRecipient", "type": {"kind": "struct", "fields": [
+				{"name": "address", "type": "pubkey"},
+				{"name": "amount", "type": "u64"}
+			]}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout := EnvelopeLayoutFromIDL(idl); layout != EnvelopeLayoutV2 {
+		t.Errorf("layout = %d, want V2", layout)
+	}
+	report := &IDLReport{Program: idl.Name}
+	CompareAccountLayout(report, idl, "Envelope", envelopeAccountFields, nil)
+	CompareAccountLayout(report, idl, "EnvelopeType", nil, envelopeTypeVariants)
+	if err := report.Err(); err != nil {
+		t.Fatalf("matching IDL: %v", err)
+	}
+
+	// Legacy IDL of a program whose CustomSplit keeps fixed-size recipients
+	legacy, err := ParseIDL([]byte(`{
+		"name": "usdc_envelope",
+		"instructions": [],
+		"accounts": [{"name": "Envelope", "type": {"kind": "struct", "fields": [
+			{"name": "owner", "type": "publicKey"},
+			{"name": "envelopeId", "type": "u64"},
+			{"name": "envelopeType", "type": {"defined": "EnvelopeType"}},
+			{"name": "totalAmount", "type": "u64"}
+		]}}],
+		"types": [{"name": "EnvelopeType", "type": {"kind": "enum", "variants": [
+			{"name": "DirectFixed", "fields": [{"name": "allowedAddress", "type": "publicKey"}]},
+			{"name": "GroupFixed"},
+			{"name": "GroupRandom"},
+			{"name": "CustomSplit", "fields": [{"array": ["publicKey", 19]}]}
+		]}}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout := EnvelopeLayoutFromIDL(legacy); layout != EnvelopeLayoutV1 {
+		t.Errorf("legacy layout = %d, want V1", layout)
+	}
+	report = &IDLReport{Program: legacy.Name}
+	CompareAccountLayout(report, legacy, "Envelope", envelopeAccountFields, nil)
+	CompareAccountLayout(report, legacy, "EnvelopeType", nil, envelopeTypeVariants)
+	if len(report.Mismatches) != 2 || report.Mismatches[0].Field != "fields" || report.Mismatches[1].Field != "variant 3" {
+		t.Errorf("mismatches = %+v, want fields and variant 3", report.Mismatches)
+	}
+	if !errors.Is(report.Err(), ErrIDLMismatch) {
+		t.Errorf("err = %v, want ErrIDLMismatch", report.Err())
+	}
+}
+
+func TestCustomSplitTransactionSize(t *testing.T) {
+	c, err := NewUSDCEnvelopeClientWithClients(rpc.New("http://127.0.0.1:0"), nil, chain.Devnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTokenProgramOverride(c.usdcMint, TokenProgramID)
+	user := solana.NewWallet().PublicKey()
+
+	build := func(n int) *solana.Transaction {
+		amounts := make(map[string]uint64, n)
+		for i := 0; i < n; i++ {
+			amounts[solana.NewWallet().PublicKey().String()] = 1_000_000
+		}
+		params, err := CustomSplitParams(amounts, 3600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instruction, err := c.BuildCreateEnvelopeInstruction(user, solana.NewWallet().PublicKey(), params, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := solana.NewTransaction([]solana.Instruction{instruction}, solana.Hash{}, solana.TransactionPayer(user))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	// Largest recipient count whose create transaction checkTransactionSize accepts
+	fits := 0
+	for n := 1; ; n++ {
+		err := checkTransactionSize(build(n), EnvelopeTypeCustomSplit)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidEnvelopeParams) {
+				t.Fatalf("%d recipients: %v", n, err)
+			}
+			break
+		}
+		fits = n
+	}
+	if fits != MaxSplitRecipients {
+		t.Errorf("create transactions fit %d recipients, MaxSplitRecipients is %d", fits, MaxSplitRecipients)
+	}
+
+	recipients := make([]SplitRecipient, MaxSplitRecipients+1)
+	for i := range recipients {
+		recipients[i] = SplitRecipient{Address: solana.NewWallet().PublicKey(), Amount: 1}
+	}
+	if err := ValidateCustomSplit(EnvelopeTypeCustomSplit, recipients, uint64(len(recipients)), uint64(len(recipients))); !errors.Is(err, ErrInvalidEnvelopeParams) {
+		t.Errorf("ValidateCustomSplit accepted %d recipients: %v", len(recipients), err)
+	}
+}
+
+func TestCreateEnvelopeLayoutV1(t *testing.T) {
+	c, err := NewUSDCEnvelopeClientWithClients(rpc.New("http://127.0.0.1:0"), nil, chain.Devnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTokenProgramOverride(c.usdcMint, TokenProgramID)
+	user := solana.NewWallet().PublicKey()
+	params := CreateEnvelopeParams{EnvelopeType: EnvelopeTypeData{Type: EnvelopeTypeGroupFixed}, TotalAmount: 1_000_000, TotalUsers: 2, ExpirySeconds: 3600}
+
+	size := func() int {
+		t.Helper()
+		instruction, err := c.BuildCreateEnvelopeInstruction(user, user, params, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := instruction.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(data)
+	}
+	v2 := size()
+
+	// V1 create ends at expiry_seconds: a trailing start_time would be read as garbage by the program
+	c.SetEnvelopeLayout(EnvelopeLayoutV1)
+	if v1 := size(); v1 != v2-8 {
+		t.Errorf("V1 create data = %d bytes, want %d", v1, v2-8)
+	}
+	params.StartTime = 1_800_000_000
+	if _, err := c.BuildCreateEnvelopeInstruction(user, user, params, 1); err == nil {
+		t.Error("V1 create with a start_time built")
+	}
+}
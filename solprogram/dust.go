@@ -0,0 +1,37 @@
+package solprogram
+
+import (
+	"blockchain/chain"
+	"blockchain/dust"
+)
+
+// MinShare - Smallest amount one claimer of a new envelope receives (GroupRandom: the average,
+// the program may draw less for a single claimer)
+func MinShare(envelopeType EnvelopeType, totalAmount, totalUsers uint64, recipients []SplitRecipient) uint64 {
+	switch envelopeType {
+	case EnvelopeTypeDirectFixed:
+		return totalAmount
+	case EnvelopeTypeCustomSplit:
+		var smallest uint64
+		for i, recipient := range recipients {
+			if i == 0 || recipient.Amount < smallest {
+				smallest = recipient.Amount
+			}
+		}
+		return smallest
+	}
+	if totalUsers == 0 {
+		return totalAmount
+	}
+	return totalAmount / totalUsers
+}
+
+// checkDust - Per-user share of a new envelope against the dust threshold of token (no check when policy is nil)
+func checkDust(policy *dust.Policy, token string, envelopeType EnvelopeType, totalAmount, totalUsers uint64, recipients []SplitRecipient) error {
+	return policy.CheckUint64(chain.Solana, token, "per-user share", MinShare(envelopeType, totalAmount, totalUsers, recipients))
+}
+
+// SetDustPolicy - Minimum per-user USDC share of new envelopes (dust.Default, nil disables it)
+func (c *USDCEnvelopeClient) SetDustPolicy(policy *dust.Policy) {
+	c.dust = policy
+}
@@ -0,0 +1,31 @@
+package solprogram
+
+import (
+	"blockchain/txencoding"
+)
+
+// encodeUnsignedTx - Base64 unsigned transaction from CreateTransaction* in the encoding the client asked for
+func encodeUnsignedTx(unsignedTxBase64 string, e txencoding.Encoding) string {
+	if unsignedTxBase64 == "" || e == "" || e == txencoding.Base64 {
+		return unsignedTxBase64
+	}
+	txBytes, err := txencoding.Base64.Decode(unsignedTxBase64)
+	if err != nil {
+		return unsignedTxBase64
+	}
+	return e.Encode(txBytes)
+}
+
+// normalizeSignedTx - Signed transaction as base64, the form Send* and the claim orchestrator take,
+// plus the encoding it was sent in (detected when encoding is "")
+func normalizeSignedTx(signedTx, encoding string) (string, txencoding.Encoding, error) {
+	e, err := txencoding.Parse(encoding, "")
+	if err != nil {
+		return "", "", err
+	}
+	txBytes, e, err := txencoding.DecodeAuto(signedTx, e)
+	if err != nil {
+		return "", "", err
+	}
+	return txencoding.Base64.Encode(txBytes), e, nil
+}
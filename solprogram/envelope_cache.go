@@ -0,0 +1,212 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/sync/singleflight"
+
+	"blockchain/metrics"
+)
+
+// DefaultEnvelopeInfoCacheTTL - How long a fetched envelope stays fresh.
+// Short on purpose: claims change RemainingAmount/ClaimedCount every few seconds.
+const DefaultEnvelopeInfoCacheTTL = 2 * time.Second
+
+// envelopeFetchTimeout - Upper bound for the shared RPC read behind the cache
+const envelopeFetchTimeout = 10 * time.Second
+
+var (
+	envelopeCacheHits   = metrics.Counter("solprogram_envelope_info_cache_hits")
+	envelopeCacheMisses = metrics.Counter("solprogram_envelope_info_cache_misses")
+	envelopeCacheShared = metrics.Counter("solprogram_envelope_info_singleflight_shared")
+)
+
+type envelopeCacheEntry struct {
+	info      EnvelopeInfo
+	fetchedAt time.Time
+}
+
+// envelopeGeneration - Invalidation count of one key; a fetch stores its result only when the count
+// hasn't moved since it started
+type envelopeGeneration struct {
+	n             uint64
+	invalidatedAt time.Time
+}
+
+// envelopeInfoCache - Per-envelope response cache with singleflight deduplication
+type envelopeInfoCache struct {
+	ttl      time.Duration
+	mu       sync.RWMutex
+	entries  map[string]envelopeCacheEntry
+	gens     map[string]envelopeGeneration
+	sweeping bool
+	group    singleflight.Group
+}
+
+func newEnvelopeInfoCache(ttl time.Duration) *envelopeInfoCache {
+	return &envelopeInfoCache{
+		ttl:     ttl,
+		entries: make(map[string]envelopeCacheEntry),
+		gens:    make(map[string]envelopeGeneration),
+	}
+}
+
+func envelopeCacheKey(owner solana.PublicKey, envelopeID uint64) string {
+	return fmt.Sprintf("%s:%d", owner, envelopeID)
+}
+
+// clone - Deep copy: callers may mutate what they get without touching the cached entry or each other
+func (e *EnvelopeInfo) clone() *EnvelopeInfo {
+	info := *e
+	if e.AllowedAddress != nil {
+		allowed := *e.AllowedAddress
+		info.AllowedAddress = &allowed
+	}
+	if e.Recipients != nil {
+		info.Recipients = append([]SplitRecipient(nil), e.Recipients...)
+	}
+	if e.StartTime != nil {
+		start := *e.StartTime
+		info.StartTime = &start
+	}
+	return &info
+}
+
+// get - Return a copy of the cached envelope if still fresh
+func (c *envelopeInfoCache) get(key string) (*EnvelopeInfo, bool) {
+	c.mu.RLock()
+	ttl := c.ttl
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ttl <= 0 || !ok || time.Since(entry.fetchedAt) > ttl {
+		return nil, false
+	}
+
+	info := entry.info.clone()
+	info.refreshDerived(time.Now())
+	return info, true
+}
+
+// generation - Current invalidation count of key, taken before a fetch
+func (c *envelopeInfoCache) generation(key string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gens[key].n
+}
+
+// set - Store a fetch started at generation gen; dropped when key was invalidated meanwhile
+func (c *envelopeInfoCache) set(key string, gen uint64, info *EnvelopeInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || c.gens[key].n != gen {
+		return
+	}
+
+	c.entries[key] = envelopeCacheEntry{info: *info.clone(), fetchedAt: time.Now()}
+	if !c.sweeping {
+		c.sweeping = true
+		go c.sweep()
+	}
+}
+
+func (c *envelopeInfoCache) invalidate(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.gens[key] = envelopeGeneration{n: c.gens[key].n + 1, invalidatedAt: time.Now()}
+	if !c.sweeping {
+		c.sweeping = true
+		go c.sweep()
+	}
+	c.mu.Unlock()
+	// Later callers start a fresh fetch instead of joining one that began before the change
+	c.group.Forget(key)
+}
+
+// sweep - Drop stale entries every TTL, and generations no fetch can still be waiting on;
+// runs while the cache holds anything
+func (c *envelopeInfoCache) sweep() {
+	c.mu.RLock()
+	interval := max(c.ttl, time.Second)
+	c.mu.RUnlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.mu.Lock()
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.Sub(entry.fetchedAt) > c.ttl {
+				delete(c.entries, k)
+			}
+		}
+		for k, gen := range c.gens {
+			if now.Sub(gen.invalidatedAt) > 2*envelopeFetchTimeout {
+				delete(c.gens, k)
+			}
+		}
+		if len(c.entries) == 0 && len(c.gens) == 0 {
+			c.sweeping = false
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+	}
+}
+
+// getOrFetch - Serve from cache, otherwise run a single shared fetch for all concurrent callers
+func (c *envelopeInfoCache) getOrFetch(
+	ctx context.Context,
+	key string,
+	fetch func(ctx context.Context) (*EnvelopeInfo, error),
+) (*EnvelopeInfo, error) {
+	if info, ok := c.get(key); ok {
+		envelopeCacheHits.Add(1)
+		return info, nil
+	}
+	envelopeCacheMisses.Add(1)
+
+	// The shared fetch must not die with the first caller's context
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), envelopeFetchTimeout)
+		defer cancel()
+
+		gen := c.generation(key)
+		info, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, gen, info)
+		return info, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Shared {
+			envelopeCacheShared.Add(1)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Every caller of a shared fetch gets its own copy
+		return res.Val.(*EnvelopeInfo).clone(), nil
+	}
+}
+
+// SetEnvelopeInfoCacheTTL - Change envelope info cache TTL (0 disables caching, reads still deduplicated)
+func (c *USDCEnvelopeClient) SetEnvelopeInfoCacheTTL(ttl time.Duration) {
+	c.envelopeCache.mu.Lock()
+	c.envelopeCache.ttl = ttl
+	c.envelopeCache.entries = make(map[string]envelopeCacheEntry)
+	c.envelopeCache.mu.Unlock()
+}
+
+// InvalidateEnvelopeInfo - Drop cached envelope info (call after a confirmed claim/refund)
+func (c *USDCEnvelopeClient) InvalidateEnvelopeInfo(owner solana.PublicKey, envelopeID uint64) {
+	c.envelopeCache.invalidate(envelopeCacheKey(owner, envelopeID))
+}
@@ -0,0 +1,66 @@
+package solprogram
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeInfoCacheInvalidateDuringFetch(t *testing.T) {
+	cache := newEnvelopeInfoCache(time.Minute)
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.getOrFetch(ctx, "key", func(context.Context) (*EnvelopeInfo, error) {
+			close(started)
+			<-release
+			return &EnvelopeInfo{RemainingAmount: 100}, nil
+		})
+		done <- err
+	}()
+
+	<-started
+	cache.invalidate("key") // A claim confirmed while the read was in flight
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := cache.get("key"); ok {
+		t.Fatalf("stale fetch cached after invalidate: %+v", info)
+	}
+
+	info, err := cache.getOrFetch(ctx, "key", func(context.Context) (*EnvelopeInfo, error) {
+		return &EnvelopeInfo{RemainingAmount: 60}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached, ok := cache.get("key"); !ok || info.RemainingAmount != 60 || cached.RemainingAmount != 60 {
+		t.Fatalf("fetch after invalidate = %+v, cached %+v (%v), want 60", info, cached, ok)
+	}
+}
+
+func TestEnvelopeInfoCacheCopies(t *testing.T) {
+	cache := newEnvelopeInfoCache(time.Minute)
+	allowed, start := "allowed", time.Unix(1_800_000_000, 0)
+	info, err := cache.getOrFetch(context.Background(), "key", func(context.Context) (*EnvelopeInfo, error) {
+		return &EnvelopeInfo{AllowedAddress: &allowed, Recipients: []SplitRecipient{{Amount: 5}}, StartTime: &start}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A caller mutating its copy must not reach the cached entry
+	*info.AllowedAddress = "changed"
+	info.Recipients[0].Amount = 9
+	*info.StartTime = time.Unix(0, 0)
+	cached, ok := cache.get("key")
+	if !ok {
+		t.Fatal("envelope not cached")
+	}
+	if *cached.AllowedAddress != "allowed" || cached.Recipients[0].Amount != 5 || !cached.StartTime.Equal(start) {
+		t.Errorf("cached envelope changed through a returned copy: %+v", cached)
+	}
+}
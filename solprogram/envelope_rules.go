@@ -0,0 +1,238 @@
+package solprogram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/dust"
+)
+
+// InvalidEnvelopeParams - Service error code for envelopes refused by the per-type rules
+const InvalidEnvelopeParams = "INVALID_ENVELOPE_PARAMS"
+
+// Upper bounds of total_users for group envelopes
+const (
+	DefaultMaxClaimers = 1000 // USDC program: one claim record account per claimer
+	SOLMaxClaimers     = 10   // SOL program: claimed_users is allocated for 10 keys (SPL.rs, 4 + 32*10)
+)
+
+// ErrInvalidEnvelopeParams - New envelope refused by the per-type rules
+var ErrInvalidEnvelopeParams = errors.New("invalid envelope parameters")
+
+// EnvelopeRuleError - Which parameter of a new envelope broke which rule
+type EnvelopeRuleError struct {
+	Type   EnvelopeTypeRequest `json:"envelope_type"`
+	Field  string              `json:"field"` // Request field name, e.g. total_users
+	Reason string              `json:"reason"`
+}
+
+func (e *EnvelopeRuleError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Type, e.Field, e.Reason)
+}
+
+func (e *EnvelopeRuleError) Unwrap() error {
+	return ErrInvalidEnvelopeParams
+}
+
+// EnvelopeTypeRule - Validation matrix row of one envelope type
+type EnvelopeTypeRule struct {
+	MinUsers       uint64
+	MaxUsers       uint64
+	AllowedAddress bool // Required (DirectFixed), otherwise it must be absent
+	EvenSplit      bool // total_amount must split evenly across total_users (GroupFixed pays equal shares)
+}
+
+// EnvelopeRules - Validation matrix per envelope type, checked before anything is built or sent
+// (a nil matrix accepts everything and leaves validation to the program)
+type EnvelopeRules map[EnvelopeType]EnvelopeTypeRule
+
+// DefaultEnvelopeRules - USDC program rules. DirectFixed: exactly 1 user and a wallet allowed_address;
+// GroupFixed: up to DefaultMaxClaimers, evenly divisible amount; GroupRandom: up to DefaultMaxClaimers;
+// CustomSplit: up to MaxSplitRecipients (all of them travel in the create transaction)
+func DefaultEnvelopeRules() EnvelopeRules {
+	return EnvelopeRules{
+		EnvelopeTypeDirectFixed: {MinUsers: 1, MaxUsers: 1, AllowedAddress: true},
+		EnvelopeTypeGroupFixed:  {MinUsers: 1, MaxUsers: DefaultMaxClaimers, EvenSplit: true},
+		EnvelopeTypeGroupRandom: {MinUsers: 1, MaxUsers: DefaultMaxClaimers},
+		EnvelopeTypeCustomSplit: {MinUsers: 1, MaxUsers: MaxSplitRecipients},
+	}
+}
+
+// SOLEnvelopeRules - SOL program rules: DirectFixed as for USDC, GroupFixed (evenly divisible amount) and
+// GroupRandom up to SOLMaxClaimers; the SOL program has no CustomSplit
+func SOLEnvelopeRules() EnvelopeRules {
+	return EnvelopeRules{
+		EnvelopeTypeDirectFixed: {MinUsers: 1, MaxUsers: 1, AllowedAddress: true},
+		EnvelopeTypeGroupFixed:  {MinUsers: 1, MaxUsers: SOLMaxClaimers, EvenSplit: true},
+		EnvelopeTypeGroupRandom: {MinUsers: 1, MaxUsers: SOLMaxClaimers},
+	}
+}
+
+// ParseEnvelopeRules - USDC program rules with the max claimers of group types from
+// ENVELOPE_MAX_CLAIMERS, e.g. "group_fixed=500,group_random=200" ("" = defaults)
+func ParseEnvelopeRules(maxClaimers string) (EnvelopeRules, error) {
+	return DefaultEnvelopeRules().WithMaxClaimers(maxClaimers)
+}
+
+// WithMaxClaimers - Copy of the program rules r with lower max claimers per type, e.g. "group_fixed=5"
+// ("" = unchanged); raising a max past the program's limit or naming a type it lacks is an error
+func (r EnvelopeRules) WithMaxClaimers(maxClaimers string) (EnvelopeRules, error) {
+	rules := make(EnvelopeRules, len(r))
+	for envelopeType, rule := range r {
+		rules[envelopeType] = rule
+	}
+	for _, entry := range strings.Split(maxClaimers, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid max claimers entry %q (want type=n)", entry)
+		}
+		envelopeType, err := EnvelopeTypeRequest(strings.TrimSpace(name)).EnvelopeType()
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid max claimers for %s: %q", name, value)
+		}
+		rule, ok := rules[envelopeType]
+		if !ok {
+			return nil, fmt.Errorf("%s is not supported by the program", name)
+		}
+		if n < rule.MinUsers {
+			return nil, fmt.Errorf("max claimers for %s must be at least %d", name, rule.MinUsers)
+		}
+		if n > rule.MaxUsers {
+			return nil, fmt.Errorf("max claimers for %s must be at most %d (program limit)", name, rule.MaxUsers)
+		}
+		rule.MaxUsers = n
+		rules[envelopeType] = rule
+	}
+	return rules, nil
+}
+
+// Validate - Check a new envelope against the rule of its type (*EnvelopeRuleError wrapping ErrInvalidEnvelopeParams)
+func (r EnvelopeRules) Validate(envelopeType EnvelopeType, totalAmount, totalUsers uint64, allowedAddress *solana.PublicKey) error {
+	if r == nil {
+		return nil
+	}
+	name := envelopeType.Request()
+	fail := func(field, format string, args ...any) error {
+		return &EnvelopeRuleError{Type: name, Field: field, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	rule, ok := r[envelopeType]
+	if !ok {
+		return fail("envelope_type", "is not supported")
+	}
+	switch {
+	case totalUsers == 0:
+		return fail("total_users", "must be greater than 0")
+	case rule.MinUsers == rule.MaxUsers && totalUsers != rule.MinUsers:
+		return fail("total_users", "must be exactly %d, got %d", rule.MinUsers, totalUsers)
+	case totalUsers < rule.MinUsers:
+		return fail("total_users", "must be at least %d, got %d", rule.MinUsers, totalUsers)
+	case rule.MaxUsers > 0 && totalUsers > rule.MaxUsers:
+		return fail("total_users", "must be at most %d, got %d", rule.MaxUsers, totalUsers)
+	}
+
+	switch {
+	case totalAmount == 0:
+		return fail("total_amount", "must be greater than 0")
+	case totalAmount < totalUsers:
+		return fail("total_amount", "%d is less than total_users %d (every claimer gets at least 1 base unit)", totalAmount, totalUsers)
+	case rule.EvenSplit && totalAmount%totalUsers != 0:
+		share := totalAmount / totalUsers
+		return fail("total_amount", "%d is not divisible by total_users %d (remainder %d), use %d or %d",
+			totalAmount, totalUsers, totalAmount%totalUsers, share*totalUsers, (share+1)*totalUsers)
+	}
+
+	hasAddress := allowedAddress != nil && !allowedAddress.IsZero()
+	switch {
+	case rule.AllowedAddress && !hasAddress:
+		return fail("allowed_address", "is required")
+	case rule.AllowedAddress && !allowedAddress.IsOnCurve():
+		// PDAs can't sign the claim, the funds would sit there until refunded
+		return fail("allowed_address", "%s is not a wallet address (off curve)", allowedAddress)
+	case !rule.AllowedAddress && hasAddress:
+		return fail("allowed_address", "is only valid for %s", RequestTypeDirectFixed)
+	}
+	return nil
+}
+
+// EnvelopeType - On-chain envelope type of a request type
+func (t EnvelopeTypeRequest) EnvelopeType() (EnvelopeType, error) {
+	switch t {
+	case RequestTypeDirectFixed:
+		return EnvelopeTypeDirectFixed, nil
+	case RequestTypeGroupFixed:
+		return EnvelopeTypeGroupFixed, nil
+	case RequestTypeGroupRandom:
+		return EnvelopeTypeGroupRandom, nil
+	case RequestTypeCustomSplit:
+		return EnvelopeTypeCustomSplit, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown envelope_type %q", ErrInvalidEnvelopeParams, t)
+	}
+}
+
+// Request - Request name of an envelope type
+func (t EnvelopeType) Request() EnvelopeTypeRequest {
+	switch t {
+	case EnvelopeTypeDirectFixed:
+		return RequestTypeDirectFixed
+	case EnvelopeTypeGroupFixed:
+		return RequestTypeGroupFixed
+	case EnvelopeTypeGroupRandom:
+		return RequestTypeGroupRandom
+	case EnvelopeTypeCustomSplit:
+		return RequestTypeCustomSplit
+	default:
+		return EnvelopeTypeRequest(fmt.Sprintf("type_%d", uint8(t)))
+	}
+}
+
+// envelopeRuleResponse - Failed response for an envelope refused by the rules or the dust policy
+func envelopeRuleResponse(err error) Response {
+	response := Response{Success: false, Message: err.Error()}
+	if errors.Is(err, ErrInvalidEnvelopeParams) {
+		response.Code = InvalidEnvelopeParams
+	}
+	if dustErr, ok := dust.AsError(err); ok {
+		response.Code = dustErr.Code
+	}
+	return response
+}
+
+// SetEnvelopeRules - Per-type validation matrix for new envelopes (nil disables it)
+func (c *USDCEnvelopeClient) SetEnvelopeRules(rules EnvelopeRules) {
+	c.envelopeRules = rules
+}
+
+// validateCreateParams - Per-type rules for CreateEnvelopeParams, with the allowed address as encoded on chain,
+// and a start time inside the claim window
+func (c *USDCEnvelopeClient) validateCreateParams(params CreateEnvelopeParams) error {
+	if err := c.envelopeRules.Validate(params.EnvelopeType.Type, params.TotalAmount, params.TotalUsers, params.EnvelopeType.AllowedAddress); err != nil {
+		return err
+	}
+	if params.EnvelopeType.Type == EnvelopeTypeCustomSplit || len(params.EnvelopeType.Recipients) > 0 {
+		if err := ValidateCustomSplit(params.EnvelopeType.Type, params.EnvelopeType.Recipients, params.TotalAmount, params.TotalUsers); err != nil {
+			return err
+		}
+	}
+	if err := checkDust(c.dust, dust.USDC, params.EnvelopeType.Type, params.TotalAmount, params.TotalUsers, params.EnvelopeType.Recipients); err != nil {
+		return err
+	}
+	if params.StartTime < 0 || params.StartTime > 0 && params.StartTime >= time.Now().Unix()+int64(params.ExpirySeconds) {
+		return &EnvelopeRuleError{Type: params.EnvelopeType.Type.Request(), Field: "start_time", Reason: "must be before the expiry"}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package solprogram
+
+import "testing"
+
+func TestWithMaxClaimers(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       EnvelopeRules
+		maxClaimers string
+		wantErr     bool
+		wantMax     uint64
+	}{
+		{name: "sol default", rules: SOLEnvelopeRules(), wantMax: SOLMaxClaimers},
+		{name: "sol lowered", rules: SOLEnvelopeRules(), maxClaimers: "group_fixed=5", wantMax: 5},
+		{name: "sol above program limit", rules: SOLEnvelopeRules(), maxClaimers: "group_fixed=11", wantErr: true},
+		{name: "sol custom split", rules: SOLEnvelopeRules(), maxClaimers: "custom_split=5", wantErr: true},
+		{name: "usdc lowered", rules: DefaultEnvelopeRules(), maxClaimers: "group_fixed=500", wantMax: 500},
+		{name: "usdc above program limit", rules: DefaultEnvelopeRules(), maxClaimers: "group_fixed=1001", wantErr: true},
+		{name: "zero", rules: DefaultEnvelopeRules(), maxClaimers: "group_fixed=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := tt.rules.WithMaxClaimers(tt.maxClaimers)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("WithMaxClaimers(%q) accepted", tt.maxClaimers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rules[EnvelopeTypeGroupFixed].MaxUsers; got != tt.wantMax {
+				t.Errorf("group_fixed max = %d, want %d", got, tt.wantMax)
+			}
+		})
+	}
+
+	// Overrides don't leak into the program defaults
+	if _, err := SOLEnvelopeRules().WithMaxClaimers("group_fixed=2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := SOLEnvelopeRules()[EnvelopeTypeGroupFixed].MaxUsers; got != SOLMaxClaimers {
+		t.Errorf("SOL default changed to %d", got)
+	}
+	if err := SOLEnvelopeRules().Validate(EnvelopeTypeGroupRandom, 1000, SOLMaxClaimers+1, nil); err == nil {
+		t.Errorf("SOL rules accepted %d claimers", SOLMaxClaimers+1)
+	}
+}
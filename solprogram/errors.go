@@ -21,6 +21,7 @@ var ProgramErrors = map[int]string{
 	6008: "MathOverflow - Math calculation overflow",
 	6009: "InsufficientFunds - Insufficient funds in envelope",
 	6010: "NothingToRefund - Nothing to refund",
+	6011: "NotStarted - Envelope not claimable yet",
 }
 
 // ExtractErrorCode tries multiple methods to extract custom program error code
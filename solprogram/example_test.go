@@ -0,0 +1,276 @@
+package solprogram_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/solprogram"
+	"blockchain/txencoding"
+)
+
+// exampleKey - Deterministic throwaway key, so examples neither hardcode secrets nor change output between runs
+func exampleKey(seed byte) solana.PrivateKey {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	return solana.PrivateKey(ed25519.NewKeyFromSeed(s))
+}
+
+// envKey - Wallet key from a base58 environment variable (examples that sign real transactions)
+func envKey(name string) solana.PrivateKey {
+	key, err := solana.PrivateKeyFromBase58(os.Getenv(name))
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return key
+}
+
+func ExampleDeriveEnvelopePDA() {
+	programID := solana.MustPublicKeyFromBase58("8sVfWmonJAzAQnS4nYcxv3GBSs4rDpvmniRrApwrh1QK")
+	owner := exampleKey(1).PublicKey()
+
+	envelope, bump, err := solprogram.DeriveEnvelopePDA(programID, owner, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(envelope, bump)
+	// Output:
+	// 5frbWBphKM4gD9yDJUR3CcJ71hr2sUUAUaBrdR6tXTJB 254
+}
+
+// Per-type rules refuse an envelope before anything is built or sent; the error says which field broke which rule.
+func ExampleEnvelopeRules_Validate() {
+	rules := solprogram.DefaultEnvelopeRules()
+	claimer := exampleKey(2).PublicKey()
+
+	err := rules.Validate(solprogram.EnvelopeTypeDirectFixed, 5_000_000, 2, &claimer)
+	fmt.Println(errors.Is(err, solprogram.ErrInvalidEnvelopeParams))
+
+	var ruleErr *solprogram.EnvelopeRuleError
+	if errors.As(err, &ruleErr) {
+		fmt.Println(ruleErr.Field, "-", ruleErr.Reason)
+	}
+
+	fmt.Println(rules.Validate(solprogram.EnvelopeTypeGroupFixed, 6_000_000, 3, nil))
+	// Output:
+	// true
+	// total_users - must be exactly 1, got 2
+	// <nil>
+}
+
+// A CustomSplit envelope pays each recipient exactly its amount.
+func ExampleCustomSplitParams() {
+	alice, bob := exampleKey(3).PublicKey(), exampleKey(4).PublicKey()
+
+	params, err := solprogram.CustomSplitParams(map[string]uint64{
+		alice.String(): 1_500_000, // 1.5 USDC
+		bob.String():   500_000,   // 0.5 USDC
+	}, 24*60*60)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(params.TotalAmount, params.TotalUsers)
+
+	err = solprogram.ValidateCustomSplit(params.EnvelopeType.Type, params.EnvelopeType.Recipients, params.TotalAmount, params.TotalUsers)
+	fmt.Println(err)
+
+	// What each wallet can claim once the envelope is on chain
+	envelope := &solprogram.EnvelopeInfo{EnvelopeType: "CustomSplit", Recipients: params.EnvelopeType.Recipients}
+	amount, _ := envelope.Entitlement(alice)
+	fmt.Println(amount)
+	_, err = envelope.Entitlement(exampleKey(5).PublicKey())
+	fmt.Println(errors.Is(err, solprogram.ErrNotRecipient))
+	// Output:
+	// 2000000 2
+	// <nil>
+	// 1500000
+	// true
+}
+
+// Claims before the start time are refused with a countdown clients can show.
+func ExampleEnvelopeInfo_ValidateClaimWindow() {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	startsAt := now.Add(90 * time.Minute)
+	envelope := &solprogram.EnvelopeInfo{StartTime: &startsAt}
+
+	err := envelope.ValidateClaimWindow(now)
+	if notStarted, ok := solprogram.AsClaimNotStartedError(err); ok {
+		fmt.Println(notStarted.Code, notStarted.StartsInSeconds)
+	}
+	fmt.Println(envelope.ValidateClaimWindow(startsAt))
+	// Output:
+	// CLAIM_NOT_STARTED 5400
+	// <nil>
+}
+
+// Program errors come back as custom codes inside the RPC error.
+func ExampleExtractErrorCode() {
+	err := errors.New(`transaction failed: {"err":{"InstructionError":[0,{"Custom":6001}]}}`)
+
+	if code := solprogram.ExtractErrorCode(err); code != nil {
+		fmt.Println(*code, solprogram.ProgramErrors[*code])
+	}
+	// Output:
+	// 6001 AlreadyClaimed - You have already claimed this envelope
+}
+
+// KeySigner plays the user's wallet: it signs unsigned transactions for the wallet it holds a key for.
+func ExampleKeySigner() {
+	owner := exampleKey(6)
+	transfer := solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{
+		solana.Meta(owner.PublicKey()).WRITE().SIGNER(),
+	}, []byte{2, 0, 0, 0})
+	tx, err := solana.NewTransaction([]solana.Instruction{transfer}, solana.Hash{}, solana.TransactionPayer(owner.PublicKey()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	unsigned, _ := tx.MarshalBinary()
+
+	signer := solprogram.KeySigner(owner)
+	signed, err := signer(context.Background(), txencoding.Base64.Encode(unsigned), owner.PublicKey())
+	if err != nil {
+		log.Fatal(err)
+	}
+	signedBytes, _ := txencoding.Base64.Decode(signed)
+	signedTx, _ := solana.TransactionFromBytes(signedBytes)
+	fmt.Println(signedTx.VerifySignatures() == nil)
+
+	_, err = signer(context.Background(), txencoding.Base64.Encode(unsigned), exampleKey(7).PublicKey())
+	fmt.Println(err != nil)
+	// Output:
+	// true
+	// true
+}
+
+// Batch submissions: higher priority first (at-risk refunds before claims), FIFO within a priority.
+func ExampleSubmissionQueue() {
+	results := make(chan solprogram.SubmissionResult)
+	queue := solprogram.NewSubmissionQueue(
+		func(ctx context.Context, s *solprogram.Submission) (string, error) {
+			return "sig-" + s.SignedTx, nil // Usually USDCEnvelopeClient.SubmitQueued
+		},
+		nil,
+		solprogram.SubmissionQueueConfig{OnResult: func(r solprogram.SubmissionResult) { results <- r }},
+	)
+
+	queue.Enqueue(&solprogram.Submission{ID: "claim-1", Kind: solprogram.SubmissionClaim, SignedTx: "a"})
+	queue.Enqueue(&solprogram.Submission{ID: "claim-2", Kind: solprogram.SubmissionClaim, SignedTx: "b"})
+	queue.Enqueue(&solprogram.Submission{ID: "refund-1", Kind: solprogram.SubmissionRefund, SignedTx: "c", Priority: solprogram.PriorityUrgent})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx)
+
+	for range 3 {
+		r := <-results
+		fmt.Println(r.Submission.ID, r.Signature, r.Err)
+	}
+	// Output:
+	// refund-1 sig-c <nil>
+	// claim-1 sig-a <nil>
+	// claim-2 sig-b <nil>
+}
+
+// Unsigned flow: the service builds transactions, the user's wallet signs them, the service submits.
+// The clients are injected; without a websocket client confirmations are polled.
+func ExampleNewUSDCEnvelopeClientWithClients() {
+	client, err := solprogram.NewUSDCEnvelopeClientWithClients(rpc.New(rpc.DevNet_RPC), nil, chain.Devnet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+	owner := envKey("ENVELOPE_OWNER_KEY")
+	wallet := solprogram.KeySigner(owner) // In production: the user's wallet
+
+	submit := func(unsigned *solprogram.UnsignedTransactionResponse, signer solana.PublicKey) string {
+		signed, err := wallet(ctx, unsigned.UnsignedTransaction, signer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err := client.SubmitSignedTransaction(solprogram.SignedTransactionRequest{
+			TransactionID:     unsigned.TransactionID,
+			SignedTransaction: signed,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return result.Signature
+	}
+
+	// Create: 1 USDC for 4 claimers, expiring in an hour
+	userState, err := client.GetUserState(ctx, owner.PublicKey())
+	if err != nil {
+		log.Fatal(err) // GenerateUnsignedInitUserState first
+	}
+	ownerATA, _ := client.GetUSDCTokenAddress(owner.PublicKey())
+	envelopeID := userState.LastEnvelopeID + 1
+	unsigned, err := client.GenerateUnsignedCreateEnvelope(owner.PublicKey(), ownerATA, solprogram.CreateEnvelopeParams{
+		EnvelopeType:  solprogram.EnvelopeTypeData{Type: solprogram.EnvelopeTypeGroupFixed},
+		TotalAmount:   1_000_000,
+		TotalUsers:    4,
+		ExpirySeconds: 3600,
+	}, envelopeID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("created:", submit(unsigned, owner.PublicKey()))
+
+	// Claim: the claimer signs its own claim
+	claimer := envKey("ENVELOPE_CLAIMER_KEY")
+	wallet = solprogram.KeySigner(owner, claimer)
+	claimerATA, _ := client.GetUSDCTokenAddress(claimer.PublicKey())
+	unsigned, err = client.GenerateUnsignedClaim(solprogram.ClaimEnvelopeParams{
+		EnvelopeID:          envelopeID,
+		Owner:               owner.PublicKey(),
+		Claimer:             claimer.PublicKey(),
+		ClaimerTokenAccount: claimerATA,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("claimed:", submit(unsigned, claimer.PublicKey()))
+
+	// Refund: what's left after expiry goes back to the owner
+	unsigned, err = client.GenerateUnsignedRefund(solprogram.RefundParams{
+		EnvelopeID:        envelopeID,
+		Owner:             owner.PublicKey(),
+		OwnerTokenAccount: ownerATA,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("refunded:", submit(unsigned, owner.PublicKey()))
+}
+
+// The same create → claim → wait for expiry → refund flow with retries per step.
+func ExampleFlowOrchestrator() {
+	client, err := solprogram.NewUSDCEnvelopeClientWithClients(rpc.New(rpc.DevNet_RPC), nil, chain.Devnet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	owner, claimer := envKey("ENVELOPE_OWNER_KEY"), envKey("ENVELOPE_CLAIMER_KEY")
+
+	flow := solprogram.NewFlowOrchestrator(client, solprogram.KeySigner(owner, claimer))
+	flow.Hooks.AfterStep = func(r solprogram.FlowStepResult) {
+		fmt.Println(r.Step, r.Attempts, r.Err)
+	}
+	params := solprogram.CreateEnvelopeParams{
+		EnvelopeType:  solprogram.EnvelopeTypeData{Type: solprogram.EnvelopeTypeGroupRandom},
+		TotalAmount:   2_000_000,
+		TotalUsers:    2,
+		ExpirySeconds: 120,
+	}
+	result, err := flow.Run(context.Background(), nil, flow.CompleteFlow(owner.PublicKey(), params, claimer.PublicKey())...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.State.Results)
+}
@@ -0,0 +1,406 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/sync/errgroup"
+
+	"blockchain/backoff"
+	"blockchain/txencoding"
+)
+
+// FlowSigner - Signs an unsigned transaction as wallet (any payload encoding), i.e. what the user's wallet does in production
+type FlowSigner func(ctx context.Context, unsignedTx string, wallet solana.PublicKey) (string, error)
+
+// KeySigner - FlowSigner backed by local keys (E2E and smoke tests only). Partial-signs,
+// so signatures already on the transaction (e.g. a fee sponsor) are kept; the encoding is kept too.
+func KeySigner(keys ...solana.PrivateKey) FlowSigner {
+	return func(_ context.Context, unsignedTx string, wallet solana.PublicKey) (string, error) {
+		var key *solana.PrivateKey
+		for i := range keys {
+			if keys[i].PublicKey().Equals(wallet) {
+				key = &keys[i]
+				break
+			}
+		}
+		if key == nil {
+			return "", fmt.Errorf("no key for %s", wallet)
+		}
+
+		txBytes, encoding, err := txencoding.DecodeAuto(unsignedTx, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		var tx solana.Transaction
+		if err := tx.UnmarshalWithDecoder(bin.NewBinDecoder(txBytes)); err != nil {
+			return "", fmt.Errorf("failed to unmarshal transaction: %w", err)
+		}
+		if _, err := tx.PartialSign(func(k solana.PublicKey) *solana.PrivateKey {
+			if k.Equals(wallet) {
+				return key
+			}
+			return nil
+		}); err != nil {
+			return "", fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		signed, err := tx.MarshalBinary()
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal signed transaction: %w", err)
+		}
+		return encoding.Encode(signed), nil
+	}
+}
+
+// FlowState - Values shared by the steps of one run
+type FlowState struct {
+	Owner      solana.PublicKey  `json:"owner"`
+	EnvelopeID uint64            `json:"envelope_id"`
+	Results    map[string]string `json:"results"` // Step (or step/claimer) -> transaction signature
+
+	mu sync.Mutex
+}
+
+func (s *FlowState) record(key, signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Results == nil {
+		s.Results = make(map[string]string)
+	}
+	s.Results[key] = signature
+}
+
+// FlowStep - One step of a flow; Run may be retried, so it must be safe to call again
+type FlowStep struct {
+	Name        string
+	Run         func(ctx context.Context, state *FlowState) error
+	MaxAttempts int // Overrides FlowRetry.MaxAttempts when > 0 (1 = no retry)
+}
+
+// FlowStepResult - Outcome of one step
+type FlowStepResult struct {
+	Step     string        `json:"step"`
+	Attempts int           `json:"attempts"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Err      error         `json:"-"`
+}
+
+// FlowResult - Outcome of a run; Steps holds every step that ran, the failed one last
+type FlowResult struct {
+	State   *FlowState       `json:"state"`
+	Steps   []FlowStepResult `json:"steps"`
+	Elapsed time.Duration    `json:"elapsed"`
+}
+
+// FlowRetry - Retry policy for steps
+type FlowRetry struct {
+	MaxAttempts int            // Per step (default 3)
+	Backoff     backoff.Policy // Between attempts (default backoff.Default)
+	Retryable   func(error) bool
+}
+
+// FlowHooks - Optional callbacks, e.g. test logging or progress output
+type FlowHooks struct {
+	BeforeStep func(step string, attempt int)
+	AfterStep  func(result FlowStepResult)
+}
+
+// DefaultFlowRetry - 3 attempts; program errors (custom codes) are final
+var DefaultFlowRetry = FlowRetry{
+	MaxAttempts: 3,
+	Backoff:     backoff.Default,
+	Retryable:   flowRetryable,
+}
+
+func flowRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return IsNodeBehind(err) || ExtractErrorCode(err) == nil
+}
+
+// FlowOrchestrator - Runs envelope flows (create → claim → wait → refund) step by step with
+// retries and hooks, so E2E and smoke tests drive the same flows as the cmd/usdc demo
+type FlowOrchestrator struct {
+	Client *USDCEnvelopeClient
+	Signer FlowSigner
+	Retry  FlowRetry
+	Hooks  FlowHooks
+	Queue  *SubmissionQueue // Optional: refunds are sent through it, prioritized by refund risk
+}
+
+// NewFlowOrchestrator - Orchestrator with DefaultFlowRetry
+func NewFlowOrchestrator(client *USDCEnvelopeClient, signer FlowSigner) *FlowOrchestrator {
+	return &FlowOrchestrator{Client: client, Signer: signer, Retry: DefaultFlowRetry}
+}
+
+// Run - Run steps in order, stopping at the first step that fails after its retries
+func (o *FlowOrchestrator) Run(ctx context.Context, state *FlowState, steps ...FlowStep) (*FlowResult, error) {
+	if state == nil {
+		state = &FlowState{}
+	}
+	start := time.Now()
+	result := &FlowResult{State: state}
+	defer func() { result.Elapsed = time.Since(start) }()
+
+	for _, step := range steps {
+		stepResult := o.runStep(ctx, state, step)
+		result.Steps = append(result.Steps, stepResult)
+		if o.Hooks.AfterStep != nil {
+			o.Hooks.AfterStep(stepResult)
+		}
+		if stepResult.Err != nil {
+			return result, fmt.Errorf("flow step %s failed after %d attempt(s): %w", step.Name, stepResult.Attempts, stepResult.Err)
+		}
+	}
+	return result, nil
+}
+
+func (o *FlowOrchestrator) runStep(ctx context.Context, state *FlowState, step FlowStep) FlowStepResult {
+	maxAttempts := step.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = max(o.Retry.MaxAttempts, 1)
+	}
+	retryable := o.Retry.Retryable
+	if retryable == nil {
+		retryable = flowRetryable
+	}
+
+	start := time.Now()
+	result := FlowStepResult{Step: step.Name}
+	for {
+		result.Attempts++
+		if o.Hooks.BeforeStep != nil {
+			o.Hooks.BeforeStep(step.Name, result.Attempts)
+		}
+		result.Err = step.Run(ctx, state)
+		result.Elapsed = time.Since(start)
+		if result.Err == nil || result.Attempts >= maxAttempts || !retryable(result.Err) {
+			return result
+		}
+		if !sleepCtx(ctx, o.Retry.Backoff.Delay(result.Attempts)) {
+			result.Err = errors.Join(result.Err, ctx.Err())
+			return result
+		}
+	}
+}
+
+// submit - Sign as wallet and submit an unsigned transaction
+func (o *FlowOrchestrator) submit(ctx context.Context, unsigned *UnsignedTransactionResponse, wallet solana.PublicKey) (string, error) {
+	signed, err := o.Signer(ctx, unsigned.UnsignedTransaction, wallet)
+	if err != nil {
+		return "", err
+	}
+	result, err := o.Client.SubmitSignedTransaction(SignedTransactionRequest{
+		TransactionID:     unsigned.TransactionID,
+		SignedTransaction: signed,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Signature, nil
+}
+
+// submitRefund - Sign as the owner and submit a refund, through Queue when set (rebuilt and signed
+// again when its blockhash expires in the queue)
+func (o *FlowOrchestrator) submitRefund(ctx context.Context, build func() (*UnsignedTransactionResponse, error), state *FlowState) (string, error) {
+	unsigned, err := build()
+	if err != nil {
+		return "", err
+	}
+	if o.Queue == nil {
+		return o.submit(ctx, unsigned, state.Owner)
+	}
+	signed, err := o.Signer(ctx, unsigned.UnsignedTransaction, state.Owner)
+	if err != nil {
+		return "", err
+	}
+	submission := &Submission{
+		ID:         unsigned.TransactionID,
+		Kind:       SubmissionRefund,
+		SignedTx:   signed,
+		Owner:      state.Owner,
+		EnvelopeID: state.EnvelopeID,
+		Resign: func(ctx context.Context) (string, error) {
+			unsigned, err := build()
+			if err != nil {
+				return "", err
+			}
+			return o.Signer(ctx, unsigned.UnsignedTransaction, state.Owner)
+		},
+	}
+	if _, err := o.Client.EnqueueRefundSubmission(ctx, o.Queue, submission); err != nil {
+		log.Printf("refund risk of envelope #%d unknown, queued at normal priority: %v", state.EnvelopeID, err)
+	}
+	result, err := o.Queue.Wait(ctx, submission)
+	if err != nil {
+		return "", err
+	}
+	return result.Signature, result.Err
+}
+
+// CreateStep - Create an envelope owned by owner with the next envelope ID; sets state.Owner/EnvelopeID
+func (o *FlowOrchestrator) CreateStep(owner solana.PublicKey, params CreateEnvelopeParams) FlowStep {
+	return FlowStep{
+		Name: "create",
+		Run: func(ctx context.Context, state *FlowState) error {
+			userState, err := o.Client.GetUserState(ctx, owner)
+			if err != nil {
+				return fmt.Errorf("failed to get user state (initialize it first): %w", err)
+			}
+			tokenAccount, err := o.Client.GetUSDCTokenAddress(owner)
+			if err != nil {
+				return err
+			}
+			envelopeID := userState.LastEnvelopeID + 1
+			unsigned, err := o.Client.GenerateUnsignedCreateEnvelope(owner, tokenAccount, params, envelopeID)
+			if err != nil {
+				return err
+			}
+			signature, err := o.submit(ctx, unsigned, owner)
+			if err != nil {
+				return err
+			}
+			state.Owner = owner
+			state.EnvelopeID = envelopeID
+			state.record("create", signature)
+			return nil
+		},
+	}
+}
+
+// ClaimStep - Claim state's envelope as each claimer, concurrently; each claim is retried on its own
+// and fee-sponsored when the client has a FeeSponsor
+func (o *FlowOrchestrator) ClaimStep(claimers ...solana.PublicKey) FlowStep {
+	return FlowStep{
+		Name:        "claim",
+		MaxAttempts: 1, // Retried per claimer below
+		Run: func(ctx context.Context, state *FlowState) error {
+			group, ctx := errgroup.WithContext(ctx)
+			for _, claimer := range claimers {
+				group.Go(func() error {
+					key := "claim/" + claimer.String()
+					claim := o.runStep(ctx, state, FlowStep{
+						Name: key,
+						Run: func(ctx context.Context, state *FlowState) error {
+							tokenAccount, err := o.Client.GetUSDCTokenAddress(claimer)
+							if err != nil {
+								return err
+							}
+							unsigned, err := o.Client.GenerateSponsoredClaim(ClaimEnvelopeParams{
+								EnvelopeID:          state.EnvelopeID,
+								Owner:               state.Owner,
+								Claimer:             claimer,
+								ClaimerTokenAccount: tokenAccount,
+							})
+							if err != nil {
+								return err
+							}
+							signature, err := o.submit(ctx, unsigned, claimer)
+							if err != nil {
+								return err
+							}
+							state.record(key, signature)
+							return nil
+						},
+					})
+					if claim.Err != nil {
+						return fmt.Errorf("claim by %s: %w", claimer, claim.Err)
+					}
+					return nil
+				})
+			}
+			return group.Wait()
+		},
+	}
+}
+
+// WaitForStartStep - Sleep until state's envelope is claimable on chain (no-op without a start time)
+func (o *FlowOrchestrator) WaitForStartStep() FlowStep {
+	return FlowStep{
+		Name: "wait_start",
+		Run: func(ctx context.Context, state *FlowState) error {
+			for {
+				envelope, err := o.Client.GetEnvelopeInfo(ctx, state.Owner, state.EnvelopeID)
+				if err != nil {
+					return err
+				}
+				notStarted, ok := AsClaimNotStartedError(o.Client.checkClaimWindow(ctx, envelope))
+				if !ok {
+					return nil
+				}
+				if !sleepCtx(ctx, time.Duration(notStarted.StartsInSeconds)*time.Second+2*time.Second) {
+					return ctx.Err()
+				}
+			}
+		},
+	}
+}
+
+// WaitForExpiryStep - Sleep until state's envelope has expired on chain
+func (o *FlowOrchestrator) WaitForExpiryStep() FlowStep {
+	return FlowStep{
+		Name: "wait_expiry",
+		Run: func(ctx context.Context, state *FlowState) error {
+			for {
+				envelope, err := o.Client.GetEnvelopeInfo(ctx, state.Owner, state.EnvelopeID)
+				if err != nil {
+					return err
+				}
+				if envelope.IsExpired {
+					return nil
+				}
+				// Cluster time can trail wall time by a few seconds
+				if !sleepCtx(ctx, time.Until(envelope.ExpiryTime)+2*time.Second) {
+					return ctx.Err()
+				}
+				o.Client.InvalidateEnvelopeInfo(state.Owner, state.EnvelopeID)
+			}
+		},
+	}
+}
+
+// RefundStep - Refund state's envelope to its owner (amount 0 = everything remaining)
+func (o *FlowOrchestrator) RefundStep(amount uint64) FlowStep {
+	return FlowStep{
+		Name: "refund",
+		Run: func(ctx context.Context, state *FlowState) error {
+			tokenAccount, err := o.Client.GetUSDCTokenAddress(state.Owner)
+			if err != nil {
+				return err
+			}
+			build := func() (*UnsignedTransactionResponse, error) {
+				return o.Client.GenerateUnsignedRefund(RefundParams{
+					EnvelopeID:        state.EnvelopeID,
+					Owner:             state.Owner,
+					OwnerTokenAccount: tokenAccount,
+					Amount:            amount,
+				})
+			}
+			signature, err := o.submitRefund(ctx, build, state)
+			if err != nil {
+				return err
+			}
+			state.record("refund", signature)
+			return nil
+		},
+	}
+}
+
+// CompleteFlow - create → wait for the start time → claim (all claimers concurrently) → wait for expiry → refund
+func (o *FlowOrchestrator) CompleteFlow(owner solana.PublicKey, params CreateEnvelopeParams, claimers ...solana.PublicKey) []FlowStep {
+	steps := []FlowStep{o.CreateStep(owner, params)}
+	if len(claimers) > 0 {
+		if params.StartTime > 0 {
+			steps = append(steps, o.WaitForStartStep())
+		}
+		steps = append(steps, o.ClaimStep(claimers...))
+	}
+	return append(steps, o.WaitForExpiryStep(), o.RefundStep(0))
+}
@@ -0,0 +1,151 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/explorer"
+)
+
+// MainnetGenesisHash - Genesis of mainnet-beta; a local fork validator has its own
+const MainnetGenesisHash = "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d"
+
+// ErrForkIsMainnet - Fork RPC answers with the mainnet genesis, i.e. it is not a local fork
+var ErrForkIsMainnet = errors.New("fork RPC is mainnet itself, not a local fork validator")
+
+// ForkEnvelope - Mainnet envelope cloned into the fork
+type ForkEnvelope struct {
+	Owner      solana.PublicKey
+	EnvelopeID uint64
+}
+
+// ParseForkEnvelopes - Parse "owner:id,owner:id"
+func ParseForkEnvelopes(s string) ([]ForkEnvelope, error) {
+	var envelopes []ForkEnvelope
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		owner, id, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid fork envelope %q, expected owner:id", entry)
+		}
+		ownerKey, err := solana.PublicKeyFromBase58(owner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fork envelope owner %q: %w", owner, err)
+		}
+		envelopeID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fork envelope id %q: %w", id, err)
+		}
+		envelopes = append(envelopes, ForkEnvelope{Owner: ownerKey, EnvelopeID: envelopeID})
+	}
+	return envelopes, nil
+}
+
+// ForkConfig - Local mainnet-fork validator (solana-test-validator --clone ...) and what it clones
+type ForkConfig struct {
+	RPCURL    string             // Fork validator (default RPCURLLocalhost)
+	WSURL     string             // Default WSURLLocalhost
+	SourceURL string             // Cluster the accounts are cloned from (default RPCURLMainnet)
+	Envelopes []ForkEnvelope     // Envelopes to run claim paths against
+	Accounts  []solana.PublicKey // Extra accounts, e.g. existing claimer token accounts
+}
+
+func (cfg ForkConfig) withDefaults() ForkConfig {
+	if cfg.RPCURL == "" {
+		cfg.RPCURL = RPCURLLocalhost
+	}
+	if cfg.WSURL == "" {
+		cfg.WSURL = WSURLLocalhost
+	}
+	if cfg.SourceURL == "" {
+		cfg.SourceURL = RPCURLMainnet
+	}
+	return cfg
+}
+
+// CloneAccounts - Mainnet accounts the fork needs besides the program: config PDA, USDC mint,
+// and per envelope its PDA, vault and the owner's user state
+func (cfg ForkConfig) CloneAccounts() ([]solana.PublicKey, error) {
+	programID, err := solana.PublicKeyFromBase58(USDCProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid program ID: %w", err)
+	}
+	configPDA, _, err := DeriveConfigPDA(programID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := []solana.PublicKey{configPDA, solana.MustPublicKeyFromBase58(USDCMintMainnet)}
+
+	for _, envelope := range cfg.Envelopes {
+		for _, kind := range []PDAKind{PDAEnvelope, PDAEnvelopeVault, PDAUserState} {
+			pda, err := DerivePDA(PDAInputs{
+				Kind:       kind,
+				ProgramID:  USDCProgramID,
+				Owner:      envelope.Owner.String(),
+				EnvelopeID: envelope.EnvelopeID,
+			})
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, solana.MustPublicKeyFromBase58(pda.Address))
+		}
+	}
+	accounts = append(accounts, cfg.Accounts...)
+
+	// User state repeats for several envelopes of one owner
+	seen := make(map[solana.PublicKey]bool, len(accounts))
+	unique := accounts[:0]
+	for _, account := range accounts {
+		if !seen[account] {
+			seen[account] = true
+			unique = append(unique, account)
+		}
+	}
+	return unique, nil
+}
+
+// ValidatorArgs - solana-test-validator arguments that start the fork:
+// the program (upgradeable) and CloneAccounts, cloned from SourceURL into a fresh ledger
+func (cfg ForkConfig) ValidatorArgs() ([]string, error) {
+	cfg = cfg.withDefaults()
+	accounts, err := cfg.CloneAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--reset", "--url", cfg.SourceURL, "--clone-upgradeable-program", USDCProgramID}
+	for _, account := range accounts {
+		args = append(args, "--maybe-clone", account.String())
+	}
+	return args, nil
+}
+
+// NewUSDCEnvelopeForkClient - Client for a local mainnet fork: mainnet program and mint, local RPC,
+// localnet explorer links. Refuses to run when the RPC turns out to be mainnet itself.
+func NewUSDCEnvelopeForkClient(ctx context.Context, cfg ForkConfig) (*USDCEnvelopeClient, error) {
+	cfg = cfg.withDefaults()
+	c, err := NewUSDCEnvelopeClient(cfg.RPCURL, cfg.WSURL, chain.Mainnet)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis, err := c.rpcClient.GetGenesisHash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach fork validator at %s: %w", cfg.RPCURL, err)
+	}
+	if genesis.String() == MainnetGenesisHash {
+		return nil, ErrForkIsMainnet
+	}
+
+	c.explorer = explorer.New(chain.Solana, chain.Localnet, c.explorer.Provider)
+	return c, nil
+}
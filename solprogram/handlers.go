@@ -9,6 +9,11 @@ import (
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/dto"
+	"blockchain/pricing"
+	"blockchain/txencoding"
 )
 
 // EnvelopeTypeRequest enum
@@ -18,6 +23,7 @@ const (
 	RequestTypeDirectFixed EnvelopeTypeRequest = "direct_fixed"
 	RequestTypeGroupFixed  EnvelopeTypeRequest = "group_fixed"
 	RequestTypeGroupRandom EnvelopeTypeRequest = "group_random"
+	RequestTypeCustomSplit EnvelopeTypeRequest = "custom_split" // USDC program only
 )
 
 // CreateEnvelopeRequest with envelope types
@@ -28,37 +34,71 @@ type CreateEnvelopeRequest struct {
 	TotalUsers     uint64              `json:"total_users"`
 	ExpiryHours    uint64              `json:"expiry_hours"`
 	AllowedAddress *string             `json:"allowed_address,omitempty"`
+	GroupID        string              `json:"group_id,omitempty"` // Group/conversation the envelope is sent to (claim caps)
+	Encoding       string              `json:"encoding,omitempty"` // unsigned_tx: base64 (default) | base58 | hex
 }
 
 type ClaimEnvelopeRequest struct {
 	OwnerAddress   string `json:"owner_address"`
 	ClaimerAddress string `json:"claimer_address"`
 	EnvelopeID     uint64 `json:"envelope_id"`
+	Encoding       string `json:"encoding,omitempty"` // unsigned_tx: base64 (default) | base58 | hex
+}
+
+// SubmitClaimRequest - Signed claim plus what is needed to rebuild it on expiry
+type SubmitClaimRequest struct {
+	OwnerAddress      string `json:"owner_address"`
+	ClaimerAddress    string `json:"claimer_address"`
+	EnvelopeID        uint64 `json:"envelope_id"`
+	SignedTransaction string `json:"signed_transaction"`
+	Encoding          string `json:"encoding,omitempty"`  // signed_transaction (detected when omitted); a refreshed unsigned_tx comes back the same way
+	Preflight         string `json:"preflight,omitempty"` // simulate | skip | default
 }
 
 type RefundEnvelopeRequest struct {
 	OwnerAddress string `json:"owner_address"`
 	EnvelopeID   uint64 `json:"envelope_id"`
+	Amount       uint64 `json:"amount,omitempty"`   // Must be omitted: the SOL program refunds everything remaining
+	Encoding     string `json:"encoding,omitempty"` // unsigned_tx: base64 (default) | base58 | hex
 }
 
-type SendTransactionRequest struct {
-	SignedTransaction string `json:"signed_transaction"`
-}
+// SendTransactionRequest - Signed transaction submission; transaction_id (optional) is the one the unsigned transaction was issued with
+type SendTransactionRequest = dto.SignedTransactionRequest
 
 // Response type
 type Response struct {
-	Success        bool     `json:"success"`
-	Message        string   `json:"message,omitempty"`
-	UnsignedTx     string   `json:"unsigned_tx,omitempty"`
-	TransactionSig string   `json:"transaction_sig,omitempty"`
-	EnvelopeID     uint64   `json:"envelope_id,omitempty"`
-	ErrorCode      *int     `json:"error_code,omitempty"`
-	ProgramLogs    []string `json:"program_logs,omitempty"`
+	Success        bool           `json:"success"`
+	Message        string         `json:"message,omitempty"`
+	UnsignedTx     string         `json:"unsigned_tx,omitempty"`
+	Encoding       string         `json:"encoding,omitempty"` // Encoding of unsigned_tx
+	TransactionSig string         `json:"transaction_sig,omitempty"`
+	EnvelopeID     uint64         `json:"envelope_id,omitempty"`
+	ErrorCode      *int           `json:"error_code,omitempty"`
+	ProgramLogs    []string       `json:"program_logs,omitempty"`
+	TransactionID  string         `json:"transaction_id,omitempty"` // Of unsigned_tx, send it back with the signed transaction; on failures the key of the stored failure (GET /api/v1/submissions/failures)
+	Links          *ExplorerLinks `json:"links,omitempty"`
+	Code           string         `json:"code,omitempty"`          // Service error code, e.g. CLAIM_CAP_EXCEEDED
+	EstimatedFee   *pricing.Fee   `json:"estimated_fee,omitempty"` // Network fee of unsigned_tx
+	Warning        string         `json:"warning,omitempty"`       // e.g. claim close to expiry
+}
+
+// claimCapResponse - Failed response for a claim refused by the group cap
+func claimCapResponse(err error) Response {
+	response := Response{Success: false, Message: err.Error()}
+	if capErr, ok := AsClaimCapError(err); ok {
+		response.Code = capErr.Code
+	}
+	return response
 }
 
 func (c *Client) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if err := c.Breakers.Allow(BreakerCreate); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
 	var req CreateEnvelopeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		json.NewEncoder(w).Encode(Response{
@@ -93,22 +133,49 @@ func (c *Client) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate DirectFixed
-	if req.EnvelopeType == RequestTypeDirectFixed {
-		if req.AllowedAddress == nil || *req.AllowedAddress == "" {
-			json.NewEncoder(w).Encode(Response{
-				Success: false,
-				Message: "DirectFixed requires allowed_address",
-			})
-			return
-		}
-		if req.TotalUsers != 1 {
-			json.NewEncoder(w).Encode(Response{
-				Success: false,
-				Message: "DirectFixed must have total_users = 1",
-			})
+	// Per-type rules (users, amount split, allowed address)
+	envelopeType, err := req.EnvelopeType.EnvelopeType()
+	if err == nil && envelopeType == EnvelopeTypeCustomSplit {
+		err = &EnvelopeRuleError{Type: req.EnvelopeType, Field: "envelope_type", Reason: "is not supported by the SOL program"}
+	}
+	if err != nil {
+		json.NewEncoder(w).Encode(envelopeRuleResponse(err))
+		return
+	}
+	var allowedAddress *solana.PublicKey
+	if req.AllowedAddress != nil && *req.AllowedAddress != "" {
+		address, err := solana.PublicKeyFromBase58(*req.AllowedAddress)
+		if err != nil {
+			json.NewEncoder(w).Encode(envelopeRuleResponse(&EnvelopeRuleError{
+				Type:   req.EnvelopeType,
+				Field:  "allowed_address",
+				Reason: fmt.Sprintf("is not a valid address: %v", err),
+			}))
 			return
 		}
+		allowedAddress = &address
+	}
+	if err := c.EnvelopeRules.Validate(envelopeType, req.TotalAmount, req.TotalUsers, allowedAddress); err != nil {
+		json.NewEncoder(w).Encode(envelopeRuleResponse(err))
+		return
+	}
+	if err := checkDust(c.Dust, chain.Solana.Symbol(), envelopeType, req.TotalAmount, req.TotalUsers, nil); err != nil {
+		json.NewEncoder(w).Encode(envelopeRuleResponse(err))
+		return
+	}
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	// Limits from the deployed program's config account
+	if err := c.Config.ValidateCreate(r.Context(), req.TotalAmount, req.TotalUsers); err != nil {
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
 	}
 
 	user := solana.MustPublicKeyFromBase58(req.UserAddress)
@@ -211,6 +278,15 @@ func (c *Client) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Claims of the envelope count against its group's cap
+	if err := c.ClaimCaps.IndexEnvelope(r.Context(), user, nextEnvelopeID, req.GroupID); err != nil {
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: fmt.Sprintf("Failed to index envelope: %v", err),
+		})
+		return
+	}
+
 	message := fmt.Sprintf("%s envelope #%d created (%.9f SOL, %d users)",
 		req.EnvelopeType, nextEnvelopeID, float64(req.TotalAmount)/1e9, req.TotalUsers)
 	if !exists {
@@ -218,10 +294,14 @@ func (c *Client) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
 	}
 
 	json.NewEncoder(w).Encode(Response{
-		Success:    true,
-		Message:    message,
-		UnsignedTx: unsignedTx,
-		EnvelopeID: nextEnvelopeID,
+		Success:       true,
+		Message:       message,
+		UnsignedTx:    encodeUnsignedTx(unsignedTx, encoding),
+		Encoding:      encoding.String(),
+		TransactionID: c.issued.issue("sol", chain.EnvelopeActionCreate),
+		EnvelopeID:    nextEnvelopeID,
+		Links:         c.links(user, nextEnvelopeID, nil),
+		EstimatedFee:  c.EstimateFee(r.Context(), unsignedTx),
 	})
 }
 
@@ -229,6 +309,11 @@ func (c *Client) HandleCreateEnvelope(w http.ResponseWriter, r *http.Request) {
 func (c *Client) HandleClaimEnvelope(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if err := c.Breakers.Allow(BreakerClaim); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
 	var req ClaimEnvelopeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
@@ -237,30 +322,129 @@ func (c *Client) HandleClaimEnvelope(w http.ResponseWriter, r *http.Request) {
 
 	owner := solana.MustPublicKeyFromBase58(req.OwnerAddress)
 	claimer := solana.MustPublicKeyFromBase58(req.ClaimerAddress)
-
-	instruction, err := BuildClaimInstruction(c.ProgramID, owner, claimer, req.EnvelopeID)
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
 	if err != nil {
 		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
 		return
 	}
 
-	unsignedTx, err := c.CreateTransaction(instruction, claimer)
+	if err := c.ClaimCaps.Check(r.Context(), owner, req.EnvelopeID, claimer); err != nil {
+		json.NewEncoder(w).Encode(claimCapResponse(err))
+		return
+	}
+
+	var warning string
+	if c.ClaimDeadline != nil {
+		envelope, err := c.getEnvelopeInfo(r.Context(), owner, req.EnvelopeID)
+		if err != nil {
+			json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+			return
+		}
+		if warning, err = c.ClaimDeadline.Check(r.Context(), c.RPC, envelope.ExpiryTime); err != nil {
+			json.NewEncoder(w).Encode(claimDeadlineResponse(err))
+			return
+		}
+	}
+
+	unsignedTx, err := c.BuildClaimTransaction(owner, claimer, req.EnvelopeID)
 	if err != nil {
 		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
 		return
 	}
 
 	json.NewEncoder(w).Encode(Response{
-		Success:    true,
-		Message:    fmt.Sprintf("Claim envelope #%d transaction created. Sign on client side.", req.EnvelopeID),
-		UnsignedTx: unsignedTx,
+		Success:       true,
+		Message:       fmt.Sprintf("Claim envelope #%d transaction created. Sign on client side.", req.EnvelopeID),
+		UnsignedTx:    encodeUnsignedTx(unsignedTx, encoding),
+		Encoding:      encoding.String(),
+		TransactionID: c.issued.issue("sol", chain.EnvelopeActionClaim),
+		EnvelopeID:    req.EnvelopeID,
+		Links:         c.links(owner, req.EnvelopeID, &claimer),
+		EstimatedFee:  c.EstimateFee(r.Context(), unsignedTx),
+		Warning:       warning,
+	})
+}
+
+// HandleSubmitClaim handles signed claim submission with automatic retry (POST /api/claim-envelope/submit).
+// Transient failures are retried server-side; an expired blockhash returns outcome "resign_required"
+// with a fresh unsigned_tx to sign and submit again.
+func (c *Client) HandleSubmitClaim(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	for _, name := range []string{BreakerClaim, BreakerSend} {
+		if err := c.Breakers.Allow(name); err != nil {
+			json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+			return
+		}
+	}
+
+	var req SubmitClaimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	owner, err := solana.PublicKeyFromBase58(req.OwnerAddress)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Invalid owner_address"})
+		return
+	}
+	claimer, err := solana.PublicKeyFromBase58(req.ClaimerAddress)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "Invalid claimer_address"})
+		return
+	}
+	mode, err := c.Preflight.FromRequest(r, req.Preflight)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+	signedTx, encoding, err := normalizeSignedTx(req.SignedTransaction, req.Encoding)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Invalid signed_transaction: %v", err)})
+		return
+	}
+
+	// The cap is counted for the envelope and claimer the transaction actually claims for
+	if err := c.checkSignedClaim(signedTx, owner, req.EnvelopeID, claimer); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Invalid signed_transaction: %v", err)})
+		return
+	}
+	release, err := c.ClaimCaps.Reserve(r.Context(), owner, req.EnvelopeID, claimer)
+	if err != nil {
+		json.NewEncoder(w).Encode(claimCapResponse(err))
+		return
+	}
+
+	result := c.Claims.Submit(r.Context(), ClaimSubmission{
+		Owner:             owner,
+		Claimer:           claimer,
+		EnvelopeID:        req.EnvelopeID,
+		SignedTransaction: signedTx,
+		Preflight:         mode,
 	})
+	if result.UnsignedTx != "" {
+		result.EstimatedFee = c.EstimateFee(r.Context(), result.UnsignedTx)
+		result.UnsignedTx = encodeUnsignedTx(result.UnsignedTx, encoding)
+		result.Encoding = encoding.String()
+	}
+	if result.Outcome != ClaimSent {
+		release()
+	}
+	if result.Outcome == ClaimFailed {
+		result.TransactionID = c.recordFailure("", signedTx, "claim", result.Message, result.ErrorCode, result.ProgramLogs)
+	}
+	json.NewEncoder(w).Encode(result)
 }
 
 // HandleRefundEnvelope handles refund envelope request
 func (c *Client) HandleRefundEnvelope(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if err := c.Breakers.Allow(BreakerRefund); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
 	var req RefundEnvelopeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
@@ -268,8 +452,28 @@ func (c *Client) HandleRefundEnvelope(w http.ResponseWriter, r *http.Request) {
 	}
 
 	owner := solana.MustPublicKeyFromBase58(req.OwnerAddress)
+	encoding, err := txencoding.Parse(req.Encoding, txencoding.Base64)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	// The SOL program only refunds everything remaining
+	if req.Amount > 0 {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: "partial refunds are not supported by the SOL program"})
+		return
+	}
+	envelope, err := c.getEnvelopeInfo(r.Context(), owner, req.EnvelopeID)
+	if err == nil {
+		_, err = envelope.ValidateRefund(0)
+	}
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
 
 	instruction, err := BuildRefundInstruction(c.ProgramID, owner, req.EnvelopeID)
+	message := fmt.Sprintf("Refund envelope #%d transaction created. Sign on client side.", req.EnvelopeID)
 	if err != nil {
 		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
 		return
@@ -282,9 +486,14 @@ func (c *Client) HandleRefundEnvelope(w http.ResponseWriter, r *http.Request) {
 	}
 
 	json.NewEncoder(w).Encode(Response{
-		Success:    true,
-		Message:    fmt.Sprintf("Refund envelope #%d transaction created. Sign on client side.", req.EnvelopeID),
-		UnsignedTx: unsignedTx,
+		Success:       true,
+		Message:       message,
+		UnsignedTx:    encodeUnsignedTx(unsignedTx, encoding),
+		Encoding:      encoding.String(),
+		TransactionID: c.issued.issue("sol", chain.EnvelopeActionRefund),
+		EnvelopeID:    req.EnvelopeID,
+		Links:         c.links(owner, req.EnvelopeID, nil),
+		EstimatedFee:  c.EstimateFee(r.Context(), unsignedTx),
 	})
 }
 
@@ -292,6 +501,11 @@ func (c *Client) HandleRefundEnvelope(w http.ResponseWriter, r *http.Request) {
 func (c *Client) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if err := c.Breakers.Allow(BreakerSend); err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
 	var req SendTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		json.NewEncoder(w).Encode(Response{
@@ -301,9 +515,41 @@ func (c *Client) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Send transaction with detailed result
-	result, err := c.SendTransaction(req.SignedTransaction)
+	mode, err := c.Preflight.FromRequest(r, req.Preflight)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+	signedTx, _, err := normalizeSignedTx(req.SignedTransaction, req.Encoding)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: fmt.Sprintf("Invalid signed_transaction: %v", err)})
+		return
+	}
+
+	// Only the envelope instructions of one action (any envelope action without a transaction_id)
+	action, err := c.allowedAction(signedTx, req.TransactionID)
+	if err != nil {
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	// Claims sent through the generic path count against the claim caps too
+	release, err := c.reserveClaim(r.Context(), signedTx)
+	if err != nil {
+		json.NewEncoder(w).Encode(claimCapResponse(err))
+		return
+	}
+
+	// Send transaction with detailed result; refunds wait their turn in RefundQueue when set
+	var result *SendTransactionResult
+	if envelope, owner, ok := c.refundAccounts(signedTx); ok && c.RefundQueue != nil && (action == "" || action == chain.EnvelopeActionRefund) {
+		result, err = c.sendQueuedRefund(r.Context(), req.TransactionID, signedTx, envelope, owner, mode)
+	} else {
+		result, err = c.SendActionWithPreflight(signedTx, action, mode)
+	}
 	if err != nil {
+		release()
+
 		// Parse error to user-friendly message
 		friendlyError := ParseSolanaError(err)
 
@@ -329,6 +575,7 @@ func (c *Client) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
 			response.Message = "Transaction expired. Please request a new unsigned transaction and try again."
 			response.ErrorCode = nil // No custom error code for this
 		}
+		response.TransactionID = c.recordFailure(req.TransactionID, signedTx, "send", errStr, response.ErrorCode, response.ProgramLogs)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
@@ -337,6 +584,7 @@ func (c *Client) HandleSendTransaction(w http.ResponseWriter, r *http.Request) {
 		Success:        true,
 		Message:        "Transaction sent successfully",
 		TransactionSig: result.Signature,
+		Links:          &ExplorerLinks{Transaction: c.Explorer.Tx(result.Signature)},
 	})
 }
 
@@ -353,7 +601,7 @@ type SignTransactionResponse struct {
 	SignedTransaction string `json:"signed_transaction"`
 }
 
-// HandleSignTransaction signs transaction on backend (⚠️ TESTING ONLY!)
+// HandleSignTransaction signs transaction on backend (⚠️ TESTING ONLY! operators: use `ops sign` offline)
 func (c *Client) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -430,3 +678,55 @@ func (c *Client) HandleSignTransaction(w http.ResponseWriter, r *http.Request) {
 		SignedTransaction: signedTxBase64,
 	})
 }
+
+// VerifyPDARequest - Inputs plus the address the client derived
+type VerifyPDARequest struct {
+	PDAInputs
+	ClientAddress string `json:"client_address"`
+}
+
+// VerifyPDAResponse - Parity result with the canonical derivation
+type VerifyPDAResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message,omitempty"`
+	Result  *PDAVerification `json:"result,omitempty"`
+}
+
+// HandleVerifyPDA checks a client-derived PDA against ours (POST /api/pda/verify).
+// program_id defaults to this client's program.
+func (c *Client) HandleVerifyPDA(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req VerifyPDARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(VerifyPDAResponse{Success: false, Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	if req.ProgramID == "" {
+		req.ProgramID = c.ProgramID.String()
+	}
+
+	result, err := VerifyPDA(req.PDAInputs, req.ClientAddress)
+	if err != nil {
+		json.NewEncoder(w).Encode(VerifyPDAResponse{Success: false, Message: err.Error()})
+		return
+	}
+	message := "Client address matches canonical derivation"
+	if !result.Match {
+		message = "Client address does not match canonical derivation"
+	}
+	json.NewEncoder(w).Encode(VerifyPDAResponse{Success: true, Message: message, Result: result})
+}
+
+// HandlePDATestVectors returns the published PDA test vectors (GET /api/pda/test-vectors)
+func (c *Client) HandlePDATestVectors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vectors, err := PDATestVectors()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(Response{Success: false, Message: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(vectors)
+}
@@ -0,0 +1,695 @@
+package solprogram
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// IDLSeed - Seed of the Anchor IDL account (created with seed from the program's base PDA)
+const IDLSeed = "anchor:idl"
+
+// idlHeaderSize - Anchor IDL account header: discriminator (8) + authority (32) + data length (4)
+const idlHeaderSize = 8 + 32 + 4
+
+var (
+	// ErrNoIDL - Program has no Anchor IDL account on chain
+	ErrNoIDL = errors.New("program has no on-chain IDL")
+	// ErrIDLMismatch - Deployed IDL disagrees with what the Go builders encode
+	ErrIDLMismatch = errors.New("deployed IDL does not match instruction builders")
+)
+
+// IDLAccount - Account of an instruction, in order
+type IDLAccount struct {
+	Name     string `json:"name"` // snake_case
+	Writable bool   `json:"writable"`
+	Signer   bool   `json:"signer"`
+}
+
+// IDLInstruction - Instruction as the program declares it
+type IDLInstruction struct {
+	Name          string       `json:"name"` // snake_case
+	Discriminator []byte       `json:"discriminator"`
+	Accounts      []IDLAccount `json:"accounts"`
+	Args          []string     `json:"args"` // snake_case names, in encoding order
+}
+
+// IDLError - Custom program error
+type IDLError struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// IDLField - Struct or enum variant field; Type is normalized, e.g. "pubkey", "vec<(pubkey, u64)>" with
+// defined structs spelled as tuples of their field types and other defined types by name
+type IDLField struct {
+	Name string `json:"name"` // snake_case ("" for tuple variant fields)
+	Type string `json:"type"`
+}
+
+// IDLVariant - Enum variant
+type IDLVariant struct {
+	Name   string     `json:"name"` // snake_case
+	Fields []IDLField `json:"fields,omitempty"`
+}
+
+// IDLTypeDef - Account or defined type: a struct (Fields) or an enum (Variants)
+type IDLTypeDef struct {
+	Name     string       `json:"name"`
+	Kind     string       `json:"kind"` // struct | enum
+	Fields   []IDLField   `json:"fields,omitempty"`
+	Variants []IDLVariant `json:"variants,omitempty"`
+}
+
+// IDL - Anchor IDL normalized across the legacy (camelCase, isMut/isSigner) and 0.30+ (snake_case,
+// explicit discriminators, writable/signer) formats
+type IDL struct {
+	Name         string           `json:"name"`
+	Instructions []IDLInstruction `json:"instructions"`
+	Errors       []IDLError       `json:"errors"`
+	Types        []IDLTypeDef     `json:"types,omitempty"` // Accounts and defined types
+}
+
+type rawIDLAccount struct {
+	Name     string          `json:"name"`
+	IsMut    bool            `json:"isMut"`
+	IsSigner bool            `json:"isSigner"`
+	Writable bool            `json:"writable"`
+	Signer   bool            `json:"signer"`
+	Accounts []rawIDLAccount `json:"accounts"` // Composite accounts struct, flattened in order
+}
+
+type rawIDLTypeDef struct {
+	Name string `json:"name"`
+	Type *struct {
+		Kind   string `json:"kind"`
+		Fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+		Variants []struct {
+			Name   string            `json:"name"`
+			Fields []json.RawMessage `json:"fields"` // {name, type} or a bare type (tuple variants)
+		} `json:"variants"`
+	} `json:"type"`
+}
+
+type rawIDL struct {
+	Name     string `json:"name"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Instructions []struct {
+		Name          string          `json:"name"`
+		Discriminator []int           `json:"discriminator"`
+		Accounts      []rawIDLAccount `json:"accounts"`
+		Args          []struct {
+			Name string `json:"name"`
+		} `json:"args"`
+	} `json:"instructions"`
+	Errors   []IDLError      `json:"errors"`
+	Accounts []rawIDLTypeDef `json:"accounts"` // Legacy IDLs define account structs here
+	Types    []rawIDLTypeDef `json:"types"`
+}
+
+// ParseIDL - IDL from Anchor IDL JSON; legacy IDLs get the discriminator Anchor derives from the name
+func ParseIDL(data []byte) (*IDL, error) {
+	var raw rawIDL
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid IDL JSON: %w", err)
+	}
+	idl := &IDL{Name: raw.Name, Errors: raw.Errors}
+	if idl.Name == "" {
+		idl.Name = raw.Metadata.Name
+	}
+	for _, ri := range raw.Instructions {
+		inst := IDLInstruction{Name: snakeCase(ri.Name)}
+		if len(ri.Discriminator) > 0 {
+			for _, b := range ri.Discriminator {
+				inst.Discriminator = append(inst.Discriminator, byte(b))
+			}
+		} else {
+			inst.Discriminator = getAnchorDiscriminator(inst.Name)
+		}
+		inst.Accounts = flattenIDLAccounts(nil, ri.Accounts)
+		for _, arg := range ri.Args {
+			inst.Args = append(inst.Args, snakeCase(arg.Name))
+		}
+		idl.Instructions = append(idl.Instructions, inst)
+	}
+
+	defs := make(map[string]rawIDLTypeDef)
+	for _, def := range append(raw.Accounts, raw.Types...) {
+		if def.Type != nil {
+			defs[def.Name] = def
+		}
+	}
+	for _, def := range append(raw.Accounts, raw.Types...) {
+		if def.Type == nil || idl.hasType(def.Name) {
+			continue
+		}
+		typeDef := IDLTypeDef{Name: def.Name, Kind: def.Type.Kind}
+		for _, f := range def.Type.Fields {
+			typeDef.Fields = append(typeDef.Fields, IDLField{Name: snakeCase(f.Name), Type: idlTypeName(f.Type, defs)})
+		}
+		for _, v := range def.Type.Variants {
+			variant := IDLVariant{Name: snakeCase(v.Name)}
+			for _, f := range v.Fields {
+				var named struct {
+					Name string          `json:"name"`
+					Type json.RawMessage `json:"type"`
+				}
+				if json.Unmarshal(f, &named) == nil && named.Type != nil {
+					variant.Fields = append(variant.Fields, IDLField{Name: snakeCase(named.Name), Type: idlTypeName(named.Type, defs)})
+				} else {
+					variant.Fields = append(variant.Fields, IDLField{Type: idlTypeName(f, defs)})
+				}
+			}
+			typeDef.Variants = append(typeDef.Variants, variant)
+		}
+		idl.Types = append(idl.Types, typeDef)
+	}
+	return idl, nil
+}
+
+// idlTypeName - Normalized name of an IDL type: "publicKey" -> "pubkey", {"vec": T} -> "vec<T>",
+// {"option": T} -> "option<T>", {"array": [T, n]} -> "[T; n]", defined structs -> "(T1, T2)"
+// (what Borsh writes for them), other defined types by name
+func idlTypeName(raw json.RawMessage, defs map[string]rawIDLTypeDef) string {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		if name == "publicKey" {
+			return "pubkey"
+		}
+		return name
+	}
+	var composite struct {
+		Vec     json.RawMessage   `json:"vec"`
+		Option  json.RawMessage   `json:"option"`
+		Array   []json.RawMessage `json:"array"`
+		Defined json.RawMessage   `json:"defined"`
+	}
+	if err := json.Unmarshal(raw, &composite); err != nil {
+		return string(raw)
+	}
+	switch {
+	case composite.Vec != nil:
+		return "vec<" + idlTypeName(composite.Vec, defs) + ">"
+	case composite.Option != nil:
+		return "option<" + idlTypeName(composite.Option, defs) + ">"
+	case len(composite.Array) == 2:
+		return "[" + idlTypeName(composite.Array[0], defs) + "; " + string(composite.Array[1]) + "]"
+	case composite.Defined != nil:
+		// Legacy: "Name", 0.30+: {"name": "Name"}
+		var definedName string
+		if json.Unmarshal(composite.Defined, &definedName) != nil {
+			var named struct {
+				Name string `json:"name"`
+			}
+			json.Unmarshal(composite.Defined, &named)
+			definedName = named.Name
+		}
+		def, ok := defs[definedName]
+		if !ok || def.Type.Kind != "struct" {
+			return definedName
+		}
+		fields := make([]string, 0, len(def.Type.Fields))
+		for _, f := range def.Type.Fields {
+			fields = append(fields, idlTypeName(f.Type, defs))
+		}
+		return "(" + strings.Join(fields, ", ") + ")"
+	}
+	return string(raw)
+}
+
+func (idl *IDL) hasType(name string) bool {
+	_, ok := idl.Type(name)
+	return ok
+}
+
+// Type - Account or defined type named name, if declared
+func (idl *IDL) Type(name string) (IDLTypeDef, bool) {
+	for _, def := range idl.Types {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return IDLTypeDef{}, false
+}
+
+func flattenIDLAccounts(out []IDLAccount, accounts []rawIDLAccount) []IDLAccount {
+	for _, a := range accounts {
+		if len(a.Accounts) > 0 {
+			out = flattenIDLAccounts(out, a.Accounts)
+			continue
+		}
+		out = append(out, IDLAccount{Name: snakeCase(a.Name), Writable: a.IsMut || a.Writable, Signer: a.IsSigner || a.Signer})
+	}
+	return out
+}
+
+// snakeCase - expiryHours -> expiry_hours (snake_case names are unchanged)
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Instruction - Instruction named name (snake_case), if declared
+func (idl *IDL) Instruction(name string) (IDLInstruction, bool) {
+	for _, inst := range idl.Instructions {
+		if inst.Name == name {
+			return inst, true
+		}
+	}
+	return IDLInstruction{}, false
+}
+
+// IDLAddress - Anchor IDL account of programID
+func IDLAddress(programID solana.PublicKey) (solana.PublicKey, error) {
+	base, _, err := solana.FindProgramAddress([][]byte{}, programID)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return solana.CreateWithSeed(base, IDLSeed, programID)
+}
+
+// FetchIDL - IDL the program published with `anchor idl init` (ErrNoIDL when there is none)
+func FetchIDL(ctx context.Context, client *rpc.Client, programID solana.PublicKey) (*IDL, error) {
+	address, err := IDLAddress(programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive IDL address: %w", err)
+	}
+	info, err := client.GetAccountInfo(ctx, address)
+	if errors.Is(err, rpc.ErrNotFound) || err == nil && (info == nil || info.Value == nil) {
+		return nil, fmt.Errorf("%w: %s (IDL account %s)", ErrNoIDL, programID, address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IDL account: %w", err)
+	}
+
+	data := info.Value.Data.GetBinary()
+	if len(data) < idlHeaderSize {
+		return nil, fmt.Errorf("IDL account %s too short (%d bytes)", address, len(data))
+	}
+	size := binary.LittleEndian.Uint32(data[idlHeaderSize-4 : idlHeaderSize])
+	if int(size) > len(data)-idlHeaderSize {
+		return nil, fmt.Errorf("IDL account %s truncated (%d of %d bytes)", address, len(data)-idlHeaderSize, size)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data[idlHeaderSize : idlHeaderSize+int(size)]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate IDL: %w", err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate IDL: %w", err)
+	}
+	return ParseIDL(raw)
+}
+
+// InstructionLayout - What a Go builder encodes for one instruction: the discriminator and account
+// flags are taken from a built instruction, account and argument names are declared next to it
+type InstructionLayout struct {
+	Name          string       `json:"name"`
+	Discriminator []byte       `json:"discriminator"`
+	Accounts      []IDLAccount `json:"accounts"`
+	Args          []string     `json:"args"`
+}
+
+// layoutOf - Layout of a built instruction; names label its accounts in order
+func layoutOf(name string, inst solana.Instruction, err error, accounts []string, args ...string) (InstructionLayout, error) {
+	if err != nil {
+		return InstructionLayout{}, fmt.Errorf("failed to build %s: %w", name, err)
+	}
+	data, err := inst.Data()
+	if err != nil {
+		return InstructionLayout{}, fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	layout := InstructionLayout{Name: name, Discriminator: data[:min(8, len(data))], Args: args}
+	for i, meta := range inst.Accounts() {
+		account := IDLAccount{Writable: meta.IsWritable, Signer: meta.IsSigner}
+		if i < len(accounts) {
+			account.Name = accounts[i]
+		}
+		layout.Accounts = append(layout.Accounts, account)
+	}
+	return layout, nil
+}
+
+// layoutKey - Placeholder wallet for building layouts
+var layoutKey = solana.MustPublicKeyFromBase58("11111111111111111111111111111112")
+
+// InstructionLayouts - Layouts of the SOL program builders (BuildInitUserStateInstruction, ...)
+func InstructionLayouts(programID solana.PublicKey) ([]InstructionLayout, error) {
+	refund := []string{"envelope", "owner"}
+	builds := []func() (InstructionLayout, error){
+		func() (InstructionLayout, error) {
+			inst, err := BuildInitUserStateInstruction(programID, layoutKey)
+			return layoutOf("init_user_state", inst, err, []string{"user_state", "user", "system_program"})
+		},
+		func() (InstructionLayout, error) {
+			inst, err := BuildCreateEnvelopeInstruction(programID, layoutKey, 1, RequestTypeGroupFixed, 1, 1, 1, nil)
+			return layoutOf("create", inst, err, []string{"user_state", "envelope", "user", "system_program"},
+				"envelope_type", "total_amount", "total_users", "expiry_hours")
+		},
+		func() (InstructionLayout, error) {
+			inst, err := BuildClaimInstruction(programID, layoutKey, layoutKey, 1)
+			return layoutOf("claim", inst, err, []string{"envelope", "claimer"})
+		},
+		func() (InstructionLayout, error) {
+			inst, err := BuildRefundInstruction(programID, layoutKey, 1)
+			return layoutOf("refund", inst, err, refund)
+		},
+	}
+	return buildLayouts(builds)
+}
+
+// InstructionLayouts - Layouts of the USDC program builders (resolves the mint's token program)
+func (c *USDCEnvelopeClient) InstructionLayouts() ([]InstructionLayout, error) {
+	refund := []string{"envelope", "envelope_vault", "owner_token_account", "owner", "token_program", "system_program"}
+	builds := []func() (InstructionLayout, error){
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildInitUserStateInstruction(layoutKey)
+			return layoutOf("init_user_state", inst, err, []string{"user_state", "user", "system_program"})
+		},
+		func() (InstructionLayout, error) {
+			params := CreateEnvelopeParams{EnvelopeType: EnvelopeTypeData{Type: EnvelopeTypeGroupFixed}, TotalAmount: 1, TotalUsers: 1, ExpirySeconds: 1}
+			inst, err := c.BuildCreateEnvelopeInstruction(layoutKey, layoutKey, params, 1)
+			args := []string{"envelope_type", "total_amount", "total_users", "expiry_seconds", "start_time"}
+			if c.layout == EnvelopeLayoutV1 {
+				args = args[:len(args)-1]
+			}
+			return layoutOf("create", inst, err,
+				[]string{"user_state", "envelope", "envelope_vault", "user_token_account", "usdc_mint", "user", "token_program", "system_program"},
+				args...)
+		},
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildClaimInstruction(ClaimEnvelopeParams{EnvelopeID: 1, Owner: layoutKey, Claimer: layoutKey, ClaimerTokenAccount: layoutKey})
+			return layoutOf("claim", inst, err,
+				[]string{"envelope", "envelope_vault", "claimer_token_account", "claim_record", "claimer", "token_program", "system_program"})
+		},
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildRefundInstruction(RefundParams{EnvelopeID: 1, Owner: layoutKey, OwnerTokenAccount: layoutKey})
+			return layoutOf("refund", inst, err, refund)
+		},
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildRefundInstruction(RefundParams{EnvelopeID: 1, Owner: layoutKey, OwnerTokenAccount: layoutKey, Amount: 1})
+			return layoutOf("partial_refund", inst, err, refund, "amount")
+		},
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildCancelInstruction(layoutKey, 1)
+			return layoutOf("cancel", inst, err, []string{"envelope", "user_state", "owner"})
+		},
+		func() (InstructionLayout, error) {
+			inst, err := c.BuildCloseEnvelopeInstruction(layoutKey, 1)
+			return layoutOf("close", inst, err, []string{"envelope", "owner"})
+		},
+	}
+	return buildLayouts(builds)
+}
+
+func buildLayouts(builds []func() (InstructionLayout, error)) ([]InstructionLayout, error) {
+	layouts := make([]InstructionLayout, 0, len(builds))
+	for _, build := range builds {
+		layout, err := build()
+		if err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// IDLMismatch - One difference between the deployed IDL and the Go side
+type IDLMismatch struct {
+	Instruction string `json:"instruction,omitempty"` // "" for error codes and accounts
+	Account     string `json:"account,omitempty"`     // Account or type whose layout differs
+	Field       string `json:"field"`
+	Builder     string `json:"builder"`
+	IDL         string `json:"idl"`
+}
+
+// IDLReport - Diff of the deployed IDL against the builders and ProgramErrors
+type IDLReport struct {
+	Program    string        `json:"program"`
+	Mismatches []IDLMismatch `json:"mismatches"`
+}
+
+func (r *IDLReport) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: program %s, %d difference(s) (builder vs idl)", ErrIDLMismatch, r.Program, len(r.Mismatches))
+	for _, m := range r.Mismatches {
+		subject := m.Field
+		if m.Instruction != "" {
+			subject = m.Instruction + " " + m.Field
+		}
+		if m.Account != "" {
+			subject = "account " + m.Account + " " + m.Field
+		}
+		fmt.Fprintf(&b, "\n  %s: %s vs %s", subject, m.Builder, m.IDL)
+	}
+	return b.String()
+}
+
+func (r *IDLReport) Unwrap() error {
+	return ErrIDLMismatch
+}
+
+// Err - r when there are mismatches, otherwise nil
+func (r *IDLReport) Err() error {
+	if r == nil || len(r.Mismatches) == 0 {
+		return nil
+	}
+	return r
+}
+
+func (r *IDLReport) add(instruction, field, builder, idl string) {
+	r.Mismatches = append(r.Mismatches, IDLMismatch{Instruction: instruction, Field: field, Builder: builder, IDL: idl})
+}
+
+// CompareIDL - Every difference in instruction names, discriminators, account order and flags,
+// argument order and error codes (errors: code -> "Name - message", as ProgramErrors). Instructions
+// the IDL declares but no builder encodes are not reported.
+func CompareIDL(idl *IDL, layouts []InstructionLayout, errs map[int]string) *IDLReport {
+	report := &IDLReport{Program: idl.Name, Mismatches: []IDLMismatch{}}
+	for _, layout := range layouts {
+		inst, ok := idl.Instruction(layout.Name)
+		if !ok {
+			report.add(layout.Name, "instruction", "encoded", "missing")
+			continue
+		}
+		if !bytes.Equal(layout.Discriminator, inst.Discriminator) {
+			report.add(layout.Name, "discriminator", hex.EncodeToString(layout.Discriminator), hex.EncodeToString(inst.Discriminator))
+		}
+		if len(layout.Accounts) != len(inst.Accounts) {
+			report.add(layout.Name, "account count", fmt.Sprint(len(layout.Accounts)), fmt.Sprint(len(inst.Accounts)))
+		}
+		for i := range max(len(layout.Accounts), len(inst.Accounts)) {
+			want, got := "missing", "missing"
+			if i < len(layout.Accounts) {
+				want = formatIDLAccount(layout.Accounts[i])
+			}
+			if i < len(inst.Accounts) {
+				got = formatIDLAccount(inst.Accounts[i])
+			}
+			if want != got {
+				report.add(layout.Name, fmt.Sprintf("account %d", i), want, got)
+			}
+		}
+		if strings.Join(layout.Args, ",") != strings.Join(inst.Args, ",") {
+			report.add(layout.Name, "args", "("+strings.Join(layout.Args, ", ")+")", "("+strings.Join(inst.Args, ", ")+")")
+		}
+	}
+
+	declared := make(map[int]string, len(idl.Errors))
+	for _, e := range idl.Errors {
+		declared[e.Code] = e.Name
+	}
+	codes := make([]int, 0, len(errs)+len(declared))
+	for code := range errs {
+		codes = append(codes, code)
+	}
+	for code := range declared {
+		if _, ok := errs[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		want, got := "missing", "missing"
+		if msg, ok := errs[code]; ok {
+			want, _, _ = strings.Cut(msg, " - ")
+		}
+		if name, ok := declared[code]; ok {
+			got = name
+		}
+		if want != got {
+			report.add("", fmt.Sprintf("error %d", code), want, got)
+		}
+	}
+	return report
+}
+
+// CompareAccountLayout - Differences between the fields a parser reads from account (or enum variants,
+// for enums) and what the IDL declares, added to report
+func CompareAccountLayout(report *IDLReport, idl *IDL, account string, fields []IDLField, variants []IDLVariant) {
+	def, ok := idl.Type(account)
+	if !ok {
+		report.Mismatches = append(report.Mismatches, IDLMismatch{Account: account, Field: "type", Builder: "parsed", IDL: "missing"})
+		return
+	}
+	diff := func(field, parsed, declared string) {
+		if parsed != declared {
+			report.Mismatches = append(report.Mismatches, IDLMismatch{Account: account, Field: field, Builder: parsed, IDL: declared})
+		}
+	}
+	if variants == nil {
+		diff("fields", formatIDLFields(fields), formatIDLFields(def.Fields))
+		return
+	}
+	for i := range max(len(variants), len(def.Variants)) {
+		parsed, declared := "missing", "missing"
+		if i < len(variants) {
+			parsed = variants[i].Name + formatIDLFields(variants[i].Fields)
+		}
+		if i < len(def.Variants) {
+			declared = def.Variants[i].Name + formatIDLFields(def.Variants[i].Fields)
+		}
+		diff(fmt.Sprintf("variant %d", i), parsed, declared)
+	}
+}
+
+func formatIDLFields(fields []IDLField) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Name == "" {
+			parts = append(parts, f.Type)
+			continue
+		}
+		parts = append(parts, f.Name+": "+f.Type)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func formatIDLAccount(a IDLAccount) string {
+	flags := ""
+	if a.Writable {
+		flags += "w"
+	}
+	if a.Signer {
+		flags += "s"
+	}
+	if flags == "" {
+		return a.Name
+	}
+	return a.Name + " [" + flags + "]"
+}
+
+// ValidateIDL - Diff of the deployed SOL program IDL against the builders (ErrNoIDL when not published)
+func (c *Client) ValidateIDL(ctx context.Context) (*IDLReport, error) {
+	idl, err := FetchIDL(ctx, c.RPC, c.ProgramID)
+	if err != nil {
+		return nil, err
+	}
+	layouts, err := InstructionLayouts(c.ProgramID)
+	if err != nil {
+		return nil, err
+	}
+	return CompareIDL(idl, layouts, ProgramErrors), nil
+}
+
+// ValidateIDL - Diff of the deployed USDC program IDL against the builders and the Envelope account
+// parser (ErrNoIDL when not published). The parser switches to the Envelope layout the IDL declares.
+func (c *USDCEnvelopeClient) ValidateIDL(ctx context.Context) (*IDLReport, error) {
+	idl, err := FetchIDL(ctx, c.rpcClient, c.programID)
+	if err != nil {
+		return nil, err
+	}
+	// The builders encode create for the layout too, so switch before building them
+	layout := EnvelopeLayoutFromIDL(idl)
+	c.SetEnvelopeLayout(layout)
+	layouts, err := c.InstructionLayouts()
+	if err != nil {
+		return nil, err
+	}
+	report := CompareIDL(idl, layouts, ProgramErrors)
+	CompareAccountLayout(report, idl, "Envelope", layout.fields(), nil)
+	CompareAccountLayout(report, idl, "EnvelopeType", nil, envelopeTypeVariants)
+	return report, nil
+}
+
+// DetectEnvelopeLayout - Switch to the Envelope layout the deployed IDL declares, for clients that
+// don't run ValidateIDL (ErrNoIDL when not published; the layout is then left as is)
+func (c *USDCEnvelopeClient) DetectEnvelopeLayout(ctx context.Context) (EnvelopeLayout, error) {
+	idl, err := FetchIDL(ctx, c.rpcClient, c.programID)
+	if err != nil {
+		return c.layout, err
+	}
+	layout := EnvelopeLayoutFromIDL(idl)
+	c.SetEnvelopeLayout(layout)
+	return layout, nil
+}
+
+// IDLCheckMode - What startup does with an IDL mismatch
+type IDLCheckMode string
+
+const (
+	IDLCheckFail IDLCheckMode = "fail" // Refuse to start
+	IDLCheckWarn IDLCheckMode = "warn" // Log the diff and start
+	IDLCheckOff  IDLCheckMode = "off"  // Don't fetch the IDL
+)
+
+// ParseIDLCheckMode - IDL_CHECK: fail (default), warn or off
+func ParseIDLCheckMode(s string) (IDLCheckMode, error) {
+	switch mode := IDLCheckMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return IDLCheckFail, nil
+	case IDLCheckFail, IDLCheckWarn, IDLCheckOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown IDL check mode %q (want fail, warn or off)", s)
+	}
+}
+
+// Enforce - Startup decision on a ValidateIDL result: the report error in fail mode, otherwise nil.
+// A missing IDL or an unreachable RPC is logged and never fails startup.
+func (m IDLCheckMode) Enforce(report *IDLReport, err error) error {
+	switch {
+	case m == IDLCheckOff:
+		return nil
+	case err != nil:
+		log.Printf("IDL check skipped: %v", err)
+		return nil
+	case report.Err() == nil:
+		log.Printf("IDL check: program %s matches the instruction builders", report.Program)
+		return nil
+	case m == IDLCheckWarn:
+		log.Printf("⚠️ %v", report)
+		return nil
+	default:
+		return report
+	}
+}
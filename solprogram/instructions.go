@@ -23,6 +23,10 @@ var (
 	CreateDisc        = getDiscriminator("global:create")
 	ClaimDisc         = getDiscriminator("global:claim")
 	RefundDisc        = getDiscriminator("global:refund")
+
+	// Deprecated: the SOL program (SPL.rs) has no partial_refund instruction; partial refunds are
+	// USDC only (DiscriminatorPartialRefund)
+	PartialRefundDisc = getDiscriminator("global:partial_refund")
 )
 
 // DeriveUserStatePDA derives user_state PDA address
@@ -161,26 +165,6 @@ func BuildCreateEnvelopeInstruction(
 	binary.LittleEndian.PutUint64(expiryBytes, expiryHours)
 	instructionData = append(instructionData, expiryBytes...)
 
-	// ✅ DEBUG LOGGING
-	fmt.Printf("\n=== CREATE INSTRUCTION DEBUG ===\n")
-	fmt.Printf("Program ID: %s\n", programID)
-	fmt.Printf("User: %s\n", user)
-	fmt.Printf("User State PDA: %s\n", userStatePDA)
-	fmt.Printf("Envelope PDA: %s\n", envelopePDA)
-	fmt.Printf("Envelope ID: %d\n", envelopeID)
-	fmt.Printf("Envelope Type: %s\n", envelopeType)
-	fmt.Printf("Total Amount: %d\n", totalAmount)
-	fmt.Printf("Total Users: %d\n", totalUsers)
-	fmt.Printf("Expiry Hours: %d\n", expiryHours)
-	fmt.Printf("\nInstruction Data (%d bytes):\n", len(instructionData))
-	fmt.Printf("  Discriminator: %v\n", discriminator)
-	fmt.Printf("  EnvelopeType: %v (len=%d)\n", envelopeTypeData, len(envelopeTypeData))
-	fmt.Printf("  TotalAmount: %v\n", amountBytes)
-	fmt.Printf("  TotalUsers: %v\n", usersBytes)
-	fmt.Printf("  ExpiryHours: %v\n", expiryBytes)
-	fmt.Printf("  Full hex: %x\n", instructionData)
-	fmt.Printf("================================\n\n")
-
 	return solana.NewInstruction(
 		programID,
 		solana.AccountMetaSlice{
@@ -229,3 +213,15 @@ func BuildRefundInstruction(
 		RefundDisc[:],
 	), nil
 }
+
+// BuildPartialRefundInstruction - Always fails: the SOL program only refunds everything remaining.
+//
+// Deprecated: use BuildRefundInstruction; partial refunds are USDC only (RefundParams.Amount).
+func BuildPartialRefundInstruction(
+	programID solana.PublicKey,
+	owner solana.PublicKey,
+	envelopeID uint64,
+	amount uint64,
+) (solana.Instruction, error) {
+	return nil, fmt.Errorf("partial_refund is not an instruction of the SOL envelope program")
+}
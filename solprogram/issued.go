@@ -0,0 +1,128 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// issuedTransactionTTL - How long an issued transaction ID is remembered (well past blockhash expiry)
+const issuedTransactionTTL = 10 * time.Minute
+
+// issuedTransactionSweepInterval - How often expired IDs are dropped while any are remembered
+const issuedTransactionSweepInterval = time.Minute
+
+// issuedTransactions - IDs of the unsigned transactions handed out and the envelope action each was
+// built for, so a submission of a known ID is checked against what the server built
+type issuedTransactions struct {
+	mu       sync.Mutex
+	ids      map[string]issuedTransaction
+	sweeping bool
+}
+
+type issuedTransaction struct {
+	action    string
+	createdAt time.Time
+}
+
+func newIssuedTransactions() *issuedTransactions {
+	return &issuedTransactions{ids: make(map[string]issuedTransaction)}
+}
+
+// issue - New transaction ID for an unsigned transaction of action, e.g. "usdc_claim_<n>"
+func (i *issuedTransactions) issue(prefix, action string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	id := fmt.Sprintf("%s_%s_%d", prefix, action, now.UnixNano())
+	for _, taken := i.ids[id]; taken; _, taken = i.ids[id] {
+		now = now.Add(time.Nanosecond)
+		id = fmt.Sprintf("%s_%s_%d", prefix, action, now.UnixNano())
+	}
+	i.ids[id] = issuedTransaction{action: action, createdAt: now}
+	if !i.sweeping {
+		i.sweeping = true
+		go i.sweep(issuedTransactionSweepInterval)
+	}
+	return id
+}
+
+// sweep - Drop expired IDs every interval, while there are any
+func (i *issuedTransactions) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		i.mu.Lock()
+		now := time.Now()
+		for id, issued := range i.ids {
+			if now.Sub(issued.createdAt) > issuedTransactionTTL {
+				delete(i.ids, id)
+			}
+		}
+		if len(i.ids) == 0 {
+			i.sweeping = false
+			i.mu.Unlock()
+			return
+		}
+		i.mu.Unlock()
+	}
+}
+
+// lookup - Action transactionID was issued for by this instance (ok = false when unknown or expired)
+func (i *issuedTransactions) lookup(transactionID string) (string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	issued, ok := i.ids[transactionID]
+	if !ok || time.Since(issued.createdAt) > issuedTransactionTTL {
+		return "", false
+	}
+	return issued.action, true
+}
+
+// submissionAction - Action a submitted transaction is checked against. Without a transaction_id any
+// envelope action is allowed (""). With one, the action comes from the transaction's own envelope
+// instructions, and must be the action the ID was issued for when issued says it knows the ID.
+func submissionAction(allowList *InstructionAllowList, tx *solana.Transaction, transactionID string, issued func(string) (string, bool)) (string, error) {
+	if allowList == nil || transactionID == "" {
+		return "", nil
+	}
+	action, err := allowList.ActionOf(tx)
+	if err != nil {
+		return "", err
+	}
+	if issuedAction, ok := issued(transactionID); ok && issuedAction != action {
+		return "", allowList.reject(fmt.Errorf("%w: transaction_id %s was issued for a %s transaction, not %s",
+			ErrInstructionNotAllowed, transactionID, issuedAction, action))
+	}
+	return action, nil
+}
+
+// allowedAction - Allow-list action of a signed transaction sent through HandleSendTransaction
+func (c *Client) allowedAction(signedTxBase64, transactionID string) (string, error) {
+	tx, err := solana.TransactionFromBase64(signedTxBase64)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse transaction: %w", err)
+	}
+	return submissionAction(c.AllowList, tx, transactionID, c.issued.lookup)
+}
+
+// issuedAction - Action a transaction ID was issued for by GenerateUnsigned*. IDs issued by another
+// instance or before a restart are known when the shared transaction store still holds their unsigned
+// transaction (the action is then the one the ID encodes).
+func (c *USDCEnvelopeClient) issuedAction(ctx context.Context) func(string) (string, bool) {
+	return func(transactionID string) (string, bool) {
+		if action, ok := c.issued.lookup(transactionID); ok || c.txStore == nil {
+			return action, ok
+		}
+		if _, err := c.txStore.GetUnsignedTransaction(ctx, transactionID); err != nil {
+			return "", false
+		}
+		action := DeclaredAction(transactionID)
+		return action, action != ""
+	}
+}
@@ -0,0 +1,77 @@
+package solprogram
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+)
+
+func TestIssuedTransactions(t *testing.T) {
+	issued := newIssuedTransactions()
+	claimID := issued.issue("usdc", chain.EnvelopeActionClaim)
+	refundID := issued.issue("usdc", chain.EnvelopeActionRefund)
+	if claimID == refundID {
+		t.Fatalf("duplicate ID %s", claimID)
+	}
+
+	if action, ok := issued.lookup(claimID); !ok || action != chain.EnvelopeActionClaim {
+		t.Errorf("lookup(%s) = %q, %v; want claim", claimID, action, ok)
+	}
+	for _, id := range []string{"", "anything", "usdc_claim_1"} {
+		if _, ok := issued.lookup(id); ok {
+			t.Errorf("lookup(%q) found an ID that was never issued", id)
+		}
+	}
+	if got := DeclaredAction(refundID); got != chain.EnvelopeActionRefund {
+		t.Errorf("DeclaredAction(%s) = %q", refundID, got)
+	}
+}
+
+func TestSubmissionAction(t *testing.T) {
+	programID := solana.MustPublicKeyFromBase58("8sVfWmonJAzAQnS4nYcxv3GBSs4rDpvmniRrApwrh1QK")
+	allowList := SOLAllowList(programID)
+	owner := solana.NewWallet().PublicKey()
+	claimer := solana.NewWallet().PublicKey()
+
+	claim, err := BuildClaimInstruction(programID, owner, claimer, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, err := BuildRefundInstruction(programID, owner, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTx := func(instructions ...solana.Instruction) *solana.Transaction {
+		tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(claimer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	issued := newIssuedTransactions()
+	claimID := issued.issue("sol", chain.EnvelopeActionClaim)
+
+	// No transaction_id: any envelope action
+	if action, err := submissionAction(allowList, newTx(claim), "", issued.lookup); err != nil || action != "" {
+		t.Errorf("without ID = %q, %v; want any action", action, err)
+	}
+	// IDs issued before a restart or by another replica: the transaction's own action
+	if action, err := submissionAction(allowList, newTx(claim), "sol_claim_1", issued.lookup); err != nil || action != chain.EnvelopeActionClaim {
+		t.Errorf("unknown ID = %q, %v; want claim", action, err)
+	}
+	if action, err := submissionAction(allowList, newTx(claim), claimID, issued.lookup); err != nil || action != chain.EnvelopeActionClaim {
+		t.Errorf("issued ID = %q, %v; want claim", action, err)
+	}
+	// A refund signed in place of the issued claim
+	if _, err := submissionAction(allowList, newTx(refund), claimID, issued.lookup); !errors.Is(err, ErrInstructionNotAllowed) {
+		t.Errorf("refund under claim ID: err = %v, want ErrInstructionNotAllowed", err)
+	}
+	// Instructions of more than one action
+	if _, err := submissionAction(allowList, newTx(claim, refund), "sol_claim_1", issued.lookup); !errors.Is(err, ErrInstructionNotAllowed) {
+		t.Errorf("claim + refund: err = %v, want ErrInstructionNotAllowed", err)
+	}
+}
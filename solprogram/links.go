@@ -0,0 +1,77 @@
+package solprogram
+
+import (
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/explorer"
+)
+
+// ExplorerLinks - Explorer links of the accounts involved in an envelope action
+type ExplorerLinks struct {
+	Transaction string `json:"transaction,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Claimer     string `json:"claimer,omitempty"`
+	Envelope    string `json:"envelope,omitempty"`
+	Vault       string `json:"vault,omitempty"`
+	ClaimRecord string `json:"claim_record,omitempty"`
+	Program     string `json:"program,omitempty"`
+}
+
+// envelopeLinks - Links for owner, envelope PDA and (optional) vault/claimer/claim record
+func envelopeLinks(e explorer.Explorer, programID, owner, envelopePDA solana.PublicKey, vault, claimer, claimRecord *solana.PublicKey) *ExplorerLinks {
+	links := &ExplorerLinks{
+		Owner:    e.Address(owner.String()),
+		Envelope: e.Address(envelopePDA.String()),
+		Program:  e.Program(programID.String()),
+	}
+	if vault != nil {
+		links.Vault = e.TokenAccount(vault.String())
+	}
+	if claimer != nil {
+		links.Claimer = e.Address(claimer.String())
+	}
+	if claimRecord != nil {
+		links.ClaimRecord = e.Address(claimRecord.String())
+	}
+	return links
+}
+
+// links - Explorer links of a SOL envelope (lamports live in the envelope PDA, no vault)
+func (c *Client) links(owner solana.PublicKey, envelopeID uint64, claimer *solana.PublicKey) *ExplorerLinks {
+	envelopePDA, _, err := DeriveEnvelopePDA(c.ProgramID, owner, envelopeID)
+	if err != nil {
+		return nil
+	}
+	return envelopeLinks(c.Explorer, c.ProgramID, owner, envelopePDA, nil, claimer, nil)
+}
+
+// SetExplorerProvider - Explorer used for links in responses ("" = explorer.solana.com)
+func (c *USDCEnvelopeClient) SetExplorerProvider(provider explorer.Provider) {
+	c.explorer = explorer.New(c.explorer.Chain, c.explorer.Network, provider)
+}
+
+// Explorer - Link builder matching the client's network
+func (c *USDCEnvelopeClient) Explorer() explorer.Explorer {
+	return c.explorer
+}
+
+// EnvelopeLinks - Explorer links of an envelope (claimer optional)
+func (c *USDCEnvelopeClient) EnvelopeLinks(owner solana.PublicKey, envelopeID uint64, claimer *solana.PublicKey) (*ExplorerLinks, error) {
+	envelopePDA, _, err := c.DeriveEnvelopePDA(owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	vault, _, err := c.DeriveEnvelopeVaultPDA(owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	var claimRecord *solana.PublicKey
+	if claimer != nil {
+		pda, _, err := c.DeriveClaimRecordPDA(envelopePDA, *claimer)
+		if err != nil {
+			return nil, err
+		}
+		claimRecord = &pda
+	}
+	return envelopeLinks(c.explorer, c.programID, owner, envelopePDA, &vault, claimer, claimRecord), nil
+}
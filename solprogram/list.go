@@ -0,0 +1,210 @@
+package solprogram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/pagination"
+)
+
+// DefaultPageSize - Page size used by the Iter* helpers
+const DefaultPageSize = 50
+
+// claimRecordDiscriminator - Anchor account discriminator of ClaimRecord
+var claimRecordDiscriminator = func() []byte {
+	h := sha256.Sum256([]byte("account:ClaimRecord"))
+	return h[:8]
+}()
+
+// GetEnvelopesByOwner - One page of owner's envelopes, newest first.
+// Cursor is the envelope id to continue from ("" = latest); closed envelopes are skipped.
+func (c *USDCEnvelopeClient) GetEnvelopesByOwner(ctx context.Context, owner solana.PublicKey, cursor string, limit int) (*pagination.Page[*EnvelopeInfo], error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	page := &pagination.Page[*EnvelopeInfo]{}
+
+	var from uint64
+	if cursor == "" {
+		userState, err := c.GetUserState(ctx, owner)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return page, nil // Never created an envelope
+			}
+			return nil, err
+		}
+		from = userState.LastEnvelopeID
+	} else {
+		id, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		from = id
+	}
+	if from == 0 {
+		return page, nil
+	}
+
+	ids := make([]uint64, 0, limit)
+	pdas := make([]solana.PublicKey, 0, limit)
+	for id := from; id > 0 && len(ids) < limit; id-- {
+		pda, _, err := c.DeriveEnvelopePDA(owner, id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		pdas = append(pdas, pda)
+	}
+
+	accounts, err := c.rpcClient.GetMultipleAccounts(ctx, pdas...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelopes: %w", err)
+	}
+	for i, acc := range accounts.Value {
+		if acc == nil {
+			continue
+		}
+		envelope, err := parseEnvelopeData(acc.Data.GetBinary(), c.layout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse envelope %d: %w", ids[i], err)
+		}
+		page.Items = append(page.Items, envelope)
+	}
+
+	if last := ids[len(ids)-1]; last > 1 {
+		page.NextCursor = strconv.FormatUint(last-1, 10)
+	}
+	return page, nil
+}
+
+// IterEnvelopesByOwner - Iterate all of owner's envelopes, newest first
+func (c *USDCEnvelopeClient) IterEnvelopesByOwner(ctx context.Context, owner solana.PublicKey) *pagination.Iterator[*EnvelopeInfo] {
+	return pagination.New(ctx, func(ctx context.Context, cursor string) (*pagination.Page[*EnvelopeInfo], error) {
+		return c.GetEnvelopesByOwner(ctx, owner, cursor, DefaultPageSize)
+	})
+}
+
+// GetClaimsByEnvelope - One page of claims on an envelope, oldest first.
+// Cursor is "<claimed_at>:<claimer>" of the last item returned. Every call scans the envelope's
+// claim records; use IterClaimsByEnvelope to walk all pages on one scan.
+func (c *USDCEnvelopeClient) GetClaimsByEnvelope(ctx context.Context, owner solana.PublicKey, envelopeID uint64, cursor string, limit int) (*pagination.Page[*ClaimRecord], error) {
+	claims, err := c.fetchClaimRecords(ctx, owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	return claimsPage(claims, cursor, limit)
+}
+
+// IterClaimsByEnvelope - Iterate all claims on an envelope, oldest first. The claim records are
+// fetched once, with the first page, and the later pages come from that snapshot.
+func (c *USDCEnvelopeClient) IterClaimsByEnvelope(ctx context.Context, owner solana.PublicKey, envelopeID uint64) *pagination.Iterator[*ClaimRecord] {
+	var claims []*ClaimRecord
+	fetched := false
+	return pagination.New(ctx, func(ctx context.Context, cursor string) (*pagination.Page[*ClaimRecord], error) {
+		if !fetched {
+			var err error
+			if claims, err = c.fetchClaimRecords(ctx, owner, envelopeID); err != nil {
+				return nil, err
+			}
+			fetched = true
+		}
+		return claimsPage(claims, cursor, DefaultPageSize)
+	})
+}
+
+// claimsPage - Page of sorted claims after cursor
+func claimsPage(claims []*ClaimRecord, cursor string, limit int) (*pagination.Page[*ClaimRecord], error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	start := 0
+	if cursor != "" {
+		afterAt, afterClaimer, err := parseClaimCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(claims), func(i int) bool {
+			return claimAfter(claims[i], afterAt, afterClaimer)
+		})
+	}
+
+	end := min(start+limit, len(claims))
+	page := &pagination.Page[*ClaimRecord]{Items: claims[start:end]}
+	if end < len(claims) {
+		last := claims[end-1]
+		page.NextCursor = fmt.Sprintf("%d:%s", last.ClaimedAt, last.Claimer)
+	}
+	return page, nil
+}
+
+// fetchClaimRecords - All claim records of the envelope sorted by (claimed_at, claimer).
+// ClaimRecord stores only envelope_id, so matches are re-derived to drop other owners' envelopes.
+func (c *USDCEnvelopeClient) fetchClaimRecords(ctx context.Context, owner solana.PublicKey, envelopeID uint64) ([]*ClaimRecord, error) {
+	envelopePDA, _, err := c.DeriveEnvelopePDA(owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	idBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(idBytes, envelopeID)
+
+	accounts, err := c.rpcClient.GetProgramAccountsWithOpts(ctx, c.programID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: claimRecordDiscriminator}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 8 + 32, Bytes: idBytes}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claim records: %w", err)
+	}
+
+	claims := make([]*ClaimRecord, 0, len(accounts))
+	for _, acc := range accounts {
+		record, err := parseClaimRecordData(acc.Account.Data.GetBinary())
+		if err != nil {
+			continue
+		}
+		pda, _, err := c.DeriveClaimRecordPDA(envelopePDA, record.Claimer)
+		if err != nil || !pda.Equals(acc.Pubkey) {
+			continue
+		}
+		claims = append(claims, record)
+	}
+
+	sort.Slice(claims, func(i, j int) bool {
+		return claimAfter(claims[j], claims[i].ClaimedAt, claims[i].Claimer)
+	})
+	return claims, nil
+}
+
+// claimAfter - Whether claim sorts after (claimedAt, claimer)
+func claimAfter(claim *ClaimRecord, claimedAt int64, claimer solana.PublicKey) bool {
+	if claim.ClaimedAt != claimedAt {
+		return claim.ClaimedAt > claimedAt
+	}
+	return strings.Compare(claim.Claimer.String(), claimer.String()) > 0
+}
+
+func parseClaimCursor(cursor string) (int64, solana.PublicKey, error) {
+	at, claimer, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return 0, solana.PublicKey{}, fmt.Errorf("invalid cursor")
+	}
+	claimedAt, err := strconv.ParseInt(at, 10, 64)
+	if err != nil {
+		return 0, solana.PublicKey{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	pubkey, err := solana.PublicKeyFromBase58(claimer)
+	if err != nil {
+		return 0, solana.PublicKey{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return claimedAt, pubkey, nil
+}
@@ -0,0 +1,123 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+
+	"blockchain/backoff"
+	"blockchain/metrics"
+)
+
+// minContextSlotTTL - How long a confirmation slot is enforced on reads; every healthy node is past it by then
+const minContextSlotTTL = 2 * time.Minute
+
+// nodeBehindRetries - Reads retried while the node is behind the recorded slot
+const nodeBehindRetries = 6
+
+// rpcMinContextSlotNotReached - JSON-RPC error "Minimum context slot has not been reached"
+const rpcMinContextSlotNotReached = -32016
+
+// ErrNodeBehind - RPC node hasn't reached the slot of a transaction we already saw confirmed
+var ErrNodeBehind = errors.New("rpc node is behind the minimum context slot")
+
+var nodeBehindReads = metrics.Counter("solprogram_min_context_slot_retries")
+
+// IsNodeBehind - Error means the RPC node is behind (retryable), not that the account is missing
+func IsNodeBehind(err error) bool {
+	if errors.Is(err, ErrNodeBehind) {
+		return true
+	}
+	var rpcErr *jsonrpc.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == rpcMinContextSlotNotReached
+}
+
+// RecordMinContextSlot - Reads of owner's user state and envelope must observe at least slot
+// (the slot a create/claim/refund was confirmed in). Confirmations seen by WaitForConfirmation and
+// SubmitSignedTransaction are recorded automatically.
+func (c *USDCEnvelopeClient) RecordMinContextSlot(owner solana.PublicKey, envelopeID uint64, slot uint64) {
+	c.recordMinContextSlot(owner.String(), envelopeID, slot)
+}
+
+func (c *USDCEnvelopeClient) recordMinContextSlot(owner string, envelopeID uint64, slot uint64) {
+	if slot == 0 {
+		return
+	}
+	key := fmt.Sprintf("%s:%d", owner, envelopeID) // envelopeCacheKey
+	c.minSlots.raise(owner, slot)
+	c.minSlots.raise(key, slot)
+	c.envelopeCache.invalidate(key)
+}
+
+// getAccountInfo - Account read honouring the recorded slot for key: at confirmed commitment with
+// minContextSlot, retried with backoff while the node is behind
+func (c *USDCEnvelopeClient) getAccountInfo(ctx context.Context, key string, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	minSlot := c.minSlots.get(key)
+	if minSlot == 0 {
+		return c.rpcClient.GetAccountInfo(ctx, account)
+	}
+
+	opts := &rpc.GetAccountInfoOpts{
+		Encoding:       solana.EncodingBase64,
+		Commitment:     rpc.CommitmentConfirmed,
+		MinContextSlot: &minSlot,
+	}
+	for attempt := 1; ; attempt++ {
+		result, err := c.rpcClient.GetAccountInfoWithOpts(ctx, account, opts)
+		if err == nil || errors.Is(err, rpc.ErrNotFound) || !IsNodeBehind(err) {
+			return result, err
+		}
+		nodeBehindReads.Add(1)
+		if attempt >= nodeBehindRetries || !sleepCtx(ctx, backoff.Default.Delay(attempt)) {
+			return nil, fmt.Errorf("%w: slot %d for %s", ErrNodeBehind, minSlot, account)
+		}
+	}
+}
+
+// slotFloors - Minimum context slot per key (owner, or owner:envelopeID), forgotten after minContextSlotTTL
+type slotFloors struct {
+	mu     sync.Mutex
+	floors map[string]slotFloor
+}
+
+type slotFloor struct {
+	slot       uint64
+	recordedAt time.Time
+}
+
+func newSlotFloors() *slotFloors {
+	return &slotFloors{floors: make(map[string]slotFloor)}
+}
+
+func (s *slotFloors) raise(key string, slot uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, floor := range s.floors {
+		if now.Sub(floor.recordedAt) > minContextSlotTTL {
+			delete(s.floors, k)
+		}
+	}
+	if floor, ok := s.floors[key]; ok && floor.slot > slot {
+		slot = floor.slot
+	}
+	s.floors[key] = slotFloor{slot: slot, recordedAt: now}
+}
+
+func (s *slotFloors) get(key string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	floor, ok := s.floors[key]
+	if !ok || time.Since(floor.recordedAt) > minContextSlotTTL {
+		return 0
+	}
+	return floor.slot
+}
@@ -0,0 +1,307 @@
+package solprogram
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/alert"
+	"blockchain/circuit"
+)
+
+// Breaker names guarding envelope actions
+const (
+	BreakerCreate = "envelope_create"
+	BreakerClaim  = "envelope_claim"
+	BreakerRefund = "envelope_refund"
+	BreakerSend   = "envelope_send"
+)
+
+// AllBreakers - Every envelope action breaker
+var AllBreakers = []string{BreakerCreate, BreakerClaim, BreakerRefund, BreakerSend}
+
+// NewBreakerRegistry - Registry with all envelope action breakers (closed)
+func NewBreakerRegistry() *circuit.Registry {
+	return circuit.NewRegistry(AllBreakers...)
+}
+
+// Breaker trip causes owned by the monitor. Unavailable and pause trips are held: only the monitor
+// clears them, when the condition ends. Upgrade trips stay until an operator resets the breakers.
+const (
+	causeUnavailable = "program_unavailable"
+	causeUpgrade     = "program_upgrade"
+	causePause       = "pause:"
+)
+
+// Upgradeable loader state variants (bincode u32 tag)
+const (
+	loaderStateProgram     uint32 = 2
+	loaderStateProgramData uint32 = 3
+)
+
+// ProgramState - On-chain deployment state of the envelope program
+type ProgramState struct {
+	ProgramID          solana.PublicKey  `json:"program_id"`
+	Executable         bool              `json:"executable"`
+	Upgradeable        bool              `json:"upgradeable"`
+	ProgramDataAddress *solana.PublicKey `json:"program_data_address,omitempty"`
+	LastDeploySlot     uint64            `json:"last_deploy_slot"`
+	UpgradeAuthority   *solana.PublicKey `json:"upgrade_authority,omitempty"`
+	PausedAccounts     []string          `json:"paused_accounts,omitempty"`
+	CheckedAt          time.Time         `json:"checked_at"`
+}
+
+// PauseAccount - Config account holding a paused flag, and the breakers it controls
+type PauseAccount struct {
+	Address          solana.PublicKey
+	PausedFlagOffset int      // Byte offset of the bool flag (after the 8-byte discriminator)
+	Breakers         []string // Empty = all breakers
+}
+
+// ProgramMonitorConfig - Monitor settings
+type ProgramMonitorConfig struct {
+	ProgramID     solana.PublicKey
+	Interval      time.Duration // Default 30s
+	PauseAccounts []PauseAccount
+	// TripOnUpgrade - Open all breakers when a new deployment is detected, until an operator resets them
+	TripOnUpgrade bool
+}
+
+// ProgramMonitor - Watch program upgrades, authority changes and pause flags
+type ProgramMonitor struct {
+	rpcClient *rpc.Client
+	cfg       ProgramMonitorConfig
+	breakers  *circuit.Registry
+	alerter   alert.Alerter
+
+	mu     sync.Mutex
+	last   *ProgramState
+	paused map[solana.PublicKey]bool
+}
+
+// NewProgramMonitor - Create program monitor
+func NewProgramMonitor(rpcClient *rpc.Client, cfg ProgramMonitorConfig, breakers *circuit.Registry, alerter alert.Alerter) *ProgramMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if alerter == nil {
+		alerter = alert.LogAlerter{}
+	}
+	return &ProgramMonitor{
+		rpcClient: rpcClient,
+		cfg:       cfg,
+		breakers:  breakers,
+		alerter:   alerter,
+		paused:    make(map[solana.PublicKey]bool),
+	}
+}
+
+// Run - Poll until ctx is cancelled
+func (m *ProgramMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(ctx); err != nil {
+			log.Printf("⚠️  program monitor: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastState - Last observed program state (nil before first check)
+func (m *ProgramMonitor) LastState() *ProgramState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Check - Fetch program state once, fire alerts and flip breakers on changes
+func (m *ProgramMonitor) Check(ctx context.Context) (*ProgramState, error) {
+	state, err := m.fetchProgramState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	prev := m.last
+	m.last = state
+	m.mu.Unlock()
+
+	wasExecutable := prev == nil || prev.Executable
+	switch {
+	case !state.Executable && wasExecutable:
+		m.breakers.HoldAll(causeUnavailable, "program is not executable (closed or undeployed)")
+		m.notify(ctx, alert.SeverityCritical, "Envelope program unavailable",
+			"program account missing or not executable; all envelope actions disabled", state)
+	case state.Executable && !wasExecutable:
+		m.breakers.ClearAll(causeUnavailable)
+		m.notify(ctx, alert.SeverityInfo, "Envelope program available",
+			"program account is executable again", state)
+	}
+	if state.Executable && prev != nil {
+		if state.LastDeploySlot != prev.LastDeploySlot {
+			msg := fmt.Sprintf("program redeployed at slot %d (previous %d)", state.LastDeploySlot, prev.LastDeploySlot)
+			if m.cfg.TripOnUpgrade {
+				m.breakers.TripAllFor(causeUpgrade, msg)
+				msg += "; all envelope actions disabled until breakers are reset"
+			}
+			m.notify(ctx, alert.SeverityCritical, "Envelope program upgraded", msg, state)
+		}
+		if !samePubkey(state.UpgradeAuthority, prev.UpgradeAuthority) {
+			m.notify(ctx, alert.SeverityWarning, "Envelope program authority changed",
+				fmt.Sprintf("upgrade authority %s -> %s", pubkeyOrNone(prev.UpgradeAuthority), pubkeyOrNone(state.UpgradeAuthority)), state)
+		}
+	}
+
+	if err := m.checkPauseAccounts(ctx, state); err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+// fetchProgramState - Read program account and its programdata account
+func (m *ProgramMonitor) fetchProgramState(ctx context.Context) (*ProgramState, error) {
+	state := &ProgramState{ProgramID: m.cfg.ProgramID, CheckedAt: time.Now()}
+
+	programAcc, err := m.rpcClient.GetAccountInfo(ctx, m.cfg.ProgramID)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to get program account: %w", err)
+	}
+	if programAcc == nil || programAcc.Value == nil {
+		return state, nil
+	}
+	state.Executable = programAcc.Value.Executable
+	if !programAcc.Value.Owner.Equals(solana.BPFLoaderUpgradeableProgramID) {
+		return state, nil // Immutable loader, nothing else to watch
+	}
+	state.Upgradeable = true
+
+	data := programAcc.Value.Data.GetBinary()
+	if len(data) < 36 || binary.LittleEndian.Uint32(data[0:4]) != loaderStateProgram {
+		return nil, fmt.Errorf("unexpected upgradeable loader program state")
+	}
+	programData := solana.PublicKeyFromBytes(data[4:36])
+	state.ProgramDataAddress = &programData
+
+	dataAcc, err := m.rpcClient.GetAccountInfoWithOpts(ctx, programData, &rpc.GetAccountInfoOpts{
+		Encoding:  solana.EncodingBase64,
+		DataSlice: &rpc.DataSlice{Offset: uint64Ptr(0), Length: uint64Ptr(45)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get programdata account: %w", err)
+	}
+	if dataAcc == nil || dataAcc.Value == nil {
+		return nil, fmt.Errorf("programdata account not found")
+	}
+
+	// ProgramData { slot: u64, upgrade_authority_address: Option<Pubkey> }
+	header := dataAcc.Value.Data.GetBinary()
+	if len(header) < 13 || binary.LittleEndian.Uint32(header[0:4]) != loaderStateProgramData {
+		return nil, fmt.Errorf("unexpected upgradeable loader programdata state")
+	}
+	state.LastDeploySlot = binary.LittleEndian.Uint64(header[4:12])
+	if header[12] == 1 && len(header) >= 45 {
+		authority := solana.PublicKeyFromBytes(header[13:45])
+		state.UpgradeAuthority = &authority
+	}
+	return state, nil
+}
+
+// checkPauseAccounts - Trip/reset breakers following on-chain paused flags
+func (m *ProgramMonitor) checkPauseAccounts(ctx context.Context, state *ProgramState) error {
+	for _, acc := range m.cfg.PauseAccounts {
+		info, err := m.rpcClient.GetAccountInfo(ctx, acc.Address)
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return fmt.Errorf("failed to get pause account %s: %w", acc.Address, err)
+		}
+		paused := false
+		if info != nil && info.Value != nil {
+			data := info.Value.Data.GetBinary()
+			if acc.PausedFlagOffset >= len(data) {
+				return fmt.Errorf("pause account %s too short for offset %d", acc.Address, acc.PausedFlagOffset)
+			}
+			paused = data[acc.PausedFlagOffset] != 0
+		}
+
+		cause := causePause + acc.Address.String()
+		names := acc.Breakers
+		if len(names) == 0 {
+			names = AllBreakers
+		}
+
+		m.mu.Lock()
+		wasPaused := m.paused[acc.Address]
+		m.paused[acc.Address] = paused
+		m.mu.Unlock()
+
+		if paused {
+			state.PausedAccounts = append(state.PausedAccounts, acc.Address.String())
+		}
+		switch {
+		case paused && !wasPaused:
+			for _, name := range names {
+				m.breakers.Get(name).Hold(cause, "program paused by authority ("+acc.Address.String()+")")
+			}
+			m.notify(ctx, alert.SeverityCritical, "Envelope program paused",
+				fmt.Sprintf("pause flag set on %s; breakers opened: %v", acc.Address, names), state)
+		case !paused && wasPaused:
+			for _, name := range names {
+				m.breakers.Get(name).Clear(cause)
+			}
+			m.notify(ctx, alert.SeverityInfo, "Envelope program unpaused",
+				fmt.Sprintf("pause flag cleared on %s; pause released on breakers: %v", acc.Address, names), state)
+		}
+	}
+	return nil
+}
+
+func (m *ProgramMonitor) notify(ctx context.Context, severity alert.Severity, title, message string, state *ProgramState) {
+	err := m.alerter.Send(ctx, alert.Alert{
+		Severity: severity,
+		Source:   "solprogram.monitor",
+		Title:    title,
+		Message:  message,
+		Labels: map[string]string{
+			"program_id": state.ProgramID.String(),
+			"slot":       fmt.Sprintf("%d", state.LastDeploySlot),
+		},
+		Time: time.Now(),
+	})
+	if err != nil {
+		log.Printf("⚠️  program monitor: failed to send alert: %v", err)
+	}
+}
+
+func samePubkey(a, b *solana.PublicKey) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return bytes.Equal(a[:], b[:])
+}
+
+func pubkeyOrNone(p *solana.PublicKey) string {
+	if p == nil {
+		return "none (immutable)"
+	}
+	return p.String()
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
@@ -3,6 +3,7 @@ package solprogram
 import (
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
@@ -30,8 +31,85 @@ func parseUserStateData(data []byte) (*UserState, error) {
 	}, nil
 }
 
-// parseEnvelopeData - Parse envelope account data
-func parseEnvelopeData(data []byte) (*EnvelopeInfo, error) {
+// envelopeTailLen - total_amount, total_users, withdrawn_amount, claimed_count, expiry and is_cancelled
+const envelopeTailLen = 5*8 + 1
+
+// envelopeAccountFields - USDC program Envelope account fields (EnvelopeLayoutV2), in order (checked against the deployed IDL by USDCEnvelopeClient.ValidateIDL)
+var envelopeAccountFields = []IDLField{
+	{Name: "owner", Type: "pubkey"},
+	{Name: "envelope_id", Type: "u64"},
+	{Name: "envelope_type", Type: "EnvelopeType"},
+	{Name: "total_amount", Type: "u64"},
+	{Name: "total_users", Type: "u64"},
+	{Name: "withdrawn_amount", Type: "u64"},
+	{Name: "claimed_count", Type: "u64"},
+	{Name: "expiry", Type: "i64"},
+	{Name: "is_cancelled", Type: "bool"},
+	{Name: "start_time", Type: "i64"},
+}
+
+// EnvelopeLayout - Envelope account layout version of the deployed USDC program. Accounts carry no
+// version of their own, so the parser follows the program's: V2 reads start_time, V1 never does.
+type EnvelopeLayout int
+
+const (
+	EnvelopeLayoutV1 EnvelopeLayout = 1 // Before claim windows: ends at is_cancelled
+	EnvelopeLayoutV2 EnvelopeLayout = 2 // start_time after is_cancelled (default)
+)
+
+// ParseEnvelopeLayout - ENVELOPE_LAYOUT: v1 or v2 ("" = v2)
+func ParseEnvelopeLayout(s string) (EnvelopeLayout, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "v2":
+		return EnvelopeLayoutV2, nil
+	case "v1":
+		return EnvelopeLayoutV1, nil
+	default:
+		return 0, fmt.Errorf("unknown envelope layout %q (want v1 or v2)", s)
+	}
+}
+
+// EnvelopeLayoutFromIDL - Layout of the Envelope account the IDL declares: V2 when it has start_time
+// (V2 when the IDL doesn't declare the account)
+func EnvelopeLayoutFromIDL(idl *IDL) EnvelopeLayout {
+	def, ok := idl.Type("Envelope")
+	if !ok {
+		return EnvelopeLayoutV2
+	}
+	for _, field := range def.Fields {
+		if field.Name == "start_time" {
+			return EnvelopeLayoutV2
+		}
+	}
+	return EnvelopeLayoutV1
+}
+
+// fields - Envelope account fields parseEnvelopeData reads for the layout, in order
+func (l EnvelopeLayout) fields() []IDLField {
+	if l == EnvelopeLayoutV1 {
+		return envelopeAccountFields[:len(envelopeAccountFields)-1]
+	}
+	return envelopeAccountFields
+}
+
+// tailLen - Length of the fields after envelope_type
+func (l EnvelopeLayout) tailLen() int {
+	if l == EnvelopeLayoutV1 {
+		return envelopeTailLen
+	}
+	return envelopeTailLen + 8
+}
+
+// envelopeTypeVariants - EnvelopeType variants parseEnvelopeData reads, by discriminator
+var envelopeTypeVariants = []IDLVariant{
+	{Name: "direct_fixed", Fields: []IDLField{{Name: "allowed_address", Type: "pubkey"}}},
+	{Name: "group_fixed"},
+	{Name: "group_random"},
+	{Name: "custom_split", Fields: []IDLField{{Name: "recipients", Type: "vec<(pubkey, u64)>"}}},
+}
+
+// parseEnvelopeData - Parse envelope account data written with layout
+func parseEnvelopeData(data []byte, layout EnvelopeLayout) (*EnvelopeInfo, error) {
 	if len(data) < 120 { // Minimum size
 		return nil, fmt.Errorf("invalid envelope data length: %d", len(data))
 	}
@@ -53,6 +131,7 @@ func parseEnvelopeData(data []byte) (*EnvelopeInfo, error) {
 
 	var envelopeTypeName string
 	var allowedAddress *string
+	var recipients []SplitRecipient
 
 	switch envelopeTypeDiscriminator {
 	case 0: // DirectFixed
@@ -65,16 +144,35 @@ func parseEnvelopeData(data []byte) (*EnvelopeInfo, error) {
 		envelopeTypeName = "GroupFixed"
 	case 2: // GroupRandom
 		envelopeTypeName = "GroupRandom"
+	case 3: // CustomSplit: Vec<(Pubkey, u64)>, Borsh writes the next field right after it
+		envelopeTypeName = "CustomSplit"
+		if len(data) < offset+4 {
+			return nil, fmt.Errorf("invalid envelope data length: %d", len(data))
+		}
+		n := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if len(data) < offset+40*n+layout.tailLen() {
+			return nil, fmt.Errorf("invalid envelope data length: %d for %d recipients", len(data), n)
+		}
+		recipients = make([]SplitRecipient, n)
+		for i := range recipients {
+			recipients[i].Address = solana.PublicKeyFromBytes(data[offset : offset+32])
+			recipients[i].Amount = binary.LittleEndian.Uint64(data[offset+32 : offset+40])
+			offset += 40
+		}
 	default:
 		return nil, fmt.Errorf("unknown envelope type: %d", envelopeTypeDiscriminator)
 	}
 
 	// Align to 8-byte boundary if needed (Rust alignment)
 	// For non-DirectFixed, we may need to skip padding
-	if envelopeTypeDiscriminator != 0 {
+	if envelopeTypeDiscriminator == 1 || envelopeTypeDiscriminator == 2 {
 		// Skip padding to align (33 bytes needs 7 bytes padding to reach 40)
 		offset += 39 // Skip to reach consistent offset
 	}
+	if len(data) < offset+layout.tailLen() {
+		return nil, fmt.Errorf("invalid envelope data length: %d", len(data))
+	}
 
 	// Parse total_amount (8 bytes)
 	totalAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
@@ -98,28 +196,51 @@ func parseEnvelopeData(data []byte) (*EnvelopeInfo, error) {
 
 	// Parse is_cancelled (1 byte bool)
 	isCancelled := data[offset] != 0
+	offset += 1
 
-	// Calculate remaining amount
-	remainingAmount := totalAmount - withdrawnAmount
+	// Parse start_time (8 bytes, i64 timestamp, 0 = claimable on creation); V1 programs have no
+	// claim windows
+	var startTime *time.Time
+	if layout == EnvelopeLayoutV2 {
+		if startTimestamp := int64(binary.LittleEndian.Uint64(data[offset : offset+8])); startTimestamp > 0 {
+			t := time.Unix(startTimestamp, 0)
+			startTime = &t
+		}
+	}
 
-	// Convert expiry to time.Time
-	expiryTime := time.Unix(expiryTimestamp, 0)
-	isExpired := time.Now().Unix() >= expiryTimestamp
+	// Calculate remaining amount (withdrawn_amount includes claims and partial refunds)
+	var remainingAmount uint64
+	if withdrawnAmount < totalAmount {
+		remainingAmount = totalAmount - withdrawnAmount
+	}
 
-	return &EnvelopeInfo{
+	info := &EnvelopeInfo{
 		Owner:           owner,
 		EnvelopeID:      envelopeID,
 		EnvelopeType:    envelopeTypeName,
 		AllowedAddress:  allowedAddress,
+		Recipients:      recipients,
 		TotalAmount:     totalAmount,
 		TotalUsers:      totalUsers,
 		WithdrawnAmount: withdrawnAmount,
 		ClaimedCount:    claimedCount,
 		RemainingAmount: remainingAmount,
 		IsCancelled:     isCancelled,
-		ExpiryTime:      expiryTime,
-		IsExpired:       isExpired,
-	}, nil
+		ExpiryTime:      time.Unix(expiryTimestamp, 0),
+		StartTime:       startTime,
+	}
+	info.refreshDerived(time.Now())
+	return info, nil
+}
+
+// refreshDerived - Recompute the time-dependent fields (IsExpired, IsStarted, RefundableAmount) at now
+func (e *EnvelopeInfo) refreshDerived(now time.Time) {
+	e.IsExpired = now.Unix() >= e.ExpiryTime.Unix()
+	e.IsStarted = e.StartTime == nil || !now.Before(*e.StartTime)
+	e.RefundableAmount = 0
+	if e.IsExpired {
+		e.RefundableAmount = e.RemainingAmount
+	}
 }
 
 // parseClaimRecordData - Parse claim record account data
@@ -153,3 +274,67 @@ func parseClaimRecordData(data []byte) (*ClaimRecord, error) {
 		ClaimedAt:  claimedAt,
 	}, nil
 }
+
+// parseProgramConfigData - Parse program config account data
+func parseProgramConfigData(data []byte) (*ProgramConfig, error) {
+	if len(data) < programConfigLen {
+		return nil, fmt.Errorf("invalid config data length: %d", len(data))
+	}
+
+	// Skip 8-byte discriminator
+	offset := 8
+
+	admin := solana.PublicKeyFromBytes(data[offset : offset+32])
+	offset += 32
+
+	maxCreateAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
+	offset += 8
+
+	minAmountPerUser := binary.LittleEndian.Uint64(data[offset : offset+8])
+	offset += 8
+
+	return &ProgramConfig{
+		Admin:            admin,
+		MaxCreateAmount:  maxCreateAmount,
+		MinAmountPerUser: minAmountPerUser,
+		Paused:           data[offset] != 0,
+		OnChain:          true,
+	}, nil
+}
+
+// parseSOLEnvelopeData - Amounts and expiry of a SOL program EnvelopeAccount (see SPL.rs):
+// discriminator(8) + owner(32) + envelope_id(8) + envelope_type enum + amount + withdrawn_amount + total_claimed + expiry
+func parseSOLEnvelopeData(data []byte) (*EnvelopeInfo, error) {
+	offset := 8 + 32 + 8
+	if len(data) < offset+1 {
+		return nil, fmt.Errorf("invalid envelope data length: %d", len(data))
+	}
+	switch data[offset] {
+	case 0: // DirectFixed { allowed_address, amount }
+		offset += 1 + 32 + 8
+	case 1, 2: // GroupFixed / GroupRandom { total_users, amount }
+		offset += 1 + 8 + 8
+	default:
+		return nil, fmt.Errorf("unknown envelope type: %d", data[offset])
+	}
+	if len(data) < offset+32 {
+		return nil, fmt.Errorf("invalid envelope data length: %d", len(data))
+	}
+
+	amount := binary.LittleEndian.Uint64(data[offset:])
+	withdrawn := binary.LittleEndian.Uint64(data[offset+8:])
+	totalClaimed := binary.LittleEndian.Uint64(data[offset+16:])
+	expiry := int64(binary.LittleEndian.Uint64(data[offset+24:]))
+
+	info := &EnvelopeInfo{
+		TotalAmount:     amount,
+		WithdrawnAmount: withdrawn,
+		ExpiryTime:      time.Unix(expiry, 0),
+	}
+	// The program refunds amount - total_claimed
+	if totalClaimed < amount {
+		info.RemainingAmount = amount - totalClaimed
+	}
+	info.refreshDerived(time.Now())
+	return info, nil
+}
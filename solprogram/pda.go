@@ -0,0 +1,263 @@
+package solprogram
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+//go:generate go run ../cmd/ops pda-vectors -out pda_vectors.json
+
+// PDAKind - Program-derived account type
+type PDAKind string
+
+const (
+	PDAUserState     PDAKind = "user_state"     // [b"user_state", user]
+	PDAEnvelope      PDAKind = "envelope"       // [b"envelope", owner, envelope_id u64 LE]
+	PDAEnvelopeVault PDAKind = "envelope_vault" // [b"envelope_vault", owner, envelope_id u64 LE] (USDC)
+	PDAClaimRecord   PDAKind = "claim"          // [b"claim", envelope PDA, claimer] (USDC)
+	PDAConfig        PDAKind = "config"         // [b"config"]
+)
+
+// PDAInputs - Derivation inputs (unused fields ignored per kind)
+type PDAInputs struct {
+	Kind       PDAKind `json:"kind"`
+	ProgramID  string  `json:"program_id"`
+	Owner      string  `json:"owner,omitempty"` // Envelope owner, or the user for user_state
+	EnvelopeID uint64  `json:"envelope_id,omitempty"`
+	Claimer    string  `json:"claimer,omitempty"`
+}
+
+// PDASeed - One seed, in the order passed to find_program_address
+type PDASeed struct {
+	Name string `json:"name"`
+	Hex  string `json:"hex"`
+	Text string `json:"text,omitempty"` // Literal seeds only
+}
+
+// PDADerivation - Canonical derivation
+type PDADerivation struct {
+	Kind      PDAKind   `json:"kind"`
+	ProgramID string    `json:"program_id"`
+	Address   string    `json:"address"`
+	Bump      uint8     `json:"bump"`
+	Seeds     []PDASeed `json:"seeds"`
+}
+
+// PDAVerification - Parity check of a client-derived address
+type PDAVerification struct {
+	Match         bool           `json:"match"`
+	ClientAddress string         `json:"client_address"`
+	Canonical     *PDADerivation `json:"canonical"`
+	Hint          string         `json:"hint,omitempty"` // Likely cause of a mismatch, when recognised
+}
+
+// DerivePDA - Canonical address, bump and seeds for the inputs
+func DerivePDA(in PDAInputs) (*PDADerivation, error) {
+	programID, err := solana.PublicKeyFromBase58(in.ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid program_id: %w", err)
+	}
+	seeds, err := pdaSeeds(in, programID, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+
+	address, bump, err := findPDA(seeds, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive %s PDA: %w", in.Kind, err)
+	}
+	return &PDADerivation{
+		Kind:      in.Kind,
+		ProgramID: programID.String(),
+		Address:   address.String(),
+		Bump:      bump,
+		Seeds:     seeds,
+	}, nil
+}
+
+// VerifyPDA - Compare a client-derived address against the canonical derivation
+func VerifyPDA(in PDAInputs, clientAddress string) (*PDAVerification, error) {
+	client, err := solana.PublicKeyFromBase58(clientAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client_address: %w", err)
+	}
+	canonical, err := DerivePDA(in)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PDAVerification{
+		Match:         canonical.Address == client.String(),
+		ClientAddress: client.String(),
+		Canonical:     canonical,
+	}
+	if !result.Match {
+		result.Hint = mismatchHint(in, client)
+	}
+	return result, nil
+}
+
+// pdaSeeds - Seeds for a kind, with the envelope ID in the given byte order
+func pdaSeeds(in PDAInputs, programID solana.PublicKey, order binary.ByteOrder) ([]PDASeed, error) {
+	literal := func(s []byte) PDASeed {
+		return PDASeed{Name: "literal", Hex: hex.EncodeToString(s), Text: string(s)}
+	}
+	key := func(name, value string) (PDASeed, error) {
+		pk, err := solana.PublicKeyFromBase58(value)
+		if err != nil {
+			return PDASeed{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return PDASeed{Name: name, Hex: hex.EncodeToString(pk.Bytes())}, nil
+	}
+	envelopeID := func() PDASeed {
+		b := make([]byte, 8)
+		order.PutUint64(b, in.EnvelopeID)
+		return PDASeed{Name: "envelope_id", Hex: hex.EncodeToString(b)}
+	}
+
+	switch in.Kind {
+	case PDAConfig:
+		return []PDASeed{literal(SeedConfig)}, nil
+
+	case PDAUserState:
+		owner, err := key("owner", in.Owner)
+		if err != nil {
+			return nil, err
+		}
+		return []PDASeed{literal(SeedUserState), owner}, nil
+
+	case PDAEnvelope, PDAEnvelopeVault:
+		owner, err := key("owner", in.Owner)
+		if err != nil {
+			return nil, err
+		}
+		prefix := SeedEnvelope
+		if in.Kind == PDAEnvelopeVault {
+			prefix = SeedEnvelopeVault
+		}
+		return []PDASeed{literal(prefix), owner, envelopeID()}, nil
+
+	case PDAClaimRecord:
+		envelope, err := DerivePDA(PDAInputs{Kind: PDAEnvelope, ProgramID: programID.String(), Owner: in.Owner, EnvelopeID: in.EnvelopeID})
+		if err != nil {
+			return nil, err
+		}
+		claimer, err := key("claimer", in.Claimer)
+		if err != nil {
+			return nil, err
+		}
+		envelopeSeed, _ := key("envelope", envelope.Address)
+		return []PDASeed{literal(SeedClaim), envelopeSeed, claimer}, nil
+	}
+	return nil, fmt.Errorf("unknown PDA kind: %q", in.Kind)
+}
+
+// findPDA - find_program_address over hex seeds
+func findPDA(seeds []PDASeed, programID solana.PublicKey) (solana.PublicKey, uint8, error) {
+	raw := make([][]byte, len(seeds))
+	for i, seed := range seeds {
+		raw[i], _ = hex.DecodeString(seed.Hex)
+	}
+	return solana.FindProgramAddress(raw, programID)
+}
+
+// mismatchHint - Re-derive with the usual client mistakes and name the one that matches
+func mismatchHint(in PDAInputs, client solana.PublicKey) string {
+	matches := func(alt PDAInputs, order binary.ByteOrder) bool {
+		programID, err := solana.PublicKeyFromBase58(alt.ProgramID)
+		if err != nil {
+			return false
+		}
+		seeds, err := pdaSeeds(alt, programID, order)
+		if err != nil {
+			return false
+		}
+		address, _, err := findPDA(seeds, programID)
+		return err == nil && address.Equals(client)
+	}
+
+	if in.Kind != PDAConfig && in.Kind != PDAUserState && matches(in, binary.BigEndian) {
+		return "envelope_id was encoded big-endian; the program uses u64 little-endian (to_le_bytes)"
+	}
+	for _, other := range []string{SOLProgramID, USDCProgramID} {
+		alt := in
+		alt.ProgramID = other
+		if other != in.ProgramID && matches(alt, binary.LittleEndian) {
+			return "derived with program " + other + " instead of " + in.ProgramID
+		}
+	}
+	switch in.Kind {
+	case PDAClaimRecord:
+		owner, errOwner := solana.PublicKeyFromBase58(in.Owner)
+		claimer, errClaimer := solana.PublicKeyFromBase58(in.Claimer)
+		programID, errProgram := solana.PublicKeyFromBase58(in.ProgramID)
+		if errOwner == nil && errClaimer == nil && errProgram == nil {
+			address, _, err := solana.FindProgramAddress([][]byte{SeedClaim, owner.Bytes(), claimer.Bytes()}, programID)
+			if err == nil && address.Equals(client) {
+				return "claim record was derived from the owner wallet; the seed is the envelope PDA"
+			}
+		}
+	case PDAEnvelopeVault:
+		alt := in
+		alt.Kind = PDAEnvelope
+		if matches(alt, binary.LittleEndian) {
+			return "client address is the envelope PDA; the vault uses the \"envelope_vault\" seed"
+		}
+	}
+	return ""
+}
+
+// PDATestVector - Published derivation for client SDK parity tests
+type PDATestVector struct {
+	Name     string        `json:"name"`
+	Inputs   PDAInputs     `json:"inputs"`
+	Expected PDADerivation `json:"expected"`
+}
+
+// Fixed test vector wallets (not funded, no known private keys needed)
+const (
+	testVectorOwner   = "wFuFPgHsLt9t5HALqFQqbdM9WvyQstdKN8NQXB3GWeD"
+	testVectorClaimer = "3YkzQC2PwFGvJr2GS7FDBopvG5tda4eXdq5pmwEbWeyd"
+)
+
+// PDATestVectors - Canonical derivations covering every kind on both programs, including
+// envelope IDs whose byte order matters (0, 1, 256, 2^32, max u64)
+func PDATestVectors() ([]PDATestVector, error) {
+	var inputs []PDAInputs
+	for _, programID := range []string{SOLProgramID, USDCProgramID} {
+		inputs = append(inputs,
+			PDAInputs{Kind: PDAConfig, ProgramID: programID},
+			PDAInputs{Kind: PDAUserState, ProgramID: programID, Owner: testVectorOwner},
+		)
+		for _, id := range []uint64{0, 1, 256, 1 << 32, ^uint64(0)} {
+			inputs = append(inputs, PDAInputs{Kind: PDAEnvelope, ProgramID: programID, Owner: testVectorOwner, EnvelopeID: id})
+		}
+	}
+	for _, id := range []uint64{1, 256} {
+		inputs = append(inputs,
+			PDAInputs{Kind: PDAEnvelopeVault, ProgramID: USDCProgramID, Owner: testVectorOwner, EnvelopeID: id},
+			PDAInputs{Kind: PDAClaimRecord, ProgramID: USDCProgramID, Owner: testVectorOwner, EnvelopeID: id, Claimer: testVectorClaimer},
+		)
+	}
+
+	vectors := make([]PDATestVector, 0, len(inputs))
+	for _, in := range inputs {
+		derivation, err := DerivePDA(in)
+		if err != nil {
+			return nil, err
+		}
+		program := "sol"
+		if in.ProgramID == USDCProgramID {
+			program = "usdc"
+		}
+		name := fmt.Sprintf("%s/%s", program, in.Kind)
+		if in.Kind != PDAConfig && in.Kind != PDAUserState {
+			name = fmt.Sprintf("%s/%d", name, in.EnvelopeID)
+		}
+		vectors = append(vectors, PDATestVector{Name: name, Inputs: in, Expected: *derivation})
+	}
+	return vectors, nil
+}
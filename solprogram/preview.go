@@ -0,0 +1,85 @@
+package solprogram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/preflight"
+	"blockchain/txencoding"
+)
+
+// SimulatePreviewRequest - Unsigned transaction to preview (POST /api/simulate-preview)
+type SimulatePreviewRequest struct {
+	UnsignedTx string `json:"unsigned_tx"`
+	Encoding   string `json:"encoding,omitempty"` // unsigned_tx: base64 | base58 | hex (detected when omitted)
+}
+
+// SimulatePreviewResponse - Balance preview of the unsigned transaction
+type SimulatePreviewResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message,omitempty"`
+	Preview *preflight.Preview `json:"preview,omitempty"`
+}
+
+// decodeUnsignedTx - Unsigned transaction in any supported encoding ("" = detected)
+func decodeUnsignedTx(unsignedTx, encoding string) (*solana.Transaction, error) {
+	e, err := txencoding.Parse(encoding, "")
+	if err != nil {
+		return nil, err
+	}
+	txBytes, _, err := txencoding.DecodeAuto(unsignedTx, e)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := solana.TransactionFromBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// SimulatePreview - Pre/post SOL and token balances of an unsigned transaction, for wallets to show
+// before asking for a signature
+func (c *Client) SimulatePreview(ctx context.Context, unsignedTx, encoding string) (*preflight.Preview, error) {
+	tx, err := decodeUnsignedTx(unsignedTx, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return preflight.SimulatePreview(ctx, c.RPC, c.Prices, tx)
+}
+
+// SimulatePreview - Pre/post SOL and USDC balances of an unsigned transaction from GenerateUnsigned*,
+// for wallets to show before asking for a signature
+func (c *USDCEnvelopeClient) SimulatePreview(ctx context.Context, unsignedTx string) (*preflight.Preview, error) {
+	tx, err := decodeUnsignedTx(unsignedTx, "")
+	if err != nil {
+		return nil, err
+	}
+	return preflight.SimulatePreview(ctx, c.rpcClient, c.prices, tx)
+}
+
+// HandleSimulatePreview handles balance previews of unsigned transactions (POST /api/simulate-preview)
+func (c *Client) HandleSimulatePreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SimulatePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(SimulatePreviewResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if req.UnsignedTx == "" {
+		json.NewEncoder(w).Encode(SimulatePreviewResponse{Success: false, Message: "unsigned_tx is required"})
+		return
+	}
+
+	preview, err := c.SimulatePreview(r.Context(), req.UnsignedTx, req.Encoding)
+	if err != nil {
+		json.NewEncoder(w).Encode(SimulatePreviewResponse{Success: false, Message: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(SimulatePreviewResponse{Success: true, Preview: preview})
+}
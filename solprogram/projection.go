@@ -0,0 +1,112 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/projection"
+)
+
+// EnvelopeEvents - projection.Source re-fetched from chain: a create event per envelope account,
+// a claim event per claim record and a refund event for withdrawals claims don't account for.
+// Envelopes whose account was closed are gone from chain; replay the persisted history for those.
+func (c *USDCEnvelopeClient) EnvelopeEvents(ctx context.Context, emit func(projection.Event) error) error {
+	accounts, err := c.rpcClient.GetProgramAccountsWithOpts(ctx, c.programID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: envelopeAccountDiscriminator}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get envelope accounts: %w", err)
+	}
+	claimAccounts, err := c.rpcClient.GetProgramAccountsWithOpts(ctx, c.programID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: claimRecordDiscriminator}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get claim records: %w", err)
+	}
+	// ClaimRecord stores only envelope_id; the owner is matched by re-deriving the PDA below
+	claimsByID := make(map[uint64][]*rpc.KeyedAccount)
+	for _, acc := range claimAccounts {
+		record, err := parseClaimRecordData(acc.Account.Data.GetBinary())
+		if err != nil {
+			return fmt.Errorf("failed to parse claim record %s: %w", acc.Pubkey, err)
+		}
+		claimsByID[record.EnvelopeID] = append(claimsByID[record.EnvelopeID], acc)
+	}
+
+	for _, acc := range accounts {
+		envelope, err := parseEnvelopeData(acc.Account.Data.GetBinary(), c.layout)
+		if err != nil {
+			return fmt.Errorf("failed to parse envelope account %s: %w", acc.Pubkey, err)
+		}
+		owner := envelope.Owner.String()
+		err = emit(projection.Event{
+			Chain:        chain.Solana,
+			Action:       chain.EnvelopeActionCreate,
+			Owner:        owner,
+			EnvelopeID:   envelope.EnvelopeID,
+			Amount:       envelope.TotalAmount,
+			EnvelopeType: envelope.EnvelopeType,
+			TotalUsers:   envelope.TotalUsers,
+			ExpiryTime:   envelope.ExpiryTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		envelopePDA, _, err := c.DeriveEnvelopePDA(envelope.Owner, envelope.EnvelopeID)
+		if err != nil {
+			return err
+		}
+		var claimed uint64
+		for _, claimAcc := range claimsByID[envelope.EnvelopeID] {
+			claim, err := parseClaimRecordData(claimAcc.Account.Data.GetBinary())
+			if err != nil {
+				return fmt.Errorf("failed to parse claim record %s: %w", claimAcc.Pubkey, err)
+			}
+			pda, _, err := c.DeriveClaimRecordPDA(envelopePDA, claim.Claimer)
+			if err != nil || !pda.Equals(claimAcc.Pubkey) {
+				continue
+			}
+			claimed += claim.Amount
+			err = emit(projection.Event{
+				Chain:      chain.Solana,
+				Action:     chain.EnvelopeActionClaim,
+				Owner:      owner,
+				EnvelopeID: envelope.EnvelopeID,
+				Actor:      claim.Claimer.String(),
+				Time:       time.Unix(claim.ClaimedAt, 0),
+				Amount:     claim.Amount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// WithdrawnAmount covers claims and refunds alike
+		if envelope.WithdrawnAmount > claimed || envelope.IsCancelled {
+			var refunded uint64
+			if envelope.WithdrawnAmount > claimed {
+				refunded = envelope.WithdrawnAmount - claimed
+			}
+			err := emit(projection.Event{
+				Chain:      chain.Solana,
+				Action:     chain.EnvelopeActionRefund,
+				Owner:      owner,
+				EnvelopeID: envelope.EnvelopeID,
+				Amount:     refunded,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
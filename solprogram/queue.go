@@ -0,0 +1,300 @@
+package solprogram
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/alert"
+	"blockchain/backpressure"
+	"blockchain/preflight"
+)
+
+// Priority - Submission priority, higher is sent first
+type Priority int
+
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+	PriorityUrgent Priority = 20 // at-risk refunds
+)
+
+// Submission kinds
+const (
+	SubmissionCreate = "create"
+	SubmissionClaim  = "claim"
+	SubmissionRefund = "refund"
+	SubmissionSend   = "send"
+)
+
+// Submission - Signed transaction waiting to be sent
+type Submission struct {
+	ID         string           `json:"id"` // Transaction ID of the unsigned transaction it was signed from
+	Kind       string           `json:"kind"`
+	SignedTx   string           `json:"-"` // base64
+	Priority   Priority         `json:"priority"`
+	Owner      solana.PublicKey `json:"owner"`
+	EnvelopeID uint64           `json:"envelope_id"`
+	Attempts   int              `json:"attempts"`
+	LastError  string           `json:"last_error,omitempty"`
+	EnqueuedAt time.Time        `json:"enqueued_at"`
+	Preflight  preflight.Mode   `json:"-"` // Mode the submitter sends with ("" = its default)
+	// Resign - Fresh signed transaction after the blockhash expired (optional: without it an expired
+	// submission fails with ErrSubmissionExpired, the signer has to rebuild it)
+	Resign func(ctx context.Context) (string, error) `json:"-"`
+
+	seq  uint64
+	done chan SubmissionResult // Final result, see Wait
+}
+
+// ErrSubmissionExpired - The signed transaction's blockhash expired and the submission has no Resign
+var ErrSubmissionExpired = errors.New("transaction expired; request a new unsigned transaction and sign it again")
+
+// SubmissionResult - Outcome of a submission (after retries)
+type SubmissionResult struct {
+	Submission *Submission
+	Signature  string
+	Err        error
+}
+
+// SubmitFunc - Send a submission's signed transaction, returns signature
+type SubmitFunc func(ctx context.Context, s *Submission) (string, error)
+
+// SubmissionQueueConfig - Queue settings
+type SubmissionQueueConfig struct {
+	MaxAttempts      int           // Default 5
+	RetryDelay       time.Duration // Default 2s, doubled per attempt
+	RefundAlertAfter int           // Alert after this many failed refund attempts (default 3)
+	MaxDepth         int           // Pressure reports saturation at this many waiting submissions (default 100)
+	OnResult         func(SubmissionResult)
+}
+
+// SubmissionQueue - Priority queue in front of transaction submission
+type SubmissionQueue struct {
+	submit  SubmitFunc
+	alerter alert.Alerter
+	cfg     SubmissionQueueConfig
+	avgSend atomic.Int64 // EWMA of submit durations, nanoseconds
+
+	mu     sync.Mutex
+	items  submissionHeap
+	seq    uint64
+	notify chan struct{}
+}
+
+// NewSubmissionQueue - Create queue; submit is usually USDCEnvelopeClient.SubmitQueued
+func NewSubmissionQueue(submit SubmitFunc, alerter alert.Alerter, cfg SubmissionQueueConfig) *SubmissionQueue {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 2 * time.Second
+	}
+	if cfg.RefundAlertAfter <= 0 {
+		cfg.RefundAlertAfter = 3
+	}
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 100
+	}
+	if alerter == nil {
+		alerter = alert.LogAlerter{}
+	}
+	return &SubmissionQueue{
+		submit:  submit,
+		alerter: alerter,
+		cfg:     cfg,
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// Enqueue - Add submission; higher priority first, FIFO within a priority
+func (q *SubmissionQueue) Enqueue(s *Submission) {
+	q.mu.Lock()
+	if s.EnqueuedAt.IsZero() {
+		s.EnqueuedAt = time.Now()
+	}
+	q.seq++
+	s.seq = q.seq
+	if s.done == nil {
+		s.done = make(chan SubmissionResult, 1)
+	}
+	heap.Push(&q.items, s)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Len - Submissions waiting
+func (q *SubmissionQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// Pending - Snapshot of waiting submissions in send order
+func (q *SubmissionQueue) Pending() []Submission {
+	q.mu.Lock()
+	cp := make(submissionHeap, len(q.items))
+	copy(cp, q.items)
+	q.mu.Unlock()
+
+	out := make([]Submission, 0, len(cp))
+	for cp.Len() > 0 {
+		out = append(out, *heap.Pop(&cp).(*Submission))
+	}
+	return out
+}
+
+// Run - Send submissions one at a time until ctx is cancelled
+func (q *SubmissionQueue) Run(ctx context.Context) {
+	for {
+		s := q.pop()
+		if s == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+		q.process(ctx, s)
+	}
+}
+
+func (q *SubmissionQueue) pop() *Submission {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.items.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&q.items).(*Submission)
+}
+
+func (q *SubmissionQueue) process(ctx context.Context, s *Submission) {
+	s.Attempts++
+	start := time.Now()
+	sig, err := q.submit(ctx, s)
+	q.observe(time.Since(start))
+	if err == nil {
+		q.finish(SubmissionResult{Submission: s, Signature: sig})
+		return
+	}
+	s.LastError = err.Error()
+
+	if s.Kind == SubmissionRefund && s.Attempts >= q.cfg.RefundAlertAfter {
+		severity := alert.SeverityWarning
+		if s.Attempts >= q.cfg.MaxAttempts {
+			severity = alert.SeverityCritical
+		}
+		q.sendAlert(ctx, severity, s)
+	}
+
+	if s.Attempts >= q.cfg.MaxAttempts {
+		q.finish(SubmissionResult{Submission: s, Err: fmt.Errorf("giving up after %d attempts: %w", s.Attempts, err)})
+		return
+	}
+
+	// Resending can't succeed once the blockhash is gone
+	if ClassifyFailure(err) == FailureExpired {
+		if s.Resign == nil {
+			q.finish(SubmissionResult{Submission: s, Err: fmt.Errorf("%w: %v", ErrSubmissionExpired, err)})
+			return
+		}
+		signed, resignErr := s.Resign(ctx)
+		if resignErr != nil {
+			q.finish(SubmissionResult{Submission: s, Err: fmt.Errorf("failed to re-sign expired transaction: %w", resignErr)})
+			return
+		}
+		s.SignedTx = signed
+	}
+
+	delay := q.cfg.RetryDelay << (s.Attempts - 1)
+	time.AfterFunc(delay, func() { q.Enqueue(s) })
+}
+
+// observe - Record the duration of one submit (EWMA, alpha 0.2)
+func (q *SubmissionQueue) observe(d time.Duration) {
+	old := q.avgSend.Load()
+	if old > 0 {
+		d = time.Duration(old) + (d-time.Duration(old))/5
+	}
+	q.avgSend.Store(int64(d))
+}
+
+// Pressure - Queue depth against MaxDepth; submissions are sent one at a time, so the backlog above
+// MaxDepth drains at one average submit each
+func (q *SubmissionQueue) Pressure() backpressure.Pressure {
+	depth := q.Len()
+	p := backpressure.Pressure{Source: "submission_queue", Depth: depth, Capacity: q.cfg.MaxDepth, Saturated: depth >= q.cfg.MaxDepth}
+	if p.Saturated {
+		p.RetryAfter = (time.Duration(depth-q.cfg.MaxDepth+1) * time.Duration(q.avgSend.Load())).Seconds()
+	}
+	return p
+}
+
+func (q *SubmissionQueue) finish(result SubmissionResult) {
+	result.Submission.done <- result
+	if q.cfg.OnResult != nil {
+		q.cfg.OnResult(result)
+	}
+}
+
+// Wait - Final result of an enqueued submission (after its retries)
+func (q *SubmissionQueue) Wait(ctx context.Context, s *Submission) (SubmissionResult, error) {
+	select {
+	case <-ctx.Done():
+		return SubmissionResult{Submission: s}, ctx.Err()
+	case result := <-s.done:
+		return result, nil
+	}
+}
+
+func (q *SubmissionQueue) sendAlert(ctx context.Context, severity alert.Severity, s *Submission) {
+	err := q.alerter.Send(ctx, alert.Alert{
+		Severity: severity,
+		Source:   "solprogram.queue",
+		Title:    "Envelope refund failing",
+		Message:  fmt.Sprintf("refund of envelope #%d failed %d/%d times: %s", s.EnvelopeID, s.Attempts, q.cfg.MaxAttempts, s.LastError),
+		Labels: map[string]string{
+			"owner":         s.Owner.String(),
+			"envelope_id":   fmt.Sprintf("%d", s.EnvelopeID),
+			"submission_id": s.ID,
+			"priority":      fmt.Sprintf("%d", s.Priority),
+		},
+		Time: time.Now(),
+	})
+	if err != nil {
+		log.Printf("⚠️  submission queue: failed to send alert: %v", err)
+	}
+}
+
+// submissionHeap - container/heap ordering by priority desc, then enqueue order
+type submissionHeap []*Submission
+
+func (h submissionHeap) Len() int { return len(h) }
+func (h submissionHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h submissionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *submissionHeap) Push(x any)   { *h = append(*h, x.(*Submission)) }
+func (h *submissionHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,47 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmissionQueueExpiredBlockhash(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sent []string
+	queue := NewSubmissionQueue(func(_ context.Context, s *Submission) (string, error) {
+		sent = append(sent, s.SignedTx)
+		if s.SignedTx == "stale" {
+			return "", errors.New("send failed: Blockhash not found")
+		}
+		return "sig-" + s.SignedTx, nil
+	}, nil, SubmissionQueueConfig{RetryDelay: time.Millisecond})
+	go queue.Run(ctx)
+
+	// Without Resign the same signed tx is never sent again
+	stale := &Submission{ID: "a", Kind: SubmissionRefund, SignedTx: "stale"}
+	queue.Enqueue(stale)
+	result, err := queue.Wait(ctx, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(result.Err, ErrSubmissionExpired) || len(sent) != 1 {
+		t.Fatalf("result %v after %d sends, want ErrSubmissionExpired after 1", result.Err, len(sent))
+	}
+
+	// With Resign the retry sends the fresh transaction
+	sent = nil
+	resigned := &Submission{ID: "b", Kind: SubmissionRefund, SignedTx: "stale", Resign: func(context.Context) (string, error) {
+		return "fresh", nil
+	}}
+	queue.Enqueue(resigned)
+	if result, err = queue.Wait(ctx, resigned); err != nil {
+		t.Fatal(err)
+	}
+	if result.Err != nil || result.Signature != "sig-fresh" || len(sent) != 2 {
+		t.Fatalf("result %+v after sends %v, want sig-fresh after stale, fresh", result, sent)
+	}
+}
@@ -0,0 +1,33 @@
+package solprogram
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Refund validation errors, checked before building a transaction the program would reject
+var (
+	// ErrRefundNotExpired - Refunds (full or partial) only after expiry
+	ErrRefundNotExpired = errors.New("envelope not expired yet (cannot refund)")
+	// ErrNothingToRefund - Nothing left in the envelope
+	ErrNothingToRefund = errors.New("nothing to refund")
+	// ErrRefundExceedsRemaining - Partial refund larger than the remaining balance
+	ErrRefundExceedsRemaining = errors.New("refund amount exceeds remaining balance")
+)
+
+// ValidateRefund - Amount a refund of amount (0 = everything remaining) returns to the owner
+func (e *EnvelopeInfo) ValidateRefund(amount uint64) (uint64, error) {
+	if !e.IsExpired {
+		return 0, ErrRefundNotExpired
+	}
+	if e.RemainingAmount == 0 {
+		return 0, ErrNothingToRefund
+	}
+	if amount == 0 {
+		return e.RemainingAmount, nil
+	}
+	if amount > e.RemainingAmount {
+		return 0, fmt.Errorf("%w: %d > %d", ErrRefundExceedsRemaining, amount, e.RemainingAmount)
+	}
+	return amount, nil
+}
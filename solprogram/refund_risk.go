@@ -0,0 +1,325 @@
+package solprogram
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/preflight"
+)
+
+// Refund risk reasons
+const (
+	RiskVaultMissing      = "vault_missing"
+	RiskVaultShortfall    = "vault_shortfall"
+	RiskRefundOverdue     = "refund_overdue"
+	RiskOwnerWalletClosed = "owner_wallet_closed"
+	RiskLowRentBuffer     = "low_rent_buffer"             // Owner wallet can barely stay rent-exempt and pay the refund fee
+	RiskOwnerTokenAccount = "owner_token_account_missing" // USDC: the refund has nowhere to go
+)
+
+const (
+	// RefundOverdueAfter - How long after expiry an unrefunded envelope counts as at risk
+	RefundOverdueAfter = 24 * time.Hour
+	// RentBufferLamports - Lamports above its rent-exempt minimum an owner wallet needs to pay for the refund
+	RentBufferLamports uint64 = 100_000
+)
+
+// RefundRisk - Why an envelope's refund should jump the queue
+type RefundRisk struct {
+	Owner             solana.PublicKey `json:"owner"`
+	EnvelopeID        uint64           `json:"envelope_id"`
+	Vault             solana.PublicKey `json:"vault"`
+	VaultBalance      uint64           `json:"vault_balance"`
+	RemainingAmount   uint64           `json:"remaining_amount"`
+	OwnerLamports     uint64           `json:"owner_lamports"`
+	ExpiredForSeconds int64            `json:"expired_for_seconds"`
+	Reasons           []string         `json:"reasons,omitempty"`
+}
+
+// AtRisk - Any risk reason found
+func (r *RefundRisk) AtRisk() bool {
+	return len(r.Reasons) > 0
+}
+
+// Priority - Queue priority for this refund
+func (r *RefundRisk) Priority() Priority {
+	switch {
+	case len(r.Reasons) >= 2:
+		return PriorityUrgent
+	case len(r.Reasons) == 1:
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+// AssessRefundRisk - Check the vault balance, how long ago one envelope expired and the owner's accounts
+func (c *USDCEnvelopeClient) AssessRefundRisk(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*RefundRisk, error) {
+	envelope, err := c.GetEnvelopeInfo(ctx, owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	return c.assessRefundRisk(ctx, envelope)
+}
+
+func (c *USDCEnvelopeClient) assessRefundRisk(ctx context.Context, envelope *EnvelopeInfo) (*RefundRisk, error) {
+	vault, err := c.CheckVaultConsistency(ctx, envelope.Owner, envelope.EnvelopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	risk := &RefundRisk{
+		Owner:           envelope.Owner,
+		EnvelopeID:      envelope.EnvelopeID,
+		Vault:           vault.Vault,
+		VaultBalance:    vault.VaultBalance,
+		RemainingAmount: vault.RemainingAmount,
+	}
+	switch {
+	case !vault.VaultExists:
+		risk.Reasons = append(risk.Reasons, RiskVaultMissing)
+	case vault.VaultBalance < vault.RemainingAmount:
+		risk.Reasons = append(risk.Reasons, RiskVaultShortfall)
+	}
+	risk.checkOverdue(envelope.ExpiryTime)
+
+	ownerATA, err := c.GetUSDCTokenAddress(envelope.Owner)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := c.rpcClient.GetMultipleAccounts(ctx, envelope.Owner, ownerATA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owner accounts: %w", err)
+	}
+	if err := risk.checkOwner(ctx, c.rpcClient, accounts.Value[0]); err != nil {
+		return nil, err
+	}
+	if accounts.Value[1] == nil {
+		risk.Reasons = append(risk.Reasons, RiskOwnerTokenAccount)
+	}
+	return risk, nil
+}
+
+// checkOverdue - Record how long ago the envelope expired, overdue after RefundOverdueAfter
+func (r *RefundRisk) checkOverdue(expiry time.Time) {
+	if expiredFor := time.Since(expiry); expiredFor > 0 {
+		r.ExpiredForSeconds = int64(expiredFor.Seconds())
+		if expiredFor >= RefundOverdueAfter {
+			r.Reasons = append(r.Reasons, RiskRefundOverdue)
+		}
+	}
+}
+
+// checkOwner - Owner wallet closed, or too close to its rent-exempt minimum to pay for the refund
+func (r *RefundRisk) checkOwner(ctx context.Context, client *rpc.Client, owner *rpc.Account) error {
+	if owner == nil || owner.Lamports == 0 {
+		r.Reasons = append(r.Reasons, RiskOwnerWalletClosed)
+		return nil
+	}
+	r.OwnerLamports = owner.Lamports
+	rentMin, err := client.GetMinimumBalanceForRentExemption(ctx, uint64(len(owner.Data.GetBinary())), rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get rent exemption: %w", err)
+	}
+	if owner.Lamports < rentMin+RentBufferLamports {
+		r.Reasons = append(r.Reasons, RiskLowRentBuffer)
+	}
+	return nil
+}
+
+// FindAtRiskRefunds - Refundable envelopes of owner with at least one risk, most reasons first
+func (c *USDCEnvelopeClient) FindAtRiskRefunds(ctx context.Context, owner solana.PublicKey) ([]*RefundRisk, error) {
+	var risks []*RefundRisk
+	it := c.IterEnvelopesByOwner(ctx, owner)
+	for it.Next() {
+		envelope := it.Value()
+		if !envelope.IsExpired || envelope.IsCancelled || envelope.RemainingAmount == 0 {
+			continue
+		}
+		risk, err := c.assessRefundRisk(ctx, envelope)
+		if err != nil {
+			return nil, err
+		}
+		if risk.AtRisk() {
+			risks = append(risks, risk)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(risks, func(i, j int) bool { return len(risks[i].Reasons) > len(risks[j].Reasons) })
+	return risks, nil
+}
+
+// EnqueueRefund - Queue a signed refund with priority derived from its risk assessment (Wait for its result).
+// The refund is queued even when the assessment fails (at normal priority); the error is returned for logging.
+func (c *USDCEnvelopeClient) EnqueueRefund(ctx context.Context, q *SubmissionQueue, id string, signedTx string, owner solana.PublicKey, envelopeID uint64) (*Submission, *RefundRisk, error) {
+	submission := &Submission{
+		ID:         id,
+		Kind:       SubmissionRefund,
+		SignedTx:   signedTx,
+		Owner:      owner,
+		EnvelopeID: envelopeID,
+	}
+	risk, err := c.EnqueueRefundSubmission(ctx, q, submission)
+	return submission, risk, err
+}
+
+// EnqueueRefundSubmission - EnqueueRefund for a prepared submission (e.g. with Resign set); its
+// Priority is replaced by the one of the risk assessment
+func (c *USDCEnvelopeClient) EnqueueRefundSubmission(ctx context.Context, q *SubmissionQueue, s *Submission) (*RefundRisk, error) {
+	s.Priority = PriorityNormal
+	risk, err := c.AssessRefundRisk(ctx, s.Owner, s.EnvelopeID)
+	if err == nil {
+		s.Priority = risk.Priority()
+	}
+	q.Enqueue(s)
+	return risk, err
+}
+
+// SubmitQueued - SubmitFunc sending through SubmitSignedTransaction, keyed by the submission ID
+// so confirmation hooks and failure records see the transaction ID
+func (c *USDCEnvelopeClient) SubmitQueued(_ context.Context, s *Submission) (string, error) {
+	result, err := c.SubmitSignedTransaction(SignedTransactionRequest{
+		TransactionID:     s.ID,
+		SignedTransaction: s.SignedTx,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Signature, nil
+}
+
+// HandleGetAtRiskRefunds - GET /api/v1/envelopes/refund-risk?owner=xxx: owner's refundable envelopes
+// with at least one risk, most reasons first
+func (c *USDCEnvelopeClient) HandleGetAtRiskRefunds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	owner, err := solana.PublicKeyFromBase58(r.URL.Query().Get("owner"))
+	if err != nil {
+		http.Error(w, "Invalid owner", http.StatusBadRequest)
+		return
+	}
+	risks, err := c.FindAtRiskRefunds(r.Context(), owner)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(risks)
+}
+
+// AssessRefundRisk - Refund risk of a SOL program envelope: the envelope account is its own vault
+func (c *Client) AssessRefundRisk(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*RefundRisk, error) {
+	envelopePDA, _, err := DeriveEnvelopePDA(c.ProgramID, owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	return c.assessRefundRisk(ctx, envelopePDA, owner)
+}
+
+func (c *Client) assessRefundRisk(ctx context.Context, envelopePDA, owner solana.PublicKey) (*RefundRisk, error) {
+	accounts, err := c.RPC.GetMultipleAccounts(ctx, envelopePDA, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope accounts: %w", err)
+	}
+	risk := &RefundRisk{Owner: owner, Vault: envelopePDA}
+	if envelopeAcc := accounts.Value[0]; envelopeAcc == nil {
+		risk.Reasons = append(risk.Reasons, RiskVaultMissing)
+	} else {
+		data := envelopeAcc.Data.GetBinary()
+		envelope, err := parseSOLEnvelopeData(data)
+		if err != nil {
+			return nil, err
+		}
+		risk.EnvelopeID = binary.LittleEndian.Uint64(data[8+32:])
+		risk.RemainingAmount = envelope.RemainingAmount
+
+		// Lamports above the rent-exempt minimum back the remaining amount
+		rentMin, err := c.RPC.GetMinimumBalanceForRentExemption(ctx, uint64(len(data)), rpc.CommitmentConfirmed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rent exemption: %w", err)
+		}
+		if envelopeAcc.Lamports > rentMin {
+			risk.VaultBalance = envelopeAcc.Lamports - rentMin
+		}
+		if risk.VaultBalance < risk.RemainingAmount {
+			risk.Reasons = append(risk.Reasons, RiskVaultShortfall)
+		}
+		risk.checkOverdue(envelope.ExpiryTime)
+	}
+	if err := risk.checkOwner(ctx, c.RPC, accounts.Value[1]); err != nil {
+		return nil, err
+	}
+	return risk, nil
+}
+
+// SubmitQueued - SubmitFunc sending a SOL envelope submission with its preflight mode
+func (c *Client) SubmitQueued(_ context.Context, s *Submission) (string, error) {
+	result, err := c.SendActionWithPreflight(s.SignedTx, s.Kind, s.Preflight)
+	if err != nil {
+		return "", err
+	}
+	return result.Signature, nil
+}
+
+// sendQueuedRefund - Send a signed refund through RefundQueue, prioritized by its refund risk
+func (c *Client) sendQueuedRefund(ctx context.Context, transactionID, signedTx string, envelopePDA, owner solana.PublicKey, mode preflight.Mode) (*SendTransactionResult, error) {
+	submission := &Submission{
+		ID:        transactionID,
+		Kind:      SubmissionRefund,
+		SignedTx:  signedTx,
+		Priority:  PriorityNormal,
+		Owner:     owner,
+		Preflight: mode,
+	}
+	risk, err := c.assessRefundRisk(ctx, envelopePDA, owner)
+	if err != nil {
+		log.Printf("refund risk of envelope %s unknown, queued at normal priority: %v", envelopePDA, err)
+	} else {
+		submission.Priority = risk.Priority()
+		submission.EnvelopeID = risk.EnvelopeID
+	}
+	c.RefundQueue.Enqueue(submission)
+
+	result, err := c.RefundQueue.Wait(ctx, submission)
+	if err == nil {
+		err = result.Err
+	}
+	if err != nil {
+		return &SendTransactionResult{ErrorCode: ExtractErrorCode(err)}, err
+	}
+	return &SendTransactionResult{Signature: result.Signature}, nil
+}
+
+// refundAccounts - Envelope and owner of the SOL program refund in a signed transaction
+func (c *Client) refundAccounts(signedTxBase64 string) (envelope, owner solana.PublicKey, ok bool) {
+	tx, err := solana.TransactionFromBase64(signedTxBase64)
+	if err != nil {
+		return envelope, owner, false
+	}
+	for _, inst := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(inst.ProgramIDIndex)
+		if err != nil || !programID.Equals(c.ProgramID) || len(inst.Accounts) < 2 || !bytes.HasPrefix(inst.Data, RefundDisc[:]) {
+			continue
+		}
+		accounts, err := inst.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			return envelope, owner, false
+		}
+		return accounts[0].PublicKey, accounts[1].PublicKey, true
+	}
+	return envelope, owner, false
+}
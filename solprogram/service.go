@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"time"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/chain"
+	"blockchain/preflight"
+	"blockchain/txencoding"
 )
 
 // InitUserState - Initialize user state (first time only)
@@ -67,41 +70,6 @@ func (c *USDCEnvelopeClient) InitUserState(ctx context.Context, userPrivateKey s
 	}, nil
 }
 
-// WaitForConfirmation - Wait for transaction confirmation with timeout
-func (c *USDCEnvelopeClient) WaitForConfirmation(ctx context.Context, signature string, timeoutSeconds int) error {
-	sig, err := solana.SignatureFromBase58(signature)
-	if err != nil {
-		return fmt.Errorf("invalid signature: %w", err)
-	}
-
-	maxRetries := timeoutSeconds / 2 // Poll every 2 seconds
-	for i := 0; i < maxRetries; i++ {
-		status, err := c.rpcClient.GetSignatureStatuses(ctx, true, sig)
-		if err == nil && status != nil && len(status.Value) > 0 && status.Value[0] != nil {
-			txStatus := status.Value[0]
-
-			// Check if confirmed or finalized
-			if txStatus.ConfirmationStatus == rpc.ConfirmationStatusConfirmed ||
-				txStatus.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
-				if txStatus.Err != nil {
-					return fmt.Errorf("transaction failed: %v", txStatus.Err)
-				}
-				return nil // Success!
-			}
-
-			// Check if failed
-			if txStatus.Err != nil {
-				return fmt.Errorf("transaction failed: %v", txStatus.Err)
-			}
-		}
-
-		// Wait 2 seconds before retry
-		time.Sleep(2 * time.Second)
-	}
-
-	return fmt.Errorf("timeout waiting for confirmation after %d seconds", timeoutSeconds)
-}
-
 // CreateEnvelope - Create new envelope
 func (c *USDCEnvelopeClient) CreateEnvelope(
 	ctx context.Context,
@@ -111,6 +79,13 @@ func (c *USDCEnvelopeClient) CreateEnvelope(
 ) (*CreateEnvelopeResponse, error) {
 	user := userPrivateKey.PublicKey()
 
+	if err := c.validateCreateParams(params); err != nil {
+		return nil, err
+	}
+	if err := c.config.ValidateCreate(ctx, params.TotalAmount, params.TotalUsers); err != nil {
+		return nil, err
+	}
+
 	// Get user state to get next envelope ID
 	userState, err := c.GetUserState(ctx, user)
 	if err != nil {
@@ -140,6 +115,9 @@ func (c *USDCEnvelopeClient) CreateEnvelope(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
+	if err := checkTransactionSize(tx, params.EnvelopeType.Type); err != nil {
+		return nil, err
+	}
 
 	// Sign transaction
 	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
@@ -158,16 +136,21 @@ func (c *USDCEnvelopeClient) CreateEnvelope(
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
 
+	c.trackEnvelopeTx(sig.String(), chain.EnvelopeActionCreate, user, nextEnvelopeID, user, params.TotalAmount, solana.PublicKey{})
+
 	// Derive PDAs for response
 	envelopePDA, _, _ := c.DeriveEnvelopePDA(user, nextEnvelopeID)
 	vaultPDA, _, _ := c.DeriveEnvelopeVaultPDA(user, nextEnvelopeID)
 
+	links := envelopeLinks(c.explorer, c.programID, user, envelopePDA, &vaultPDA, nil, nil)
+	links.Transaction = c.explorer.Tx(sig.String())
 	return &CreateEnvelopeResponse{
 		EnvelopeID:  nextEnvelopeID,
 		EnvelopePDA: envelopePDA,
 		VaultPDA:    vaultPDA,
 		Signature:   sig.String(),
 		Message:     "Envelope created successfully",
+		Links:       links,
 	}, nil
 }
 
@@ -186,6 +169,10 @@ func (c *USDCEnvelopeClient) CreateUnsignedEnvelope(
 
 	nextEnvelopeID := userState.LastEnvelopeID + 1
 
+	if err := c.VerifyTokenAccount(ctx, userTokenAccount, user, c.usdcMint); err != nil {
+		return nil, err
+	}
+
 	// Build instruction
 	instruction, err := c.BuildCreateEnvelopeInstruction(user, userTokenAccount, params, nextEnvelopeID)
 	if err != nil {
@@ -224,6 +211,7 @@ func (c *USDCEnvelopeClient) CreateUnsignedEnvelope(
 		VaultPDA:            vaultPDA,
 		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
 		Message:             "Unsigned transaction created - sign on client side",
+		Links:               envelopeLinks(c.explorer, c.programID, user, envelopePDA, &vaultPDA, nil, nil),
 	}, nil
 }
 
@@ -273,16 +261,28 @@ func (c *USDCEnvelopeClient) ClaimEnvelope(
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
 
+	release, err := c.claimCaps.Reserve(ctx, params.Owner, params.EnvelopeID, params.Claimer)
+	if err != nil {
+		return nil, err
+	}
+
 	// Send transaction
 	sig, err := c.rpcClient.SendTransaction(ctx, tx)
 	if err != nil {
+		release()
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
+	c.trackEnvelopeTx(sig.String(), chain.EnvelopeActionClaim, params.Owner, params.EnvelopeID, params.Claimer, 0, params.ClaimerTokenAccount)
 
+	links, _ := c.EnvelopeLinks(params.Owner, params.EnvelopeID, &params.Claimer)
+	if links != nil {
+		links.Transaction = c.explorer.Tx(sig.String())
+	}
 	return &ClaimEnvelopeResponse{
 		EnvelopeID: params.EnvelopeID,
 		Signature:  sig.String(),
 		Message:    "Claim successful",
+		Links:      links,
 	}, nil
 }
 
@@ -292,6 +292,17 @@ func (c *USDCEnvelopeClient) RefundEnvelope(
 	ownerPrivateKey solana.PrivateKey,
 	ownerTokenAccount solana.PublicKey,
 	envelopeID uint64,
+) (*RefundResponse, error) {
+	return c.RefundEnvelopeAmount(ctx, ownerPrivateKey, ownerTokenAccount, envelopeID, 0)
+}
+
+// RefundEnvelopeAmount - Refund amount of the unclaimed USDC after expiry (0 = everything remaining)
+func (c *USDCEnvelopeClient) RefundEnvelopeAmount(
+	ctx context.Context,
+	ownerPrivateKey solana.PrivateKey,
+	ownerTokenAccount solana.PublicKey,
+	envelopeID uint64,
+	amount uint64,
 ) (*RefundResponse, error) {
 	owner := ownerPrivateKey.PublicKey()
 
@@ -299,6 +310,16 @@ func (c *USDCEnvelopeClient) RefundEnvelope(
 		EnvelopeID:        envelopeID,
 		Owner:             owner,
 		OwnerTokenAccount: ownerTokenAccount,
+		Amount:            amount,
+	}
+
+	envelope, err := c.GetEnvelopeInfo(ctx, owner, envelopeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope: %w", err)
+	}
+	refunded, err := envelope.ValidateRefund(amount)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build instruction
@@ -339,18 +360,31 @@ func (c *USDCEnvelopeClient) RefundEnvelope(
 	if err != nil {
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
+	c.trackEnvelopeTx(sig.String(), chain.EnvelopeActionRefund, owner, envelopeID, owner, amount, ownerTokenAccount)
+	c.InvalidateEnvelopeInfo(owner, envelopeID)
 
+	message := "Refund successful"
+	if refunded < envelope.RemainingAmount {
+		message = "Partial refund successful"
+	}
+	links, _ := c.EnvelopeLinks(owner, envelopeID, nil)
+	if links != nil {
+		links.Transaction = c.explorer.Tx(sig.String())
+	}
 	return &RefundResponse{
-		EnvelopeID: envelopeID,
-		Signature:  sig.String(),
-		Message:    "Refund successful",
+		EnvelopeID:      envelopeID,
+		RefundedAmount:  refunded,
+		RemainingAmount: envelope.RemainingAmount - refunded,
+		Signature:       sig.String(),
+		Message:         message,
+		Links:           links,
 	}, nil
 }
 
-// SendSignedTransaction - Send signed transaction from client
-func (c *USDCEnvelopeClient) SendSignedTransaction(ctx context.Context, signedTxBase64 string) (string, error) {
+// SendSignedTransaction - Send signed transaction from client (base64, base58 or hex)
+func (c *USDCEnvelopeClient) SendSignedTransaction(ctx context.Context, signedTx string) (string, error) {
 	// Decode transaction
-	txBytes, err := base64.StdEncoding.DecodeString(signedTxBase64)
+	txBytes, _, err := txencoding.DecodeAuto(signedTx, "")
 	if err != nil {
 		return "", fmt.Errorf("failed to decode transaction: %w", err)
 	}
@@ -362,7 +396,7 @@ func (c *USDCEnvelopeClient) SendSignedTransaction(ctx context.Context, signedTx
 	}
 
 	// Send transaction
-	sig, err := c.rpcClient.SendTransaction(ctx, tx)
+	sig, err := preflight.Send(ctx, c.rpcClient, tx, c.preflight.Resolve("", preflight.Default))
 	if err != nil {
 		return "", fmt.Errorf("failed to send transaction: %w", err)
 	}
@@ -0,0 +1,120 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"blockchain/chain"
+	"blockchain/metrics"
+	"blockchain/storage"
+)
+
+// DefaultMaxSponsorRiskScore - Wallets scoring at or above this pay their own fees
+const DefaultMaxSponsorRiskScore = 50
+
+// sponsorRiskTimeout - Budget for scoring a wallet before deciding
+const sponsorRiskTimeout = 10 * time.Second
+
+// FeeSponsor - Pays network fees of claims for wallets that pass the risk check
+type FeeSponsor struct {
+	Key      solana.PrivateKey
+	Scorer   *WalletRiskScorer
+	MaxScore int // Default DefaultMaxSponsorRiskScore
+}
+
+// Sponsorship - Sponsorship decision attached to a claim
+type Sponsorship struct {
+	Sponsored bool        `json:"sponsored"`
+	Sponsor   string      `json:"sponsor,omitempty"` // Fee payer, already signed
+	Risk      *WalletRisk `json:"risk,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+// SetFeeSponsor - Enable fee sponsorship for GenerateSponsoredClaim (nil disables it)
+func (c *USDCEnvelopeClient) SetFeeSponsor(sponsor *FeeSponsor) {
+	c.sponsor = sponsor
+	if sponsor != nil && sponsor.Scorer != nil && c.history != nil {
+		sponsor.Scorer.SetStore(c.history)
+	}
+}
+
+// GenerateSponsoredClaim - Claim whose fee is paid by the sponsor when the claimer passes the
+// wallet risk check; otherwise (or without a sponsor) a normal claim paid by the claimer.
+// The decision and score are returned in Sponsorship and recorded in the history store.
+func (c *USDCEnvelopeClient) GenerateSponsoredClaim(params ClaimEnvelopeParams) (*UnsignedTransactionResponse, error) {
+	if c.sponsor == nil {
+		return c.GenerateUnsignedClaim(params)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sponsorRiskTimeout)
+	defer cancel()
+	decision := c.sponsor.decide(ctx, params.Claimer)
+
+	var response *UnsignedTransactionResponse
+	var err error
+	if decision.Sponsored {
+		response, err = c.generateUnsignedClaim(params, &c.sponsor.Key)
+	} else {
+		response, err = c.GenerateUnsignedClaim(params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.recordSponsorship(ctx, params, decision)
+	response.Sponsorship = decision
+	if decision.Sponsored {
+		c.sponsor.Scorer.noteSponsored(decision.Risk)
+		response.Message = "Fee sponsored - sign as claimer and submit"
+	}
+	return response, nil
+}
+
+// decide - Score the wallet; fails closed when the check itself fails
+func (s *FeeSponsor) decide(ctx context.Context, wallet solana.PublicKey) *Sponsorship {
+	if s.Scorer == nil {
+		return &Sponsorship{Reason: "risk scorer not configured"}
+	}
+	risk, err := s.Scorer.Score(ctx, wallet)
+	if err != nil {
+		return &Sponsorship{Risk: &WalletRisk{Wallet: wallet.String()}, Reason: fmt.Sprintf("risk check failed: %v", err)}
+	}
+	maxScore := s.MaxScore
+	if maxScore <= 0 {
+		maxScore = DefaultMaxSponsorRiskScore
+	}
+	if risk.Score >= maxScore {
+		return &Sponsorship{Risk: risk, Reason: fmt.Sprintf("risk score %d >= %d", risk.Score, maxScore)}
+	}
+	return &Sponsorship{Sponsored: true, Sponsor: s.Key.PublicKey().String(), Risk: risk}
+}
+
+// recordSponsorship - Metrics plus a stored decision when a history store is set
+func (c *USDCEnvelopeClient) recordSponsorship(ctx context.Context, params ClaimEnvelopeParams, decision *Sponsorship) {
+	if decision.Sponsored {
+		metrics.Counter("sponsorship_granted").Add(1)
+	} else {
+		metrics.Counter("sponsorship_denied").Add(1)
+	}
+	if c.history == nil || decision.Risk == nil {
+		return
+	}
+	record := &storage.SponsorshipDecision{
+		Chain:      chain.Solana,
+		Wallet:     decision.Risk.Wallet,
+		Action:     chain.EnvelopeActionClaim,
+		Owner:      params.Owner.String(),
+		EnvelopeID: params.EnvelopeID,
+		Score:      decision.Risk.Score,
+		Signals:    decision.Risk.Signals,
+		FundedBy:   decision.Risk.FundedBy,
+		Sponsored:  decision.Sponsored,
+		Reason:     decision.Reason,
+	}
+	if err := c.history.RecordSponsorshipDecision(ctx, record); err != nil {
+		log.Printf("failed to record sponsorship decision for %s: %v", record.Wallet, err)
+	}
+}
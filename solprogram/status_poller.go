@@ -0,0 +1,115 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// DefaultStatusBatchWindow - How long the poller collects lookups before one getSignatureStatuses call
+	DefaultStatusBatchWindow = 200 * time.Millisecond
+	// maxSignaturesPerStatusCall - RPC limit of getSignatureStatuses
+	maxSignaturesPerStatusCall = 256
+	statusCallTimeout          = 10 * time.Second
+)
+
+type statusReply struct {
+	status *rpc.SignatureStatusesResult // nil when the node has not seen the signature
+	err    error
+}
+
+// StatusPoller - Batches signature status lookups of all in-flight confirmations
+// into one getSignatureStatuses call per window
+type StatusPoller struct {
+	client *rpc.Client
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[solana.Signature][]chan statusReply
+	running bool
+}
+
+// NewStatusPoller - Create poller (window default DefaultStatusBatchWindow)
+func NewStatusPoller(client *rpc.Client, window time.Duration) *StatusPoller {
+	if window <= 0 {
+		window = DefaultStatusBatchWindow
+	}
+	return &StatusPoller{
+		client:  client,
+		window:  window,
+		pending: make(map[solana.Signature][]chan statusReply),
+	}
+}
+
+// Status - Status of sig from the next batch (nil status = not seen yet)
+func (p *StatusPoller) Status(ctx context.Context, sig solana.Signature) (*rpc.SignatureStatusesResult, error) {
+	reply := make(chan statusReply, 1)
+
+	p.mu.Lock()
+	p.pending[sig] = append(p.pending[sig], reply)
+	if !p.running {
+		p.running = true
+		go p.loop()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-reply:
+		return r.status, r.err
+	}
+}
+
+// loop - Runs while there are lookups waiting, one batch per window
+func (p *StatusPoller) loop() {
+	ticker := time.NewTicker(p.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		batch := p.pending
+		if len(batch) == 0 {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+		p.pending = make(map[solana.Signature][]chan statusReply)
+		p.mu.Unlock()
+
+		p.flush(batch)
+	}
+}
+
+func (p *StatusPoller) flush(batch map[solana.Signature][]chan statusReply) {
+	sigs := make([]solana.Signature, 0, len(batch))
+	for sig := range batch {
+		sigs = append(sigs, sig)
+	}
+
+	for start := 0; start < len(sigs); start += maxSignaturesPerStatusCall {
+		chunk := sigs[start:min(start+maxSignaturesPerStatusCall, len(sigs))]
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusCallTimeout)
+		result, err := p.client.GetSignatureStatuses(ctx, true, chunk...)
+		cancel()
+		if err == nil && (result == nil || len(result.Value) != len(chunk)) {
+			err = fmt.Errorf("unexpected getSignatureStatuses response")
+		}
+
+		for i, sig := range chunk {
+			r := statusReply{err: err}
+			if err == nil {
+				r.status = result.Value[i]
+			}
+			for _, reply := range batch[sig] {
+				reply <- r
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package solprogram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SPL token account layout (165 bytes, Token-2022 extensions follow): mint 0..32, owner 32..64, amount 64..72, ..., state at 108
+const (
+	tokenAccountSize        = 165
+	tokenAccountStateOffset = 108
+	tokenAccountInitialized = 1
+	tokenAccountFrozen      = 2
+)
+
+// Token account verification errors
+var (
+	ErrTokenAccountNotFound    = errors.New("token account not found")
+	ErrTokenAccountNotToken    = errors.New("account is not an SPL token account")
+	ErrTokenAccountWrongMint   = errors.New("token account mint mismatch")
+	ErrTokenAccountWrongOwner  = errors.New("token account is not owned by the expected wallet")
+	ErrTokenAccountNotUsable   = errors.New("token account is not initialized")
+	ErrTokenAccountFrozenState = errors.New("token account is frozen")
+)
+
+// VerifyTokenAccount - Check on RPC that tokenAccount holds mint and belongs to wallet
+func (c *USDCEnvelopeClient) VerifyTokenAccount(ctx context.Context, tokenAccount, wallet, mint solana.PublicKey) error {
+	info, err := c.rpcClient.GetAccountInfo(ctx, tokenAccount)
+	if err != nil || info == nil || info.Value == nil {
+		return fmt.Errorf("%w: %s", ErrTokenAccountNotFound, tokenAccount)
+	}
+	tokenProgram, err := c.TokenProgramForMint(ctx, mint)
+	if err != nil {
+		return err
+	}
+	if !info.Value.Owner.Equals(tokenProgram) {
+		return fmt.Errorf("%w: %s owned by %s, expected %s", ErrTokenAccountNotToken, tokenAccount, info.Value.Owner, tokenProgram)
+	}
+
+	data := info.Value.Data.GetBinary()
+	if len(data) < tokenAccountSize {
+		return fmt.Errorf("%w: %s", ErrTokenAccountNotToken, tokenAccount)
+	}
+	if accMint := solana.PublicKeyFromBytes(data[0:32]); !accMint.Equals(mint) {
+		return fmt.Errorf("%w: %s holds %s, expected %s", ErrTokenAccountWrongMint, tokenAccount, accMint, mint)
+	}
+	if accOwner := solana.PublicKeyFromBytes(data[32:64]); !accOwner.Equals(wallet) {
+		return fmt.Errorf("%w: %s belongs to %s, expected %s", ErrTokenAccountWrongOwner, tokenAccount, accOwner, wallet)
+	}
+	switch data[tokenAccountStateOffset] {
+	case tokenAccountInitialized:
+		return nil
+	case tokenAccountFrozen:
+		return fmt.Errorf("%w: %s", ErrTokenAccountFrozenState, tokenAccount)
+	default:
+		return fmt.Errorf("%w: %s", ErrTokenAccountNotUsable, tokenAccount)
+	}
+}
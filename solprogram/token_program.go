@@ -0,0 +1,117 @@
+package solprogram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// KnownTokenPrograms - Token programs accepted as mint owners without an explicit override
+var KnownTokenPrograms = []solana.PublicKey{TokenProgramID, solana.Token2022ProgramID}
+
+// tokenProgramResolver - Per-mint owner program lookup; mint owners never change, so results are cached for good
+type tokenProgramResolver struct {
+	rpcClient *rpc.Client
+
+	mu        sync.RWMutex
+	overrides map[solana.PublicKey]solana.PublicKey
+	cache     map[solana.PublicKey]solana.PublicKey
+}
+
+func newTokenProgramResolver(rpcClient *rpc.Client) *tokenProgramResolver {
+	return &tokenProgramResolver{
+		rpcClient: rpcClient,
+		overrides: make(map[solana.PublicKey]solana.PublicKey),
+		cache:     make(map[solana.PublicKey]solana.PublicKey),
+	}
+}
+
+func (r *tokenProgramResolver) resolve(ctx context.Context, mint solana.PublicKey) (solana.PublicKey, error) {
+	r.mu.RLock()
+	program, ok := r.overrides[mint]
+	if !ok {
+		program, ok = r.cache[mint]
+	}
+	r.mu.RUnlock()
+	if ok {
+		return program, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	info, err := r.rpcClient.GetAccountInfo(ctx, mint)
+	if err != nil || info == nil || info.Value == nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to resolve token program of mint %s: %w", mint, err)
+	}
+	program = info.Value.Owner
+	if !isKnownTokenProgram(program) {
+		return solana.PublicKey{}, fmt.Errorf("mint %s is owned by unsupported program %s (set a token program override)", mint, program)
+	}
+
+	r.mu.Lock()
+	r.cache[mint] = program
+	r.mu.Unlock()
+	return program, nil
+}
+
+func (r *tokenProgramResolver) setOverride(mint, program solana.PublicKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[mint] = program
+}
+
+func isKnownTokenProgram(program solana.PublicKey) bool {
+	for _, known := range KnownTokenPrograms {
+		if known.Equals(program) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenProgramForMint - Token program owning mint (override > cache > RPC lookup)
+func (c *USDCEnvelopeClient) TokenProgramForMint(ctx context.Context, mint solana.PublicKey) (solana.PublicKey, error) {
+	return c.tokenPrograms.resolve(ctx, mint)
+}
+
+// SetTokenProgramOverride - Pin the token program of a mint (skips RPC lookup, allows non-standard programs)
+func (c *USDCEnvelopeClient) SetTokenProgramOverride(mint, program solana.PublicKey) {
+	c.tokenPrograms.setOverride(mint, program)
+}
+
+// SetTokenProgramOverrides - Pin several mints at once (typically from config)
+func (c *USDCEnvelopeClient) SetTokenProgramOverrides(overrides map[solana.PublicKey]solana.PublicKey) {
+	for mint, program := range overrides {
+		c.tokenPrograms.setOverride(mint, program)
+	}
+}
+
+// ParseTokenProgramOverrides - Parse "mint=program,mint=program" (e.g. from TOKEN_PROGRAM_OVERRIDES)
+func ParseTokenProgramOverrides(s string) (map[solana.PublicKey]solana.PublicKey, error) {
+	overrides := make(map[solana.PublicKey]solana.PublicKey)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		mintStr, programStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid token program override %q (want mint=program)", pair)
+		}
+		mint, err := solana.PublicKeyFromBase58(strings.TrimSpace(mintStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mint in override %q: %w", pair, err)
+		}
+		program, err := solana.PublicKeyFromBase58(strings.TrimSpace(programStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid program in override %q: %w", pair, err)
+		}
+		overrides[mint] = program
+	}
+	return overrides, nil
+}
@@ -4,6 +4,9 @@ import (
 	"time"
 
 	"github.com/gagliardetto/solana-go"
+
+	"blockchain/receipt"
+	"blockchain/txstatus"
 )
 
 // EnvelopeType - Tipe envelope yang tersedia
@@ -13,6 +16,7 @@ const (
 	EnvelopeTypeDirectFixed EnvelopeType = 0
 	EnvelopeTypeGroupFixed  EnvelopeType = 1
 	EnvelopeTypeGroupRandom EnvelopeType = 2
+	EnvelopeTypeCustomSplit EnvelopeType = 3
 )
 
 // TokenType - Tipe token yang didukung
@@ -32,6 +36,13 @@ type DirectFixedEnvelope struct {
 type EnvelopeTypeData struct {
 	Type           EnvelopeType
 	AllowedAddress *solana.PublicKey // Only for DirectFixed
+	Recipients     []SplitRecipient  // Only for CustomSplit
+}
+
+// SplitRecipient - Recipient of a CustomSplit envelope and the exact amount they can claim
+type SplitRecipient struct {
+	Address solana.PublicKey `json:"address"`
+	Amount  uint64           `json:"amount"`
 }
 
 // UserState - State untuk tracking envelope IDs per user
@@ -51,6 +62,7 @@ type EnvelopeAccount struct {
 	ClaimedCount    uint64
 	Expiry          int64
 	IsCancelled     bool
+	StartTime       int64 // Unix time claims open (0 = on creation)
 }
 
 // ClaimRecord - Record untuk track siapa sudah claim
@@ -67,7 +79,9 @@ type CreateEnvelopeParams struct {
 	TotalAmount    uint64
 	TotalUsers     uint64
 	ExpirySeconds  uint64
+	StartTime      int64             // Optional: unix time claims open, before the expiry (0 = on creation)
 	AllowedAddress *solana.PublicKey // Optional: hanya untuk DirectFixed
+	GroupID        string            // Optional: group/conversation the envelope is sent to (claim caps)
 }
 
 // CreateEnvelopeResponse - Response setelah create envelope
@@ -78,6 +92,7 @@ type CreateEnvelopeResponse struct {
 	Signature           string           `json:"signature"`
 	UnsignedTransaction string           `json:"unsigned_transaction,omitempty"`
 	Message             string           `json:"message"`
+	Links               *ExplorerLinks   `json:"links,omitempty"`
 }
 
 // ClaimEnvelopeParams - Parameters untuk claim envelope
@@ -90,11 +105,12 @@ type ClaimEnvelopeParams struct {
 
 // ClaimEnvelopeResponse - Response setelah claim
 type ClaimEnvelopeResponse struct {
-	EnvelopeID          uint64 `json:"envelope_id"`
-	ClaimedAmount       uint64 `json:"claimed_amount"`
-	Signature           string `json:"signature"`
-	UnsignedTransaction string `json:"unsigned_transaction,omitempty"`
-	Message             string `json:"message"`
+	EnvelopeID          uint64         `json:"envelope_id"`
+	ClaimedAmount       uint64         `json:"claimed_amount"`
+	Signature           string         `json:"signature"`
+	UnsignedTransaction string         `json:"unsigned_transaction,omitempty"`
+	Message             string         `json:"message"`
+	Links               *ExplorerLinks `json:"links,omitempty"`
 }
 
 // RefundParams - Parameters untuk refund
@@ -102,15 +118,18 @@ type RefundParams struct {
 	EnvelopeID        uint64
 	Owner             solana.PublicKey
 	OwnerTokenAccount solana.PublicKey
+	Amount            uint64 // Optional: partial refund in base units (0 = refund everything remaining)
 }
 
 // RefundResponse - Response setelah refund
 type RefundResponse struct {
-	EnvelopeID          uint64 `json:"envelope_id"`
-	RefundedAmount      uint64 `json:"refunded_amount"`
-	Signature           string `json:"signature"`
-	UnsignedTransaction string `json:"unsigned_transaction,omitempty"`
-	Message             string `json:"message"`
+	EnvelopeID          uint64         `json:"envelope_id"`
+	RefundedAmount      uint64         `json:"refunded_amount"`
+	RemainingAmount     uint64         `json:"remaining_amount"` // Left in the envelope after a partial refund
+	Signature           string         `json:"signature"`
+	UnsignedTransaction string         `json:"unsigned_transaction,omitempty"`
+	Message             string         `json:"message"`
+	Links               *ExplorerLinks `json:"links,omitempty"`
 }
 
 // EnvelopeInfo - Info lengkap tentang envelope
@@ -119,24 +138,31 @@ type EnvelopeInfo struct {
 	EnvelopeID      uint64           `json:"envelope_id"`
 	EnvelopeType    string           `json:"envelope_type"`
 	AllowedAddress  *string          `json:"allowed_address,omitempty"`
+	Recipients      []SplitRecipient `json:"recipients,omitempty"` // CustomSplit entitlements
 	TotalAmount     uint64           `json:"total_amount"`
 	TotalUsers      uint64           `json:"total_users"`
 	WithdrawnAmount uint64           `json:"withdrawn_amount"`
 	ClaimedCount    uint64           `json:"claimed_count"`
 	RemainingAmount uint64           `json:"remaining_amount"`
-	IsCancelled     bool             `json:"is_cancelled"`
-	ExpiryTime      time.Time        `json:"expiry_time"`
-	IsExpired       bool             `json:"is_expired"`
+	// RefundableAmount - RemainingAmount once expired (partial refunds may take any part of it), else 0
+	RefundableAmount uint64    `json:"refundable_amount"`
+	IsCancelled      bool      `json:"is_cancelled"`
+	ExpiryTime       time.Time `json:"expiry_time"`
+	IsExpired        bool      `json:"is_expired"`
+	// StartTime - When claims open (nil = on creation); IsStarted is false until then
+	StartTime *time.Time `json:"start_time,omitempty"`
+	IsStarted bool       `json:"is_started"`
 }
 
-// TransactionStatus - Status transaksi
-type TransactionStatus string
+// TransactionStatus - Status transaksi (shared vocabulary, see package txstatus)
+type TransactionStatus = txstatus.Status
 
 const (
-	StatusPending   TransactionStatus = "pending"
-	StatusConfirmed TransactionStatus = "confirmed"
-	StatusFinalized TransactionStatus = "finalized"
-	StatusFailed    TransactionStatus = "failed"
+	StatusPending   = txstatus.Pending
+	StatusConfirmed = txstatus.Confirmed
+	StatusFinalized = txstatus.Finalized
+	StatusFailed    = txstatus.Failed
+	StatusNotFound  = txstatus.NotFound
 )
 
 // TransactionResult - Hasil transaksi
@@ -145,4 +171,6 @@ type TransactionResult struct {
 	Status      TransactionStatus `json:"status"`
 	Error       *string           `json:"error,omitempty"`
 	ExplorerURL string            `json:"explorer_url"`
+	Receipt     *receipt.Receipt  `json:"receipt,omitempty"`      // Set once the transaction has landed
+	ProgramLogs []string          `json:"program_logs,omitempty"` // Set on failure
 }
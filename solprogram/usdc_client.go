@@ -2,8 +2,8 @@ package solprogram
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
+	"log"
 	"time"
 
 	bin "github.com/gagliardetto/binary"
@@ -11,6 +11,19 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 	confirm "github.com/gagliardetto/solana-go/rpc/sendAndConfirmTransaction"
 	"github.com/gagliardetto/solana-go/rpc/ws"
+
+	"blockchain/attestation"
+	"blockchain/chain"
+	"blockchain/circuit"
+	"blockchain/dto"
+	"blockchain/dust"
+	"blockchain/explorer"
+	"blockchain/preflight"
+	"blockchain/pricing"
+	"blockchain/receipt"
+	"blockchain/storage"
+	"blockchain/txencoding"
+	"blockchain/txstatus"
 )
 
 // USDCEnvelopeClient - Client untuk interact dengan USDC envelope program
@@ -19,19 +32,52 @@ type USDCEnvelopeClient struct {
 	wsClient  *ws.Client
 	programID solana.PublicKey
 	usdcMint  solana.PublicKey
-	network   string // "devnet", "mainnet", "localhost"
+	network   chain.Network
+
+	envelopeCache *envelopeInfoCache
+	breakers      *circuit.Registry
+	tokenPrograms *tokenProgramResolver
+	preflight     *preflight.Policy
+	config        *ProgramConfigCache
+	history       *storage.Store
+	explorer      explorer.Explorer
+	statusPoller  *StatusPoller
+	claimCaps     *ClaimCapPolicy
+	prices        pricing.Source
+	confirmHooks  []ConfirmationHook
+	envelopeTxs   *envelopeTxs
+	issued        *issuedTransactions
+	minSlots      *slotFloors
+	claimDeadline *ClaimDeadlinePolicy
+	allowList     *InstructionAllowList
+	envelopeRules EnvelopeRules
+	dust          *dust.Policy
+	sponsor       *FeeSponsor
+	attestor      *attestation.Signer
+	txStore       *storage.TransactionStore
+	encoding      txencoding.Encoding
+	layout        EnvelopeLayout
 }
 
 // NewUSDCEnvelopeClient - Create new USDC envelope client
-func NewUSDCEnvelopeClient(rpcURL string, wsURL string, network string) (*USDCEnvelopeClient, error) {
-	client := rpc.New(rpcURL)
+func NewUSDCEnvelopeClient(rpcURL string, wsURL string, network chain.Network) (*USDCEnvelopeClient, error) {
+	return NewUSDCEnvelopeClientWithRPC(rpc.New(rpcURL), wsURL, network)
+}
 
+// NewUSDCEnvelopeClientWithRPC - Create new USDC envelope client on an existing RPC client, e.g. rpcpool.Router.Client()
+func NewUSDCEnvelopeClientWithRPC(client *rpc.Client, wsURL string, network chain.Network) (*USDCEnvelopeClient, error) {
 	// Connect to WebSocket for transaction confirmation
 	wsClient, err := ws.Connect(context.Background(), wsURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to websocket: %w", err)
 	}
+	return NewUSDCEnvelopeClientWithClients(client, wsClient, network)
+}
 
+// NewUSDCEnvelopeClientWithClients - Create new USDC envelope client on injected clients without dialing
+// anything (tests, examples, shared connections). wsClient may be nil: SubmitSignedTransaction then
+// confirms by polling signature statuses instead of a websocket subscription.
+func NewUSDCEnvelopeClientWithClients(client *rpc.Client, wsClient *ws.Client, network chain.Network) (*USDCEnvelopeClient, error) {
 	programID, err := solana.PublicKeyFromBase58(USDCProgramID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid program ID: %w", err)
@@ -39,7 +85,7 @@ func NewUSDCEnvelopeClient(rpcURL string, wsURL string, network string) (*USDCEn
 
 	// Select USDC mint based on network
 	var usdcMintAddr string
-	if network == "mainnet" {
+	if network.IsMainnet() {
 		usdcMintAddr = USDCMintMainnet
 	} else {
 		usdcMintAddr = USDCMintDevnet
@@ -56,9 +102,35 @@ func NewUSDCEnvelopeClient(rpcURL string, wsURL string, network string) (*USDCEn
 		programID: programID,
 		usdcMint:  usdcMint,
 		network:   network,
+
+		envelopeCache: newEnvelopeInfoCache(DefaultEnvelopeInfoCacheTTL),
+		breakers:      NewBreakerRegistry(),
+		tokenPrograms: newTokenProgramResolver(client),
+		config:        NewProgramConfigCache(client, programID, DefaultUSDCProgramConfig, DefaultProgramConfigTTL),
+		explorer:      explorer.New(chain.Solana, network, explorer.SolanaExplorer),
+		statusPoller:  NewStatusPoller(client, DefaultStatusBatchWindow),
+		envelopeTxs:   newEnvelopeTxs(),
+		issued:        newIssuedTransactions(),
+		minSlots:      newSlotFloors(),
+		claimDeadline: DefaultClaimDeadlinePolicy,
+		allowList:     USDCAllowList(programID),
+		envelopeRules: DefaultEnvelopeRules(),
+		dust:          dust.Default(),
+		encoding:      txencoding.Base64,
+		layout:        EnvelopeLayoutV2,
 	}, nil
 }
 
+// GetProgramConfig - Program limits from the config PDA (cached)
+func (c *USDCEnvelopeClient) GetProgramConfig(ctx context.Context) (*ProgramConfig, error) {
+	return c.config.Get(ctx)
+}
+
+// Breakers - Circuit breakers guarding envelope actions
+func (c *USDCEnvelopeClient) Breakers() *circuit.Registry {
+	return c.breakers
+}
+
 // GetClient - Get RPC client
 func (c *USDCEnvelopeClient) GetClient() *rpc.Client {
 	return c.rpcClient
@@ -143,10 +215,14 @@ func (c *USDCEnvelopeClient) DeriveClaimRecordPDA(envelopePDA solana.PublicKey,
 
 // GetAssociatedTokenAddress - Derive Associated Token Account address for a wallet and mint
 func (c *USDCEnvelopeClient) GetAssociatedTokenAddress(wallet solana.PublicKey, mint solana.PublicKey) (solana.PublicKey, error) {
+	tokenProgram, err := c.TokenProgramForMint(context.Background(), mint)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
 	ata, _, err := solana.FindProgramAddress(
 		[][]byte{
 			wallet.Bytes(),
-			TokenProgramID.Bytes(),
+			tokenProgram.Bytes(),
 			mint.Bytes(),
 		},
 		AssociatedTokenProgID,
@@ -169,7 +245,7 @@ func (c *USDCEnvelopeClient) GetUserState(ctx context.Context, userPubkey solana
 		return nil, err
 	}
 
-	accountInfo, err := c.rpcClient.GetAccountInfo(ctx, userStatePDA)
+	accountInfo, err := c.getAccountInfo(ctx, userPubkey.String(), userStatePDA)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user state: %w", err)
 	}
@@ -187,14 +263,21 @@ func (c *USDCEnvelopeClient) GetUserState(ctx context.Context, userPubkey solana
 	return userState, nil
 }
 
-// GetEnvelopeInfo - Fetch envelope info from blockchain
+// GetEnvelopeInfo - Fetch envelope info (cached for a short TTL, concurrent reads share one RPC call)
 func (c *USDCEnvelopeClient) GetEnvelopeInfo(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*EnvelopeInfo, error) {
+	return c.envelopeCache.getOrFetch(ctx, envelopeCacheKey(owner, envelopeID), func(ctx context.Context) (*EnvelopeInfo, error) {
+		return c.fetchEnvelopeInfo(ctx, owner, envelopeID)
+	})
+}
+
+// fetchEnvelopeInfo - Fetch envelope info from blockchain
+func (c *USDCEnvelopeClient) fetchEnvelopeInfo(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*EnvelopeInfo, error) {
 	envelopePDA, _, err := c.DeriveEnvelopePDA(owner, envelopeID)
 	if err != nil {
 		return nil, err
 	}
 
-	accountInfo, err := c.rpcClient.GetAccountInfo(ctx, envelopePDA)
+	accountInfo, err := c.getAccountInfo(ctx, envelopeCacheKey(owner, envelopeID), envelopePDA)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get envelope info: %w", err)
 	}
@@ -204,7 +287,7 @@ func (c *USDCEnvelopeClient) GetEnvelopeInfo(ctx context.Context, owner solana.P
 	}
 
 	// Parse account data
-	envelope, err := parseEnvelopeData(accountInfo.Value.Data.GetBinary())
+	envelope, err := parseEnvelopeData(accountInfo.Value.Data.GetBinary(), c.layout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse envelope: %w", err)
 	}
@@ -235,19 +318,13 @@ func (c *USDCEnvelopeClient) GetTransactionStatus(ctx context.Context, signature
 	txStatus := status.Value[0]
 	result := &TransactionResult{
 		Signature:   signature,
+		Status:      txstatus.FromSolana(txStatus.ConfirmationStatus, txStatus.Err),
 		ExplorerURL: c.getExplorerURL(signature),
 	}
 
 	if txStatus.Err != nil {
 		errMsg := fmt.Sprintf("%v", txStatus.Err)
-		result.Status = StatusFailed
 		result.Error = &errMsg
-	} else if txStatus.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
-		result.Status = StatusFinalized
-	} else if txStatus.ConfirmationStatus == rpc.ConfirmationStatusConfirmed {
-		result.Status = StatusConfirmed
-	} else {
-		result.Status = StatusPending
 	}
 
 	return result, nil
@@ -255,10 +332,7 @@ func (c *USDCEnvelopeClient) GetTransactionStatus(ctx context.Context, signature
 
 // getExplorerURL - Generate explorer URL
 func (c *USDCEnvelopeClient) getExplorerURL(signature string) string {
-	if c.network == "mainnet" {
-		return fmt.Sprintf(ExplorerURLMainnet, signature)
-	}
-	return fmt.Sprintf(ExplorerURLDevnet, signature)
+	return c.explorer.Tx(signature)
 }
 
 // Helper function to convert uint64 to little-endian bytes
@@ -281,17 +355,19 @@ func uint64ToBytes(n uint64) []byte {
 
 // UnsignedTransactionResponse - Response for unsigned transaction
 type UnsignedTransactionResponse struct {
-	TransactionID       string `json:"transaction_id"`
-	UnsignedTransaction string `json:"unsigned_transaction"` // base64 encoded
-	RecentBlockhash     string `json:"recent_blockhash"`
-	Message             string `json:"message,omitempty"`
+	TransactionID       string              `json:"transaction_id"`
+	UnsignedTransaction string              `json:"unsigned_transaction"` // base64 unless SetPayloadEncoding says otherwise
+	Encoding            txencoding.Encoding `json:"encoding"`
+	RecentBlockhash     string              `json:"recent_blockhash"`
+	EstimatedFee        *pricing.Fee        `json:"estimated_fee,omitempty"` // Network fee the signer will pay
+	Message             string              `json:"message,omitempty"`
+	Sponsorship         *Sponsorship        `json:"sponsorship,omitempty"`  // Set by GenerateSponsoredClaim
+	Warning             string              `json:"warning,omitempty"`      // e.g. claim close to expiry (ClaimDeadlinePolicy.WarnOnly)
+	ClaimAmount         uint64              `json:"claim_amount,omitempty"` // Claims: claimer's entitlement (omitted for GroupRandom, drawn on chain)
 }
 
 // SignedTransactionRequest - Request to send signed transaction
-type SignedTransactionRequest struct {
-	TransactionID     string `json:"transaction_id"`
-	SignedTransaction string `json:"signed_transaction"` // base64 encoded
-}
+type SignedTransactionRequest = dto.SignedTransactionRequest
 
 // GenerateUnsignedInitUserState - Generate unsigned transaction for init_user_state
 func (c *USDCEnvelopeClient) GenerateUnsignedInitUserState(user solana.PublicKey) (*UnsignedTransactionResponse, error) {
@@ -324,12 +400,15 @@ func (c *USDCEnvelopeClient) GenerateUnsignedInitUserState(user solana.PublicKey
 		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
 	}
 
-	transactionID := fmt.Sprintf("usdc_init_%d", time.Now().UnixNano())
+	transactionID := c.issued.issue("usdc", EnvelopeActionInit)
+	c.keepUnsigned(ctx, transactionID, txBytes)
 
 	return &UnsignedTransactionResponse{
 		TransactionID:       transactionID,
-		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
+		UnsignedTransaction: c.encoding.Encode(txBytes),
+		Encoding:            c.encoding,
 		RecentBlockhash:     recent.Value.Blockhash.String(),
+		EstimatedFee:        c.estimateFee(ctx, tx),
 		Message:             "Transaction ready to be signed by user",
 	}, nil
 }
@@ -341,6 +420,21 @@ func (c *USDCEnvelopeClient) GenerateUnsignedCreateEnvelope(
 	params CreateEnvelopeParams,
 	nextEnvelopeID uint64,
 ) (*UnsignedTransactionResponse, error) {
+	if err := c.breakers.Allow(BreakerCreate); err != nil {
+		return nil, err
+	}
+
+	if err := c.validateCreateParams(params); err != nil {
+		return nil, err
+	}
+	if err := c.config.ValidateCreate(context.Background(), params.TotalAmount, params.TotalUsers); err != nil {
+		return nil, err
+	}
+
+	if err := c.VerifyTokenAccount(context.Background(), userTokenAccount, user, c.usdcMint); err != nil {
+		return nil, err
+	}
+
 	// Build instruction
 	instruction, err := c.BuildCreateEnvelopeInstruction(user, userTokenAccount, params, nextEnvelopeID)
 	if err != nil {
@@ -363,6 +457,9 @@ func (c *USDCEnvelopeClient) GenerateUnsignedCreateEnvelope(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
+	if err := checkTransactionSize(tx, params.EnvelopeType.Type); err != nil {
+		return nil, err
+	}
 
 	// Serialize transaction
 	txBytes, err := tx.MarshalBinary()
@@ -370,12 +467,21 @@ func (c *USDCEnvelopeClient) GenerateUnsignedCreateEnvelope(
 		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
 	}
 
-	transactionID := fmt.Sprintf("usdc_create_%d", time.Now().UnixNano())
+	// Claims of the envelope count against its group's cap
+	if err := c.claimCaps.IndexEnvelope(ctx, user, nextEnvelopeID, params.GroupID); err != nil {
+		return nil, fmt.Errorf("failed to index envelope: %w", err)
+	}
+
+	transactionID := c.issued.issue("usdc", chain.EnvelopeActionCreate)
+	c.keepUnsigned(ctx, transactionID, txBytes)
+	c.trackEnvelopeTx(transactionID, chain.EnvelopeActionCreate, user, nextEnvelopeID, user, params.TotalAmount, solana.PublicKey{})
 
 	return &UnsignedTransactionResponse{
 		TransactionID:       transactionID,
-		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
+		UnsignedTransaction: c.encoding.Encode(txBytes),
+		Encoding:            c.encoding,
 		RecentBlockhash:     recent.Value.Blockhash.String(),
+		EstimatedFee:        c.estimateFee(ctx, tx),
 		Message:             "Transaction ready to be signed by user",
 	}, nil
 }
@@ -384,6 +490,44 @@ func (c *USDCEnvelopeClient) GenerateUnsignedCreateEnvelope(
 func (c *USDCEnvelopeClient) GenerateUnsignedClaim(
 	params ClaimEnvelopeParams,
 ) (*UnsignedTransactionResponse, error) {
+	return c.generateUnsignedClaim(params, nil)
+}
+
+// generateUnsignedClaim - Claim paid by the claimer, or by sponsor (pre-signed) when set
+func (c *USDCEnvelopeClient) generateUnsignedClaim(
+	params ClaimEnvelopeParams,
+	sponsor *solana.PrivateKey,
+) (*UnsignedTransactionResponse, error) {
+	if err := c.breakers.Allow(BreakerClaim); err != nil {
+		return nil, err
+	}
+
+	if err := c.claimCaps.Check(context.Background(), params.Owner, params.EnvelopeID, params.Claimer); err != nil {
+		return nil, err
+	}
+
+	envelope, err := c.GetEnvelopeInfo(context.Background(), params.Owner, params.EnvelopeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope: %w", err)
+	}
+	if err := c.checkClaimWindow(context.Background(), envelope); err != nil {
+		return nil, err
+	}
+	entitlement, err := envelope.Entitlement(params.Claimer)
+	if err != nil {
+		return nil, err
+	}
+	var warning string
+	if c.claimDeadline != nil {
+		if warning, err = c.claimDeadline.Check(context.Background(), c.rpcClient, envelope.ExpiryTime); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.VerifyTokenAccount(context.Background(), params.ClaimerTokenAccount, params.Claimer, c.usdcMint); err != nil {
+		return nil, err
+	}
+
 	// Build instruction
 	instruction, err := c.BuildClaimInstruction(params)
 	if err != nil {
@@ -397,15 +541,29 @@ func (c *USDCEnvelopeClient) GenerateUnsignedClaim(
 		return nil, fmt.Errorf("failed to get recent blockhash: %w", err)
 	}
 
-	// Build transaction WITHOUT signatures
+	// Build transaction WITHOUT claimer signature
+	payer := params.Claimer
+	if sponsor != nil {
+		payer = sponsor.PublicKey()
+	}
 	tx, err := solana.NewTransaction(
 		[]solana.Instruction{instruction},
 		recent.Value.Blockhash,
-		solana.TransactionPayer(params.Claimer),
+		solana.TransactionPayer(payer),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
+	if sponsor != nil {
+		if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+			if key.Equals(payer) {
+				return sponsor
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to sign as sponsor: %w", err)
+		}
+	}
 
 	// Serialize transaction
 	txBytes, err := tx.MarshalBinary()
@@ -413,13 +571,19 @@ func (c *USDCEnvelopeClient) GenerateUnsignedClaim(
 		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
 	}
 
-	transactionID := fmt.Sprintf("usdc_claim_%d", time.Now().UnixNano())
+	transactionID := c.issued.issue("usdc", chain.EnvelopeActionClaim)
+	c.keepUnsigned(ctx, transactionID, txBytes)
+	c.trackEnvelopeTx(transactionID, chain.EnvelopeActionClaim, params.Owner, params.EnvelopeID, params.Claimer, 0, params.ClaimerTokenAccount)
 
 	return &UnsignedTransactionResponse{
 		TransactionID:       transactionID,
-		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
+		UnsignedTransaction: c.encoding.Encode(txBytes),
+		Encoding:            c.encoding,
 		RecentBlockhash:     recent.Value.Blockhash.String(),
+		EstimatedFee:        c.estimateFee(ctx, tx),
 		Message:             "Transaction ready to be signed by user",
+		Warning:             warning,
+		ClaimAmount:         entitlement,
 	}, nil
 }
 
@@ -427,6 +591,23 @@ func (c *USDCEnvelopeClient) GenerateUnsignedClaim(
 func (c *USDCEnvelopeClient) GenerateUnsignedRefund(
 	params RefundParams,
 ) (*UnsignedTransactionResponse, error) {
+	if err := c.breakers.Allow(BreakerRefund); err != nil {
+		return nil, err
+	}
+
+	if err := c.VerifyTokenAccount(context.Background(), params.OwnerTokenAccount, params.Owner, c.usdcMint); err != nil {
+		return nil, err
+	}
+
+	// Validate against the remaining balance (partial refunds)
+	envelope, err := c.GetEnvelopeInfo(context.Background(), params.Owner, params.EnvelopeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope: %w", err)
+	}
+	if _, err := envelope.ValidateRefund(params.Amount); err != nil {
+		return nil, err
+	}
+
 	// Build instruction
 	instruction, err := c.BuildRefundInstruction(params)
 	if err != nil {
@@ -456,12 +637,16 @@ func (c *USDCEnvelopeClient) GenerateUnsignedRefund(
 		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
 	}
 
-	transactionID := fmt.Sprintf("usdc_refund_%d", time.Now().UnixNano())
+	transactionID := c.issued.issue("usdc", chain.EnvelopeActionRefund)
+	c.keepUnsigned(ctx, transactionID, txBytes)
+	c.trackEnvelopeTx(transactionID, chain.EnvelopeActionRefund, params.Owner, params.EnvelopeID, params.Owner, params.Amount, params.OwnerTokenAccount)
 
 	return &UnsignedTransactionResponse{
 		TransactionID:       transactionID,
-		UnsignedTransaction: base64.StdEncoding.EncodeToString(txBytes),
+		UnsignedTransaction: c.encoding.Encode(txBytes),
+		Encoding:            c.encoding,
 		RecentBlockhash:     recent.Value.Blockhash.String(),
+		EstimatedFee:        c.estimateFee(ctx, tx),
 		Message:             "Transaction ready to be signed by user",
 	}, nil
 }
@@ -469,8 +654,16 @@ func (c *USDCEnvelopeClient) GenerateUnsignedRefund(
 // SubmitSignedTransaction - Send signed transaction to blockchain
 // Note: This is a convenience wrapper for unsigned transaction flow
 func (c *USDCEnvelopeClient) SubmitSignedTransaction(req SignedTransactionRequest) (*TransactionResult, error) {
-	// Decode signed transaction
-	txBytes, err := base64.StdEncoding.DecodeString(req.SignedTransaction)
+	if err := c.breakers.Allow(BreakerSend); err != nil {
+		return nil, err
+	}
+
+	// Decode signed transaction (encoding detected unless the request names it)
+	encoding, err := txencoding.Parse(req.Encoding, "")
+	if err != nil {
+		return nil, err
+	}
+	txBytes, _, err := txencoding.DecodeAuto(req.SignedTransaction, encoding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode signed transaction: %w", err)
 	}
@@ -487,34 +680,181 @@ func (c *USDCEnvelopeClient) SubmitSignedTransaction(req SignedTransactionReques
 		return nil, fmt.Errorf("transaction is not signed")
 	}
 
+	// Only our envelope instructions of one action (plus system/token/memo/compute budget), so our RPC
+	// quota and sponsor can't be used for arbitrary payloads. The action comes from the transaction's own
+	// instructions and must match what this server issued transaction_id for, when it knows the ID.
+	action, err := submissionAction(c.allowList, &tx, req.TransactionID, c.issuedAction(context.Background()))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.allowList.Validate(&tx, action); err != nil {
+		return nil, err
+	}
+
+	mode, err := preflight.Parse(req.Preflight)
+	if err != nil {
+		return nil, err
+	}
+	mode = c.preflight.Resolve("", mode)
+
 	// Send transaction to Solana
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	sig, err := confirm.SendAndConfirmTransaction(
-		ctx,
-		c.rpcClient,
-		c.wsClient,
-		&tx,
-	)
+	// Claims count against the cap for the envelope and claimer the transaction actually claims for
+	release, err := c.reserveClaim(ctx, &tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = preflight.Check(ctx, c.rpcClient, &tx, mode)
+	var sig solana.Signature
+	if err == nil {
+		sig, err = c.sendAndConfirm(ctx, &tx, mode.TransactionOpts())
+	}
+	preflight.Record(mode, err)
 
 	if err != nil {
-		return &TransactionResult{
+		release()
+		result := &TransactionResult{
 			Signature:   "",
 			Status:      StatusFailed,
 			Error:       stringPtr(fmt.Sprintf("Failed to send transaction: %v", err)),
 			ExplorerURL: "",
-		}, err
+			ProgramLogs: preflight.Logs(err),
+		}
+		c.history.ReportSubmissionFailure(&storage.SubmissionFailure{
+			TransactionID: req.TransactionID,
+			Chain:         chain.Solana,
+			Action:        "send",
+			Signature:     tx.Signatures[0].String(),
+			ErrorCode:     ExtractErrorCode(err),
+			Message:       err.Error(),
+			ProgramLogs:   result.ProgramLogs,
+		})
+		return result, err
 	}
 
 	signature := sig.String()
 
-	return &TransactionResult{
+	result := &TransactionResult{
 		Signature:   signature,
 		Status:      StatusFinalized,
 		Error:       nil,
 		ExplorerURL: c.getExplorerURL(signature),
-	}, nil
+	}
+	// Best effort: receipt can still be fetched later via GetSubmissionReceipt
+	if r, err := receipt.FetchSolana(ctx, c.rpcClient, signature); err == nil {
+		result.Receipt = r.WithPrices(ctx, c.prices)
+	}
+	if req.TransactionID != "" {
+		var slot uint64
+		if result.Receipt != nil {
+			slot = result.Receipt.Slot
+		}
+		c.confirmed(ctx, req.TransactionID, req.TransactionID, signature, slot, &tx)
+	}
+	return result, nil
+}
+
+// sendAndConfirm - Send tx and wait for confirmation over the websocket, or by polling signature
+// statuses when the client has none
+func (c *USDCEnvelopeClient) sendAndConfirm(ctx context.Context, tx *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error) {
+	if c.wsClient != nil {
+		return confirm.SendAndConfirmTransactionWithOpts(ctx, c.rpcClient, c.wsClient, tx, opts, nil)
+	}
+	sig, err := c.rpcClient.SendTransactionWithOpts(ctx, tx, opts)
+	if err != nil {
+		return sig, err
+	}
+	_, err = c.WaitForConfirmation(ctx, sig.String(), WaitOptions{})
+	return sig, err
+}
+
+// SetPreflightPolicy - Default preflight mode for submissions that don't request one
+func (c *USDCEnvelopeClient) SetPreflightPolicy(policy *preflight.Policy) {
+	c.preflight = policy
+}
+
+// SetPayloadEncoding - Encoding of the unsigned transactions returned by GenerateUnsigned* (default base64);
+// submissions accept any encoding
+func (c *USDCEnvelopeClient) SetPayloadEncoding(encoding txencoding.Encoding) error {
+	if !encoding.IsValid() {
+		return fmt.Errorf("invalid encoding %q", encoding)
+	}
+	c.encoding = encoding
+	return nil
+}
+
+// SetEnvelopeLayout - Envelope account layout of the deployed program (default V2; ValidateIDL and
+// DetectEnvelopeLayout set it from the IDL). V1 also drops start_time from create.
+func (c *USDCEnvelopeClient) SetEnvelopeLayout(layout EnvelopeLayout) {
+	c.layout = layout
+}
+
+// SetClaimCapPolicy - Per-group daily claim caps for the claim paths (nil = unlimited)
+func (c *USDCEnvelopeClient) SetClaimCapPolicy(policy *ClaimCapPolicy) {
+	c.claimCaps = policy
+}
+
+// reserveClaim - Reserve the claim caps for the claim in a signed transaction (nil-safe, release is a
+// no-op when the transaction claims nothing)
+func (c *USDCEnvelopeClient) reserveClaim(ctx context.Context, tx *solana.Transaction) (func(), error) {
+	if c.claimCaps == nil {
+		return func() {}, nil
+	}
+	claim, err := findSignedClaim(tx, c.programID, DiscriminatorClaim, 0, 4)
+	if err != nil || claim == nil {
+		return func() {}, err
+	}
+	owner, envelopeID, err := claimTarget(ctx, c.rpcClient, claim, c.DeriveEnvelopePDA)
+	if err != nil {
+		return nil, err
+	}
+	return c.claimCaps.Reserve(ctx, owner, envelopeID, claim.claimer)
+}
+
+// SetHistoryStore - Persist failed submissions (with program logs) and sponsorship decisions for support lookups
+func (c *USDCEnvelopeClient) SetHistoryStore(store *storage.Store) {
+	c.history = store
+	if c.sponsor != nil && c.sponsor.Scorer != nil {
+		c.sponsor.Scorer.SetStore(store)
+	}
+}
+
+// GetSubmissionReceipt - Slot, block time, fees and compute units of a landed transaction
+// (receipt.ErrNotLanded while it is still in flight)
+func (c *USDCEnvelopeClient) GetSubmissionReceipt(ctx context.Context, signature string) (*receipt.Receipt, error) {
+	r, err := receipt.FetchSolana(ctx, c.rpcClient, signature)
+	if err != nil {
+		return nil, err
+	}
+	return r.WithPrices(ctx, c.prices), nil
+}
+
+// SetTransactionStore - Keep generated unsigned transactions, encrypted at rest
+func (c *USDCEnvelopeClient) SetTransactionStore(store *storage.TransactionStore) {
+	c.txStore = store
+}
+
+// keepUnsigned - Best-effort copy of an unsigned transaction in the TransactionStore
+func (c *USDCEnvelopeClient) keepUnsigned(ctx context.Context, transactionID string, txBytes []byte) {
+	if c.txStore == nil {
+		return
+	}
+	if err := c.txStore.PutUnsignedTransaction(ctx, chain.Solana, transactionID, txBytes, storage.UnsignedTransactionTTL); err != nil {
+		log.Printf("failed to store unsigned transaction %s: %v", transactionID, err)
+	}
+}
+
+// SetPriceSource - USD prices for fee estimates and receipts (nil = SOL only)
+func (c *USDCEnvelopeClient) SetPriceSource(source pricing.Source) {
+	c.prices = source
+}
+
+// estimateFee - Fee of an unsigned transaction in SOL and USD
+func (c *USDCEnvelopeClient) estimateFee(ctx context.Context, tx *solana.Transaction) *pricing.Fee {
+	return pricing.FormatLamports(ctx, c.prices, receipt.EstimateSolanaFee(ctx, c.rpcClient, tx))
 }
 
 // stringPtr - helper to get string pointer
@@ -1,6 +1,7 @@
 package solprogram
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
@@ -21,6 +22,7 @@ var (
 	DiscriminatorCreate        = getAnchorDiscriminator("create")
 	DiscriminatorClaim         = getAnchorDiscriminator("claim")
 	DiscriminatorRefund        = getAnchorDiscriminator("refund")
+	DiscriminatorPartialRefund = getAnchorDiscriminator("partial_refund")
 	DiscriminatorCancel        = getAnchorDiscriminator("cancel")
 	DiscriminatorClose         = getAnchorDiscriminator("close")
 )
@@ -55,6 +57,11 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 	params CreateEnvelopeParams,
 	nextEnvelopeID uint64,
 ) (solana.Instruction, error) {
+	tokenProgram, err := c.TokenProgramForMint(context.Background(), c.usdcMint)
+	if err != nil {
+		return nil, err
+	}
+
 	// Derive PDAs
 	userStatePDA, _, err := c.DeriveUserStatePDA(user)
 	if err != nil {
@@ -71,8 +78,13 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 		return nil, err
 	}
 
-	// Build instruction data: discriminator (8 bytes) + envelope_type + amounts + expiry
-	data := make([]byte, 0, 8+1+32+8+8+8)
+	// V1 programs have no claim windows: their create takes no start_time
+	if c.layout == EnvelopeLayoutV1 && params.StartTime != 0 {
+		return nil, fmt.Errorf("start_time is not supported by the deployed program (envelope layout v1)")
+	}
+
+	// Build instruction data: discriminator (8 bytes) + envelope_type + amounts + expiry + start_time (V2)
+	data := make([]byte, 0, 8+1+32+8+8+8+8)
 	// Add Anchor discriminator
 	data = append(data, DiscriminatorCreate...)
 
@@ -87,6 +99,18 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 		data = append(data, params.EnvelopeType.AllowedAddress.Bytes()...)
 	}
 
+	// If CustomSplit, add recipients as Vec<(Pubkey, u64)>: length (4 bytes) + 40 bytes each
+	if params.EnvelopeType.Type == EnvelopeTypeCustomSplit {
+		if len(params.EnvelopeType.Recipients) == 0 {
+			return nil, fmt.Errorf("recipients required for CustomSplit")
+		}
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(params.EnvelopeType.Recipients)))
+		for _, recipient := range params.EnvelopeType.Recipients {
+			data = append(data, recipient.Address.Bytes()...)
+			data = binary.LittleEndian.AppendUint64(data, recipient.Amount)
+		}
+	}
+
 	// Total amount (8 bytes)
 	amountBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(amountBytes, params.TotalAmount)
@@ -102,6 +126,13 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 	binary.LittleEndian.PutUint64(expiryBytes, params.ExpirySeconds)
 	data = append(data, expiryBytes...)
 
+	// Start time (8 bytes, i64, 0 = claimable on creation)
+	if c.layout != EnvelopeLayoutV1 {
+		startBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint64(startBytes, uint64(params.StartTime))
+		data = append(data, startBytes...)
+	}
+
 	accounts := []*solana.AccountMeta{
 		solana.Meta(userStatePDA).WRITE(),
 		solana.Meta(envelopePDA).WRITE(),
@@ -109,7 +140,7 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 		solana.Meta(userTokenAccount).WRITE(),
 		solana.Meta(c.usdcMint),
 		solana.Meta(user).SIGNER().WRITE(),
-		solana.Meta(TokenProgramID),
+		solana.Meta(tokenProgram),
 		solana.Meta(SystemProgramID),
 	}
 
@@ -124,6 +155,11 @@ func (c *USDCEnvelopeClient) BuildCreateEnvelopeInstruction(
 func (c *USDCEnvelopeClient) BuildClaimInstruction(
 	params ClaimEnvelopeParams,
 ) (solana.Instruction, error) {
+	tokenProgram, err := c.TokenProgramForMint(context.Background(), c.usdcMint)
+	if err != nil {
+		return nil, err
+	}
+
 	// Derive PDAs
 	envelopePDA, _, err := c.DeriveEnvelopePDA(params.Owner, params.EnvelopeID)
 	if err != nil {
@@ -152,7 +188,7 @@ func (c *USDCEnvelopeClient) BuildClaimInstruction(
 		solana.Meta(params.ClaimerTokenAccount).WRITE(),
 		solana.Meta(claimRecordPDA).WRITE(),
 		solana.Meta(params.Claimer).SIGNER().WRITE(),
-		solana.Meta(TokenProgramID),
+		solana.Meta(tokenProgram),
 		solana.Meta(SystemProgramID),
 	}
 
@@ -163,10 +199,15 @@ func (c *USDCEnvelopeClient) BuildClaimInstruction(
 	), nil
 }
 
-// BuildRefundInstruction - Build refund instruction
+// BuildRefundInstruction - Build refund instruction (partial_refund when params.Amount is set)
 func (c *USDCEnvelopeClient) BuildRefundInstruction(
 	params RefundParams,
 ) (solana.Instruction, error) {
+	tokenProgram, err := c.TokenProgramForMint(context.Background(), c.usdcMint)
+	if err != nil {
+		return nil, err
+	}
+
 	// Derive PDAs
 	envelopePDA, _, err := c.DeriveEnvelopePDA(params.Owner, params.EnvelopeID)
 	if err != nil {
@@ -178,8 +219,14 @@ func (c *USDCEnvelopeClient) BuildRefundInstruction(
 		return nil, err
 	}
 
-	// Build instruction data - only discriminator for refund
+	// Build instruction data - only discriminator for a full refund,
+	// discriminator + amount (u64) for partial_refund (same accounts)
 	data := DiscriminatorRefund
+	if params.Amount > 0 {
+		data = make([]byte, 0, 16)
+		data = append(data, DiscriminatorPartialRefund...)
+		data = binary.LittleEndian.AppendUint64(data, params.Amount)
+	}
 
 	// Account order MUST match Rust program's Refund struct:
 	// 1. envelope, 2. envelope_vault, 3. owner_token_account,
@@ -189,7 +236,7 @@ func (c *USDCEnvelopeClient) BuildRefundInstruction(
 		solana.Meta(vaultPDA).WRITE(),
 		solana.Meta(params.OwnerTokenAccount).WRITE(),
 		solana.Meta(params.Owner).SIGNER().WRITE(),
-		solana.Meta(TokenProgramID),
+		solana.Meta(tokenProgram),
 		solana.Meta(SystemProgramID),
 	}
 
@@ -0,0 +1,256 @@
+package solprogram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/alert"
+	"blockchain/metrics"
+)
+
+// envelopeAccountDiscriminator - Anchor account discriminator of EnvelopeAccount
+var envelopeAccountDiscriminator = func() []byte {
+	h := sha256.Sum256([]byte("account:EnvelopeAccount"))
+	return h[:8]
+}()
+
+// tokenAccountAmountOffset - mint(32) + owner(32), then amount u64
+const tokenAccountAmountOffset = 64
+
+var vaultMismatches = metrics.Counter("solprogram_vault_balance_mismatches")
+
+// VaultConsistency - Vault token balance vs the envelope's RemainingAmount, read at the same slot
+type VaultConsistency struct {
+	Owner           solana.PublicKey `json:"owner"`
+	EnvelopeID      uint64           `json:"envelope_id"`
+	Vault           solana.PublicKey `json:"vault"`
+	Slot            uint64           `json:"slot"`
+	RemainingAmount uint64           `json:"remaining_amount"`
+	VaultExists     bool             `json:"vault_exists"`
+	VaultBalance    uint64           `json:"vault_balance"`
+	Difference      int64            `json:"difference"` // vault_balance - remaining_amount
+	Consistent      bool             `json:"consistent"`
+	CheckedAt       time.Time        `json:"checked_at"`
+}
+
+// CheckVaultConsistency - Compare one envelope's vault balance with its RemainingAmount (bypasses the info cache)
+func (c *USDCEnvelopeClient) CheckVaultConsistency(ctx context.Context, owner solana.PublicKey, envelopeID uint64) (*VaultConsistency, error) {
+	envelopePDA, _, err := c.DeriveEnvelopePDA(owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+	vault, _, err := c.DeriveEnvelopeVaultPDA(owner, envelopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// One call so both accounts come from the same slot; a claim landing in between can't fake a mismatch
+	accounts, err := c.rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{envelopePDA, vault}, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope accounts: %w", err)
+	}
+	if len(accounts.Value) != 2 || accounts.Value[0] == nil {
+		return nil, fmt.Errorf("envelope not found")
+	}
+	envelope, err := parseEnvelopeData(accounts.Value[0].Data.GetBinary(), c.layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+
+	check := &VaultConsistency{
+		Owner:           owner,
+		EnvelopeID:      envelopeID,
+		Vault:           vault,
+		Slot:            accounts.Context.Slot,
+		RemainingAmount: envelope.RemainingAmount,
+		CheckedAt:       time.Now(),
+	}
+	if vaultAcc := accounts.Value[1]; vaultAcc != nil {
+		data := vaultAcc.Data.GetBinary()
+		if len(data) < tokenAccountAmountOffset+8 {
+			return nil, fmt.Errorf("invalid vault token account data length: %d", len(data))
+		}
+		check.VaultExists = true
+		check.VaultBalance = binary.LittleEndian.Uint64(data[tokenAccountAmountOffset:])
+	}
+	check.Difference = int64(check.VaultBalance) - int64(check.RemainingAmount)
+	check.Consistent = check.Difference == 0 && (check.VaultExists || check.RemainingAmount == 0)
+	return check, nil
+}
+
+// listActiveEnvelopes - All envelopes of the program that still hold funds
+func (c *USDCEnvelopeClient) listActiveEnvelopes(ctx context.Context) ([]*EnvelopeInfo, error) {
+	accounts, err := c.rpcClient.GetProgramAccountsWithOpts(ctx, c.programID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: envelopeAccountDiscriminator}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get envelope accounts: %w", err)
+	}
+
+	envelopes := make([]*EnvelopeInfo, 0, len(accounts))
+	for _, acc := range accounts {
+		envelope, err := parseEnvelopeData(acc.Account.Data.GetBinary(), c.layout)
+		if err != nil || envelope.IsCancelled || envelope.RemainingAmount == 0 {
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, nil
+}
+
+// VaultChecker - Periodic vault balance invariant check over all active envelopes
+type VaultChecker struct {
+	client   *USDCEnvelopeClient
+	alerter  alert.Alerter
+	interval time.Duration
+
+	mu         sync.Mutex
+	mismatches map[string]*VaultConsistency
+}
+
+// NewVaultChecker - Create checker (interval default 10m)
+func NewVaultChecker(client *USDCEnvelopeClient, alerter alert.Alerter, interval time.Duration) *VaultChecker {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	if alerter == nil {
+		alerter = alert.LogAlerter{}
+	}
+	return &VaultChecker{
+		client:     client,
+		alerter:    alerter,
+		interval:   interval,
+		mismatches: make(map[string]*VaultConsistency),
+	}
+}
+
+// Run - Check until ctx is cancelled
+func (v *VaultChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := v.CheckAll(ctx); err != nil {
+			log.Printf("⚠️  vault checker: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll - Check every active envelope, alert on new mismatches and return the current ones
+func (v *VaultChecker) CheckAll(ctx context.Context) ([]*VaultConsistency, error) {
+	envelopes, err := v.client.listActiveEnvelopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]*VaultConsistency)
+	for _, envelope := range envelopes {
+		check, err := v.client.CheckVaultConsistency(ctx, envelope.Owner, envelope.EnvelopeID)
+		if err != nil {
+			log.Printf("⚠️  vault check %s #%d: %v", envelope.Owner, envelope.EnvelopeID, err)
+			continue
+		}
+		if check.Consistent {
+			continue
+		}
+		current[envelopeCacheKey(envelope.Owner, envelope.EnvelopeID)] = check
+	}
+
+	v.mu.Lock()
+	previous := v.mismatches
+	v.mismatches = current
+	v.mu.Unlock()
+
+	result := make([]*VaultConsistency, 0, len(current))
+	for key, check := range current {
+		result = append(result, check)
+		if _, known := previous[key]; known {
+			continue // already alerted
+		}
+		vaultMismatches.Add(1)
+		v.notify(ctx, check)
+	}
+	return result, nil
+}
+
+// Mismatches - Mismatches found by the last CheckAll
+func (v *VaultChecker) Mismatches() []*VaultConsistency {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	result := make([]*VaultConsistency, 0, len(v.mismatches))
+	for _, check := range v.mismatches {
+		result = append(result, check)
+	}
+	return result
+}
+
+func (v *VaultChecker) notify(ctx context.Context, check *VaultConsistency) {
+	err := v.alerter.Send(ctx, alert.Alert{
+		Severity: alert.SeverityCritical,
+		Source:   "solprogram.vault_checker",
+		Title:    "Envelope vault balance mismatch",
+		Message: fmt.Sprintf("envelope %s #%d: vault holds %d, envelope expects %d (diff %d) at slot %d",
+			check.Owner, check.EnvelopeID, check.VaultBalance, check.RemainingAmount, check.Difference, check.Slot),
+		Labels: map[string]string{
+			"owner":       check.Owner.String(),
+			"envelope_id": strconv.FormatUint(check.EnvelopeID, 10),
+			"vault":       check.Vault.String(),
+		},
+		Time: check.CheckedAt,
+	})
+	if err != nil {
+		log.Printf("⚠️  vault checker alert failed: %v", err)
+	}
+}
+
+// HandleGetVaultConsistency - GET /api/v1/envelopes/vault-consistency?owner=xxx&envelope_id=1
+// (without parameters: mismatches found by the last run)
+func (v *VaultChecker) HandleGetVaultConsistency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	if query.Get("owner") == "" {
+		json.NewEncoder(w).Encode(v.Mismatches())
+		return
+	}
+	owner, err := solana.PublicKeyFromBase58(query.Get("owner"))
+	if err != nil {
+		http.Error(w, "Invalid owner", http.StatusBadRequest)
+		return
+	}
+	envelopeID, err := strconv.ParseUint(query.Get("envelope_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid envelope_id", http.StatusBadRequest)
+		return
+	}
+	check, err := v.client.CheckVaultConsistency(r.Context(), owner, envelopeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	json.NewEncoder(w).Encode(check)
+}
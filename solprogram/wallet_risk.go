@@ -0,0 +1,293 @@
+package solprogram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"blockchain/storage"
+)
+
+// Wallet risk signals
+const (
+	RiskSignalNoHistory           = "no_history"
+	RiskSignalNewWallet           = "new_wallet"
+	RiskSignalLowActivity         = "low_activity"
+	RiskSignalBlockedFunder       = "blocked_funder"
+	RiskSignalSharedFunder        = "shared_funder"
+	RiskSignalProvider            = "provider"
+	RiskSignalProviderUnavailable = "provider_unavailable"
+)
+
+// Signal weights (scores are capped at 100)
+var walletRiskWeights = map[string]int{
+	RiskSignalNoHistory:     60,
+	RiskSignalNewWallet:     35,
+	RiskSignalLowActivity:   20,
+	RiskSignalBlockedFunder: 60,
+	RiskSignalSharedFunder:  40,
+}
+
+// WalletRiskConfig - Thresholds of the built-in heuristics
+type WalletRiskConfig struct {
+	MinWalletAge         time.Duration   // Younger wallets are flagged new_wallet (default 72h)
+	MinPriorTransactions int             // Fewer signatures are flagged low_activity (default 5)
+	MaxHistoryPages      int             // getSignaturesForAddress pages (1000 each) before a wallet counts as established (default 3)
+	MaxFunderFanOut      int             // Sponsored wallets per funder within FanOutWindow before shared_funder (default 5)
+	FanOutWindow         time.Duration   // Default 24h
+	BlockedFunders       map[string]bool // Known farm funders
+}
+
+// DefaultWalletRiskConfig - Default heuristic thresholds
+var DefaultWalletRiskConfig = WalletRiskConfig{
+	MinWalletAge:         72 * time.Hour,
+	MinPriorTransactions: 5,
+	MaxHistoryPages:      3,
+	MaxFunderFanOut:      5,
+	FanOutWindow:         24 * time.Hour,
+}
+
+// WalletRiskProvider - External risk provider; scores are 0 (safe) - 100 (sybil)
+type WalletRiskProvider interface {
+	WalletRiskScore(ctx context.Context, wallet string) (int, error)
+}
+
+// HTTPRiskProvider - GET <URL>?wallet=<address> returning {"score": N}
+type HTTPRiskProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+var _ WalletRiskProvider = HTTPRiskProvider{}
+
+// WalletRiskScore - Score from the provider
+func (p HTTPRiskProvider) WalletRiskScore(ctx context.Context, wallet string) (int, error) {
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid risk provider URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("wallet", wallet)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("risk provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("risk provider returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Score int `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("invalid risk provider response: %w", err)
+	}
+	return min(max(body.Score, 0), 100), nil
+}
+
+// WalletRisk - Risk assessment of one wallet
+type WalletRisk struct {
+	Wallet            string     `json:"wallet"`
+	Score             int        `json:"score"` // 0 (safe) - 100 (sybil)
+	Signals           []string   `json:"signals,omitempty"`
+	FirstSeen         *time.Time `json:"first_seen,omitempty"` // Nil for established wallets (history not fully scanned)
+	PriorTransactions int        `json:"prior_transactions"`   // Lower bound for established wallets
+	FundedBy          string     `json:"funded_by,omitempty"`  // Fee payer of the wallet's first transaction
+	ProviderScore     *int       `json:"provider_score,omitempty"`
+}
+
+func (r *WalletRisk) flag(signal string) {
+	r.Signals = append(r.Signals, signal)
+	r.Score = min(r.Score+walletRiskWeights[signal], 100)
+}
+
+// WalletRiskScorer - Scores wallets from on-chain history, funding source and an optional provider
+type WalletRiskScorer struct {
+	rpc      *rpc.Client
+	cfg      WalletRiskConfig
+	provider WalletRiskProvider
+	store    *storage.Store // Fan-out from recorded decisions when set (survives restarts)
+
+	mu        sync.Mutex
+	sponsored map[string]map[string]time.Time // funder -> wallet -> sponsored at
+}
+
+// NewWalletRiskScorer - Scorer over the RPC; zero config fields take DefaultWalletRiskConfig, provider may be nil
+func NewWalletRiskScorer(rpcClient *rpc.Client, cfg WalletRiskConfig, provider WalletRiskProvider) *WalletRiskScorer {
+	if cfg.MinWalletAge <= 0 {
+		cfg.MinWalletAge = DefaultWalletRiskConfig.MinWalletAge
+	}
+	if cfg.MinPriorTransactions <= 0 {
+		cfg.MinPriorTransactions = DefaultWalletRiskConfig.MinPriorTransactions
+	}
+	if cfg.MaxHistoryPages <= 0 {
+		cfg.MaxHistoryPages = DefaultWalletRiskConfig.MaxHistoryPages
+	}
+	if cfg.MaxFunderFanOut <= 0 {
+		cfg.MaxFunderFanOut = DefaultWalletRiskConfig.MaxFunderFanOut
+	}
+	if cfg.FanOutWindow <= 0 {
+		cfg.FanOutWindow = DefaultWalletRiskConfig.FanOutWindow
+	}
+	return &WalletRiskScorer{
+		rpc:       rpcClient,
+		cfg:       cfg,
+		provider:  provider,
+		sponsored: make(map[string]map[string]time.Time),
+	}
+}
+
+// SetStore - Count funder fan-out from recorded sponsorship decisions
+func (s *WalletRiskScorer) SetStore(store *storage.Store) {
+	s.store = store
+}
+
+// Score - Assess a wallet. RPC errors are returned; provider errors only add provider_unavailable.
+func (s *WalletRiskScorer) Score(ctx context.Context, wallet solana.PublicKey) (*WalletRisk, error) {
+	risk := &WalletRisk{Wallet: wallet.String()}
+
+	// Walk history back to the first transaction, up to MaxHistoryPages
+	const pageSize = 1000
+	limit := pageSize
+	var oldest *rpc.TransactionSignature
+	established := false
+	opts := &rpc.GetSignaturesForAddressOpts{Limit: &limit, Commitment: rpc.CommitmentFinalized}
+	for page := 0; ; page++ {
+		sigs, err := s.rpc.GetSignaturesForAddressWithOpts(ctx, wallet, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wallet history: %w", err)
+		}
+		risk.PriorTransactions += len(sigs)
+		if len(sigs) > 0 {
+			oldest = sigs[len(sigs)-1]
+			opts.Before = oldest.Signature
+		}
+		if len(sigs) < pageSize {
+			break
+		}
+		if page+1 >= s.cfg.MaxHistoryPages {
+			established = true
+			break
+		}
+	}
+
+	switch {
+	case risk.PriorTransactions == 0:
+		risk.flag(RiskSignalNoHistory)
+	case !established:
+		if oldest.BlockTime != nil {
+			firstSeen := oldest.BlockTime.Time()
+			risk.FirstSeen = &firstSeen
+			if time.Since(firstSeen) < s.cfg.MinWalletAge {
+				risk.flag(RiskSignalNewWallet)
+			}
+		}
+		if risk.PriorTransactions < s.cfg.MinPriorTransactions {
+			risk.flag(RiskSignalLowActivity)
+		}
+		risk.FundedBy = s.funder(ctx, wallet, oldest.Signature)
+	}
+
+	if risk.FundedBy != "" {
+		if s.cfg.BlockedFunders[risk.FundedBy] {
+			risk.flag(RiskSignalBlockedFunder)
+		}
+		if s.fanOut(ctx, risk.FundedBy, risk.Wallet) >= s.cfg.MaxFunderFanOut {
+			risk.flag(RiskSignalSharedFunder)
+		}
+	}
+
+	if s.provider != nil {
+		score, err := s.provider.WalletRiskScore(ctx, risk.Wallet)
+		if err != nil {
+			risk.Signals = append(risk.Signals, RiskSignalProviderUnavailable)
+		} else {
+			risk.ProviderScore = &score
+			if score > risk.Score {
+				risk.Score = score
+				risk.Signals = append(risk.Signals, RiskSignalProvider)
+			}
+		}
+	}
+	return risk, nil
+}
+
+// funder - Fee payer of the wallet's first transaction, when it is another wallet ("" if unknown)
+func (s *WalletRiskScorer) funder(ctx context.Context, wallet solana.PublicKey, first solana.Signature) string {
+	maxVersion := uint64(0)
+	result, err := s.rpc.GetTransaction(ctx, first, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil || result == nil || result.Transaction == nil {
+		return ""
+	}
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil || len(tx.Message.AccountKeys) == 0 {
+		return ""
+	}
+	if payer := tx.Message.AccountKeys[0]; !payer.Equals(wallet) {
+		return payer.String()
+	}
+	return ""
+}
+
+// fanOut - Other wallets sponsored within the window that share this funder
+func (s *WalletRiskScorer) fanOut(ctx context.Context, funder, wallet string) int {
+	since := time.Now().Add(-s.cfg.FanOutWindow)
+	if s.store != nil {
+		if count, err := s.store.CountSponsoredByFunder(ctx, funder, wallet, since); err == nil {
+			return int(count)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for w, at := range s.sponsored[funder] {
+		if w != wallet && at.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// noteSponsored - Remember a sponsored wallet for in-memory fan-out counting
+func (s *WalletRiskScorer) noteSponsored(risk *WalletRisk) {
+	if risk.FundedBy == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := time.Now().Add(-s.cfg.FanOutWindow)
+	wallets := s.sponsored[risk.FundedBy]
+	if wallets == nil {
+		wallets = make(map[string]time.Time)
+		s.sponsored[risk.FundedBy] = wallets
+	}
+	for w, at := range wallets {
+		if at.Before(since) {
+			delete(wallets, w)
+		}
+	}
+	wallets[risk.Wallet] = time.Now()
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"blockchain/chain"
+)
+
+// RecordAudit - Append an entry to the audit trail
+func (s *Store) RecordAudit(ctx context.Context, entry *AuditLog) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if entry.Action == "" {
+		return fmt.Errorf("action required")
+	}
+	return s.db.WithContext(ctx).Create(entry).Error
+}
+
+// RecordRemediation - Audit an admin remediation of a stuck transaction (action "stuck.<action>").
+// Always logged; also written to the audit trail when s is a configured store.
+func (s *Store) RecordRemediation(ctx context.Context, c chain.ChainID, operator, action, transactionID, outcome string) {
+	if operator == "" {
+		operator = "admin"
+	}
+	log.Printf("🛠️ %s stuck transaction %s: %s by %s: %s", c, transactionID, action, operator, outcome)
+	if s == nil || s.db == nil {
+		return
+	}
+	if err := s.RecordAudit(ctx, &AuditLog{
+		Actor:      operator,
+		Action:     "stuck." + action,
+		ResourceID: transactionID,
+		Detail:     fmt.Sprintf("chain=%s %s", c, outcome),
+	}); err != nil {
+		log.Printf("failed to audit %s of %s: %v", action, transactionID, err)
+	}
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"blockchain/chain"
+)
+
+// GetEnvelopeGroup - Group of an indexed envelope ("" when not indexed or not in a group)
+func (s *Store) GetEnvelopeGroup(ctx context.Context, c chain.ChainID, ownerAddress string, envelopeID uint64) (string, error) {
+	if s.db == nil {
+		return "", fmt.Errorf("database not configured")
+	}
+
+	var metadata EnvelopeMetadata
+	err := s.db.WithContext(ctx).
+		Where("chain = ? AND owner_address = ? AND envelope_id = ?", c, ownerAddress, envelopeID).
+		Order("id DESC").
+		First(&metadata).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return metadata.GroupID, err
+}
+
+// GetGroupClaimCap - Admin cap of a group (found = false when none is set)
+func (s *Store) GetGroupClaimCap(ctx context.Context, groupID string) (*GroupClaimCap, bool, error) {
+	if s.db == nil {
+		return nil, false, fmt.Errorf("database not configured")
+	}
+
+	var claimCap GroupClaimCap
+	err := s.db.WithContext(ctx).Where("group_id = ?", groupID).First(&claimCap).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &claimCap, true, nil
+}
+
+// SetGroupClaimCap - Create or replace the cap of a group
+func (s *Store) SetGroupClaimCap(ctx context.Context, claimCap *GroupClaimCap) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if claimCap.GroupID == "" {
+		return fmt.Errorf("group_id required")
+	}
+	if claimCap.MaxPerDay < 0 {
+		return fmt.Errorf("max_per_day must not be negative")
+	}
+	return s.db.WithContext(ctx).Save(claimCap).Error
+}
+
+// DeleteGroupClaimCap - Remove a group's cap (the default applies again)
+func (s *Store) DeleteGroupClaimCap(ctx context.Context, groupID string) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return s.db.WithContext(ctx).Where("group_id = ?", groupID).Delete(&GroupClaimCap{}).Error
+}
+
+// ListGroupClaimCaps - All admin caps
+func (s *Store) ListGroupClaimCaps(ctx context.Context) ([]GroupClaimCap, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	var caps []GroupClaimCap
+	err := s.db.WithContext(ctx).Order("group_id ASC").Find(&caps).Error
+	return caps, err
+}
+
+// GetGroupClaimCount - Claims of claimer in group on day (YYYY-MM-DD)
+func (s *Store) GetGroupClaimCount(ctx context.Context, groupID, claimer, day string) (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+
+	var count GroupClaimCount
+	err := s.db.WithContext(ctx).
+		Where("group_id = ? AND claimer = ? AND day = ?", groupID, claimer, day).
+		First(&count).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return count.Count, err
+}
+
+// ReserveGroupClaimCount - Atomically count one more claim of claimer in group on day unless that
+// would exceed limit; used is the count after a reservation, or the current count when refused
+func (s *Store) ReserveGroupClaimCount(ctx context.Context, groupID, claimer, day string, limit int) (used int, ok bool, err error) {
+	if s.db == nil {
+		return 0, false, fmt.Errorf("database not configured")
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&GroupClaimCount{GroupID: groupID, Claimer: claimer, Day: day}).Error
+		if err != nil {
+			return err
+		}
+		// Conditional increment: concurrent reservations serialize on the row and re-check the count
+		result := tx.Model(&GroupClaimCount{}).
+			Where("group_id = ? AND claimer = ? AND day = ? AND count < ?", groupID, claimer, day, limit).
+			Updates(map[string]interface{}{"count": gorm.Expr("count + 1"), "updated_at": gorm.Expr("CURRENT_TIMESTAMP")})
+		if result.Error != nil {
+			return result.Error
+		}
+		ok = result.RowsAffected == 1
+
+		var count GroupClaimCount
+		if err := tx.Where("group_id = ? AND claimer = ? AND day = ?", groupID, claimer, day).First(&count).Error; err != nil {
+			return err
+		}
+		used = count.Count
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return used, ok, nil
+}
+
+// ReleaseGroupClaimCount - Give back a reservation whose claim did not land
+func (s *Store) ReleaseGroupClaimCount(ctx context.Context, groupID, claimer, day string) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return s.db.WithContext(ctx).Model(&GroupClaimCount{}).
+		Where("group_id = ? AND claimer = ? AND day = ? AND count > 0", groupID, claimer, day).
+		Updates(map[string]interface{}{"count": gorm.Expr("count - 1"), "updated_at": gorm.Expr("CURRENT_TIMESTAMP")}).Error
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReserveGroupClaimCountConcurrent(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "caps.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	const limit = 3
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := store.ReserveGroupClaimCount(context.Background(), "group", "alice", "2026-01-02", limit)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := reserved.Load(); got != limit {
+		t.Fatalf("reserved %d claims, want %d", got, limit)
+	}
+
+	ctx := context.Background()
+	if used, ok, err := store.ReserveGroupClaimCount(ctx, "group", "alice", "2026-01-02", limit); err != nil || ok || used != limit {
+		t.Fatalf("reserve past the limit = (%d, %v, %v), want (%d, false, nil)", used, ok, err, limit)
+	}
+	if err := store.ReleaseGroupClaimCount(ctx, "group", "alice", "2026-01-02"); err != nil {
+		t.Fatal(err)
+	}
+	if used, ok, err := store.ReserveGroupClaimCount(ctx, "group", "alice", "2026-01-02", limit); err != nil || !ok || used != limit {
+		t.Fatalf("reserve after release = (%d, %v, %v), want (%d, true, nil)", used, ok, err, limit)
+	}
+	if used, ok, err := store.ReserveGroupClaimCount(ctx, "group", "bob", "2026-01-02", limit); err != nil || !ok || used != 1 {
+		t.Fatalf("reserve of another claimer = (%d, %v, %v), want (1, true, nil)", used, ok, err)
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"blockchain/chain"
+)
+
+// GetEnvelopeMetadataByOwner - Indexed envelopes of an owner on a chain, newest first
+func (s *Store) GetEnvelopeMetadataByOwner(ctx context.Context, c chain.ChainID, ownerAddress string) ([]EnvelopeMetadata, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	var envelopes []EnvelopeMetadata
+	err := s.db.WithContext(ctx).
+		Where("chain = ? AND owner_address = ?", c, ownerAddress).
+		Order("envelope_id DESC").
+		Find(&envelopes).Error
+	return envelopes, err
+}
+
+// IndexEnvelope - Record the group of an envelope, one row per (chain, owner, envelope_id); a later
+// create of the same envelope ID (the earlier one never landed) replaces the group
+func (s *Store) IndexEnvelope(ctx context.Context, metadata *EnvelopeMetadata) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing EnvelopeMetadata
+		err := tx.Where("chain = ? AND owner_address = ? AND envelope_id = ?",
+			metadata.Chain, metadata.OwnerAddress, metadata.EnvelopeID).
+			Order("id DESC").
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(metadata).Error
+		}
+		if err != nil {
+			return err
+		}
+		metadata.ID = existing.ID
+		return tx.Model(&existing).Update("group_id", metadata.GroupID).Error
+	})
+}
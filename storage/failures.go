@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// RecordSubmissionFailure - Persist error and program logs of a failed submission,
+// keyed by the signature when the client sent no transaction ID
+func (s *Store) RecordSubmissionFailure(ctx context.Context, failure *SubmissionFailure) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if failure.TransactionID == "" {
+		failure.TransactionID = failure.Signature
+	}
+	if failure.TransactionID == "" {
+		return fmt.Errorf("transaction_id or signature required")
+	}
+	return s.db.WithContext(ctx).Create(failure).Error
+}
+
+// ReportSubmissionFailure - Best-effort RecordSubmissionFailure for send paths: logs instead of failing,
+// no-op on a nil Store. Returns the key the failure was stored under ("" when it wasn't).
+func (s *Store) ReportSubmissionFailure(failure *SubmissionFailure) string {
+	if s == nil {
+		return ""
+	}
+	if err := s.RecordSubmissionFailure(context.Background(), failure); err != nil {
+		log.Printf("failed to record submission failure %s: %v", failure.TransactionID, err)
+		return ""
+	}
+	return failure.TransactionID
+}
+
+// GetSubmissionFailures - All recorded failures of a transaction, oldest first
+func (s *Store) GetSubmissionFailures(ctx context.Context, transactionID string) ([]SubmissionFailure, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	var failures []SubmissionFailure
+	err := s.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&failures).Error
+	return failures, err
+}
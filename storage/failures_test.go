@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReportSubmissionFailureKeyedBySignature(t *testing.T) {
+	var none *Store
+	if key := none.ReportSubmissionFailure(&SubmissionFailure{Signature: "sig"}); key != "" {
+		t.Errorf("nil store stored failure under %q", key)
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "failures.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if key := store.ReportSubmissionFailure(&SubmissionFailure{Message: "no key"}); key != "" {
+		t.Errorf("failure without transaction ID or signature stored under %q", key)
+	}
+	key := store.ReportSubmissionFailure(&SubmissionFailure{Signature: "5sig", Action: "send", Message: "blockhash not found"})
+	if key != "5sig" {
+		t.Fatalf("key = %q, want the signature", key)
+	}
+	failures, err := store.GetSubmissionFailures(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || failures[0].Message != "blockhash not found" {
+		t.Errorf("failures = %+v", failures)
+	}
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"blockchain/dto"
+)
+
+// ErrorResponse - Standard error response
+type ErrorResponse = dto.ErrorResponse
+
+// HandleEraseUserData - POST /api/v1/privacy/erase
+func (s *Store) HandleEraseUserData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ErasureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" || req.RequestedBy == "" {
+		respondError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	record, err := s.EraseUserData(r.Context(), req)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, record, http.StatusOK)
+}
+
+// HandleGetDeletionRecords - GET /api/v1/privacy/deletions?user_id=xxx
+func (s *Store) HandleGetDeletionRecords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		respondError(w, "user_id parameter required", http.StatusBadRequest)
+		return
+	}
+	records, err := s.GetDeletionRecords(r.Context(), userID)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, records, http.StatusOK)
+}
+
+// HandleGetSubmissionFailures - GET /api/v1/submissions/failures?transaction_id=xxx
+func (s *Store) HandleGetSubmissionFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	transactionID := r.URL.Query().Get("transaction_id")
+	if transactionID == "" {
+		respondError(w, "transaction_id parameter required", http.StatusBadRequest)
+		return
+	}
+	failures, err := s.GetSubmissionFailures(r.Context(), transactionID)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(failures) == 0 {
+		respondError(w, "no failures recorded for transaction", http.StatusNotFound)
+		return
+	}
+	respondJSON(w, failures, http.StatusOK)
+}
+
+// HandleClaimCaps - GET lists group claim caps, PUT sets one, DELETE ?group_id=xxx removes one
+// (/admin/claim-caps)
+func (s *Store) HandleClaimCaps(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		caps, err := s.ListGroupClaimCaps(r.Context())
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, caps, http.StatusOK)
+
+	case http.MethodPut:
+		var claimCap GroupClaimCap
+		if err := json.NewDecoder(r.Body).Decode(&claimCap); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if claimCap.GroupID == "" || claimCap.UpdatedBy == "" {
+			respondError(w, "Missing required fields", http.StatusBadRequest)
+			return
+		}
+		if claimCap.MaxPerDay < 0 {
+			respondError(w, "max_per_day must not be negative", http.StatusBadRequest)
+			return
+		}
+		if err := s.SetGroupClaimCap(r.Context(), &claimCap); err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, claimCap, http.StatusOK)
+
+	case http.MethodDelete:
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			respondError(w, "group_id parameter required", http.StatusBadRequest)
+			return
+		}
+		if err := s.DeleteGroupClaimCap(r.Context(), groupID); err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Helper functions
+func respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, message string, status int) {
+	respondJSON(w, ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	}, status)
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blockchain/metrics"
+)
+
+// Default slow-query thresholds
+const (
+	DefaultSlowQueryThreshold     = 200 * time.Millisecond
+	DefaultVerySlowQueryThreshold = time.Second
+)
+
+// instrumentStartKey - Statement instance key holding the query start time
+const instrumentStartKey = "storage:instrument_start"
+
+// InstrumentConfig - Query instrumentation settings
+type InstrumentConfig struct {
+	SlowThreshold     time.Duration            // Log queries slower than this (default 200ms)
+	VerySlowThreshold time.Duration            // Log with bound values as well (default 1s)
+	Thresholds        map[string]time.Duration // Per-operation slow threshold override ("query", "create", "update", "delete", "row", "raw")
+	Logger            *log.Logger              // Slow-query log (std logger when nil)
+}
+
+// Instrument - Register gorm callbacks recording per-operation latency, errors and slow queries,
+// and publish connection-pool stats as the db_pool expvar. Call once per DB.
+func Instrument(db *gorm.DB, cfg InstrumentConfig) error {
+	if db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if cfg.SlowThreshold <= 0 {
+		cfg.SlowThreshold = DefaultSlowQueryThreshold
+	}
+	if cfg.VerySlowThreshold <= 0 {
+		cfg.VerySlowThreshold = DefaultVerySlowQueryThreshold
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+
+	type registrar interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+	cb := db.Callback()
+	hooks := []struct {
+		op            string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, h := range hooks {
+		if err := h.before.Register("storage:instrument_before_"+h.op, startTimer); err != nil {
+			return fmt.Errorf("failed to register %s callback: %w", h.op, err)
+		}
+		if err := h.after.Register("storage:instrument_after_"+h.op, cfg.observe(h.op)); err != nil {
+			return fmt.Errorf("failed to register %s callback: %w", h.op, err)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	metrics.Func("db_pool", func() any { return sqlDB.Stats() })
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(instrumentStartKey, time.Now())
+}
+
+// observe - After-callback for one operation
+func (cfg InstrumentConfig) observe(op string) func(*gorm.DB) {
+	threshold := cfg.SlowThreshold
+	if t, ok := cfg.Thresholds[op]; ok && t > 0 {
+		threshold = t
+	}
+
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(instrumentStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start)
+
+		metrics.Counter("db_queries_" + op).Add(1)
+		metrics.Counter("db_query_latency_ms_total_" + op).Add(elapsed.Milliseconds())
+		metrics.Gauge("db_query_last_latency_ms_" + op).Set(elapsed.Milliseconds())
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			metrics.Counter("db_query_errors_" + op).Add(1)
+		}
+		if elapsed < threshold {
+			return
+		}
+
+		metrics.Counter("db_slow_queries_" + op).Add(1)
+		sql := db.Statement.SQL.String()
+		if elapsed >= cfg.VerySlowThreshold {
+			// Bound values only past the second threshold, they may hold user data
+			sql = db.Dialector.Explain(sql, db.Statement.Vars...)
+		}
+		cfg.Logger.Printf("slow query: op=%s table=%s elapsed=%s rows=%d err=%v sql=%s",
+			op, db.Statement.Table, elapsed, db.RowsAffected, db.Error, sql)
+	}
+}
+
+// Ping - Database health probe (for /readyz)
+func (s *Store) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"time"
+
+	"blockchain/chain"
+)
+
+// EnvelopeMetadata - Off-chain metadata attached to an on-chain envelope
+type EnvelopeMetadata struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	Chain        chain.ChainID `gorm:"index;size:20" json:"chain"`
+	EnvelopeID   uint64        `gorm:"index" json:"envelope_id"`
+	OwnerAddress string        `gorm:"index;size:64" json:"owner_address"` // On-chain reference, never erased
+	Signature    string        `gorm:"index;size:88" json:"signature"`     // On-chain reference, never erased
+	UserID       string        `gorm:"index;size:64" json:"user_id"`
+	GroupID      string        `gorm:"index;size:64" json:"group_id"`
+	Remarks      string        `gorm:"type:text" json:"remarks"`
+	AnonymizedAt *time.Time    `json:"anonymized_at,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+func (EnvelopeMetadata) TableName() string {
+	return "envelope_metadata"
+}
+
+// AddressBookEntry - Saved recipient of a user
+type AddressBookEntry struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	UserID    string        `gorm:"index;size:64" json:"user_id"`
+	Chain     chain.ChainID `gorm:"size:20" json:"chain"`
+	Label     string        `gorm:"size:128" json:"label"`
+	Address   string        `gorm:"size:64" json:"address"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func (AddressBookEntry) TableName() string {
+	return "address_book_entries"
+}
+
+// AuditLog - Audit trail entry
+type AuditLog struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Actor        string     `gorm:"index;size:64" json:"actor"`
+	UserID       string     `gorm:"index;size:64" json:"user_id"`
+	Action       string     `gorm:"index;size:64" json:"action"`
+	ResourceID   string     `gorm:"index;size:128" json:"resource_id"`
+	IPAddress    string     `gorm:"size:64" json:"ip_address,omitempty"`
+	UserAgent    string     `gorm:"size:256" json:"user_agent,omitempty"`
+	Detail       string     `gorm:"type:text" json:"detail,omitempty"`
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// SubmissionFailure - Error and program logs of a failed submission, kept for support lookups
+type SubmissionFailure struct {
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	TransactionID string        `gorm:"index;size:128" json:"transaction_id"`
+	Chain         chain.ChainID `gorm:"index;size:20" json:"chain"`
+	Action        string        `gorm:"size:32" json:"action,omitempty"` // send, claim
+	Signature     string        `gorm:"index;size:88" json:"signature,omitempty"`
+	ErrorCode     *int          `json:"error_code,omitempty"`
+	Message       string        `gorm:"type:text" json:"message"`
+	ProgramLogs   []string      `gorm:"serializer:json;type:text" json:"program_logs"`
+	CreatedAt     time.Time     `gorm:"index" json:"created_at"`
+}
+
+func (SubmissionFailure) TableName() string {
+	return "submission_failures"
+}
+
+// GroupClaimCap - Admin-set limit of envelopes a wallet may claim per group per day
+type GroupClaimCap struct {
+	GroupID   string    `gorm:"primaryKey;size:64" json:"group_id"`
+	MaxPerDay int       `json:"max_per_day"` // 0 = unlimited
+	UpdatedBy string    `gorm:"size:64" json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (GroupClaimCap) TableName() string {
+	return "group_claim_caps"
+}
+
+// GroupClaimCount - Claims of one wallet in one group on one (UTC) day
+type GroupClaimCount struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GroupID   string    `gorm:"uniqueIndex:idx_group_claimer_day;size:64" json:"group_id"`
+	Claimer   string    `gorm:"uniqueIndex:idx_group_claimer_day;size:64" json:"claimer"` // On-chain reference
+	Day       string    `gorm:"uniqueIndex:idx_group_claimer_day;size:10" json:"day"`     // YYYY-MM-DD
+	Count     int       `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (GroupClaimCount) TableName() string {
+	return "group_claim_counts"
+}
+
+// SponsorshipDecision - Risk score of a wallet and whether its fees were sponsored
+type SponsorshipDecision struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	Chain      chain.ChainID `gorm:"index;size:20" json:"chain"`
+	Wallet     string        `gorm:"index;size:64" json:"wallet"` // On-chain reference
+	Action     string        `gorm:"size:32" json:"action"`       // claim
+	Owner      string        `gorm:"size:64" json:"owner,omitempty"`
+	EnvelopeID uint64        `json:"envelope_id,omitempty"`
+	Score      int           `json:"score"` // 0 (safe) - 100 (sybil)
+	Signals    []string      `gorm:"serializer:json;type:text" json:"signals"`
+	FundedBy   string        `gorm:"index;size:64" json:"funded_by,omitempty"`
+	Sponsored  bool          `gorm:"index" json:"sponsored"`
+	Reason     string        `gorm:"size:256" json:"reason,omitempty"`
+	CreatedAt  time.Time     `gorm:"index" json:"created_at"`
+}
+
+func (SponsorshipDecision) TableName() string {
+	return "sponsorship_decisions"
+}
+
+// DeletionRecord - Proof that an erasure request was executed (contains no PII)
+type DeletionRecord struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	RequestID            string    `gorm:"uniqueIndex;size:64" json:"request_id"`
+	SubjectRef           string    `gorm:"index;size:64" json:"subject_ref"` // Pseudonym of the user, see Pseudonymize
+	Mode                 string    `gorm:"size:20" json:"mode"`
+	RequestedBy          string    `gorm:"size:64" json:"requested_by"`
+	Reason               string    `gorm:"size:256" json:"reason,omitempty"`
+	MetadataAnonymized   int64     `json:"metadata_anonymized"`
+	AddressBookRemoved   int64     `json:"address_book_removed"`
+	AuditLogsAnonymized  int64     `json:"audit_logs_anonymized"`
+	OnChainRefsPreserved int64     `json:"on_chain_refs_preserved"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func (DeletionRecord) TableName() string {
+	return "deletion_records"
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"blockchain/encryption"
+)
+
+// Open - gorm DB for DATABASE_URL, instrumented with cfg (query metrics, slow-query log); nil when
+// dsn is empty. postgres://... or a key=value DSN opens Postgres, sqlite:<path> a local SQLite file.
+func Open(dsn string, cfg InstrumentConfig) (*gorm.DB, error) {
+	if dsn = strings.TrimSpace(dsn); dsn == "" {
+		return nil, nil
+	}
+	var dialector gorm.Dialector
+	if path, ok := strings.CutPrefix(dsn, "sqlite:"); ok {
+		dialector = sqlite.Open(path)
+	} else {
+		dialector = postgres.Open(dsn)
+	}
+	// Slow queries are logged by Instrument, with bound values only past VerySlowThreshold
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := Instrument(db, cfg); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// ParseInstrumentConfig - Slow-query thresholds from env, e.g. DB_SLOW_QUERY=200ms DB_VERY_SLOW_QUERY=1s ("" = default)
+func ParseInstrumentConfig(slow, verySlow string) (InstrumentConfig, error) {
+	var cfg InstrumentConfig
+	for _, f := range []struct {
+		value string
+		dst   *time.Duration
+	}{{slow, &cfg.SlowThreshold}, {verySlow, &cfg.VerySlowThreshold}} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid slow query threshold %q", f.value)
+		}
+		*f.dst = d
+	}
+	return cfg, nil
+}
+
+// OpenStore - Open dsn and migrate the Store tables; nil when dsn is empty
+func OpenStore(dsn string, cfg InstrumentConfig) (*Store, error) {
+	db, err := Open(dsn, cfg)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	store := NewStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return store, nil
+}
+
+// OpenTransactionStore - TransactionStore on db with the keys of source, table migrated;
+// nil when db or source is nil
+func OpenTransactionStore(ctx context.Context, db *gorm.DB, source encryption.KeySource) (*TransactionStore, error) {
+	if db == nil || source == nil {
+		return nil, nil
+	}
+	keys, err := source.Keyring(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+	store := NewTransactionStore(db, keys)
+	if err := store.AutoMigrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return store, nil
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErasureMode - How user-linked data is handled
+type ErasureMode string
+
+const (
+	// ErasureModeErase - Delete what can be deleted, drop the user link from what must be kept
+	ErasureModeErase ErasureMode = "erase"
+	// ErasureModeAnonymize - Keep all rows, replace PII with a pseudonym
+	ErasureModeAnonymize ErasureMode = "anonymize"
+)
+
+// redacted - Placeholder written over free-text PII
+const redacted = "[redacted]"
+
+const (
+	// DefaultAuditRetention - How long audit PII (IP address, user agent) is kept
+	DefaultAuditRetention = 90 * 24 * time.Hour
+	// DefaultRetentionInterval - How often RunRetention anonymizes expired audit PII
+	DefaultRetentionInterval = time.Hour
+)
+
+// ErasureRequest - Parameters untuk erase/anonymize data user
+type ErasureRequest struct {
+	UserID      string      `json:"user_id"`
+	Mode        ErasureMode `json:"mode"`
+	RequestedBy string      `json:"requested_by"`
+	Reason      string      `json:"reason,omitempty"`
+}
+
+// Pseudonymize - Stable reference for a user ID, keyed with secret (HMAC-SHA256) so it
+// can't be reversed by hashing candidate user IDs without the secret
+func Pseudonymize(secret []byte, userID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(userID))
+	return "anon_" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// SetPseudonymSecret - Key of the pseudonyms written by EraseUserData; erasure is refused without one
+func (s *Store) SetPseudonymSecret(secret []byte) {
+	s.pseudonymSecret = secret
+}
+
+// pseudonymize - Pseudonym of userID under the configured secret
+func (s *Store) pseudonymize(userID string) (string, error) {
+	if len(s.pseudonymSecret) == 0 {
+		return "", fmt.Errorf("pseudonym secret not configured")
+	}
+	return Pseudonymize(s.pseudonymSecret, userID), nil
+}
+
+// EraseUserData - Erase or anonymize all off-chain data linked to a user.
+// On-chain references (envelope IDs, owner addresses, signatures) are kept
+// because they are public and immutable; only the link to the user is removed.
+func (s *Store) EraseUserData(ctx context.Context, req ErasureRequest) (*DeletionRecord, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if req.RequestedBy == "" {
+		return nil, fmt.Errorf("requested_by is required")
+	}
+	if req.Mode == "" {
+		req.Mode = ErasureModeErase
+	}
+	if req.Mode != ErasureModeErase && req.Mode != ErasureModeAnonymize {
+		return nil, fmt.Errorf("invalid erasure mode: %s", req.Mode)
+	}
+
+	subject, err := s.pseudonymize(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Erase drops the user link outright; anonymize keeps a pseudonym so the
+	// user's rows stay correlatable without the raw user ID
+	link := subject
+	if req.Mode == ErasureModeErase {
+		link = ""
+	}
+	now := time.Now()
+	record := &DeletionRecord{
+		RequestID:   fmt.Sprintf("del_%d", now.UnixNano()),
+		SubjectRef:  subject,
+		Mode:        string(req.Mode),
+		RequestedBy: req.RequestedBy,
+		Reason:      req.Reason,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Envelope metadata: keep on-chain refs, drop remarks and user link
+		res := tx.Model(&EnvelopeMetadata{}).
+			Where("user_id = ?", req.UserID).
+			Updates(map[string]interface{}{
+				"user_id":       link,
+				"remarks":       "",
+				"group_id":      "",
+				"anonymized_at": now,
+			})
+		if res.Error != nil {
+			return fmt.Errorf("failed to anonymize envelope metadata: %w", res.Error)
+		}
+		record.MetadataAnonymized = res.RowsAffected
+		record.OnChainRefsPreserved = res.RowsAffected
+
+		// 2. Address book: delete entries (erase) or blank labels (anonymize)
+		if req.Mode == ErasureModeErase {
+			res = tx.Where("user_id = ?", req.UserID).Delete(&AddressBookEntry{})
+		} else {
+			res = tx.Model(&AddressBookEntry{}).
+				Where("user_id = ?", req.UserID).
+				Updates(map[string]interface{}{"user_id": subject, "label": ""})
+		}
+		if res.Error != nil {
+			return fmt.Errorf("failed to erase address book: %w", res.Error)
+		}
+		record.AddressBookRemoved = res.RowsAffected
+
+		// 3. Audit trail is append-only: scrub PII but keep the events
+		res = tx.Model(&AuditLog{}).
+			Where("user_id = ? OR actor = ?", req.UserID, req.UserID).
+			Updates(map[string]interface{}{
+				"user_id":       link,
+				"ip_address":    "",
+				"user_agent":    "",
+				"detail":        redacted,
+				"anonymized_at": now,
+			})
+		if res.Error != nil {
+			return fmt.Errorf("failed to anonymize audit logs: %w", res.Error)
+		}
+		if err := tx.Model(&AuditLog{}).Where("actor = ?", req.UserID).Update("actor", link).Error; err != nil {
+			return fmt.Errorf("failed to anonymize audit actor: %w", err)
+		}
+		record.AuditLogsAnonymized = res.RowsAffected
+
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to save deletion record: %w", err)
+		}
+
+		// The erasure itself is audited, without the raw user ID
+		return tx.Create(&AuditLog{
+			Actor:      req.RequestedBy,
+			UserID:     link,
+			Action:     "privacy.erase",
+			ResourceID: record.RequestID,
+			Detail:     fmt.Sprintf("mode=%s", req.Mode),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// ApplyRetention - Anonymize audit PII older than the retention period
+func (s *Store) ApplyRetention(ctx context.Context, retention time.Duration) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	if retention <= 0 {
+		return 0, fmt.Errorf("retention must be positive")
+	}
+
+	res := s.db.WithContext(ctx).Model(&AuditLog{}).
+		Where("created_at < ? AND anonymized_at IS NULL", time.Now().Add(-retention)).
+		Updates(map[string]interface{}{
+			"ip_address":    "",
+			"user_agent":    "",
+			"anonymized_at": time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// ParseRetention - Audit PII retention from env, e.g. AUDIT_RETENTION=720h ("" = DefaultAuditRetention, "off" = keep forever)
+func ParseRetention(value string) (time.Duration, error) {
+	switch value {
+	case "":
+		return DefaultAuditRetention, nil
+	case "off":
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid retention %q", value)
+	}
+	return d, nil
+}
+
+// RunRetention - ApplyRetention now and every interval (default DefaultRetentionInterval) until ctx is done
+func (s *Store) RunRetention(ctx context.Context, retention, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRetentionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.ApplyRetention(ctx, retention)
+		if err != nil {
+			log.Printf("audit retention failed: %v", err)
+		} else if n > 0 {
+			log.Printf("audit retention: anonymized %d audit logs older than %s", n, retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetDeletionRecords - List erasure records for a user (looked up by pseudonym)
+func (s *Store) GetDeletionRecords(ctx context.Context, userID string) ([]DeletionRecord, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	subject, err := s.pseudonymize(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DeletionRecord
+	err = s.db.WithContext(ctx).
+		Where("subject_ref = ?", subject).
+		Order("created_at DESC").
+		Find(&records).Error
+	return records, err
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEraseUserData(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "erase.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	req := ErasureRequest{UserID: "alice", RequestedBy: "dpo"}
+
+	if _, err := store.EraseUserData(ctx, req); err == nil {
+		t.Fatal("erasure without a pseudonym secret succeeded")
+	}
+
+	secret := []byte("secret")
+	if Pseudonymize(secret, "alice") == Pseudonymize([]byte("other"), "alice") {
+		t.Error("pseudonym doesn't depend on the secret")
+	}
+	store.SetPseudonymSecret(secret)
+	seed := func(user string) {
+		t.Helper()
+		if err := db.Create(&AuditLog{Actor: user, UserID: user, Action: "login", IPAddress: "10.0.0.1"}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&EnvelopeMetadata{EnvelopeID: 7, OwnerAddress: "owner-" + user, Signature: "sig-" + user, UserID: user, Remarks: "rent"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	seed("alice")
+	seed("bob")
+
+	// Erase drops the user link entirely: no pseudonym is left behind to correlate rows
+	record, err := store.EraseUserData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SubjectRef != Pseudonymize(secret, "alice") || record.AuditLogsAnonymized != 1 || record.OnChainRefsPreserved != 1 {
+		t.Errorf("deletion record = %+v", record)
+	}
+	var logs []AuditLog
+	if err := db.Where("action = ? AND ip_address = ?", "login", "").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].UserID != "" || logs[0].Actor != "" {
+		t.Errorf("audit logs after erasure = %+v", logs)
+	}
+	var meta EnvelopeMetadata
+	if err := db.Where("owner_address = ?", "owner-alice").First(&meta).Error; err != nil {
+		t.Fatal(err)
+	}
+	if meta.UserID != "" || meta.Remarks != "" || meta.Signature != "sig-alice" {
+		t.Errorf("envelope metadata after erasure = %+v", meta)
+	}
+	var leaked int64
+	if err := db.Model(&AuditLog{}).Where("user_id = ? OR actor = ?", record.SubjectRef, record.SubjectRef).Count(&leaked).Error; err != nil {
+		t.Fatal(err)
+	}
+	if leaked != 0 {
+		t.Errorf("%d audit logs still carry the pseudonym after erasure", leaked)
+	}
+
+	records, err := store.GetDeletionRecords(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].RequestID != record.RequestID {
+		t.Errorf("deletion records = %+v", records)
+	}
+
+	// Anonymize keeps the rows correlatable under the pseudonym
+	record, err = store.EraseUserData(ctx, ErasureRequest{UserID: "bob", RequestedBy: "dpo", Mode: ErasureModeAnonymize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := Pseudonymize(secret, "bob")
+	var pseudonymous []AuditLog
+	if err := db.Where("action = ? AND user_id = ?", "login", subject).Find(&pseudonymous).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(pseudonymous) != 1 || pseudonymous[0].Actor != subject || pseudonymous[0].IPAddress != "" {
+		t.Errorf("audit logs after anonymization = %+v", pseudonymous)
+	}
+	var kept EnvelopeMetadata
+	if err := db.Where("owner_address = ?", "owner-bob").First(&kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if kept.UserID != subject {
+		t.Errorf("envelope metadata after anonymization = %+v", kept)
+	}
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordSponsorshipDecision - Persist a sponsorship decision with the risk score behind it
+func (s *Store) RecordSponsorshipDecision(ctx context.Context, decision *SponsorshipDecision) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if decision.Wallet == "" {
+		return fmt.Errorf("wallet required")
+	}
+	return s.db.WithContext(ctx).Create(decision).Error
+}
+
+// CountSponsoredByFunder - Sponsored wallets other than exceptWallet funded by the same source since the given time
+func (s *Store) CountSponsoredByFunder(ctx context.Context, fundedBy, exceptWallet string, since time.Time) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	var count int64
+	err := s.db.WithContext(ctx).
+		Model(&SponsorshipDecision{}).
+		Where("funded_by = ? AND wallet <> ? AND sponsored = ? AND created_at >= ?", fundedBy, exceptWallet, true, since).
+		Distinct("wallet").
+		Count(&count).Error
+	return count, err
+}
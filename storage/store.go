@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Store - Off-chain data store (metadata, address book, audit trail, submission failures, claim caps, sponsorship decisions)
+type Store struct {
+	db              *gorm.DB
+	pseudonymSecret []byte // see SetPseudonymSecret
+}
+
+// NewStore - Create store on top of an opened gorm DB
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// DB - Get underlying gorm DB
+func (s *Store) DB() *gorm.DB {
+	return s.db
+}
+
+// AutoMigrate - Create/upgrade all tables owned by this package
+func (s *Store) AutoMigrate() error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return s.db.AutoMigrate(
+		&EnvelopeMetadata{},
+		&AddressBookEntry{},
+		&AuditLog{},
+		&DeletionRecord{},
+		&SubmissionFailure{},
+		&GroupClaimCap{},
+		&GroupClaimCount{},
+		&SponsorshipDecision{},
+	)
+}
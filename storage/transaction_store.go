@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blockchain/chain"
+	"blockchain/encryption"
+)
+
+// Payload kinds kept in the TransactionStore
+const (
+	PayloadUnsignedTransaction = "unsigned_tx"
+	PayloadSignedTransaction   = "signed_tx"
+	PayloadWebhook             = "webhook"
+)
+
+// DefaultPurgeInterval - How often RunPurge deletes expired payloads
+const DefaultPurgeInterval = 10 * time.Minute
+
+// UnsignedTransactionTTL - How long unsigned transactions are kept (well past blockhash expiry)
+const UnsignedTransactionTTL = 10 * time.Minute
+
+// SignedTransactionTTL - How long signed transactions are kept for rebroadcasts by the stuck transaction resolver
+const SignedTransactionTTL = 24 * time.Hour
+
+// WebhookArchiveTTL - How long archived webhook bodies are kept
+const WebhookArchiveTTL = 30 * 24 * time.Hour
+
+// ErrPayloadNotFound - No (unexpired) payload under that reference
+var ErrPayloadNotFound = errors.New("payload not found")
+
+// SealedPayload - Payload encrypted at rest (recipient/amount details never stored in clear)
+type SealedPayload struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	Kind      string        `gorm:"uniqueIndex:idx_payload_ref;size:20" json:"kind"`
+	Reference string        `gorm:"uniqueIndex:idx_payload_ref;size:128" json:"reference"` // Transaction ID, webhook delivery ID
+	Chain     chain.ChainID `gorm:"size:20" json:"chain,omitempty"`
+	KeyID     string        `gorm:"index;size:64" json:"key_id"`
+	Sealed    string        `gorm:"type:text" json:"-"`
+	ExpiresAt *time.Time    `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func (SealedPayload) TableName() string {
+	return "sealed_payloads"
+}
+
+// TransactionStore - Unsigned transactions and webhook bodies, AES-GCM encrypted at rest
+type TransactionStore struct {
+	db   *gorm.DB
+	keys *encryption.Keyring
+}
+
+// NewTransactionStore - Store on top of an opened gorm DB; keys is required (no plaintext fallback)
+func NewTransactionStore(db *gorm.DB, keys *encryption.Keyring) *TransactionStore {
+	return &TransactionStore{db: db, keys: keys}
+}
+
+// AutoMigrate - Create/upgrade the sealed_payloads table
+func (s *TransactionStore) AutoMigrate() error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	return s.db.AutoMigrate(&SealedPayload{})
+}
+
+// aad - Binds a ciphertext to its row so sealed values can't be swapped between references
+func aad(kind, reference string) []byte {
+	return []byte(kind + "/" + reference)
+}
+
+// Put - Seal and upsert a payload (ttl 0 = keep until deleted)
+func (s *TransactionStore) Put(ctx context.Context, kind, reference string, c chain.ChainID, body []byte, ttl time.Duration) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	if s.keys == nil {
+		return fmt.Errorf("encryption keyring not configured")
+	}
+	if reference == "" {
+		return fmt.Errorf("reference required")
+	}
+	sealed, err := s.keys.Seal(body, aad(kind, reference))
+	if err != nil {
+		return err
+	}
+	row := SealedPayload{
+		Kind:      kind,
+		Reference: reference,
+		Chain:     c,
+		KeyID:     s.keys.ActiveKeyID(),
+		Sealed:    sealed,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		row.ExpiresAt = &expiresAt
+	}
+	return s.db.WithContext(ctx).
+		Where(SealedPayload{Kind: kind, Reference: reference}).
+		Assign(SealedPayload{Chain: c, KeyID: row.KeyID, Sealed: sealed, ExpiresAt: row.ExpiresAt}).
+		FirstOrCreate(&row).Error
+}
+
+// Get - Decrypted payload
+func (s *TransactionStore) Get(ctx context.Context, kind, reference string) ([]byte, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	if s.keys == nil {
+		return nil, fmt.Errorf("encryption keyring not configured")
+	}
+	var row SealedPayload
+	err := s.db.WithContext(ctx).
+		Where("kind = ? AND reference = ? AND (expires_at IS NULL OR expires_at > ?)", kind, reference, time.Now()).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPayloadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.keys.Open(row.Sealed, aad(kind, reference))
+}
+
+// PutUnsignedTransaction - Keep an unsigned transaction until it expires
+func (s *TransactionStore) PutUnsignedTransaction(ctx context.Context, c chain.ChainID, transactionID string, payload []byte, ttl time.Duration) error {
+	return s.Put(ctx, PayloadUnsignedTransaction, transactionID, c, payload, ttl)
+}
+
+// GetUnsignedTransaction - Unsigned transaction by transaction ID
+func (s *TransactionStore) GetUnsignedTransaction(ctx context.Context, transactionID string) ([]byte, error) {
+	return s.Get(ctx, PayloadUnsignedTransaction, transactionID)
+}
+
+// PutSignedTransaction - Keep a signed transaction as it was sent
+func (s *TransactionStore) PutSignedTransaction(ctx context.Context, c chain.ChainID, transactionID string, payload []byte, ttl time.Duration) error {
+	return s.Put(ctx, PayloadSignedTransaction, transactionID, c, payload, ttl)
+}
+
+// GetSignedTransaction - Signed transaction by transaction ID
+func (s *TransactionStore) GetSignedTransaction(ctx context.Context, transactionID string) ([]byte, error) {
+	return s.Get(ctx, PayloadSignedTransaction, transactionID)
+}
+
+// ArchiveWebhook - Keep a webhook body for WebhookArchiveTTL under a random reference (returned).
+// The reference carries nothing derived from the body, so it can't be used to confirm a guessed payload.
+func (s *TransactionStore) ArchiveWebhook(ctx context.Context, body []byte) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate webhook reference: %w", err)
+	}
+	reference := "wh_" + hex.EncodeToString(id)
+	return reference, s.Put(ctx, PayloadWebhook, reference, "", body, WebhookArchiveTTL)
+}
+
+// Rotate - Re-seal up to batchSize payloads still under an old key; call until it returns 0
+// before removing the old key from the keyring
+func (s *TransactionStore) Rotate(ctx context.Context, batchSize int) (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	if s.keys == nil {
+		return 0, fmt.Errorf("encryption keyring not configured")
+	}
+	var rows []SealedPayload
+	if err := s.db.WithContext(ctx).
+		Where("key_id <> ?", s.keys.ActiveKeyID()).
+		Order("id ASC").
+		Limit(batchSize).
+		Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		sealed, err := s.keys.Reseal(row.Sealed, aad(row.Kind, row.Reference))
+		if err != nil {
+			return rotated, fmt.Errorf("failed to reseal payload %d: %w", row.ID, err)
+		}
+		if err := s.db.WithContext(ctx).Model(&SealedPayload{}).Where("id = ?", row.ID).
+			Updates(map[string]interface{}{"sealed": sealed, "key_id": s.keys.ActiveKeyID()}).Error; err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
+
+// PurgeExpired - Delete expired payloads
+func (s *TransactionStore) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	result := s.db.WithContext(ctx).Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).Delete(&SealedPayload{})
+	return result.RowsAffected, result.Error
+}
+
+// RunPurge - PurgeExpired every interval (default DefaultPurgeInterval) until ctx is done
+func (s *TransactionStore) RunPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.PurgeExpired(ctx); err != nil {
+			log.Printf("failed to purge expired payloads: %v", err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package txencoding - Text encodings of unsigned and signed transaction payloads
+// (base64, base58, hex) shared by the create and submit endpoints of every chain
+package txencoding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+// Encoding - Payload text encoding
+type Encoding string
+
+const (
+	Base64 Encoding = "base64" // Solana default
+	Base58 Encoding = "base58" // Some Solana wallet SDKs
+	Hex    Encoding = "hex"    // EVM default (0x prefix accepted on input)
+)
+
+// All - Every supported encoding
+var All = []Encoding{Base64, Base58, Hex}
+
+// Parse - Encoding from a request parameter ("" = def)
+func Parse(s string, def Encoding) (Encoding, error) {
+	e := Encoding(strings.ToLower(strings.TrimSpace(s)))
+	if e == "" {
+		return def, nil
+	}
+	if !e.IsValid() {
+		return "", fmt.Errorf("invalid encoding %q (base64 | base58 | hex)", s)
+	}
+	return e, nil
+}
+
+// IsValid - Check encoding is supported
+func (e Encoding) IsValid() bool {
+	switch e {
+	case Base64, Base58, Hex:
+		return true
+	}
+	return false
+}
+
+// String - Implements fmt.Stringer
+func (e Encoding) String() string {
+	return string(e)
+}
+
+// Encode - Payload bytes as text
+func (e Encoding) Encode(b []byte) string {
+	switch e {
+	case Base58:
+		return base58.Encode(b)
+	case Hex:
+		return hex.EncodeToString(b)
+	default:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+// Decode - Payload text as bytes; whitespace is ignored
+func (e Encoding) Decode(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	var b []byte
+	var err error
+	switch e {
+	case Base64:
+		b, err = base64.StdEncoding.DecodeString(s)
+	case Base58:
+		b, err = base58.Decode(s)
+	case Hex:
+		b, err = hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	default:
+		return nil, fmt.Errorf("invalid encoding %q", e)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", e, err)
+	}
+	return b, nil
+}
+
+// Detect - Guess the encoding of a payload:
+//   - hex: optional 0x, even length, hex digits only
+//   - base64: contains a character base58 leaves out (0 O I l + / =)
+//   - base58: otherwise
+//
+// A serialized transaction is hundreds of characters long, so a base64 or base58 payload
+// that happens to fit a narrower alphabet is not a practical concern.
+func Detect(s string) Encoding {
+	s = strings.Join(strings.Fields(s), "")
+	if isHex(strings.TrimPrefix(s, "0x")) {
+		return Hex
+	}
+	if strings.ContainsAny(s, "0OIl+/=") {
+		return Base64
+	}
+	return Base58
+}
+
+// DecodeAuto - Decode with e, or with the detected encoding when e is "" (returned)
+func DecodeAuto(s string, e Encoding) ([]byte, Encoding, error) {
+	if e == "" {
+		e = Detect(s)
+	}
+	b, err := e.Decode(s)
+	return b, e, err
+}
+
+func isHex(s string) bool {
+	if s == "" || len(s)%2 != 0 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package txstatus
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Status - Shared transaction status vocabulary for every chain package
+type Status string
+
+const (
+	Pending   Status = "pending"   // Broadcast, not yet confirmed
+	Confirmed Status = "confirmed" // Included in a block (Solana: confirmed commitment)
+	Finalized Status = "finalized" // Permanent (Solana: finalized commitment)
+	Failed    Status = "failed"    // Landed with error or rejected
+	NotFound  Status = "not_found" // Unknown to the node
+)
+
+// All - Every valid status, in lifecycle order
+var All = []Status{Pending, Confirmed, Finalized, Failed, NotFound}
+
+// Parse - Parse status string (case sensitive, as stored in DB and API)
+func Parse(s string) (Status, error) {
+	status := Status(s)
+	if !status.IsValid() {
+		return "", fmt.Errorf("invalid transaction status: %q", s)
+	}
+	return status, nil
+}
+
+// String - Implements fmt.Stringer
+func (s Status) String() string {
+	return string(s)
+}
+
+// IsValid - Check status is part of the shared vocabulary
+func (s Status) IsValid() bool {
+	switch s {
+	case Pending, Confirmed, Finalized, Failed, NotFound:
+		return true
+	}
+	return false
+}
+
+// IsTerminal - Status will not change anymore
+func (s Status) IsTerminal() bool {
+	return s == Finalized || s == Failed
+}
+
+// IsSuccess - Transaction landed without error
+func (s Status) IsSuccess() bool {
+	return s == Confirmed || s == Finalized
+}
+
+// FromSolana - Map Solana signature status to shared status
+func FromSolana(confirmation rpc.ConfirmationStatusType, txErr interface{}) Status {
+	if txErr != nil {
+		return Failed
+	}
+	switch confirmation {
+	case rpc.ConfirmationStatusFinalized:
+		return Finalized
+	case rpc.ConfirmationStatusConfirmed:
+		return Confirmed
+	default:
+		return Pending
+	}
+}
+
+// evmReceiptSuccessful - Same value as go-ethereum types.ReceiptStatusSuccessful
+const evmReceiptSuccessful uint64 = 1
+
+// FromEVMReceipt - Map EVM receipt status to shared status
+func FromEVMReceipt(receiptStatus uint64) Status {
+	if receiptStatus == evmReceiptSuccessful {
+		return Confirmed
+	}
+	return Failed
+}
+
+// Value - Implements driver.Valuer so Status can be used as a DB column
+func (s Status) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	if !s.IsValid() {
+		return nil, fmt.Errorf("invalid transaction status: %q", string(s))
+	}
+	return string(s), nil
+}
+
+// Scan - Implements sql.Scanner
+func (s *Status) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		*s = Status(v)
+	case []byte:
+		*s = Status(v)
+	default:
+		return fmt.Errorf("cannot scan %T into txstatus.Status", src)
+	}
+	if *s != "" && !s.IsValid() {
+		return fmt.Errorf("invalid transaction status in DB: %q", string(*s))
+	}
+	return nil
+}
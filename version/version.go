@@ -0,0 +1,126 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+
+	"blockchain/chain"
+)
+
+// Build info, overridable at link time:
+//
+//	go build -ldflags "-X blockchain/version.Version=v1.2.0 -X blockchain/version.BuildTime=2024-01-01T00:00:00Z"
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// APISchemaVersion - Bump when request/response shapes change incompatibly
+const APISchemaVersion = "1"
+
+// GoAPIVersion - Bump when the exported Go API of solprogram, chainsol or chainbnb changes
+// incompatibly (checked against golden files by the apistability tests)
+const GoAPIVersion = "2"
+
+// Feature names clients can negotiate on
+const (
+	FeaturePriorityFees  = "priority_fees"
+	FeatureDurableNonces = "durable_nonces"
+	FeatureBatchClaims   = "batch_claims"
+	FeatureCanary        = "canary"
+	FeatureEnvelopeCache = "envelope_info_cache"
+	FeatureSandbox       = "sandbox"
+)
+
+// DefaultFeatures - Feature support of this build
+func DefaultFeatures() map[string]bool {
+	return map[string]bool{
+		FeaturePriorityFees:  false,
+		FeatureDurableNonces: false,
+		FeatureBatchClaims:   false,
+		FeatureCanary:        true,
+		FeatureEnvelopeCache: true,
+		FeatureSandbox:       false,
+	}
+}
+
+// Info - Response of GET /version
+type Info struct {
+	Version          string            `json:"version"`
+	GitCommit        string            `json:"git_commit,omitempty"`
+	BuildTime        string            `json:"build_time,omitempty"`
+	GoVersion        string            `json:"go_version"`
+	APISchemaVersion string            `json:"api_schema_version"`
+	Chains           []chain.ChainID   `json:"chains"`
+	Actions          []string          `json:"actions"`
+	ProgramIDs       map[string]string `json:"program_ids,omitempty"`
+	Features         map[string]bool   `json:"features"`
+}
+
+// New - Build info for a service exposing the given chains, actions and programs
+func New(chains []chain.ChainID, actions []string, programIDs map[string]string) Info {
+	info := Info{
+		Version:          Version,
+		GitCommit:        GitCommit,
+		BuildTime:        BuildTime,
+		GoVersion:        runtime.Version(),
+		APISchemaVersion: APISchemaVersion,
+		Chains:           append([]chain.ChainID(nil), chains...),
+		Actions:          append([]string(nil), actions...),
+		ProgramIDs:       programIDs,
+		Features:         DefaultFeatures(),
+	}
+	slices.Sort(info.Chains)
+	sort.Strings(info.Actions)
+
+	// Fall back to VCS stamp embedded by the go toolchain
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// Supports - Check if a feature is enabled
+func (i Info) Supports(feature string) bool {
+	return i.Features[feature]
+}
+
+// WithFeature - Override a feature flag
+func (i Info) WithFeature(feature string, enabled bool) Info {
+	features := make(map[string]bool, len(i.Features)+1)
+	for k, v := range i.Features {
+		features[k] = v
+	}
+	features[feature] = enabled
+	i.Features = features
+	return i
+}
+
+// Handler - GET /version
+func Handler(info Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-API-Schema-Version", info.APISchemaVersion)
+		json.NewEncoder(w).Encode(info)
+	}
+}